@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aide-family/magicbox/pointer"
+	"github.com/aide-family/magicbox/strutil"
+	"github.com/aide-family/magicbox/strutil/cnst"
+	"github.com/go-kratos/kratos/contrib/registry/etcd/v2"
+	kubeRegistry "github.com/go-kratos/kratos/contrib/registry/kubernetes/v2"
+	"github.com/go-kratos/kratos/v2/config/env"
+	"github.com/go-kratos/kratos/v2/config/file"
+	klog "github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/transport/http"
+	clientV3 "go.etcd.io/etcd/client/v3"
+	ggrpc "google.golang.org/grpc"
+
+	"github.com/aide-family/rabbit/internal/conf"
+	"github.com/aide-family/rabbit/pkg/config"
+	"github.com/aide-family/rabbit/pkg/connect"
+	"github.com/aide-family/rabbit/pkg/merr"
+)
+
+// ErrStopCall 调用返回包装了该错误的错误时不再尝试其他节点，例如已经输出了部分结果
+var ErrStopCall = errors.New("stop calling other nodes")
+
+// ClusterClient 客户端命令连接集群的公共逻辑：加载客户端配置、初始化服务发现并依次尝试集群端点
+type ClusterClient struct {
+	bc        *config.ClientConfig
+	discovery connect.Registry
+	close     func() error
+}
+
+// ClusterConn 集群中一个端点的连接，按集群协议只有 HTTP 或 GRPC 其中之一不为空
+type ClusterConn struct {
+	Name     string
+	Endpoint string
+	HTTP     *http.Client
+	GRPC     *ggrpc.ClientConn
+}
+
+// NewClusterClient 加载 configPath 下的客户端配置，配置了 etcd 或 kubernetes 注册中心时初始化服务发现
+func NewClusterClient(configPath string) (*ClusterClient, error) {
+	var bc config.ClientConfig
+	if err := conf.Load(&bc, env.NewSource(), file.NewSource(configPath)); err != nil {
+		return nil, merr.ErrorInternal("load config failed").WithCause(err)
+	}
+	client := &ClusterClient{bc: &bc, close: func() error { return nil }}
+	switch bc.GetRegistryType() {
+	case config.RegistryType_ETCD:
+		etcdConfig := bc.GetEtcd()
+		if pointer.IsNil(etcdConfig) {
+			return nil, merr.ErrorInternal("etcd config is not found")
+		}
+		etcdClient, err := clientV3.New(clientV3.Config{
+			Endpoints:   strutil.SplitSkipEmpty(etcdConfig.GetEndpoints(), ","),
+			Username:    etcdConfig.GetUsername(),
+			Password:    etcdConfig.GetPassword(),
+			DialTimeout: 10 * time.Second,
+		})
+		if err != nil {
+			return nil, merr.ErrorInternal("etcd client initialization failed").WithCause(err)
+		}
+		client.discovery = etcd.New(etcdClient, etcd.Namespace(bc.GetNamespace()))
+		client.close = etcdClient.Close
+	case config.RegistryType_KUBERNETES:
+		kubeConfig := bc.GetKubernetes()
+		if pointer.IsNil(kubeConfig) {
+			return nil, merr.ErrorInternal("kubernetes config is not found")
+		}
+		kubeClient, err := connect.NewKubernetesClientSet(kubeConfig.GetKubeConfig())
+		if err != nil {
+			return nil, merr.ErrorInternal("kubernetes client initialization failed").WithCause(err)
+		}
+		client.discovery = kubeRegistry.NewRegistry(kubeClient, bc.GetNamespace())
+	}
+	return client, nil
+}
+
+// Config 返回加载的客户端配置
+func (c *ClusterClient) Config() *config.ClientConfig {
+	return c.bc
+}
+
+// Close 关闭服务发现使用的客户端
+func (c *ClusterClient) Close() error {
+	return c.close()
+}
+
+// WithMetadata 设置鉴权和命名空间元数据，一元调用由客户端中间件转换为请求头
+func (c *ClusterClient) WithMetadata(ctx context.Context, namespace string) context.Context {
+	return metadata.NewClientContext(ctx, metadata.Metadata{
+		cnst.MetadataGlobalKeyAuthorization: {c.bc.GetJwtToken()},
+		cnst.MetadataGlobalKeyNamespace:     {namespace},
+	})
+}
+
+// Call 依次连接集群配置的端点并调用 call，调用成功后结束，每次调用结束后关闭连接
+// timeout 为 0 时使用集群配置的请求超时，call 返回包装了 ErrStopCall 的错误时不再尝试其他节点
+func (c *ClusterClient) Call(timeout time.Duration, call func(conn *ClusterConn) error) error {
+	clusterConfig := c.bc.GetCluster()
+	clusterName := clusterConfig.GetName()
+	if timeout <= 0 {
+		timeout = clusterConfig.GetTimeout().AsDuration()
+	}
+	for _, clusterEndpoint := range strutil.SplitSkipEmpty(clusterConfig.GetEndpoints(), ",") {
+		initConfig := connect.NewDefaultConfig(clusterName, clusterEndpoint, timeout, clusterConfig.GetProtocol().String())
+		conn, err := c.dial(initConfig)
+		if err != nil {
+			klog.Warnw("msg", "cluster client initialization failed", "cluster", clusterName, "endpoint", clusterEndpoint, "error", err)
+			continue
+		}
+		err = call(conn)
+		_ = conn.close()
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, ErrStopCall) {
+			return err
+		}
+		klog.Warnw("msg", "call cluster failed", "cluster", clusterName, "endpoint", clusterEndpoint, "error", err)
+	}
+	return merr.ErrorInternal("no available nodes")
+}
+
+func (c *ClusterClient) dial(cluster connect.InitConfig) (*ClusterConn, error) {
+	conn := &ClusterConn{Name: cluster.GetName(), Endpoint: cluster.GetEndpoint()}
+	opts := []connect.InitOption{
+		connect.WithDiscovery(c.discovery),
+	}
+	var err error
+	switch cluster.GetProtocol() {
+	case connect.ProtocolHTTP:
+		if conn.HTTP, err = connect.InitHTTPClient(cluster, opts...); err != nil {
+			return nil, merr.ErrorInternalServer("failed to initialize HTTP client").WithCause(err)
+		}
+	case connect.ProtocolGRPC:
+		if conn.GRPC, err = connect.InitGRPCClient(cluster, opts...); err != nil {
+			return nil, merr.ErrorInternalServer("failed to initialize GRPC client").WithCause(err)
+		}
+	default:
+		return nil, merr.ErrorInternalServer("cluster %s unknown protocol", cluster.GetName())
+	}
+	return conn, nil
+}
+
+func (c *ClusterConn) close() error {
+	if c.HTTP != nil {
+		return c.HTTP.Close()
+	}
+	return c.GRPC.Close()
+}
@@ -0,0 +1,36 @@
+// Package export is the export command for the Rabbit service
+package export
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aide-family/rabbit/cmd"
+)
+
+const cmdLong = `Export message logs of a namespace as CSV or JSONL for audits and delivery reports.
+
+The export takes the same filters as listing message logs and streams the results
+across all matching weekly tables or log files, so large exports do not need to fit
+in memory on either side.
+
+Key Features:
+  • Filters: Time range, status, type, recipient, config, template, subject and tags
+  • Formats: CSV with a header row, or JSONL with one message log per line
+  • Redaction: Hide passwords, secrets and headers in the config snapshots (--redact-secrets)
+
+The time range is limited to 31 days per export. The output is written to stdout
+unless --output is set.`
+
+func NewCmd() *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export message logs as CSV or JSONL",
+		Long:  cmdLong,
+		Annotations: map[string]string{
+			"group": cmd.MessageCommands,
+		},
+		Run: run,
+	}
+	flags.addFlags(exportCmd)
+	return exportCmd
+}
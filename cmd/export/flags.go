@@ -0,0 +1,87 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aide-family/rabbit/cmd"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+	"github.com/aide-family/rabbit/pkg/enum"
+)
+
+type Flags struct {
+	*cmd.GlobalFlags
+
+	StartAt       int64         `json:"startAtUnix" yaml:"startAtUnix"`
+	EndAt         int64         `json:"endAtUnix" yaml:"endAtUnix"`
+	Status        string        `json:"status" yaml:"status"`
+	Type          string        `json:"type" yaml:"type"`
+	Recipient     string        `json:"recipient" yaml:"recipient"`
+	ConfigUID     int64         `json:"configUID" yaml:"configUID"`
+	TemplateUID   int64         `json:"templateUID" yaml:"templateUID"`
+	Subject       string        `json:"subject" yaml:"subject"`
+	Tags          []string      `json:"tags" yaml:"tags"`
+	Format        string        `json:"format" yaml:"format"`
+	RedactSecrets bool          `json:"redactSecrets" yaml:"redactSecrets"`
+	Output        string        `json:"output" yaml:"output"`
+	Timeout       time.Duration `json:"timeout" yaml:"timeout"`
+}
+
+var flags Flags
+
+func (f *Flags) addFlags(c *cobra.Command) {
+	f.GlobalFlags = cmd.GetGlobalFlags()
+	c.Flags().Int64Var(&f.StartAt, "start-at", 0, "The unix timestamp (seconds) to export from, defaults to 7 days before --end-at, example: --start-at=1767225600")
+	c.Flags().Int64Var(&f.EndAt, "end-at", 0, "The unix timestamp (seconds) to export to, defaults to now, example: --end-at=1769904000")
+	c.Flags().StringVar(&f.Status, "status", "", "The status of the messages, one of pending, sending, sent, failed, cancelled, dead_letter, example: --status=failed")
+	c.Flags().StringVar(&f.Type, "type", "", "The type of the messages, one of email, webhook, sms, example: --type=email")
+	c.Flags().StringVar(&f.Recipient, "recipient", "", "The email address or phone number the messages were sent to, example: --recipient=alice@example.com")
+	c.Flags().Int64Var(&f.ConfigUID, "config-uid", 0, "The uid of the email, sms or webhook config, example: --config-uid=1")
+	c.Flags().Int64Var(&f.TemplateUID, "template-uid", 0, "The uid of the template, example: --template-uid=1")
+	c.Flags().StringVar(&f.Subject, "subject", "", "The keyword of the email subject, example: --subject=outage")
+	c.Flags().StringSliceVar(&f.Tags, "tag", []string{}, "The tags the messages must all have, example: --tag=outage --tag=team-sre")
+	c.Flags().StringVarP(&f.Format, "format", "f", "csv", "The output format, one of csv, jsonl, example: --format=jsonl")
+	c.Flags().BoolVar(&f.RedactSecrets, "redact-secrets", false, "Hide passwords, secrets and headers in the config snapshots, example: --redact-secrets")
+	c.Flags().StringVarP(&f.Output, "output", "o", "", "The file to write the export to, defaults to stdout, example: --output=./message-logs.csv")
+	c.Flags().DurationVar(&f.Timeout, "timeout", 10*time.Minute, "The timeout of the whole export, example: --timeout=30m")
+}
+
+func (f *Flags) parseRequestParams() (*apiv1.ExportMessageLogsRequest, error) {
+	endAt := f.EndAt
+	if endAt == 0 {
+		endAt = time.Now().Unix()
+	}
+	startAt := f.StartAt
+	if startAt == 0 {
+		startAt = time.Unix(endAt, 0).AddDate(0, 0, -7).Unix()
+	}
+	req := &apiv1.ExportMessageLogsRequest{
+		StartAtUnix:   startAt,
+		EndAtUnix:     endAt,
+		Recipient:     f.Recipient,
+		ConfigUID:     f.ConfigUID,
+		TemplateUID:   f.TemplateUID,
+		Subject:       f.Subject,
+		Tags:          f.Tags,
+		Format:        strings.ToLower(f.Format),
+		RedactSecrets: f.RedactSecrets,
+	}
+	if f.Status != "" {
+		value, ok := enum.MessageStatus_value[strings.ToUpper(f.Status)]
+		if !ok {
+			return nil, fmt.Errorf("invalid status %q, expected one of pending, sending, sent, failed, cancelled, dead_letter", f.Status)
+		}
+		req.Status = enum.MessageStatus(value)
+	}
+	if f.Type != "" {
+		value, ok := enum.MessageType_value[strings.ToUpper(f.Type)]
+		if !ok {
+			return nil, fmt.Errorf("invalid type %q, expected one of email, webhook, sms", f.Type)
+		}
+		req.Type = enum.MessageType(value)
+	}
+	return req, nil
+}
@@ -0,0 +1,146 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aide-family/magicbox/strutil"
+	"github.com/aide-family/magicbox/strutil/cnst"
+	klog "github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/go-kratos/kratos/v2/transport/http/binding"
+	"github.com/spf13/cobra"
+	ggrpc "google.golang.org/grpc"
+	grpcMetadata "google.golang.org/grpc/metadata"
+
+	"github.com/aide-family/rabbit/cmd"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+)
+
+// exportPath HTTP 导出消息日志的地址，HTTP 客户端不支持流式接口，直接下载文件
+const exportPath = "/v1/message-logs/export"
+
+func run(_ *cobra.Command, _ []string) {
+	client, err := cmd.NewClusterClient(flags.RabbitConfigPath)
+	if err != nil {
+		klog.Errorw("msg", "cluster client initialization failed", "error", err)
+		return
+	}
+	defer client.Close()
+
+	req, err := flags.parseRequestParams()
+	if err != nil {
+		klog.Errorw("msg", "parse request params failed", "error", err)
+		return
+	}
+
+	var output io.Writer = os.Stdout
+	if strutil.IsNotEmpty(flags.Output) {
+		outputFile, err := os.Create(flags.Output)
+		if err != nil {
+			klog.Errorw("msg", "create output file failed", "output", flags.Output, "error", err)
+			return
+		}
+		defer outputFile.Close()
+		output = outputFile
+	}
+	w := &countingWriter{w: output}
+
+	jwtToken := client.Config().GetJwtToken()
+	// 导出耗时较长，使用 --timeout 代替集群配置的请求超时
+	err = client.Call(flags.Timeout, func(conn *cmd.ClusterConn) error {
+		ctx, cancel := context.WithTimeout(context.Background(), flags.Timeout)
+		defer cancel()
+		var exportErr error
+		if conn.HTTP != nil {
+			exportErr = exportHTTP(ctx, conn.HTTP, conn.Endpoint, jwtToken, req, w)
+		} else {
+			exportErr = exportGRPC(ctx, conn.GRPC, jwtToken, req, w)
+		}
+		// 已经写出部分数据时不能切换节点重试，否则输出中会有重复的记录
+		if exportErr != nil && w.n > 0 {
+			return fmt.Errorf("%w: the output is incomplete: %w", cmd.ErrStopCall, exportErr)
+		}
+		return exportErr
+	})
+	if err != nil {
+		klog.Errorw("msg", "export message logs failed", "error", err)
+		return
+	}
+	klog.Debugw("msg", "export message logs success", "bytes", w.n)
+}
+
+// countingWriter 记录已写出的字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// endpointURL 将集群地址转换为 URL，使用服务发现时由客户端替换为节点地址
+func endpointURL(endpoint string) (*url.URL, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	return url.Parse(endpoint)
+}
+
+func exportHTTP(ctx context.Context, httpClient *http.Client, endpoint, jwtToken string, in *apiv1.ExportMessageLogsRequest, w io.Writer) error {
+	target, err := endpointURL(endpoint)
+	if err != nil {
+		return err
+	}
+	reqURL, err := target.Parse(binding.EncodeURL(exportPath, in, true))
+	if err != nil {
+		return err
+	}
+	httpReq, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	// 直接发送请求不会经过客户端中间件，需要手动设置鉴权和命名空间
+	httpReq.Header.Set(cnst.HTTPHeaderAuthorization, jwtToken)
+	httpReq.Header.Set(cnst.HTTPHeaderXNamespace, flags.Namespace)
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func exportGRPC(ctx context.Context, grpcClient *ggrpc.ClientConn, jwtToken string, in *apiv1.ExportMessageLogsRequest, w io.Writer) error {
+	// 客户端中间件只作用于一元调用，流式调用需要手动设置鉴权和命名空间
+	ctx = grpcMetadata.AppendToOutgoingContext(ctx,
+		cnst.HTTPHeaderAuthorization, jwtToken,
+		cnst.HTTPHeaderXNamespace, flags.Namespace,
+	)
+	stream, err := apiv1.NewMessageLogClient(grpcClient).ExportMessageLogs(ctx, in)
+	if err != nil {
+		return err
+	}
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(chunk.GetData()); err != nil {
+			return err
+		}
+	}
+}
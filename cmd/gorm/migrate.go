@@ -27,7 +27,7 @@ Use Cases:
   • Environment synchronization: Ensure database schema consistency across environments
 
 The migration process will create the database if it doesn't exist and migrate all
-tables defined in the model registry.`
+tables defined in the model registry, as well as the existing weekly message log tables.`
 
 func newMigrateCmd() *cobra.Command {
 	return &cobra.Command{
@@ -55,5 +55,27 @@ func migrate(db *gorm.DB) {
 		klog.Errorw("msg", "migrate database failed", "error", err, "tables", tables)
 		return
 	}
+	if err := migrateWeeklyTables(db); err != nil {
+		klog.Errorw("msg", "migrate weekly message log tables failed", "error", err)
+		return
+	}
 	klog.Debugw("msg", "migrate database success")
 }
+
+// migrateWeeklyTables 同步已存在的按周切分的消息日志表，这些表只在不存在时创建，不在 do.Models() 中
+func migrateWeeklyTables(db *gorm.DB) error {
+	tableNames, err := db.Migrator().GetTables()
+	if err != nil {
+		return err
+	}
+	for _, tableName := range tableNames {
+		if _, _, ok := do.ParseWeeklyTableName(do.TableNameMessageLog, tableName); !ok {
+			continue
+		}
+		klog.Debugw("msg", "migrate weekly message log table", "table", tableName)
+		if err := db.Table(tableName).AutoMigrate(&do.MessageLog{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
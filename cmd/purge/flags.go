@@ -0,0 +1,27 @@
+package purge
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aide-family/rabbit/cmd"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+)
+
+type Flags struct {
+	*cmd.GlobalFlags
+
+	RetentionDays uint32 `json:"retentionDays" yaml:"retentionDays"`
+	DryRun        bool   `json:"dryRun" yaml:"dryRun"`
+}
+
+var flags Flags
+
+func (f *Flags) addFlags(c *cobra.Command) {
+	f.GlobalFlags = cmd.GetGlobalFlags()
+	c.Flags().Uint32Var(&f.RetentionDays, "retention-days", 0, "The days of message logs to keep, 0 means using the namespace or server config, example: --retention-days=90")
+	c.Flags().BoolVar(&f.DryRun, "dry-run", false, "Only list the weeks that would be purged, example: --dry-run")
+}
+
+func (f *Flags) parseRequestParams() *apiv1.PurgeMessageLogsRequest {
+	return &apiv1.PurgeMessageLogsRequest{RetentionDays: f.RetentionDays}
+}
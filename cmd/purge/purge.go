@@ -0,0 +1,37 @@
+// Package purge is the purge command for the Rabbit service
+package purge
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aide-family/rabbit/cmd"
+)
+
+const cmdLong = `Archive and purge expired weekly message logs of a namespace.
+
+Message logs are split into weekly tables (database mode) or weekly files (file mode).
+The purge command removes the weeks that ended before the retention period, archiving
+them to gzip compressed JSONL files on the server first when archiving is enabled.
+
+Key Features:
+  • Preview: List the weeks that would be purged without touching any data (--dry-run)
+  • Retention override: Purge with a retention period other than the configured one
+  • Archiving: Expired weeks are archived to {archivePath}/{namespace}/ before being dropped
+
+The retention period defaults to the namespace metadata "retention.days", then to
+jobCore.retention.days in the server config. The server also runs the same purge in
+the background on one node at a time.`
+
+func NewCmd() *cobra.Command {
+	purgeCmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Archive and purge expired message logs",
+		Long:  cmdLong,
+		Annotations: map[string]string{
+			"group": cmd.MessageCommands,
+		},
+		Run: run,
+	}
+	flags.addFlags(purgeCmd)
+	return purgeCmd
+}
@@ -0,0 +1,52 @@
+package purge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	klog "github.com/go-kratos/kratos/v2/log"
+	"github.com/spf13/cobra"
+
+	"github.com/aide-family/rabbit/cmd"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+)
+
+func run(_ *cobra.Command, _ []string) {
+	client, err := cmd.NewClusterClient(flags.RabbitConfigPath)
+	if err != nil {
+		klog.Errorw("msg", "cluster client initialization failed", "error", err)
+		return
+	}
+	defer client.Close()
+
+	req := flags.parseRequestParams()
+	var reply *apiv1.PurgeMessageLogsReply
+	// 归档和删除可能耗时较长，不额外设置超时，使用集群配置的超时
+	err = client.Call(0, func(conn *cmd.ClusterConn) error {
+		ctx := client.WithMetadata(context.Background(), flags.Namespace)
+		var callErr error
+		switch {
+		case conn.HTTP != nil && flags.DryRun:
+			reply, callErr = apiv1.NewMessageLogHTTPClient(conn.HTTP).PreviewPurgeMessageLogs(ctx, req)
+		case conn.HTTP != nil:
+			reply, callErr = apiv1.NewMessageLogHTTPClient(conn.HTTP).PurgeMessageLogs(ctx, req)
+		case flags.DryRun:
+			reply, callErr = apiv1.NewMessageLogClient(conn.GRPC).PreviewPurgeMessageLogs(ctx, req)
+		default:
+			reply, callErr = apiv1.NewMessageLogClient(conn.GRPC).PurgeMessageLogs(ctx, req)
+		}
+		return callErr
+	})
+	if err != nil {
+		klog.Errorw("msg", "purge message logs failed", "error", err)
+		return
+	}
+
+	output, err := encoding.GetCodec("yaml").Marshal(reply)
+	if err != nil {
+		klog.Errorw("msg", "marshal purge result failed", "error", err)
+		return
+	}
+	fmt.Println(string(output))
+}
@@ -0,0 +1,29 @@
+package schema
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aide-family/rabbit/cmd"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+)
+
+type Flags struct {
+	*cmd.GlobalFlags
+
+	UID       int64 `json:"uid" yaml:"uid"`
+	Revision  int32 `json:"revision" yaml:"revision"`
+	Variables bool  `json:"variables" yaml:"variables"`
+}
+
+var flags Flags
+
+func (f *Flags) addFlags(c *cobra.Command) {
+	f.GlobalFlags = cmd.GetGlobalFlags()
+	c.Flags().Int64VarP(&f.UID, "uid", "u", 0, "The uid of the template, example: --uid=1")
+	c.Flags().Int32Var(&f.Revision, "revision", 0, "The revision of the template, 0 means the current revision, example: --revision=3")
+	c.Flags().BoolVar(&f.Variables, "variables", false, "Print the declared variables as yaml instead of the JSON Schema, example: --variables")
+}
+
+func (f *Flags) parseRequestParams() *apiv1.GetTemplateSchemaRequest {
+	return &apiv1.GetTemplateSchemaRequest{Uid: f.UID, Revision: f.Revision}
+}
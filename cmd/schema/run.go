@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	klog "github.com/go-kratos/kratos/v2/log"
+	"github.com/spf13/cobra"
+
+	"github.com/aide-family/rabbit/cmd"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+)
+
+func run(_ *cobra.Command, _ []string) {
+	client, err := cmd.NewClusterClient(flags.RabbitConfigPath)
+	if err != nil {
+		klog.Errorw("msg", "cluster client initialization failed", "error", err)
+		return
+	}
+	defer client.Close()
+
+	req := flags.parseRequestParams()
+	var reply *apiv1.GetTemplateSchemaReply
+	err = client.Call(10*time.Second, func(conn *cmd.ClusterConn) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		ctx = client.WithMetadata(ctx, flags.Namespace)
+		var callErr error
+		if conn.HTTP != nil {
+			reply, callErr = apiv1.NewTemplateHTTPClient(conn.HTTP).GetTemplateSchema(ctx, req)
+		} else {
+			reply, callErr = apiv1.NewTemplateClient(conn.GRPC).GetTemplateSchema(ctx, req)
+		}
+		return callErr
+	})
+	if err != nil {
+		klog.Errorw("msg", "get template schema failed", "error", err)
+		return
+	}
+
+	if !flags.Variables {
+		fmt.Println(reply.GetJsonSchema())
+		return
+	}
+	output, err := encoding.GetCodec("yaml").Marshal(reply.GetVariables())
+	if err != nil {
+		klog.Errorw("msg", "marshal template variables failed", "error", err)
+		return
+	}
+	fmt.Println(string(output))
+}
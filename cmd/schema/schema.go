@@ -0,0 +1,36 @@
+// Package schema is the schema command for the Rabbit service
+package schema
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aide-family/rabbit/cmd"
+)
+
+const cmdLong = `Print the input schema of a template as JSON Schema.
+
+Templates may declare the variables their json data must provide, with a type, whether
+they are required and a default value. The sender validates the json data against the
+declared variables before a message is created and fills in the defaults of the missing
+ones.
+
+Key Features:
+  • JSON Schema: Generated from the declared variables, nested names like alert.name become nested objects
+  • Revisions: Print the schema of a pinned template revision (--revision)
+  • Variables: Print the declared variables instead of the JSON Schema (--variables)
+
+The schema can be used to generate forms or to validate json data before sending.`
+
+func NewCmd() *cobra.Command {
+	schemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the input schema of a template",
+		Long:  cmdLong,
+		Annotations: map[string]string{
+			"group": cmd.MessageCommands,
+		},
+		Run: run,
+	}
+	flags.addFlags(schemaCmd)
+	return schemaCmd
+}
@@ -1,12 +1,14 @@
 package email
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aide-family/magicbox/strutil"
 	"github.com/aide-family/rabbit/cmd/send"
 	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
-	"github.com/aide-family/rabbit/pkg/config"
 	"github.com/go-kratos/kratos/v2/encoding"
 	"github.com/spf13/cobra"
 )
@@ -14,13 +16,22 @@ import (
 type Flags struct {
 	send.SendFlags
 
-	UID         int64    `json:"uid" yaml:"uid"`
-	Subject     string   `json:"subject" yaml:"subject"`
-	Body        string   `json:"body" yaml:"body"`
-	To          []string `json:"to" yaml:"to"`
-	Cc          []string `json:"cc" yaml:"cc"`
-	ContentType string   `json:"contentType" yaml:"contentType"`
-	Headers     []string `json:"headers" yaml:"headers"`
+	UID            int64         `json:"uid" yaml:"uid"`
+	Subject        string        `json:"subject" yaml:"subject"`
+	Body           string        `json:"body" yaml:"body"`
+	TextBody       string        `json:"textBody" yaml:"textBody"`
+	To             []string      `json:"to" yaml:"to"`
+	Cc             []string      `json:"cc" yaml:"cc"`
+	ContentType    string        `json:"contentType" yaml:"contentType"`
+	Headers        []string      `json:"headers" yaml:"headers"`
+	SendAt         int64         `json:"sendAtUnix" yaml:"sendAtUnix"`
+	Delay          time.Duration `json:"delay" yaml:"delay"`
+	IdempotencyKey string        `json:"idempotencyKey" yaml:"idempotencyKey"`
+	Priority       string        `json:"priority" yaml:"priority"`
+	CallbackURL    string        `json:"callbackUrl" yaml:"callbackUrl"`
+	Tags           []string      `json:"tags" yaml:"tags"`
+	Attachments    []string      `json:"attachments" yaml:"attachments"`
+	InlineImages   []string      `json:"inlineImages" yaml:"inlineImages"`
 
 	JSON          string `json:"json" yaml:"json"`
 	requestParams *apiv1.SendEmailRequest
@@ -33,10 +44,19 @@ func (f *Flags) addFlags(c *cobra.Command) {
 	c.Flags().Int64VarP(&f.UID, "uid", "u", 0, "The uid of the email")
 	c.Flags().StringVarP(&f.Subject, "subject", "s", "", "The subject of the email")
 	c.Flags().StringVarP(&f.Body, "body", "b", "", "The body of the email")
+	c.Flags().StringVar(&f.TextBody, "text-body", "", "The plain text alternative of the html body, generated from the html body when empty")
 	c.Flags().StringSliceVarP(&f.To, "to", "t", []string{}, "The to of the email, example: --to=user1@example.com --to=user2@example.com")
 	c.Flags().StringSliceVarP(&f.Cc, "cc", "c", []string{}, "The cc of the email, example: --cc=user3@example.com --cc=user4@example.com")
 	c.Flags().StringVar(&f.ContentType, "content-type", "text/plain", "The content type of the email")
 	c.Flags().StringSliceVarP(&f.Headers, "header", "H", []string{}, "The headers of the email, example: --header=X-Custom-Header:value --header=X-Another-Header:value")
+	c.Flags().Int64Var(&f.SendAt, "send-at", 0, "The unix timestamp (seconds) to send the email at, example: --send-at=1767225600")
+	c.Flags().DurationVar(&f.Delay, "delay", 0, "The delay before sending the email, example: --delay=2h")
+	c.Flags().StringVar(&f.IdempotencyKey, "idempotency-key", "", "The idempotency key of the email, repeated requests with the same key only send once, example: --idempotency-key=alert-123")
+	c.Flags().StringVar(&f.Priority, "priority", "", "The priority of the email, one of low, normal, high, critical, example: --priority=critical")
+	c.Flags().StringVar(&f.CallbackURL, "callback-url", "", "The url to receive signed status events of the email, example: --callback-url=https://example.com/rabbit/callback")
+	c.Flags().StringSliceVar(&f.Tags, "tag", []string{}, "The tags of the email, used to search message logs, example: --tag=outage --tag=team-sre")
+	c.Flags().StringSliceVarP(&f.Attachments, "attach", "a", []string{}, "The local files to attach to the email, example: --attach=./report.pdf --attach=./invoice.pdf")
+	c.Flags().StringSliceVar(&f.InlineImages, "inline-image", []string{}, "The local images to embed in the html body, referenced by cid:{filename}, example: --inline-image=./logo.png")
 	c.Flags().StringVarP(&f.JSON, "json", "j", "", `{
 	"subject": "Test Email",
 	"body": "This is a test email",
@@ -47,9 +67,6 @@ func (f *Flags) addFlags(c *cobra.Command) {
 }`)
 }
 
-func (f *Flags) applyToBootstrap(bc *config.ClientConfig) {
-}
-
 func (f *Flags) parseRequestParams() (*apiv1.SendEmailRequest, error) {
 	if strutil.IsEmpty(f.JSON) {
 		headers := make(map[string]string)
@@ -59,14 +76,30 @@ func (f *Flags) parseRequestParams() (*apiv1.SendEmailRequest, error) {
 				headers[parts[0]] = parts[1]
 			}
 		}
+		attachments, err := f.readAttachments()
+		if err != nil {
+			return nil, err
+		}
+		priority, err := send.ParsePriority(f.Priority)
+		if err != nil {
+			return nil, err
+		}
 		return &apiv1.SendEmailRequest{
-			Uid:         f.UID,
-			Subject:     f.Subject,
-			Body:        f.Body,
-			To:          f.To,
-			Cc:          f.Cc,
-			ContentType: f.ContentType,
-			Headers:     headers,
+			Uid:            f.UID,
+			Subject:        f.Subject,
+			Body:           f.Body,
+			TextBody:       f.TextBody,
+			To:             f.To,
+			Cc:             f.Cc,
+			ContentType:    f.ContentType,
+			Headers:        headers,
+			SendAtUnix:     f.SendAt,
+			DelaySeconds:   int64(f.Delay.Seconds()),
+			IdempotencyKey: f.IdempotencyKey,
+			Attachments:    attachments,
+			Priority:       priority,
+			CallbackUrl:    f.CallbackURL,
+			Tags:           f.Tags,
 		}, nil
 	}
 	var requestParams apiv1.SendEmailRequest
@@ -75,3 +108,23 @@ func (f *Flags) parseRequestParams() (*apiv1.SendEmailRequest, error) {
 	}
 	return &requestParams, nil
 }
+
+// readAttachments 读取本地附件和内嵌图片
+func (f *Flags) readAttachments() ([]*apiv1.EmailAttachment, error) {
+	attachments := make([]*apiv1.EmailAttachment, 0, len(f.Attachments)+len(f.InlineImages))
+	for _, path := range f.Attachments {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, &apiv1.EmailAttachment{Filename: filepath.Base(path), Content: content})
+	}
+	for _, path := range f.InlineImages {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, &apiv1.EmailAttachment{Filename: filepath.Base(path), Content: content, Inline: true})
+	}
+	return attachments, nil
+}
@@ -1,10 +1,14 @@
 package send
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
 
 	"github.com/aide-family/magicbox/pointer"
 	"github.com/aide-family/rabbit/cmd"
+	"github.com/aide-family/rabbit/pkg/enum"
 )
 
 type SendFlags struct {
@@ -24,3 +28,15 @@ func GetSendFlags() SendFlags {
 
 	return sendFlags
 }
+
+// ParsePriority 解析消息优先级参数，支持 low、normal、high、critical，为空时使用服务端默认优先级
+func ParsePriority(priority string) (enum.MessagePriority, error) {
+	if priority == "" {
+		return enum.MessagePriority_MessagePriority_UNKNOWN, nil
+	}
+	value, ok := enum.MessagePriority_value["PRIORITY_"+strings.ToUpper(priority)]
+	if !ok {
+		return 0, fmt.Errorf("invalid priority %q, expected one of low, normal, high, critical", priority)
+	}
+	return enum.MessagePriority(value), nil
+}
@@ -1,19 +1,90 @@
 package sms
 
 import (
-	"github.com/spf13/cobra"
+	"strings"
+	"time"
 
+	"github.com/aide-family/magicbox/strutil"
 	"github.com/aide-family/rabbit/cmd/send"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/spf13/cobra"
 )
 
 type Flags struct {
 	send.SendFlags
+
+	UID            int64         `json:"uid" yaml:"uid"`
+	PhoneNumbers   []string      `json:"phoneNumbers" yaml:"phoneNumbers"`
+	TemplateCode   string        `json:"templateCode" yaml:"templateCode"`
+	TemplateParams []string      `json:"templateParams" yaml:"templateParams"`
+	Content        string        `json:"content" yaml:"content"`
+	SendAt         int64         `json:"sendAtUnix" yaml:"sendAtUnix"`
+	Delay          time.Duration `json:"delay" yaml:"delay"`
+	IdempotencyKey string        `json:"idempotencyKey" yaml:"idempotencyKey"`
+	Priority       string        `json:"priority" yaml:"priority"`
+	CallbackURL    string        `json:"callbackUrl" yaml:"callbackUrl"`
+	Tags           []string      `json:"tags" yaml:"tags"`
+
+	JSON string `json:"json" yaml:"json"`
 }
 
 var smsFlags Flags
 
 func (f *Flags) addFlags(c *cobra.Command) {
 	f.SendFlags = send.GetSendFlags()
+	c.Flags().Int64VarP(&f.UID, "uid", "u", 0, "The uid of the sms config")
+	c.Flags().StringSliceVarP(&f.PhoneNumbers, "phone", "p", []string{}, "The phone numbers of the sms, example: --phone=13800000000 --phone=13900000000")
+	c.Flags().StringVarP(&f.TemplateCode, "template-code", "t", "", "The provider template code of the sms, example: --template-code=SMS_123456")
+	c.Flags().StringSliceVarP(&f.TemplateParams, "param", "P", []string{}, "The provider template params of the sms, example: --param=code=1234 --param=minutes=5")
+	c.Flags().StringVarP(&f.Content, "content", "c", "", "The content of the sms, only used for logging and the mock provider")
+	c.Flags().Int64Var(&f.SendAt, "send-at", 0, "The unix timestamp (seconds) to send the sms at, example: --send-at=1767225600")
+	c.Flags().DurationVar(&f.Delay, "delay", 0, "The delay before sending the sms, example: --delay=2h")
+	c.Flags().StringVar(&f.IdempotencyKey, "idempotency-key", "", "The idempotency key of the sms, repeated requests with the same key only send once, example: --idempotency-key=verify-123")
+	c.Flags().StringVar(&f.Priority, "priority", "", "The priority of the sms, one of low, normal, high, critical, example: --priority=high")
+	c.Flags().StringVar(&f.CallbackURL, "callback-url", "", "The url to receive signed status events of the sms, example: --callback-url=https://example.com/rabbit/callback")
+	c.Flags().StringSliceVar(&f.Tags, "tag", []string{}, "The tags of the sms, used to search message logs, example: --tag=verify --tag=login")
+	c.Flags().StringVarP(&f.JSON, "json", "j", "", `{
+	"uid": 1,
+	"phoneNumbers": ["13800000000", "13900000000"],
+	"templateCode": "SMS_123456",
+	"templateParams": {"code": "1234"},
+	"content": "Your verification code is 1234"
+}`)
+}
+
+func (f *Flags) parseRequestParams() (*apiv1.SendSMSRequest, error) {
+	if strutil.IsEmpty(f.JSON) {
+		templateParams := make(map[string]string)
+		for _, param := range f.TemplateParams {
+			parts := strings.SplitN(param, "=", 2)
+			if len(parts) == 2 {
+				templateParams[parts[0]] = parts[1]
+			}
+		}
+		priority, err := send.ParsePriority(f.Priority)
+		if err != nil {
+			return nil, err
+		}
+		return &apiv1.SendSMSRequest{
+			Uid:            f.UID,
+			PhoneNumbers:   f.PhoneNumbers,
+			TemplateCode:   f.TemplateCode,
+			TemplateParams: templateParams,
+			Content:        f.Content,
+			SendAtUnix:     f.SendAt,
+			DelaySeconds:   int64(f.Delay.Seconds()),
+			IdempotencyKey: f.IdempotencyKey,
+			Priority:       priority,
+			CallbackUrl:    f.CallbackURL,
+			Tags:           f.Tags,
+		}, nil
+	}
+	var requestParams apiv1.SendSMSRequest
+	if err := encoding.GetCodec("json").Unmarshal([]byte(f.JSON), &requestParams); err != nil {
+		return nil, err
+	}
+	return &requestParams, nil
 }
 
 func GetSmsFlags() Flags {
@@ -0,0 +1,45 @@
+package sms
+
+import (
+	"context"
+	"time"
+
+	klog "github.com/go-kratos/kratos/v2/log"
+	"github.com/spf13/cobra"
+
+	"github.com/aide-family/rabbit/cmd"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+)
+
+func run(_ *cobra.Command, _ []string) {
+	client, err := cmd.NewClusterClient(smsFlags.RabbitConfigPath)
+	if err != nil {
+		klog.Errorw("msg", "cluster client initialization failed", "error", err)
+		return
+	}
+	defer client.Close()
+
+	req, err := smsFlags.parseRequestParams()
+	if err != nil {
+		klog.Errorw("msg", "parse request params failed", "error", err)
+		return
+	}
+	var reply *apiv1.SendReply
+	err = client.Call(0, func(conn *cmd.ClusterConn) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		ctx = client.WithMetadata(ctx, smsFlags.Namespace)
+		var callErr error
+		if conn.HTTP != nil {
+			reply, callErr = apiv1.NewSenderHTTPClient(conn.HTTP).SendSMS(ctx, req)
+		} else {
+			reply, callErr = apiv1.NewSenderClient(conn.GRPC).SendSMS(ctx, req)
+		}
+		return callErr
+	})
+	if err != nil {
+		klog.Errorw("msg", "send sms failed", "error", err)
+		return
+	}
+	klog.Debugw("msg", "send sms success", "reply", reply)
+}
@@ -2,8 +2,9 @@
 package sms
 
 import (
-	"github.com/aide-family/rabbit/cmd"
 	"github.com/spf13/cobra"
+
+	"github.com/aide-family/rabbit/cmd"
 )
 
 const cmdLong = `Send SMS messages, supporting multiple SMS service providers and template-based delivery.
@@ -13,7 +14,8 @@ single message delivery or template-based sending.
 
 Key Features:
   • SMS delivery: Send SMS messages through configured SMS service providers
-  • Multi-provider support: Support for major SMS providers (Alibaba Cloud, Tencent Cloud, Huawei Cloud, etc.)
+  • Multi-provider support: Support for major SMS providers (Alibaba Cloud, Tencent Cloud, Huawei Cloud)
+    and a local mock provider for offline testing
   • Template-based sending: Support for using provider-provided SMS templates
   • Parameter substitution: Support for dynamic parameter replacement in templates
   • Batch sending: Support for sending SMS messages to multiple phone numbers in batch
@@ -25,7 +27,10 @@ Use Cases:
 
 SMS sending requires prior configuration of SMS service providers (API Key, Secret, etc.),
 which can be configured through configuration files or API. Sent SMS messages are processed
-immediately, making it suitable for testing and urgent scenarios.`
+immediately, making it suitable for testing and urgent scenarios.
+
+Example:
+  rabbit send sms --uid=1 --phone=13800000000 --template-code=SMS_123456 --param=code=1234`
 
 func NewCmd() *cobra.Command {
 	smsCmd := &cobra.Command{
@@ -40,7 +45,3 @@ func NewCmd() *cobra.Command {
 	smsFlags.addFlags(smsCmd)
 	return smsCmd
 }
-
-func run(cmd *cobra.Command, args []string) {
-	cmd.Help()
-}
@@ -7,6 +7,8 @@ var ProviderSetBiz = wire.NewSet(
 	NewEmail,
 	NewHealth,
 	NewEmailConfig,
+	NewSMSConfig,
+	NewSMS,
 	NewNamespace,
 	NewMessageLog,
 	NewWebhookConfig,
@@ -14,4 +16,5 @@ var ProviderSetBiz = wire.NewSet(
 	NewTemplate,
 	NewMessage,
 	NewJob,
+	NewSender,
 )
@@ -0,0 +1,110 @@
+package bo
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+	"github.com/aide-family/rabbit/pkg/enum"
+)
+
+// 命名空间 metadata 中的状态回调配置项
+const (
+	MetadataKeyCallbackURL    = "callback.url"
+	MetadataKeyCallbackSecret = "callback.secret"
+)
+
+// MessageCallbackEventType 状态回调事件类型
+const MessageCallbackEventType = "message.status"
+
+// MessageCallbackEventBo 推送给调用方的消息状态事件
+type MessageCallbackEventBo struct {
+	Event      string       `json:"event"`
+	UID        snowflake.ID `json:"uid,string"`
+	Namespace  string       `json:"namespace"`
+	Type       string       `json:"type"`
+	Status     string       `json:"status"`
+	RetryTotal int32        `json:"retryTotal"`
+	Error      string       `json:"error,omitempty"`
+	OccurredAt time.Time    `json:"occurredAt"`
+
+	status      vobj.MessageStatus
+	callbackURL string
+}
+
+// NewMessageCallbackEventBo 根据消息当前的状态创建状态事件
+func NewMessageCallbackEventBo(namespace string, message *MessageLogItemBo) *MessageCallbackEventBo {
+	return &MessageCallbackEventBo{
+		Event:       MessageCallbackEventType,
+		UID:         message.UID,
+		Namespace:   namespace,
+		Type:        enum.MessageType(message.Type).String(),
+		Status:      enum.MessageStatus(message.Status).String(),
+		RetryTotal:  message.RetryTotal,
+		Error:       message.LastError,
+		OccurredAt:  time.Now(),
+		status:      message.Status,
+		callbackURL: message.CallbackURL,
+	}
+}
+
+// GetStatus 事件对应的消息状态
+func (b *MessageCallbackEventBo) GetStatus() vobj.MessageStatus {
+	return b.status
+}
+
+// GetCallbackURL 发送请求中指定的回调地址，未指定时为空，使用命名空间的默认地址
+func (b *MessageCallbackEventBo) GetCallbackURL() string {
+	return b.callbackURL
+}
+
+type MessageCallbackLogItemBo struct {
+	UID           snowflake.ID
+	MessageLogUID snowflake.ID
+	Status        vobj.MessageStatus
+	URL           string
+	Attempt       int32
+	StatusCode    int32
+	Success       bool
+	Error         string
+	CallbackAt    time.Time
+}
+
+func NewMessageCallbackLogItemBo(doMessageCallbackLog *do.MessageCallbackLog) *MessageCallbackLogItemBo {
+	return &MessageCallbackLogItemBo{
+		UID:           doMessageCallbackLog.UID,
+		MessageLogUID: doMessageCallbackLog.MessageLogID,
+		Status:        doMessageCallbackLog.Status,
+		URL:           doMessageCallbackLog.URL,
+		Attempt:       doMessageCallbackLog.Attempt,
+		StatusCode:    doMessageCallbackLog.StatusCode,
+		Success:       doMessageCallbackLog.Success,
+		Error:         doMessageCallbackLog.Error,
+		CallbackAt:    doMessageCallbackLog.CallbackAt,
+	}
+}
+
+func (b *MessageCallbackLogItemBo) ToAPIV1MessageCallbackLogItem() *apiv1.MessageCallbackLogItem {
+	return &apiv1.MessageCallbackLogItem{
+		Uid:           b.UID.Int64(),
+		MessageLogUID: b.MessageLogUID.Int64(),
+		Status:        enum.MessageStatus(b.Status),
+		Url:           b.URL,
+		Attempt:       b.Attempt,
+		StatusCode:    b.StatusCode,
+		Success:       b.Success,
+		Error:         b.Error,
+		CallbackAt:    b.CallbackAt.Format(time.DateTime),
+	}
+}
+
+func ToAPIV1ListMessageCallbackLogReply(items []*MessageCallbackLogItemBo) *apiv1.ListMessageCallbackLogReply {
+	callbackLogs := make([]*apiv1.MessageCallbackLogItem, 0, len(items))
+	for _, item := range items {
+		callbackLogs = append(callbackLogs, item.ToAPIV1MessageCallbackLogItem())
+	}
+	return &apiv1.ListMessageCallbackLogReply{Items: callbackLogs}
+}
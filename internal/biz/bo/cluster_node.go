@@ -0,0 +1,66 @@
+package bo
+
+import (
+	"time"
+
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+	"github.com/aide-family/rabbit/pkg/enum"
+)
+
+// ClusterNodesBo 当前节点分发消息时看到的集群节点状态
+type ClusterNodesBo struct {
+	DispatchMode       string
+	LocalFallbackTotal int64
+	Nodes              []*ClusterNodeItemBo
+}
+
+type ClusterNodeItemBo struct {
+	Endpoint            string
+	State               vobj.ClusterNodeState
+	Inflight            int64
+	SuccessTotal        int64
+	FailureTotal        int64
+	ConsecutiveFailures int32
+	AvgLatency          time.Duration
+	LastError           string
+	LastErrorAt         time.Time
+	OpenedAt            time.Time
+	NextProbeAt         time.Time
+}
+
+func (b *ClusterNodeItemBo) ToAPIV1ClusterNodeItem() *apiv1.ClusterNodeItem {
+	return &apiv1.ClusterNodeItem{
+		Endpoint:            b.Endpoint,
+		State:               enum.ClusterNodeState(b.State),
+		Inflight:            b.Inflight,
+		SuccessTotal:        b.SuccessTotal,
+		FailureTotal:        b.FailureTotal,
+		ConsecutiveFailures: b.ConsecutiveFailures,
+		AvgLatencyMs:        b.AvgLatency.Milliseconds(),
+		LastError:           b.LastError,
+		LastErrorAt:         formatOptionalTime(b.LastErrorAt),
+		OpenedAt:            formatOptionalTime(b.OpenedAt),
+		NextProbeAt:         formatOptionalTime(b.NextProbeAt),
+	}
+}
+
+func ToAPIV1ListClusterNodesReply(clusterNodesBo *ClusterNodesBo) *apiv1.ListClusterNodesReply {
+	nodes := make([]*apiv1.ClusterNodeItem, 0, len(clusterNodesBo.Nodes))
+	for _, node := range clusterNodesBo.Nodes {
+		nodes = append(nodes, node.ToAPIV1ClusterNodeItem())
+	}
+	return &apiv1.ListClusterNodesReply{
+		DispatchMode:       clusterNodesBo.DispatchMode,
+		LocalFallbackTotal: clusterNodesBo.LocalFallbackTotal,
+		Nodes:              nodes,
+	}
+}
+
+// formatOptionalTime 零值时间返回空字符串
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateTime)
+}
@@ -3,7 +3,9 @@ package bo
 
 import (
 	"encoding/json"
+	"mime"
 	"net/http"
+	"path/filepath"
 	"time"
 
 	"github.com/aide-family/magicbox/message/email"
@@ -21,13 +23,84 @@ import (
 var _ email.Config = (*EmailConfigItemBo)(nil)
 
 type SendEmailBo struct {
-	UID         snowflake.ID `json:"uid"`
-	Subject     string       `json:"subject"`
-	Body        string       `json:"body"`
-	To          []string     `json:"to"`
-	Cc          []string     `json:"cc"`
-	ContentType string       `json:"content_type"`
-	Headers     http.Header  `json:"headers"`
+	UID              snowflake.ID         `json:"uid"`
+	Subject          string               `json:"subject"`
+	Body             string               `json:"body"`
+	TextBody         string               `json:"text_body,omitempty"`
+	To               []string             `json:"to"`
+	Cc               []string             `json:"cc"`
+	ContentType      string               `json:"content_type"`
+	Headers          http.Header          `json:"headers"`
+	Attachments      []*EmailAttachmentBo `json:"attachments,omitempty"`
+	SendAt           time.Time            `json:"-"`
+	IdempotencyKey   string               `json:"-"`
+	Priority         vobj.MessagePriority `json:"-"`
+	CallbackURL      string               `json:"-"`
+	TemplateUID      snowflake.ID         `json:"-"`
+	TemplateRevision int32                `json:"-"`
+	Tags             []string             `json:"-"`
+}
+
+// EmailAttachmentBo 邮件附件，blobRef 引用的文件在入队时读取到 Content 中，随消息日志保存
+type EmailAttachmentBo struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"content,omitempty"`
+	BlobRef     string `json:"blob_ref,omitempty"`
+	Inline      bool   `json:"inline,omitempty"`
+	ContentID   string `json:"content_id,omitempty"`
+}
+
+// Validate 校验附件并补全默认的 MIME 类型和 Content-ID
+func (a *EmailAttachmentBo) Validate() error {
+	if strutil.IsEmpty(a.Filename) {
+		return merr.ErrorParams("attachment filename is required")
+	}
+	hasContent, hasBlobRef := len(a.Content) > 0, strutil.IsNotEmpty(a.BlobRef)
+	if hasContent == hasBlobRef {
+		return merr.ErrorParams("attachment %s must set exactly one of content and blobRef", a.Filename)
+	}
+	if strutil.IsEmpty(a.ContentType) {
+		a.ContentType = mime.TypeByExtension(filepath.Ext(a.Filename))
+	}
+	if strutil.IsEmpty(a.ContentType) {
+		a.ContentType = "application/octet-stream"
+	}
+	if a.Inline && strutil.IsEmpty(a.ContentID) {
+		a.ContentID = a.Filename
+	}
+	return nil
+}
+
+func NewEmailAttachmentBos(attachments []*apiv1.EmailAttachment) []*EmailAttachmentBo {
+	if len(attachments) == 0 {
+		return nil
+	}
+	attachmentBos := make([]*EmailAttachmentBo, 0, len(attachments))
+	for _, attachment := range attachments {
+		attachmentBos = append(attachmentBos, &EmailAttachmentBo{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Content:     attachment.Content,
+			BlobRef:     attachment.BlobRef,
+			Inline:      attachment.Inline,
+			ContentID:   attachment.ContentId,
+		})
+	}
+	return attachmentBos
+}
+
+// IsHTML 正文是否为 HTML，HTML 邮件以 multipart/alternative 发送
+func (b *SendEmailBo) IsHTML() bool {
+	return isHTMLContentType(b.ContentType)
+}
+
+// PlainText 返回 HTML 邮件的纯文本正文，未设置时由 HTML 正文生成
+func (b *SendEmailBo) PlainText() string {
+	if strutil.IsNotEmpty(b.TextBody) {
+		return b.TextBody
+	}
+	return HTMLToText(b.Body)
 }
 
 func (b *SendEmailBo) ToMessageLog(emailConfig *EmailConfigItemBo) (*do.MessageLog, error) {
@@ -39,13 +112,23 @@ func (b *SendEmailBo) ToMessageLog(emailConfig *EmailConfigItemBo) (*do.MessageL
 	if err != nil {
 		return nil, err
 	}
-	return &do.MessageLog{
-		SendAt:  time.Now(),
-		Message: strutil.EncryptString(messageBytes),
-		Config:  strutil.EncryptString(emailConfigBytes),
-		Type:    vobj.MessageTypeEmail,
-		Status:  vobj.MessageStatusPending,
-	}, nil
+	sendAt := b.SendAt
+	if sendAt.IsZero() {
+		sendAt = time.Now()
+	}
+	messageLog := &do.MessageLog{
+		SendAt:           sendAt,
+		Message:          strutil.EncryptString(messageBytes),
+		Config:           strutil.EncryptString(emailConfigBytes),
+		Type:             vobj.MessageTypeEmail,
+		Status:           vobj.MessageStatusPending,
+		IdempotencyKey:   b.IdempotencyKey,
+		Priority:         b.Priority,
+		CallbackURL:      b.CallbackURL,
+		TemplateRevision: b.TemplateRevision,
+	}
+	recipients := append(append(make([]string, 0, len(b.To)+len(b.Cc)), b.To...), b.Cc...)
+	return messageLog.WithSearchFields(recipients, emailConfig.UID, b.TemplateUID, b.Subject, b.Tags), nil
 }
 
 func NewSendEmailBo(req *apiv1.SendEmailRequest) *SendEmailBo {
@@ -54,22 +137,36 @@ func NewSendEmailBo(req *apiv1.SendEmailRequest) *SendEmailBo {
 		headers.Add(key, value)
 	}
 	return &SendEmailBo{
-		UID:         snowflake.ParseInt64(req.Uid),
-		Subject:     req.Subject,
-		Body:        req.Body,
-		To:          req.To,
-		Cc:          req.Cc,
-		ContentType: req.ContentType,
-		Headers:     headers,
+		UID:            snowflake.ParseInt64(req.Uid),
+		Subject:        req.Subject,
+		Body:           req.Body,
+		TextBody:       req.TextBody,
+		To:             req.To,
+		Cc:             req.Cc,
+		ContentType:    req.ContentType,
+		Headers:        headers,
+		Attachments:    NewEmailAttachmentBos(req.Attachments),
+		SendAt:         NewSendAt(req.SendAtUnix, req.DelaySeconds),
+		IdempotencyKey: req.IdempotencyKey,
+		Priority:       vobj.MessagePriority(req.Priority).Normalize(),
+		CallbackURL:    req.CallbackUrl,
+		Tags:           req.Tags,
 	}
 }
 
 type SendEmailWithTemplateBo struct {
-	UID         snowflake.ID
-	TemplateUID snowflake.ID
-	JSONData    []byte
-	To          []string
-	Cc          []string
+	UID              snowflake.ID
+	TemplateUID      snowflake.ID
+	TemplateRevision int32
+	JSONData         []byte
+	To               []string
+	Cc               []string
+	Attachments      []*EmailAttachmentBo
+	SendAt           time.Time
+	IdempotencyKey   string
+	Priority         vobj.MessagePriority
+	CallbackURL      string
+	Tags             []string
 }
 
 func NewSendEmailWithTemplateBo(req *apiv1.SendEmailWithTemplateRequest) (*SendEmailWithTemplateBo, error) {
@@ -77,11 +174,18 @@ func NewSendEmailWithTemplateBo(req *apiv1.SendEmailWithTemplateRequest) (*SendE
 		return nil, merr.ErrorParams("invalid json data")
 	}
 	return &SendEmailWithTemplateBo{
-		UID:         snowflake.ParseInt64(req.Uid),
-		TemplateUID: snowflake.ParseInt64(req.TemplateUID),
-		JSONData:    []byte(req.JsonData),
-		To:          req.To,
-		Cc:          req.Cc,
+		UID:              snowflake.ParseInt64(req.Uid),
+		TemplateUID:      snowflake.ParseInt64(req.TemplateUID),
+		TemplateRevision: req.TemplateRevision,
+		JSONData:         []byte(req.JsonData),
+		To:               req.To,
+		Cc:               req.Cc,
+		Attachments:      NewEmailAttachmentBos(req.Attachments),
+		SendAt:           NewSendAt(req.SendAtUnix, req.DelaySeconds),
+		IdempotencyKey:   req.IdempotencyKey,
+		Priority:         vobj.MessagePriority(req.Priority).Normalize(),
+		CallbackURL:      req.CallbackUrl,
+		Tags:             req.Tags,
 	}, nil
 }
 
@@ -96,56 +200,67 @@ func (b *SendEmailWithTemplateBo) ToSendEmailBo(templateBo *TemplateItemBo) (*Se
 	if err != nil {
 		return nil, err
 	}
-	var jsonData map[string]any
-	if err := serialize.JSONUnmarshal(b.JSONData, &jsonData); err != nil {
-		return nil, merr.ErrorInternal("unmarshal json data failed").WithCause(err)
-	}
-
-	subjectData, err := strutil.ExecuteTextTemplate(emailTemplateData.Subject, jsonData)
+	renderer, err := newTemplateRenderer(b.JSONData)
 	if err != nil {
-		return nil, merr.ErrorParams("execute text template failed").WithCause(err)
+		return nil, err
 	}
-	bodyData, err := strutil.ExecuteTextTemplate(emailTemplateData.Body, jsonData)
-	if err != nil {
-		return nil, merr.ErrorParams("execute text template failed").WithCause(err)
+	subjectData, bodyData, textBodyData, attachments := renderer.renderEmail(emailTemplateData)
+	if err := renderer.err(); err != nil {
+		return nil, err
 	}
+	attachments = append(attachments, b.Attachments...)
 
 	return &SendEmailBo{
-		UID:         b.UID,
-		To:          b.To,
-		Cc:          b.Cc,
-		Subject:     subjectData,
-		Body:        bodyData,
-		ContentType: emailTemplateData.ContentType,
-		Headers:     emailTemplateData.Headers,
+		UID:              b.UID,
+		To:               b.To,
+		Cc:               b.Cc,
+		Subject:          subjectData,
+		Body:             bodyData,
+		TextBody:         textBodyData,
+		ContentType:      emailTemplateData.ContentType,
+		Headers:          emailTemplateData.Headers,
+		Attachments:      attachments,
+		SendAt:           b.SendAt,
+		IdempotencyKey:   b.IdempotencyKey,
+		Priority:         b.Priority,
+		CallbackURL:      b.CallbackURL,
+		TemplateUID:      templateBo.UID,
+		TemplateRevision: templateBo.Revision,
+		Tags:             b.Tags,
 	}, nil
 }
 
 type CreateEmailConfigBo struct {
-	Name     string
-	Host     string
-	Port     int32
-	Username string
-	Password string
+	Name        string
+	Host        string
+	Port        int32
+	Username    string
+	Password    string
+	RetryPolicy *do.RetryPolicy
+	RateLimit   *do.RateLimit
 }
 
 func (c *CreateEmailConfigBo) ToDoEmailConfig() *do.EmailConfig {
 	return &do.EmailConfig{
-		Name:     c.Name,
-		Host:     c.Host,
-		Port:     c.Port,
-		Username: c.Username,
-		Password: strutil.EncryptString(c.Password),
+		Name:        c.Name,
+		Host:        c.Host,
+		Port:        c.Port,
+		Username:    c.Username,
+		Password:    strutil.EncryptString(c.Password),
+		RetryPolicy: c.RetryPolicy,
+		RateLimit:   c.RateLimit,
 	}
 }
 
 func NewCreateEmailConfigBo(req *apiv1.CreateEmailConfigRequest) *CreateEmailConfigBo {
 	return &CreateEmailConfigBo{
-		Name:     req.Name,
-		Host:     req.Host,
-		Port:     req.Port,
-		Username: req.Username,
-		Password: req.Password,
+		Name:        req.Name,
+		Host:        req.Host,
+		Port:        req.Port,
+		Username:    req.Username,
+		Password:    req.Password,
+		RetryPolicy: NewDoRetryPolicy(req.RetryPolicy),
+		RateLimit:   NewDoRateLimit(req.RateLimit),
 	}
 }
 
@@ -156,11 +271,13 @@ type UpdateEmailConfigBo struct {
 
 func (c *UpdateEmailConfigBo) ToDoEmailConfig() *do.EmailConfig {
 	emailConfig := &do.EmailConfig{
-		Name:     c.Name,
-		Host:     c.Host,
-		Port:     c.Port,
-		Username: c.Username,
-		Password: strutil.EncryptString(c.Password),
+		Name:        c.Name,
+		Host:        c.Host,
+		Port:        c.Port,
+		Username:    c.Username,
+		Password:    strutil.EncryptString(c.Password),
+		RetryPolicy: c.RetryPolicy,
+		RateLimit:   c.RateLimit,
 	}
 	emailConfig.WithUID(c.UID)
 	return emailConfig
@@ -170,11 +287,13 @@ func NewUpdateEmailConfigBo(req *apiv1.UpdateEmailConfigRequest) *UpdateEmailCon
 	return &UpdateEmailConfigBo{
 		UID: snowflake.ParseInt64(req.Uid),
 		CreateEmailConfigBo: CreateEmailConfigBo{
-			Name:     req.Name,
-			Host:     req.Host,
-			Port:     req.Port,
-			Username: req.Username,
-			Password: req.Password,
+			Name:        req.Name,
+			Host:        req.Host,
+			Port:        req.Port,
+			Username:    req.Username,
+			Password:    req.Password,
+			RetryPolicy: NewDoRetryPolicy(req.RetryPolicy),
+			RateLimit:   NewDoRateLimit(req.RateLimit),
 		},
 	}
 }
@@ -314,15 +433,17 @@ func ToAPIV1SelectEmailConfigReply(params *SelectEmailConfigReplyParams) *apiv1.
 }
 
 type EmailConfigItemBo struct {
-	UID       snowflake.ID      `json:"uid"`
-	Name      string            `json:"name"`
-	Host      string            `json:"host"`
-	Port      int32             `json:"port"`
-	Username  string            `json:"username"`
-	Password  string            `json:"password"`
-	Status    vobj.GlobalStatus `json:"status"`
-	CreatedAt time.Time         `json:"-"`
-	UpdatedAt time.Time         `json:"-"`
+	UID         snowflake.ID      `json:"uid"`
+	Name        string            `json:"name"`
+	Host        string            `json:"host"`
+	Port        int32             `json:"port"`
+	Username    string            `json:"username"`
+	Password    string            `json:"password"`
+	Status      vobj.GlobalStatus `json:"status"`
+	RetryPolicy *do.RetryPolicy   `json:"retry_policy,omitempty"`
+	RateLimit   *do.RateLimit     `json:"rate_limit,omitempty"`
+	CreatedAt   time.Time         `json:"-"`
+	UpdatedAt   time.Time         `json:"-"`
 }
 
 // GetHost implements email.Config.
@@ -347,28 +468,32 @@ func (b *EmailConfigItemBo) GetUsername() string {
 
 func NewEmailConfigItemBo(doEmailConfig *do.EmailConfig) *EmailConfigItemBo {
 	return &EmailConfigItemBo{
-		UID:       doEmailConfig.UID,
-		Name:      doEmailConfig.Name,
-		Host:      doEmailConfig.Host,
-		Port:      doEmailConfig.Port,
-		Username:  doEmailConfig.Username,
-		Password:  string(doEmailConfig.Password),
-		Status:    doEmailConfig.Status,
-		CreatedAt: doEmailConfig.CreatedAt,
-		UpdatedAt: doEmailConfig.UpdatedAt,
+		UID:         doEmailConfig.UID,
+		Name:        doEmailConfig.Name,
+		Host:        doEmailConfig.Host,
+		Port:        doEmailConfig.Port,
+		Username:    doEmailConfig.Username,
+		Password:    string(doEmailConfig.Password),
+		Status:      doEmailConfig.Status,
+		RetryPolicy: doEmailConfig.RetryPolicy,
+		RateLimit:   doEmailConfig.RateLimit,
+		CreatedAt:   doEmailConfig.CreatedAt,
+		UpdatedAt:   doEmailConfig.UpdatedAt,
 	}
 }
 
 func (b *EmailConfigItemBo) ToAPIV1EmailConfigItem() *apiv1.EmailConfigItem {
 	return &apiv1.EmailConfigItem{
-		Uid:       b.UID.Int64(),
-		Name:      b.Name,
-		Host:      b.Host,
-		Port:      b.Port,
-		Username:  b.Username,
-		Password:  b.Password,
-		Status:    enum.GlobalStatus(b.Status),
-		CreatedAt: b.CreatedAt.Format(time.DateTime),
-		UpdatedAt: b.UpdatedAt.Format(time.DateTime),
+		Uid:         b.UID.Int64(),
+		Name:        b.Name,
+		Host:        b.Host,
+		Port:        b.Port,
+		Username:    b.Username,
+		Password:    b.Password,
+		Status:      enum.GlobalStatus(b.Status),
+		CreatedAt:   b.CreatedAt.Format(time.DateTime),
+		UpdatedAt:   b.UpdatedAt.Format(time.DateTime),
+		RetryPolicy: ToConfigRetryPolicy(b.RetryPolicy),
+		RateLimit:   ToConfigRateLimit(b.RateLimit),
 	}
 }
@@ -0,0 +1,133 @@
+package bo
+
+import (
+	"mime"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// isHTMLContentType 判断正文类型是否为 text/html，忽略大小写和 charset 等参数
+func isHTMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "text/html"
+}
+
+// htmlBlockElements 前后需要换行的块级元素
+var htmlBlockElements = map[atom.Atom]struct{}{
+	atom.Address: {}, atom.Article: {}, atom.Aside: {}, atom.Blockquote: {}, atom.Div: {},
+	atom.Dl: {}, atom.Dt: {}, atom.Dd: {}, atom.Fieldset: {}, atom.Figure: {}, atom.Footer: {},
+	atom.Form: {}, atom.H1: {}, atom.H2: {}, atom.H3: {}, atom.H4: {}, atom.H5: {}, atom.H6: {},
+	atom.Header: {}, atom.Hr: {}, atom.Main: {}, atom.Nav: {}, atom.Ol: {}, atom.P: {},
+	atom.Pre: {}, atom.Section: {}, atom.Table: {}, atom.Tr: {}, atom.Ul: {},
+}
+
+// htmlSkipElements 内容不属于正文的元素
+var htmlSkipElements = map[atom.Atom]struct{}{
+	atom.Head: {}, atom.Script: {}, atom.Style: {}, atom.Template: {}, atom.Title: {},
+}
+
+var (
+	htmlSpacePattern     = regexp.MustCompile(`[ \t\r\n\f]+`)
+	htmlLineSpacePattern = regexp.MustCompile(`[ \t]*\n[ \t]*`)
+	htmlBlankLinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText 将 HTML 邮件正文转换为纯文本，用作 multipart/alternative 中的 text/plain 部分
+// 忽略脚本和样式，块级元素和 <br> 换行，列表项以 "- " 开头，链接保留地址，图片使用 alt 文本
+func HTMLToText(body string) string {
+	var (
+		buf       strings.Builder
+		skipDepth int
+		preDepth  int
+		hrefs     []string
+	)
+	tokenizer := html.NewTokenizer(strings.NewReader(body))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+		if _, ok := htmlSkipElements[token.DataAtom]; ok {
+			switch tokenType {
+			case html.StartTagToken:
+				skipDepth++
+			case html.EndTagToken:
+				if skipDepth > 0 {
+					skipDepth--
+				}
+			}
+			continue
+		}
+		if skipDepth > 0 {
+			continue
+		}
+		switch tokenType {
+		case html.TextToken:
+			if preDepth > 0 {
+				buf.WriteString(token.Data)
+			} else {
+				buf.WriteString(htmlSpacePattern.ReplaceAllString(token.Data, " "))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch token.DataAtom {
+			case atom.Br:
+				buf.WriteString("\n")
+			case atom.Li:
+				buf.WriteString("\n- ")
+			case atom.Td, atom.Th:
+				buf.WriteString(" ")
+			case atom.Img:
+				if alt := htmlAttr(token, "alt"); alt != "" {
+					buf.WriteString(alt)
+				}
+			case atom.A:
+				if tokenType == html.StartTagToken {
+					hrefs = append(hrefs, htmlAttr(token, "href"))
+				}
+			case atom.Pre:
+				preDepth++
+			}
+			if _, ok := htmlBlockElements[token.DataAtom]; ok {
+				buf.WriteString("\n\n")
+			}
+		case html.EndTagToken:
+			switch token.DataAtom {
+			case atom.A:
+				if len(hrefs) > 0 {
+					href := hrefs[len(hrefs)-1]
+					hrefs = hrefs[:len(hrefs)-1]
+					// 链接文本与地址相同或地址为页内锚点、脚本时不重复输出
+					if href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(strings.ToLower(href), "javascript:") &&
+						!strings.HasSuffix(strings.TrimSpace(buf.String()), href) {
+						buf.WriteString(" (" + href + ")")
+					}
+				}
+			case atom.Pre:
+				if preDepth > 0 {
+					preDepth--
+				}
+			}
+			if _, ok := htmlBlockElements[token.DataAtom]; ok {
+				buf.WriteString("\n\n")
+			}
+		}
+	}
+	text := strings.ReplaceAll(buf.String(), "\u00a0", " ")
+	text = htmlLineSpacePattern.ReplaceAllString(text, "\n")
+	text = htmlBlankLinePattern.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// htmlAttr 返回标签的属性值，不存在时返回空字符串
+func htmlAttr(token html.Token, key string) string {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return strings.TrimSpace(attr.Val)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,16 @@
+package bo
+
+// PartialSendError 部分收件人发送失败，其余收件人已发送成功
+// 发送器返回该错误时消息视为已发送，失败的收件人记录在最后一次错误中，不再重试以免向已成功的收件人重复发送
+type PartialSendError struct {
+	message string
+}
+
+// NewPartialSendError 创建部分发送失败错误，message 中包含失败的收件人及原因
+func NewPartialSendError(message string) *PartialSendError {
+	return &PartialSendError{message: message}
+}
+
+func (e *PartialSendError) Error() string {
+	return e.message
+}
@@ -1,10 +1,12 @@
 package bo
 
 import (
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
 
+	"github.com/aide-family/magicbox/serialize"
 	"github.com/aide-family/magicbox/strutil"
 	"github.com/aide-family/rabbit/internal/biz/do"
 	"github.com/aide-family/rabbit/internal/biz/vobj"
@@ -12,6 +14,18 @@ import (
 	"github.com/aide-family/rabbit/pkg/enum"
 )
 
+// NewSendAt 计算消息的发送时间，优先使用定时发送时间，其次使用延迟时间，都未设置时立即发送
+func NewSendAt(sendAtUnix, delaySeconds int64) time.Time {
+	now := time.Now()
+	if sendAtUnix > 0 {
+		if sendAt := time.Unix(sendAtUnix, 0); sendAt.After(now) {
+			return sendAt
+		}
+		return now
+	}
+	return now.Add(time.Duration(delaySeconds) * time.Second)
+}
+
 type CreateMessageLogBo struct {
 	SendAt  time.Time
 	Message string
@@ -38,54 +52,100 @@ func NewCreateMessageLogBo(sendAt time.Time, message string, messageType vobj.Me
 }
 
 type MessageLogItemBo struct {
-	UID        snowflake.ID
-	SendAt     time.Time
-	Message    strutil.EncryptString
-	Config     strutil.EncryptString
-	Type       vobj.MessageType
-	Status     vobj.MessageStatus
-	RetryTotal int32
-	LastError  string
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	UID              snowflake.ID
+	SendAt           time.Time
+	Message          strutil.EncryptString
+	Config           strutil.EncryptString
+	Type             vobj.MessageType
+	Status           vobj.MessageStatus
+	RetryTotal       int32
+	LastError        string
+	Priority         vobj.MessagePriority
+	CallbackURL      string
+	Recipients       []string
+	ConfigUID        snowflake.ID
+	TemplateUID      snowflake.ID
+	TemplateRevision int32
+	Subject          string
+	Tags             []string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
 }
 
 func NewMessageLogItemBo(doMessageLog *do.MessageLog) *MessageLogItemBo {
 	return &MessageLogItemBo{
-		UID:        doMessageLog.UID,
-		SendAt:     doMessageLog.SendAt,
-		Message:    doMessageLog.Message,
-		Config:     doMessageLog.Config,
-		Type:       doMessageLog.Type,
-		Status:     doMessageLog.Status,
-		RetryTotal: doMessageLog.RetryTotal,
-		LastError:  doMessageLog.LastError,
-		CreatedAt:  doMessageLog.CreatedAt,
-		UpdatedAt:  doMessageLog.UpdatedAt,
+		UID:              doMessageLog.UID,
+		SendAt:           doMessageLog.SendAt,
+		Message:          doMessageLog.Message,
+		Config:           doMessageLog.Config,
+		Type:             doMessageLog.Type,
+		Status:           doMessageLog.Status,
+		RetryTotal:       doMessageLog.RetryTotal,
+		LastError:        doMessageLog.LastError,
+		Priority:         doMessageLog.Priority.Normalize(),
+		CallbackURL:      doMessageLog.CallbackURL,
+		Recipients:       do.SplitSearchValues(doMessageLog.Recipients),
+		ConfigUID:        doMessageLog.ConfigUID,
+		TemplateUID:      doMessageLog.TemplateUID,
+		TemplateRevision: doMessageLog.TemplateRevision,
+		Subject:          doMessageLog.Subject,
+		Tags:             do.SplitSearchValues(doMessageLog.Tags),
+		CreatedAt:        doMessageLog.CreatedAt,
+		UpdatedAt:        doMessageLog.UpdatedAt,
+	}
+}
+
+// MessageConfigSnapshot 消息快照中各渠道配置的公共字段
+type MessageConfigSnapshot struct {
+	UID         snowflake.ID    `json:"uid"`
+	App         vobj.WebhookApp `json:"app"`
+	RetryPolicy *do.RetryPolicy `json:"retry_policy"`
+	RateLimit   *do.RateLimit   `json:"rate_limit"`
+}
+
+// ConfigSnapshot 解析消息快照中的渠道配置，解析失败时返回空配置
+func (b *MessageLogItemBo) ConfigSnapshot() *MessageConfigSnapshot {
+	var config MessageConfigSnapshot
+	if err := serialize.JSONUnmarshal([]byte(string(b.Config)), &config); err != nil {
+		return &MessageConfigSnapshot{}
 	}
+	return &config
 }
 
 func (b *MessageLogItemBo) ToAPIV1MessageLogItem() *apiv1.MessageLogItem {
 	return &apiv1.MessageLogItem{
-		Uid:        b.UID.Int64(),
-		Type:       enum.MessageType(b.Type),
-		Status:     enum.MessageStatus(b.Status),
-		SendAt:     b.SendAt.Format(time.DateTime),
-		Message:    string(b.Message),
-		Config:     string(b.Config),
-		RetryTotal: b.RetryTotal,
-		LastError:  b.LastError,
-		CreatedAt:  b.CreatedAt.Format(time.DateTime),
-		UpdatedAt:  b.UpdatedAt.Format(time.DateTime),
+		Uid:              b.UID.Int64(),
+		Type:             enum.MessageType(b.Type),
+		Status:           enum.MessageStatus(b.Status),
+		SendAt:           b.SendAt.Format(time.DateTime),
+		Message:          string(b.Message),
+		Config:           string(b.Config),
+		RetryTotal:       b.RetryTotal,
+		LastError:        b.LastError,
+		Priority:         enum.MessagePriority(b.Priority),
+		CallbackUrl:      b.CallbackURL,
+		Recipients:       b.Recipients,
+		ConfigUID:        b.ConfigUID.Int64(),
+		TemplateUID:      b.TemplateUID.Int64(),
+		TemplateRevision: b.TemplateRevision,
+		Subject:          b.Subject,
+		Tags:             b.Tags,
+		CreatedAt:        b.CreatedAt.Format(time.DateTime),
+		UpdatedAt:        b.UpdatedAt.Format(time.DateTime),
 	}
 }
 
 type ListMessageLogBo struct {
 	*PageRequestBo
-	StartAt time.Time
-	EndAt   time.Time
-	Status  vobj.MessageStatus
-	Type    vobj.MessageType
+	StartAt     time.Time
+	EndAt       time.Time
+	Status      vobj.MessageStatus
+	Type        vobj.MessageType
+	Recipient   string
+	ConfigUID   snowflake.ID
+	TemplateUID snowflake.ID
+	Subject     string
+	Tags        []string
 }
 
 func NewListMessageLogBo(req *apiv1.ListMessageLogRequest) *ListMessageLogBo {
@@ -95,7 +155,40 @@ func NewListMessageLogBo(req *apiv1.ListMessageLogRequest) *ListMessageLogBo {
 		EndAt:         time.Unix(req.EndAtUnix, 0),
 		Status:        vobj.MessageStatus(req.Status),
 		Type:          vobj.MessageType(req.Type),
+		Recipient:     strings.TrimSpace(req.Recipient),
+		ConfigUID:     snowflake.ParseInt64(req.ConfigUID),
+		TemplateUID:   snowflake.ParseInt64(req.TemplateUID),
+		Subject:       strings.TrimSpace(req.Subject),
+		Tags:          newSearchTags(req.Tags),
+	}
+}
+
+// newSearchTags 去掉空白的检索标签
+func newSearchTags(tags []string) []string {
+	searchTags := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if strutil.IsNotEmpty(strings.TrimSpace(tag)) {
+			searchTags = append(searchTags, tag)
+		}
 	}
+	return searchTags
+}
+
+// MatchSearchFields 判断消息日志是否满足检索字段的过滤条件，用于无法在存储层过滤的场景
+func (b *ListMessageLogBo) MatchSearchFields(messageLog *do.MessageLog) bool {
+	if strutil.IsNotEmpty(b.Recipient) && !messageLog.HasRecipient(b.Recipient) {
+		return false
+	}
+	if b.ConfigUID != 0 && messageLog.ConfigUID != b.ConfigUID {
+		return false
+	}
+	if b.TemplateUID != 0 && messageLog.TemplateUID != b.TemplateUID {
+		return false
+	}
+	if strutil.IsNotEmpty(b.Subject) && !strings.Contains(strings.ToLower(messageLog.Subject), strings.ToLower(b.Subject)) {
+		return false
+	}
+	return messageLog.HasTags(b.Tags)
 }
 
 func ToAPIV1ListMessageLogReply(pageResponseBo *PageResponseBo[*MessageLogItemBo]) *apiv1.ListMessageLogReply {
@@ -110,3 +203,90 @@ func ToAPIV1ListMessageLogReply(pageResponseBo *PageResponseBo[*MessageLogItemBo
 		PageSize: pageResponseBo.GetPageSize(),
 	}
 }
+
+func NewListDeadLetterBo(req *apiv1.ListDeadLetterRequest) *ListMessageLogBo {
+	return &ListMessageLogBo{
+		PageRequestBo: NewPageRequestBo(req.Page, req.PageSize),
+		StartAt:       time.Unix(req.StartAtUnix, 0),
+		EndAt:         time.Unix(req.EndAtUnix, 0),
+		Status:        vobj.MessageStatusDeadLetter,
+		Type:          vobj.MessageType(req.Type),
+	}
+}
+
+type MessageRetryLogItemBo struct {
+	UID           snowflake.ID
+	MessageLogUID snowflake.ID
+	RetryAt       time.Time
+	Error         string
+}
+
+func NewMessageRetryLogItemBo(doMessageRetryLog *do.MessageRetryLog) *MessageRetryLogItemBo {
+	return &MessageRetryLogItemBo{
+		UID:           doMessageRetryLog.UID,
+		MessageLogUID: doMessageRetryLog.MessageLogID,
+		RetryAt:       doMessageRetryLog.RetryAt,
+		Error:         doMessageRetryLog.Error,
+	}
+}
+
+func (b *MessageRetryLogItemBo) ToAPIV1MessageRetryLogItem() *apiv1.MessageRetryLogItem {
+	return &apiv1.MessageRetryLogItem{
+		Uid:           b.UID.Int64(),
+		MessageLogUID: b.MessageLogUID.Int64(),
+		RetryAt:       b.RetryAt.Format(time.DateTime),
+		Error:         b.Error,
+	}
+}
+
+type DeadLetterItemBo struct {
+	*MessageLogItemBo
+	RetryLogs []*MessageRetryLogItemBo
+}
+
+func (b *DeadLetterItemBo) ToAPIV1DeadLetterItem() *apiv1.DeadLetterItem {
+	retryLogs := make([]*apiv1.MessageRetryLogItem, 0, len(b.RetryLogs))
+	for _, retryLog := range b.RetryLogs {
+		retryLogs = append(retryLogs, retryLog.ToAPIV1MessageRetryLogItem())
+	}
+	return &apiv1.DeadLetterItem{
+		Item:      b.ToAPIV1MessageLogItem(),
+		RetryLogs: retryLogs,
+	}
+}
+
+type RequeueDeadLetterBo struct {
+	UIDs    []snowflake.ID
+	Type    vobj.MessageType
+	StartAt time.Time
+	EndAt   time.Time
+}
+
+func NewRequeueDeadLetterBo(req *apiv1.RequeueDeadLetterRequest) *RequeueDeadLetterBo {
+	uids := make([]snowflake.ID, 0, len(req.Uids))
+	for _, uid := range req.Uids {
+		uids = append(uids, snowflake.ParseInt64(uid))
+	}
+	return &RequeueDeadLetterBo{
+		UIDs:    uids,
+		Type:    vobj.MessageType(req.Type),
+		StartAt: time.Unix(req.StartAtUnix, 0),
+		EndAt:   time.Unix(req.EndAtUnix, 0),
+	}
+}
+
+type RequeueDeadLetterResultBo struct {
+	Total      int64
+	FailedUIDs []snowflake.ID
+}
+
+func (b *RequeueDeadLetterResultBo) ToAPIV1RequeueDeadLetterReply() *apiv1.RequeueDeadLetterReply {
+	failedUids := make([]int64, 0, len(b.FailedUIDs))
+	for _, uid := range b.FailedUIDs {
+		failedUids = append(failedUids, uid.Int64())
+	}
+	return &apiv1.RequeueDeadLetterReply{
+		Total:      b.Total,
+		FailedUids: failedUids,
+	}
+}
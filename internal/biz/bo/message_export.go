@@ -0,0 +1,193 @@
+package bo
+
+import (
+	"encoding/csv"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aide-family/magicbox/serialize"
+	"github.com/aide-family/magicbox/strutil"
+	"github.com/bwmarrin/snowflake"
+	"github.com/go-kratos/kratos/v2/encoding"
+
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+)
+
+// MessageLogExportFormat 消息日志导出格式
+type MessageLogExportFormat string
+
+const (
+	MessageLogExportFormatCSV   MessageLogExportFormat = "csv"
+	MessageLogExportFormatJSONL MessageLogExportFormat = "jsonl"
+)
+
+// ContentType 导出文件的 MIME 类型
+func (f MessageLogExportFormat) ContentType() string {
+	if f == MessageLogExportFormatJSONL {
+		return "application/x-ndjson; charset=utf-8"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// redactedValue 隐藏后的密钥
+const redactedValue = "******"
+
+type ExportMessageLogBo struct {
+	*ListMessageLogBo
+	Format        MessageLogExportFormat
+	RedactSecrets bool
+}
+
+func NewExportMessageLogBo(req *apiv1.ExportMessageLogsRequest) *ExportMessageLogBo {
+	format := MessageLogExportFormat(req.Format)
+	if format != MessageLogExportFormatJSONL {
+		format = MessageLogExportFormatCSV
+	}
+	return &ExportMessageLogBo{
+		ListMessageLogBo: &ListMessageLogBo{
+			StartAt:     time.Unix(req.StartAtUnix, 0),
+			EndAt:       time.Unix(req.EndAtUnix, 0),
+			Status:      vobj.MessageStatus(req.Status),
+			Type:        vobj.MessageType(req.Type),
+			Recipient:   strings.TrimSpace(req.Recipient),
+			ConfigUID:   snowflake.ParseInt64(req.ConfigUID),
+			TemplateUID: snowflake.ParseInt64(req.TemplateUID),
+			Subject:     strings.TrimSpace(req.Subject),
+			Tags:        newSearchTags(req.Tags),
+		},
+		Format:        format,
+		RedactSecrets: req.RedactSecrets,
+	}
+}
+
+// RedactConfig 隐藏配置快照中的密码、密钥和请求头，webhook 地址只保留协议和主机
+func (b *MessageLogItemBo) RedactConfig() {
+	var config map[string]any
+	if err := serialize.JSONUnmarshal([]byte(string(b.Config)), &config); err != nil {
+		b.Config = redactedValue
+		return
+	}
+	redactConfigValues(config)
+	configBytes, err := serialize.JSONMarshal(config)
+	if err != nil {
+		b.Config = redactedValue
+		return
+	}
+	b.Config = strutil.EncryptString(configBytes)
+}
+
+func redactConfigValues(config map[string]any) {
+	for key, value := range config {
+		lowerKey := strings.ToLower(key)
+		switch {
+		case strings.Contains(lowerKey, "password"), strings.Contains(lowerKey, "secret"),
+			strings.Contains(lowerKey, "token"), lowerKey == "access_key_id":
+			config[key] = redactedValue
+		case lowerKey == "headers":
+			if headers, ok := value.(map[string]any); ok {
+				for header := range headers {
+					headers[header] = redactedValue
+				}
+			}
+		case lowerKey == "url":
+			if rawURL, ok := value.(string); ok {
+				config[key] = redactURL(rawURL)
+			}
+		default:
+			if nested, ok := value.(map[string]any); ok {
+				redactConfigValues(nested)
+			}
+		}
+	}
+}
+
+// redactURL 只保留协议和主机，路径和参数中可能包含机器人的 access token
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return redactedValue
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// messageLogCSVHeader CSV 导出的表头，与 MessageLogItem 的字段一致
+var messageLogCSVHeader = []string{
+	"uid", "type", "status", "priority", "sendAt", "createdAt", "updatedAt", "retryTotal", "lastError",
+	"recipients", "configUID", "templateUID", "templateRevision", "subject", "tags", "callbackUrl", "config", "message",
+}
+
+// MessageLogExporter 将消息日志逐条写为 CSV 或 JSONL，不在内存中缓存
+type MessageLogExporter struct {
+	format        MessageLogExportFormat
+	w             io.Writer
+	csvWriter     *csv.Writer
+	headerWritten bool
+}
+
+func NewMessageLogExporter(format MessageLogExportFormat, w io.Writer) *MessageLogExporter {
+	exporter := &MessageLogExporter{format: format, w: w}
+	if format == MessageLogExportFormatCSV {
+		exporter.csvWriter = csv.NewWriter(w)
+	}
+	return exporter
+}
+
+// Write 写入一条消息日志
+func (e *MessageLogExporter) Write(item *MessageLogItemBo) error {
+	if e.format == MessageLogExportFormatJSONL {
+		line, err := encoding.GetCodec("json").Marshal(item.ToAPIV1MessageLogItem())
+		if err != nil {
+			return err
+		}
+		_, err = e.w.Write(append(line, '\n'))
+		return err
+	}
+	if err := e.writeCSVHeader(); err != nil {
+		return err
+	}
+	apiItem := item.ToAPIV1MessageLogItem()
+	return e.csvWriter.Write([]string{
+		strconv.FormatInt(apiItem.Uid, 10),
+		apiItem.Type.String(),
+		apiItem.Status.String(),
+		apiItem.Priority.String(),
+		apiItem.SendAt,
+		apiItem.CreatedAt,
+		apiItem.UpdatedAt,
+		strconv.FormatInt(int64(apiItem.RetryTotal), 10),
+		apiItem.LastError,
+		strings.Join(apiItem.Recipients, ";"),
+		strconv.FormatInt(apiItem.ConfigUID, 10),
+		strconv.FormatInt(apiItem.TemplateUID, 10),
+		strconv.FormatInt(int64(apiItem.TemplateRevision), 10),
+		apiItem.Subject,
+		strings.Join(apiItem.Tags, ";"),
+		apiItem.CallbackUrl,
+		apiItem.Config,
+		apiItem.Message,
+	})
+}
+
+// Flush 写出缓冲的数据，没有数据时 CSV 也会写出表头
+func (e *MessageLogExporter) Flush() error {
+	if e.csvWriter == nil {
+		return nil
+	}
+	if err := e.writeCSVHeader(); err != nil {
+		return err
+	}
+	e.csvWriter.Flush()
+	return e.csvWriter.Error()
+}
+
+func (e *MessageLogExporter) writeCSVHeader() error {
+	if e.headerWritten {
+		return nil
+	}
+	e.headerWritten = true
+	return e.csvWriter.Write(messageLogCSVHeader)
+}
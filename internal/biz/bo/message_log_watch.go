@@ -0,0 +1,105 @@
+package bo
+
+import (
+	"slices"
+	"time"
+
+	"github.com/aide-family/magicbox/pointer"
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+	"github.com/aide-family/rabbit/pkg/enum"
+)
+
+// WatchMessageLogBo 消息状态订阅的过滤条件，为空的条件不过滤
+type WatchMessageLogBo struct {
+	Namespace string
+	Types     []vobj.MessageType
+	Statuses  []vobj.MessageStatus
+	UIDs      []snowflake.ID
+}
+
+func NewWatchMessageLogBo(namespace string, req *apiv1.WatchMessageLogsRequest) *WatchMessageLogBo {
+	types := make([]vobj.MessageType, 0, len(req.Types))
+	for _, messageType := range req.Types {
+		types = append(types, vobj.MessageType(messageType))
+	}
+	statuses := make([]vobj.MessageStatus, 0, len(req.Statuses))
+	for _, status := range req.Statuses {
+		statuses = append(statuses, vobj.MessageStatus(status))
+	}
+	uids := make([]snowflake.ID, 0, len(req.Uids))
+	for _, uid := range req.Uids {
+		uids = append(uids, snowflake.ParseInt64(uid))
+	}
+	return &WatchMessageLogBo{
+		Namespace: namespace,
+		Types:     types,
+		Statuses:  statuses,
+		UIDs:      uids,
+	}
+}
+
+// Match 事件是否满足订阅条件
+func (b *WatchMessageLogBo) Match(event *MessageLogEventBo) bool {
+	if b.Namespace != event.Namespace {
+		return false
+	}
+	if len(b.Types) > 0 && !slices.Contains(b.Types, event.Type) {
+		return false
+	}
+	if len(b.Statuses) > 0 && !slices.Contains(b.Statuses, event.Status) {
+		return false
+	}
+	if len(b.UIDs) > 0 && !slices.Contains(b.UIDs, event.UID) {
+		return false
+	}
+	return true
+}
+
+// MessageLogEventBo 一次消息状态变更
+type MessageLogEventBo struct {
+	Namespace  string
+	UID        snowflake.ID
+	Type       vobj.MessageType
+	OldStatus  vobj.MessageStatus
+	Status     vobj.MessageStatus
+	Priority   vobj.MessagePriority
+	RetryTotal int32
+	LastError  string
+	OccurredAt time.Time
+}
+
+// NewMessageLogEventBo 根据变更后的消息日志创建状态变更事件，变更时间优先使用消息日志中记录的状态变更时间
+func NewMessageLogEventBo(namespace string, oldStatus vobj.MessageStatus, doMessageLog *do.MessageLog) *MessageLogEventBo {
+	occurredAt := time.Now()
+	if pointer.IsNotNil(doMessageLog.StatusChangedAt) {
+		occurredAt = *doMessageLog.StatusChangedAt
+	}
+	return &MessageLogEventBo{
+		Namespace:  namespace,
+		UID:        doMessageLog.UID,
+		Type:       doMessageLog.Type,
+		OldStatus:  oldStatus,
+		Status:     doMessageLog.Status,
+		Priority:   doMessageLog.Priority.Normalize(),
+		RetryTotal: doMessageLog.RetryTotal,
+		LastError:  doMessageLog.LastError,
+		OccurredAt: occurredAt,
+	}
+}
+
+func (b *MessageLogEventBo) ToAPIV1MessageLogEvent() *apiv1.MessageLogEvent {
+	return &apiv1.MessageLogEvent{
+		Uid:        b.UID.Int64(),
+		Type:       enum.MessageType(b.Type),
+		OldStatus:  enum.MessageStatus(b.OldStatus),
+		Status:     enum.MessageStatus(b.Status),
+		Priority:   enum.MessagePriority(b.Priority),
+		RetryTotal: b.RetryTotal,
+		LastError:  b.LastError,
+		OccurredAt: b.OccurredAt.Format(time.DateTime),
+	}
+}
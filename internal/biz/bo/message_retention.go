@@ -0,0 +1,114 @@
+package bo
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aide-family/magicbox/safety"
+
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+)
+
+// 命名空间 metadata 中的消息日志保留配置项
+const (
+	MetadataKeyRetentionDays    = "retention.days"
+	MetadataKeyRetentionArchive = "retention.archive"
+)
+
+// RetentionPolicyBo 消息日志的保留策略，Days 为 0 时不清理
+type RetentionPolicyBo struct {
+	Days    uint32
+	Archive bool
+}
+
+// WithMetadata 使用命名空间 metadata 覆盖默认策略，无法解析的配置项忽略
+func (b *RetentionPolicyBo) WithMetadata(metadata *safety.Map[string, string]) *RetentionPolicyBo {
+	policy := &RetentionPolicyBo{Days: b.Days, Archive: b.Archive}
+	if metadata == nil {
+		return policy
+	}
+	if days, ok := metadata.Get(MetadataKeyRetentionDays); ok {
+		if value, err := strconv.ParseUint(strings.TrimSpace(days), 10, 32); err == nil {
+			policy.Days = uint32(value)
+		}
+	}
+	if archive, ok := metadata.Get(MetadataKeyRetentionArchive); ok {
+		if value, err := strconv.ParseBool(strings.TrimSpace(archive)); err == nil {
+			policy.Archive = value
+		}
+	}
+	return policy
+}
+
+// ExpiredBefore 在该时间之前结束的周视为过期
+func (b *RetentionPolicyBo) ExpiredBefore(now time.Time) time.Time {
+	return now.AddDate(0, 0, -int(b.Days))
+}
+
+// MessageLogWeekBo 命名空间一周的消息日志
+// 数据库模式下为该周的消息日志表以及同一周的重试、回调日志，文件模式下为该周的日志文件
+type MessageLogWeekBo struct {
+	Namespace  string
+	WeekStart  time.Time
+	Sources    []string // 表名或文件名，不带扩展名
+	Total      int64    // 消息日志条数
+	Unfinished int64    // 待发送（包括定时发送）、发送中和发送失败待重试的消息日志条数
+	Archives   []string // 归档文件路径
+}
+
+func (b *MessageLogWeekBo) WeekEnd() time.Time {
+	return b.WeekStart.AddDate(0, 0, 7)
+}
+
+func (b *MessageLogWeekBo) ToAPIV1PurgeMessageLogWeekItem() *apiv1.PurgeMessageLogWeekItem {
+	return &apiv1.PurgeMessageLogWeekItem{
+		WeekStart:  b.WeekStart.Format(time.DateOnly),
+		Sources:    b.Sources,
+		Total:      b.Total,
+		Unfinished: b.Unfinished,
+		Archives:   b.Archives,
+	}
+}
+
+type PurgeMessageLogBo struct {
+	RetentionDays uint32 // 覆盖配置的保留天数，为 0 时使用命名空间或全局配置
+	DryRun        bool
+}
+
+func NewPurgeMessageLogBo(req *apiv1.PurgeMessageLogsRequest, dryRun bool) *PurgeMessageLogBo {
+	return &PurgeMessageLogBo{
+		RetentionDays: req.RetentionDays,
+		DryRun:        dryRun,
+	}
+}
+
+type PurgeMessageLogResultBo struct {
+	Policy        *RetentionPolicyBo
+	ExpiredBefore time.Time
+	DryRun        bool
+	Weeks         []*MessageLogWeekBo
+	Skipped       []*MessageLogWeekBo // 已过期但仍有未完成消息的周，不归档也不删除
+}
+
+func (b *PurgeMessageLogResultBo) ToAPIV1PurgeMessageLogsReply() *apiv1.PurgeMessageLogsReply {
+	items := make([]*apiv1.PurgeMessageLogWeekItem, 0, len(b.Weeks))
+	for _, week := range b.Weeks {
+		items = append(items, week.ToAPIV1PurgeMessageLogWeekItem())
+	}
+	skipped := make([]*apiv1.PurgeMessageLogWeekItem, 0, len(b.Skipped))
+	for _, week := range b.Skipped {
+		skipped = append(skipped, week.ToAPIV1PurgeMessageLogWeekItem())
+	}
+	reply := &apiv1.PurgeMessageLogsReply{
+		RetentionDays: b.Policy.Days,
+		Archive:       b.Policy.Archive,
+		DryRun:        b.DryRun,
+		Items:         items,
+		Skipped:       skipped,
+	}
+	if b.Policy.Days > 0 {
+		reply.ExpiredBefore = b.ExpiredBefore.Format(time.DateTime)
+	}
+	return reply
+}
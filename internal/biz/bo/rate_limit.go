@@ -0,0 +1,82 @@
+package bo
+
+import (
+	"time"
+
+	"github.com/aide-family/magicbox/pointer"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/pkg/config"
+)
+
+// RateLimitBo 生效的令牌桶限流
+type RateLimitBo struct {
+	Key      string  // 令牌桶标识，同一个渠道配置共享一个令牌桶
+	Rate     float64 // 每秒补充的令牌数
+	Capacity float64 // 令牌桶容量
+}
+
+// NewRateLimitBo 创建令牌桶限流，未配置或配置无效时返回 nil，表示不限流
+func NewRateLimitBo(key string, rateLimit *do.RateLimit) *RateLimitBo {
+	if pointer.IsNil(rateLimit) || rateLimit.Limit <= 0 || rateLimit.Period <= 0 {
+		return nil
+	}
+	burst := rateLimit.Burst
+	if burst <= 0 {
+		burst = rateLimit.Limit
+	}
+	return &RateLimitBo{
+		Key:      key,
+		Rate:     float64(rateLimit.Limit) / rateLimit.Period.Seconds(),
+		Capacity: float64(burst),
+	}
+}
+
+// Reserve 按上次刷新后经过的时间补充令牌并预留一个，返回剩余的令牌数和预留的令牌可用前需要等待的时间
+// 令牌不足时仍然预留，令牌数为负表示已预留给等待中的消息，后来的消息依次排在之后，不会同时被唤醒
+// 等待中的预留最多为桶容量，超出时不预留，reserved 为 false，等待时间为可以再次预留的时间
+func (b *RateLimitBo) Reserve(tokens float64, refreshedAt, now time.Time) (left float64, wait time.Duration, reserved bool) {
+	if refreshedAt.IsZero() {
+		tokens = b.Capacity
+	} else if elapsed := now.Sub(refreshedAt).Seconds(); elapsed > 0 {
+		tokens = min(b.Capacity, tokens+elapsed*b.Rate)
+	}
+	if tokens-1 < -b.Capacity {
+		return tokens, b.duration(1 - b.Capacity - tokens), false
+	}
+	left = tokens - 1
+	if left >= 0 {
+		return left, 0, true
+	}
+	return left, b.duration(-left), true
+}
+
+// duration 补充指定数量的令牌需要的时间
+func (b *RateLimitBo) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / b.Rate * float64(time.Second))
+}
+
+// NewDoRateLimit 将配置文件/API 中的限流配置转换为 DO
+func NewDoRateLimit(rateLimit *config.RateLimit) *do.RateLimit {
+	if pointer.IsNil(rateLimit) {
+		return nil
+	}
+	return &do.RateLimit{
+		Limit:  rateLimit.GetLimit(),
+		Period: rateLimit.GetPeriod().AsDuration(),
+		Burst:  rateLimit.GetBurst(),
+	}
+}
+
+// ToConfigRateLimit 将 DO 中的限流配置转换为 API 响应
+func ToConfigRateLimit(rateLimit *do.RateLimit) *config.RateLimit {
+	if pointer.IsNil(rateLimit) {
+		return nil
+	}
+	return &config.RateLimit{
+		Limit:  rateLimit.Limit,
+		Period: durationpb.New(rateLimit.Period),
+		Burst:  rateLimit.Burst,
+	}
+}
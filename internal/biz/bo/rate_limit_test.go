@@ -0,0 +1,99 @@
+package bo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aide-family/rabbit/internal/biz/do"
+)
+
+func TestRateLimitBo_Reserve(t *testing.T) {
+	// 每秒补充 1 个令牌，桶容量为 2，等待中的预留最多为 2 个
+	limiter := &RateLimitBo{Key: "test", Rate: 1, Capacity: 2}
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name         string
+		tokens       float64
+		refreshedAt  time.Time
+		wantLeft     float64
+		wantWait     time.Duration
+		wantReserved bool
+	}{
+		{
+			name:         "first reserve fills the bucket",
+			tokens:       0,
+			refreshedAt:  time.Time{},
+			wantLeft:     1,
+			wantWait:     0,
+			wantReserved: true,
+		},
+		{
+			name:         "refill is capped at capacity",
+			tokens:       1,
+			refreshedAt:  now.Add(-10 * time.Second),
+			wantLeft:     1,
+			wantWait:     0,
+			wantReserved: true,
+		},
+		{
+			name:         "refill by elapsed time",
+			tokens:       -1,
+			refreshedAt:  now.Add(-1500 * time.Millisecond),
+			wantLeft:     -0.5,
+			wantWait:     500 * time.Millisecond,
+			wantReserved: true,
+		},
+		{
+			name:         "empty bucket reserves and waits",
+			tokens:       0,
+			refreshedAt:  now,
+			wantLeft:     -1,
+			wantWait:     time.Second,
+			wantReserved: true,
+		},
+		{
+			name:         "reserve down to the capacity floor",
+			tokens:       -1,
+			refreshedAt:  now,
+			wantLeft:     -2,
+			wantWait:     2 * time.Second,
+			wantReserved: true,
+		},
+		{
+			name:         "below the capacity floor is not reserved",
+			tokens:       -2,
+			refreshedAt:  now,
+			wantLeft:     -2,
+			wantWait:     time.Second,
+			wantReserved: false,
+		},
+		{
+			name:         "refreshed in the future does not refill",
+			tokens:       -2,
+			refreshedAt:  now.Add(time.Second),
+			wantLeft:     -2,
+			wantWait:     time.Second,
+			wantReserved: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, wait, reserved := limiter.Reserve(tt.tokens, tt.refreshedAt, now)
+			if left != tt.wantLeft || wait != tt.wantWait || reserved != tt.wantReserved {
+				t.Errorf("Reserve(%v, %v) = (%v, %v, %v), want (%v, %v, %v)",
+					tt.tokens, tt.refreshedAt, left, wait, reserved, tt.wantLeft, tt.wantWait, tt.wantReserved)
+			}
+		})
+	}
+}
+
+func TestNewRateLimitBo(t *testing.T) {
+	if got := NewRateLimitBo("test", nil); got != nil {
+		t.Errorf("NewRateLimitBo(nil) = %+v, want nil", got)
+	}
+	got := NewRateLimitBo("test", &do.RateLimit{Limit: 10, Period: 2 * time.Second})
+	if got == nil || got.Rate != 5 || got.Capacity != 10 {
+		t.Errorf("NewRateLimitBo() = %+v, want rate 5 and capacity 10", got)
+	}
+}
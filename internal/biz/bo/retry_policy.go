@@ -0,0 +1,161 @@
+package bo
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/aide-family/magicbox/pointer"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/pkg/config"
+)
+
+// 命名空间 metadata 中的重试策略配置项
+const (
+	MetadataKeyRetryMaxAttempts = "retry.maxAttempts"
+	MetadataKeyRetryBaseDelay   = "retry.baseDelay"
+	MetadataKeyRetryMaxDelay    = "retry.maxDelay"
+	MetadataKeyRetryMultiplier  = "retry.multiplier"
+	MetadataKeyRetryJitter      = "retry.jitter"
+)
+
+// RetryPolicyBo 生效的重试策略
+type RetryPolicyBo struct {
+	MaxAttempts int32
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Multiplier  float64
+	Jitter      float64
+}
+
+// NewRetryPolicyBo 以内置的默认策略为基础，按优先级从低到高合并重试策略，后面配置了的字段覆盖前面的
+func NewRetryPolicyBo(policies ...*do.RetryPolicy) *RetryPolicyBo {
+	b := &RetryPolicyBo{
+		MaxAttempts: 3,
+		BaseDelay:   10 * time.Second,
+		MaxDelay:    10 * time.Minute,
+		Multiplier:  2,
+		Jitter:      0.2,
+	}
+	for _, policy := range policies {
+		if pointer.IsNil(policy) {
+			continue
+		}
+		if policy.MaxAttempts != nil {
+			b.MaxAttempts = *policy.MaxAttempts
+		}
+		if policy.BaseDelay != nil {
+			b.BaseDelay = *policy.BaseDelay
+		}
+		if policy.MaxDelay != nil {
+			b.MaxDelay = *policy.MaxDelay
+		}
+		if policy.Multiplier != nil {
+			b.Multiplier = *policy.Multiplier
+		}
+		if policy.Jitter != nil {
+			b.Jitter = *policy.Jitter
+		}
+	}
+	return b
+}
+
+// CanRetry 第 attempt 次投递失败后是否还可以继续重试
+func (b *RetryPolicyBo) CanRetry(attempt int32) bool {
+	return attempt < b.MaxAttempts
+}
+
+// NextDelay 第 attempt 次投递失败后，距离下一次重试的等待时间
+func (b *RetryPolicyBo) NextDelay(attempt int32) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(b.BaseDelay) * math.Pow(multiplier, float64(max(attempt-1, 0)))
+	if b.MaxDelay > 0 && delay > float64(b.MaxDelay) {
+		delay = float64(b.MaxDelay)
+	}
+	if jitter := min(b.Jitter, 1); jitter > 0 {
+		delay += delay * jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(delay)
+}
+
+// NewDoRetryPolicy 将配置文件/API 中的重试策略转换为 DO，未设置的字段保持为 nil
+func NewDoRetryPolicy(policy *config.RetryPolicy) *do.RetryPolicy {
+	if pointer.IsNil(policy) {
+		return nil
+	}
+	doPolicy := &do.RetryPolicy{
+		MaxAttempts: policy.MaxAttempts,
+		Multiplier:  policy.Multiplier,
+		Jitter:      policy.Jitter,
+	}
+	if pointer.IsNotNil(policy.BaseDelay) {
+		baseDelay := policy.BaseDelay.AsDuration()
+		doPolicy.BaseDelay = &baseDelay
+	}
+	if pointer.IsNotNil(policy.MaxDelay) {
+		maxDelay := policy.MaxDelay.AsDuration()
+		doPolicy.MaxDelay = &maxDelay
+	}
+	return doPolicy
+}
+
+// ToConfigRetryPolicy 将 DO 中的重试策略转换为 API 响应
+func ToConfigRetryPolicy(policy *do.RetryPolicy) *config.RetryPolicy {
+	if pointer.IsNil(policy) {
+		return nil
+	}
+	configPolicy := &config.RetryPolicy{
+		MaxAttempts: policy.MaxAttempts,
+		Multiplier:  policy.Multiplier,
+		Jitter:      policy.Jitter,
+	}
+	if policy.BaseDelay != nil {
+		configPolicy.BaseDelay = durationpb.New(*policy.BaseDelay)
+	}
+	if policy.MaxDelay != nil {
+		configPolicy.MaxDelay = durationpb.New(*policy.MaxDelay)
+	}
+	return configPolicy
+}
+
+// NewRetryPolicyFromMetadata 从命名空间 metadata 中解析重试策略，未配置时返回 nil
+func NewRetryPolicyFromMetadata(metadata map[string]string) *do.RetryPolicy {
+	policy := &do.RetryPolicy{}
+	var found bool
+	if value, ok := metadata[MetadataKeyRetryMaxAttempts]; ok {
+		if maxAttempts, err := strconv.ParseInt(value, 10, 32); err == nil {
+			attempts := int32(maxAttempts)
+			policy.MaxAttempts, found = &attempts, true
+		}
+	}
+	if value, ok := metadata[MetadataKeyRetryBaseDelay]; ok {
+		if baseDelay, err := time.ParseDuration(value); err == nil {
+			policy.BaseDelay, found = &baseDelay, true
+		}
+	}
+	if value, ok := metadata[MetadataKeyRetryMaxDelay]; ok {
+		if maxDelay, err := time.ParseDuration(value); err == nil {
+			policy.MaxDelay, found = &maxDelay, true
+		}
+	}
+	if value, ok := metadata[MetadataKeyRetryMultiplier]; ok {
+		if multiplier, err := strconv.ParseFloat(value, 64); err == nil {
+			policy.Multiplier, found = &multiplier, true
+		}
+	}
+	if value, ok := metadata[MetadataKeyRetryJitter]; ok {
+		if jitter, err := strconv.ParseFloat(value, 64); err == nil {
+			policy.Jitter, found = &jitter, true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return policy
+}
@@ -0,0 +1,193 @@
+package bo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aide-family/magicbox/strutil"
+	"github.com/bwmarrin/snowflake"
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+	"github.com/aide-family/rabbit/pkg/enum"
+	"github.com/aide-family/rabbit/pkg/merr"
+)
+
+type SendBatchTargetBo struct {
+	Type             vobj.MessageType
+	UID              snowflake.ID
+	TemplateUID      snowflake.ID
+	TemplateRevision int32
+	To               []string
+	Cc               []string
+}
+
+type SendBatchBo struct {
+	Targets        []*SendBatchTargetBo
+	JSONData       []byte
+	Subject        string
+	Body           string
+	ContentType    string
+	SendAt         time.Time
+	IdempotencyKey string
+	Priority       vobj.MessagePriority
+	CallbackURL    string
+	Tags           []string
+}
+
+func NewSendBatchBo(req *apiv1.SendBatchRequest) (*SendBatchBo, error) {
+	if strutil.IsNotEmpty(req.JsonData) && !json.Valid([]byte(req.JsonData)) {
+		return nil, merr.ErrorParams("invalid json data")
+	}
+	targets := make([]*SendBatchTargetBo, 0, len(req.Targets))
+	for _, target := range req.Targets {
+		targets = append(targets, &SendBatchTargetBo{
+			Type:             vobj.MessageType(target.Type),
+			UID:              snowflake.ParseInt64(target.Uid),
+			TemplateUID:      snowflake.ParseInt64(target.TemplateUID),
+			TemplateRevision: target.TemplateRevision,
+			To:               target.To,
+			Cc:               target.Cc,
+		})
+	}
+	return &SendBatchBo{
+		Targets:        targets,
+		JSONData:       []byte(req.JsonData),
+		Subject:        req.Subject,
+		Body:           req.Body,
+		ContentType:    req.ContentType,
+		SendAt:         NewSendAt(req.SendAtUnix, req.DelaySeconds),
+		IdempotencyKey: req.IdempotencyKey,
+		Priority:       vobj.MessagePriority(req.Priority).Normalize(),
+		CallbackURL:    req.CallbackUrl,
+		Tags:           req.Tags,
+	}, nil
+}
+
+// targetIdempotencyKey 每个目标使用独立的幂等键，未设置幂等键时返回空
+func (b *SendBatchBo) targetIdempotencyKey(index int) string {
+	if strutil.IsEmpty(b.IdempotencyKey) {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", b.IdempotencyKey, index)
+}
+
+// ToSendEmailBo 将未设置模板的邮件目标转换为邮件消息
+func (b *SendBatchBo) ToSendEmailBo(index int) (*SendEmailBo, error) {
+	target := b.Targets[index]
+	if len(target.To) == 0 {
+		return nil, merr.ErrorParams("to must be greater than 0")
+	}
+	if strutil.IsEmpty(b.Body) {
+		return nil, merr.ErrorParams("body is required for email target without template")
+	}
+	return &SendEmailBo{
+		UID:            target.UID,
+		Subject:        b.Subject,
+		Body:           b.Body,
+		To:             target.To,
+		Cc:             target.Cc,
+		ContentType:    b.ContentType,
+		Headers:        make(http.Header),
+		SendAt:         b.SendAt,
+		IdempotencyKey: b.targetIdempotencyKey(index),
+		Priority:       b.Priority,
+		CallbackURL:    b.CallbackURL,
+		Tags:           b.Tags,
+	}, nil
+}
+
+// ToSendEmailWithTemplateBo 将设置了模板的邮件目标转换为模板邮件消息
+func (b *SendBatchBo) ToSendEmailWithTemplateBo(index int) (*SendEmailWithTemplateBo, error) {
+	target := b.Targets[index]
+	if len(target.To) == 0 {
+		return nil, merr.ErrorParams("to must be greater than 0")
+	}
+	if len(b.JSONData) == 0 {
+		return nil, merr.ErrorParams("json data is required for template target")
+	}
+	return &SendEmailWithTemplateBo{
+		UID:              target.UID,
+		TemplateUID:      target.TemplateUID,
+		TemplateRevision: target.TemplateRevision,
+		JSONData:         b.JSONData,
+		To:               target.To,
+		Cc:               target.Cc,
+		SendAt:           b.SendAt,
+		IdempotencyKey:   b.targetIdempotencyKey(index),
+		Priority:         b.Priority,
+		CallbackURL:      b.CallbackURL,
+		Tags:             b.Tags,
+	}, nil
+}
+
+// ToSendWebhookBo 将未设置模板的 webhook 目标转换为 webhook 消息，共享数据直接作为请求体
+func (b *SendBatchBo) ToSendWebhookBo(index int) (*SendWebhookBo, error) {
+	target := b.Targets[index]
+	if len(b.JSONData) == 0 {
+		return nil, merr.ErrorParams("json data is required for webhook target")
+	}
+	return &SendWebhookBo{
+		UID:            target.UID,
+		Data:           string(b.JSONData),
+		SendAt:         b.SendAt,
+		IdempotencyKey: b.targetIdempotencyKey(index),
+		Priority:       b.Priority,
+		CallbackURL:    b.CallbackURL,
+		Tags:           b.Tags,
+	}, nil
+}
+
+// ToSendWebhookWithTemplateBo 将设置了模板的 webhook 目标转换为模板 webhook 消息
+func (b *SendBatchBo) ToSendWebhookWithTemplateBo(index int) (*SendWebhookWithTemplateBo, error) {
+	target := b.Targets[index]
+	if len(b.JSONData) == 0 {
+		return nil, merr.ErrorParams("json data is required for template target")
+	}
+	return &SendWebhookWithTemplateBo{
+		UID:              target.UID,
+		TemplateUID:      target.TemplateUID,
+		TemplateRevision: target.TemplateRevision,
+		JSONData:         b.JSONData,
+		SendAt:           b.SendAt,
+		IdempotencyKey:   b.targetIdempotencyKey(index),
+		Priority:         b.Priority,
+		CallbackURL:      b.CallbackURL,
+		Tags:             b.Tags,
+	}, nil
+}
+
+// SendBatchResultBo 批量发送中单个目标的结果，Error 不为空时表示该目标失败
+type SendBatchResultBo struct {
+	Index      int
+	Type       vobj.MessageType
+	UID        snowflake.ID
+	MessageUID snowflake.ID
+	Error      error
+}
+
+func (b *SendBatchResultBo) ToAPIV1SendBatchResult() *apiv1.SendBatchResult {
+	result := &apiv1.SendBatchResult{
+		Index:      int32(b.Index),
+		Type:       enum.MessageType(b.Type),
+		Uid:        b.UID.Int64(),
+		MessageUID: b.MessageUID.Int64(),
+	}
+	if b.Error != nil {
+		err := kerrors.FromError(b.Error)
+		result.Code = err.GetCode()
+		result.Message = err.GetMessage()
+	}
+	return result
+}
+
+func ToAPIV1SendBatchReply(results []*SendBatchResultBo) *apiv1.SendBatchReply {
+	items := make([]*apiv1.SendBatchResult, 0, len(results))
+	for _, result := range results {
+		items = append(items, result.ToAPIV1SendBatchResult())
+	}
+	return &apiv1.SendBatchReply{Results: items}
+}
@@ -0,0 +1,71 @@
+package bo
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aide-family/magicbox/pointer"
+
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/pkg/config"
+	"github.com/aide-family/rabbit/pkg/signature"
+)
+
+// SignatureBo 生效的通用 webhook 请求签名
+type SignatureBo struct {
+	Secret          string
+	SignatureHeader string
+	TimestampHeader string
+}
+
+// NewSignatureBo 创建请求签名，未开启时返回 nil，表示不签名
+func NewSignatureBo(secret string, sign *do.Signature) *SignatureBo {
+	if pointer.IsNil(sign) || !sign.Enabled {
+		return nil
+	}
+	b := &SignatureBo{
+		Secret:          secret,
+		SignatureHeader: sign.SignatureHeader,
+		TimestampHeader: sign.TimestampHeader,
+	}
+	if b.SignatureHeader == "" {
+		b.SignatureHeader = signature.DefaultSignatureHeader
+	}
+	if b.TimestampHeader == "" {
+		b.TimestampHeader = signature.DefaultTimestampHeader
+	}
+	return b
+}
+
+// Headers 计算请求体的签名，返回需要附加的请求头
+func (b *SignatureBo) Headers(body []byte, now time.Time) map[string]string {
+	timestamp := now.Unix()
+	return map[string]string{
+		b.TimestampHeader: strconv.FormatInt(timestamp, 10),
+		b.SignatureHeader: signature.Sign(b.Secret, timestamp, body),
+	}
+}
+
+// NewDoSignature 将配置文件/API 中的请求签名配置转换为 DO
+func NewDoSignature(sign *config.WebhookSignature) *do.Signature {
+	if pointer.IsNil(sign) {
+		return nil
+	}
+	return &do.Signature{
+		Enabled:         sign.GetEnabled(),
+		SignatureHeader: sign.GetSignatureHeader(),
+		TimestampHeader: sign.GetTimestampHeader(),
+	}
+}
+
+// ToConfigSignature 将 DO 中的请求签名配置转换为 API 响应
+func ToConfigSignature(sign *do.Signature) *config.WebhookSignature {
+	if pointer.IsNil(sign) {
+		return nil
+	}
+	return &config.WebhookSignature{
+		Enabled:         sign.Enabled,
+		SignatureHeader: sign.SignatureHeader,
+		TimestampHeader: sign.TimestampHeader,
+	}
+}
@@ -0,0 +1,404 @@
+package bo
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aide-family/magicbox/serialize"
+	"github.com/aide-family/magicbox/strutil"
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+	"github.com/aide-family/rabbit/pkg/enum"
+	"github.com/aide-family/rabbit/pkg/merr"
+)
+
+type SendSMSBo struct {
+	UID              snowflake.ID         `json:"uid"`
+	PhoneNumbers     []string             `json:"phone_numbers"`
+	TemplateCode     string               `json:"template_code"`
+	TemplateParams   map[string]string    `json:"template_params,omitempty"`
+	Content          string               `json:"content,omitempty"`
+	SendAt           time.Time            `json:"-"`
+	IdempotencyKey   string               `json:"-"`
+	Priority         vobj.MessagePriority `json:"-"`
+	CallbackURL      string               `json:"-"`
+	TemplateUID      snowflake.ID         `json:"-"`
+	TemplateRevision int32                `json:"-"`
+	Tags             []string             `json:"-"`
+}
+
+func (b *SendSMSBo) ToMessageLog(smsConfig *SMSConfigItemBo) (*do.MessageLog, error) {
+	messageBytes, err := serialize.JSONMarshal(b)
+	if err != nil {
+		return nil, err
+	}
+	smsConfigBytes, err := serialize.JSONMarshal(smsConfig)
+	if err != nil {
+		return nil, err
+	}
+	sendAt := b.SendAt
+	if sendAt.IsZero() {
+		sendAt = time.Now()
+	}
+	messageLog := &do.MessageLog{
+		SendAt:           sendAt,
+		Message:          strutil.EncryptString(messageBytes),
+		Config:           strutil.EncryptString(smsConfigBytes),
+		Type:             vobj.MessageTypeSMS,
+		Status:           vobj.MessageStatusPending,
+		IdempotencyKey:   b.IdempotencyKey,
+		Priority:         b.Priority,
+		CallbackURL:      b.CallbackURL,
+		TemplateRevision: b.TemplateRevision,
+	}
+	return messageLog.WithSearchFields(b.PhoneNumbers, smsConfig.UID, b.TemplateUID, "", b.Tags), nil
+}
+
+func NewSendSMSBo(req *apiv1.SendSMSRequest) *SendSMSBo {
+	return &SendSMSBo{
+		UID:            snowflake.ParseInt64(req.Uid),
+		PhoneNumbers:   req.PhoneNumbers,
+		TemplateCode:   req.TemplateCode,
+		TemplateParams: req.TemplateParams,
+		Content:        req.Content,
+		SendAt:         NewSendAt(req.SendAtUnix, req.DelaySeconds),
+		IdempotencyKey: req.IdempotencyKey,
+		Priority:       vobj.MessagePriority(req.Priority).Normalize(),
+		CallbackURL:    req.CallbackUrl,
+		Tags:           req.Tags,
+	}
+}
+
+type SendSMSWithTemplateBo struct {
+	UID              snowflake.ID
+	TemplateUID      snowflake.ID
+	TemplateRevision int32
+	JSONData         []byte
+	PhoneNumbers     []string
+	SendAt           time.Time
+	IdempotencyKey   string
+	Priority         vobj.MessagePriority
+	CallbackURL      string
+	Tags             []string
+}
+
+func NewSendSMSWithTemplateBo(req *apiv1.SendSMSWithTemplateRequest) (*SendSMSWithTemplateBo, error) {
+	if !json.Valid([]byte(req.JsonData)) {
+		return nil, merr.ErrorParams("invalid json data")
+	}
+	return &SendSMSWithTemplateBo{
+		UID:              snowflake.ParseInt64(req.Uid),
+		TemplateUID:      snowflake.ParseInt64(req.TemplateUID),
+		TemplateRevision: req.TemplateRevision,
+		JSONData:         []byte(req.JsonData),
+		PhoneNumbers:     req.PhoneNumbers,
+		SendAt:           NewSendAt(req.SendAtUnix, req.DelaySeconds),
+		IdempotencyKey:   req.IdempotencyKey,
+		Priority:         vobj.MessagePriority(req.Priority).Normalize(),
+		CallbackURL:      req.CallbackUrl,
+		Tags:             req.Tags,
+	}, nil
+}
+
+func (b *SendSMSWithTemplateBo) ToSendSMSBo(templateBo *TemplateItemBo) (*SendSMSBo, error) {
+	if !templateBo.App.IsSMSType() {
+		return nil, merr.ErrorParams("invalid template app type, expected %s, got %s", vobj.TemplateAppSMS, templateBo.App)
+	}
+	if !templateBo.Status.IsEnabled() {
+		return nil, merr.ErrorParams("template %s(%s) is disabled", templateBo.Name, templateBo.UID)
+	}
+	smsTemplateData, err := templateBo.ToSMSTemplateData()
+	if err != nil {
+		return nil, merr.ErrorParams("invalid sms template data").WithCause(err)
+	}
+	if strutil.IsEmpty(smsTemplateData.TemplateCode) {
+		return nil, merr.ErrorParams("template %s(%s) template_code is required", templateBo.Name, templateBo.UID)
+	}
+	renderer, err := newTemplateRenderer(b.JSONData)
+	if err != nil {
+		return nil, err
+	}
+	content, params := renderer.renderSMS(smsTemplateData)
+	if err := renderer.err(); err != nil {
+		return nil, err
+	}
+
+	return &SendSMSBo{
+		UID:              b.UID,
+		PhoneNumbers:     b.PhoneNumbers,
+		TemplateCode:     smsTemplateData.TemplateCode,
+		TemplateParams:   params,
+		Content:          content,
+		SendAt:           b.SendAt,
+		IdempotencyKey:   b.IdempotencyKey,
+		Priority:         b.Priority,
+		CallbackURL:      b.CallbackURL,
+		TemplateUID:      templateBo.UID,
+		TemplateRevision: templateBo.Revision,
+		Tags:             b.Tags,
+	}, nil
+}
+
+type CreateSMSConfigBo struct {
+	Name            string
+	Provider        vobj.SMSProvider
+	AccessKeyID     string
+	AccessKeySecret string
+	SignName        string
+	Endpoint        string
+	Region          string
+	AppID           string
+	RetryPolicy     *do.RetryPolicy
+	RateLimit       *do.RateLimit
+}
+
+func (c *CreateSMSConfigBo) ToDoSMSConfig() *do.SMSConfig {
+	return &do.SMSConfig{
+		Name:            c.Name,
+		Provider:        c.Provider,
+		AccessKeyID:     c.AccessKeyID,
+		AccessKeySecret: strutil.EncryptString(c.AccessKeySecret),
+		SignName:        c.SignName,
+		Endpoint:        c.Endpoint,
+		Region:          c.Region,
+		AppID:           c.AppID,
+		RetryPolicy:     c.RetryPolicy,
+		RateLimit:       c.RateLimit,
+	}
+}
+
+func NewCreateSMSConfigBo(req *apiv1.CreateSMSConfigRequest) *CreateSMSConfigBo {
+	return &CreateSMSConfigBo{
+		Name:            req.Name,
+		Provider:        vobj.SMSProvider(req.Provider),
+		AccessKeyID:     req.AccessKeyId,
+		AccessKeySecret: req.AccessKeySecret,
+		SignName:        req.SignName,
+		Endpoint:        req.Endpoint,
+		Region:          req.Region,
+		AppID:           req.AppId,
+		RetryPolicy:     NewDoRetryPolicy(req.RetryPolicy),
+		RateLimit:       NewDoRateLimit(req.RateLimit),
+	}
+}
+
+type UpdateSMSConfigBo struct {
+	UID snowflake.ID
+	CreateSMSConfigBo
+}
+
+func (c *UpdateSMSConfigBo) ToDoSMSConfig() *do.SMSConfig {
+	smsConfig := c.CreateSMSConfigBo.ToDoSMSConfig()
+	smsConfig.WithUID(c.UID)
+	return smsConfig
+}
+
+func NewUpdateSMSConfigBo(req *apiv1.UpdateSMSConfigRequest) *UpdateSMSConfigBo {
+	return &UpdateSMSConfigBo{
+		UID: snowflake.ParseInt64(req.Uid),
+		CreateSMSConfigBo: CreateSMSConfigBo{
+			Name:            req.Name,
+			Provider:        vobj.SMSProvider(req.Provider),
+			AccessKeyID:     req.AccessKeyId,
+			AccessKeySecret: req.AccessKeySecret,
+			SignName:        req.SignName,
+			Endpoint:        req.Endpoint,
+			Region:          req.Region,
+			AppID:           req.AppId,
+			RetryPolicy:     NewDoRetryPolicy(req.RetryPolicy),
+			RateLimit:       NewDoRateLimit(req.RateLimit),
+		},
+	}
+}
+
+type UpdateSMSConfigStatusBo struct {
+	UID    snowflake.ID
+	Status vobj.GlobalStatus
+}
+
+func NewUpdateSMSConfigStatusBo(req *apiv1.UpdateSMSConfigStatusRequest) *UpdateSMSConfigStatusBo {
+	return &UpdateSMSConfigStatusBo{
+		UID:    snowflake.ParseInt64(req.Uid),
+		Status: vobj.GlobalStatus(req.Status),
+	}
+}
+
+type ListSMSConfigBo struct {
+	*PageRequestBo
+	Keyword string
+	Status  vobj.GlobalStatus
+}
+
+func NewListSMSConfigBo(req *apiv1.ListSMSConfigRequest) *ListSMSConfigBo {
+	return &ListSMSConfigBo{
+		PageRequestBo: NewPageRequestBo(req.Page, req.PageSize),
+		Keyword:       req.Keyword,
+		Status:        vobj.GlobalStatus(req.Status),
+	}
+}
+
+func ToAPIV1ListSMSConfigReply(pageResponseBo *PageResponseBo[*SMSConfigItemBo]) *apiv1.ListSMSConfigReply {
+	items := make([]*apiv1.SMSConfigItem, 0, len(pageResponseBo.GetItems()))
+	for _, item := range pageResponseBo.GetItems() {
+		items = append(items, item.ToAPIV1SMSConfigItem())
+	}
+	return &apiv1.ListSMSConfigReply{
+		Items:    items,
+		Total:    pageResponseBo.GetTotal(),
+		Page:     pageResponseBo.GetPage(),
+		PageSize: pageResponseBo.GetPageSize(),
+	}
+}
+
+// SelectSMSConfigBo 选择SMS配置的 BO
+type SelectSMSConfigBo struct {
+	Keyword string
+	Limit   int32
+	LastUID snowflake.ID
+	Status  vobj.GlobalStatus
+}
+
+// NewSelectSMSConfigBo 从 API 请求创建 BO
+func NewSelectSMSConfigBo(req *apiv1.SelectSMSConfigRequest) *SelectSMSConfigBo {
+	var lastUID snowflake.ID
+	if req.LastUID > 0 {
+		lastUID = snowflake.ParseInt64(req.LastUID)
+	}
+	return &SelectSMSConfigBo{
+		Keyword: req.Keyword,
+		Limit:   req.Limit,
+		LastUID: lastUID,
+		Status:  vobj.GlobalStatus(req.Status),
+	}
+}
+
+// SMSConfigItemSelectBo SMS配置选择项的 BO
+type SMSConfigItemSelectBo struct {
+	UID      snowflake.ID
+	Name     string
+	Status   vobj.GlobalStatus
+	Disabled bool
+	Tooltip  string
+}
+
+// NewSMSConfigItemSelectBo 从 DO 创建 BO
+func NewSMSConfigItemSelectBo(doSMSConfig *do.SMSConfig) *SMSConfigItemSelectBo {
+	return &SMSConfigItemSelectBo{
+		UID:      doSMSConfig.UID,
+		Name:     doSMSConfig.Name,
+		Status:   doSMSConfig.Status,
+		Disabled: doSMSConfig.Status != vobj.GlobalStatusEnabled,
+		Tooltip:  "",
+	}
+}
+
+// ToAPIV1SMSConfigItemSelect 转换为 API 响应
+func (b *SMSConfigItemSelectBo) ToAPIV1SMSConfigItemSelect() *apiv1.SMSConfigItemSelect {
+	return &apiv1.SMSConfigItemSelect{
+		Value:    b.UID.Int64(),
+		Label:    b.Name,
+		Disabled: b.Disabled,
+		Tooltip:  b.Tooltip,
+	}
+}
+
+// SelectSMSConfigResult Repository层返回结果
+type SelectSMSConfigResult struct {
+	Items   []*do.SMSConfig
+	Total   int64
+	LastUID snowflake.ID
+}
+
+// SelectSMSConfigBoResult Biz层返回结果
+type SelectSMSConfigBoResult struct {
+	Items   []*SMSConfigItemSelectBo
+	Total   int64
+	LastUID snowflake.ID
+}
+
+// SelectSMSConfigReplyParams 转换为API响应的参数
+type SelectSMSConfigReplyParams struct {
+	Items   []*SMSConfigItemSelectBo
+	Total   int64
+	LastUID snowflake.ID
+	Limit   int32
+}
+
+// ToAPIV1SelectSMSConfigReply 转换为 API 响应
+func ToAPIV1SelectSMSConfigReply(params *SelectSMSConfigReplyParams) *apiv1.SelectSMSConfigReply {
+	selectItems := make([]*apiv1.SMSConfigItemSelect, 0, len(params.Items))
+	for _, item := range params.Items {
+		selectItems = append(selectItems, item.ToAPIV1SMSConfigItemSelect())
+	}
+	var lastUIDInt64 int64
+	if params.LastUID > 0 {
+		lastUIDInt64 = params.LastUID.Int64()
+	}
+	// hasMore: 如果返回的记录数等于limit，说明可能还有更多记录
+	// 如果返回的记录数小于limit，说明已经查询完了
+	hasMore := int32(len(params.Items)) == params.Limit
+	return &apiv1.SelectSMSConfigReply{
+		Items:   selectItems,
+		Total:   params.Total,
+		LastUID: lastUIDInt64,
+		HasMore: hasMore,
+	}
+}
+
+type SMSConfigItemBo struct {
+	UID             snowflake.ID      `json:"uid"`
+	Name            string            `json:"name"`
+	Provider        vobj.SMSProvider  `json:"provider"`
+	AccessKeyID     string            `json:"access_key_id"`
+	AccessKeySecret string            `json:"access_key_secret"`
+	SignName        string            `json:"sign_name"`
+	Endpoint        string            `json:"endpoint"`
+	Region          string            `json:"region"`
+	AppID           string            `json:"app_id"`
+	Status          vobj.GlobalStatus `json:"status"`
+	RetryPolicy     *do.RetryPolicy   `json:"retry_policy,omitempty"`
+	RateLimit       *do.RateLimit     `json:"rate_limit,omitempty"`
+	CreatedAt       time.Time         `json:"-"`
+	UpdatedAt       time.Time         `json:"-"`
+}
+
+func NewSMSConfigItemBo(doSMSConfig *do.SMSConfig) *SMSConfigItemBo {
+	return &SMSConfigItemBo{
+		UID:             doSMSConfig.UID,
+		Name:            doSMSConfig.Name,
+		Provider:        doSMSConfig.Provider,
+		AccessKeyID:     doSMSConfig.AccessKeyID,
+		AccessKeySecret: string(doSMSConfig.AccessKeySecret),
+		SignName:        doSMSConfig.SignName,
+		Endpoint:        doSMSConfig.Endpoint,
+		Region:          doSMSConfig.Region,
+		AppID:           doSMSConfig.AppID,
+		Status:          doSMSConfig.Status,
+		RetryPolicy:     doSMSConfig.RetryPolicy,
+		RateLimit:       doSMSConfig.RateLimit,
+		CreatedAt:       doSMSConfig.CreatedAt,
+		UpdatedAt:       doSMSConfig.UpdatedAt,
+	}
+}
+
+func (b *SMSConfigItemBo) ToAPIV1SMSConfigItem() *apiv1.SMSConfigItem {
+	return &apiv1.SMSConfigItem{
+		Uid:             b.UID.Int64(),
+		Name:            b.Name,
+		Provider:        enum.SMSProvider(b.Provider),
+		AccessKeyId:     b.AccessKeyID,
+		AccessKeySecret: b.AccessKeySecret,
+		SignName:        b.SignName,
+		Endpoint:        b.Endpoint,
+		Region:          b.Region,
+		AppId:           b.AppID,
+		Status:          enum.GlobalStatus(b.Status),
+		CreatedAt:       b.CreatedAt.Format(time.DateTime),
+		UpdatedAt:       b.UpdatedAt.Format(time.DateTime),
+		RetryPolicy:     ToConfigRetryPolicy(b.RetryPolicy),
+		RateLimit:       ToConfigRateLimit(b.RateLimit),
+	}
+}
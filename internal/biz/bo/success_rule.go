@@ -0,0 +1,154 @@
+package bo
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aide-family/magicbox/pointer"
+
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/pkg/config"
+	"github.com/aide-family/rabbit/pkg/merr"
+)
+
+// SuccessRuleBo 生效的通用 webhook 成功判定规则
+type SuccessRuleBo struct {
+	StatusCodes []*do.StatusCodeRange
+	JSONField   []string
+	JSONValues  []string
+}
+
+// NewSuccessRuleBo 创建成功判定规则，未配置状态码范围时默认 2xx 视为成功
+func NewSuccessRuleBo(successRule *do.SuccessRule) *SuccessRuleBo {
+	b := &SuccessRuleBo{
+		StatusCodes: []*do.StatusCodeRange{{Min: 200, Max: 299}},
+	}
+	if pointer.IsNil(successRule) {
+		return b
+	}
+	if len(successRule.StatusCodes) > 0 {
+		b.StatusCodes = successRule.StatusCodes
+	}
+	if field := strings.TrimSpace(successRule.JSONField); field != "" {
+		b.JSONField = strings.Split(field, ".")
+	}
+	b.JSONValues = successRule.JSONValues
+	return b
+}
+
+// Check 根据响应状态码和响应体判断是否发送成功
+func (b *SuccessRuleBo) Check(statusCode int, body []byte) error {
+	if !b.matchStatusCode(statusCode) {
+		// 4xx 表示请求本身有误（地址、鉴权、签名等），重试也不会成功，408 和 429 除外
+		if statusCode >= 400 && statusCode < 500 && statusCode != http.StatusRequestTimeout && statusCode != http.StatusTooManyRequests {
+			return merr.ErrorParams("webhook response status code %d is not success, body: %s", statusCode, body)
+		}
+		return merr.ErrorInternal("webhook response status code %d is not success, body: %s", statusCode, body)
+	}
+	if len(b.JSONField) == 0 {
+		return nil
+	}
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return merr.ErrorInternal("webhook response body is not json, body: %s", body).WithCause(err)
+	}
+	value, ok := lookupJSONField(data, b.JSONField)
+	if !ok {
+		return merr.ErrorInternal("webhook response field %s not found, body: %s", strings.Join(b.JSONField, "."), body)
+	}
+	if len(b.JSONValues) == 0 {
+		return nil
+	}
+	actual := jsonFieldText(value)
+	for _, expected := range b.JSONValues {
+		if actual == expected {
+			return nil
+		}
+	}
+	return merr.ErrorInternal("webhook response field %s is %s, expected one of %v", strings.Join(b.JSONField, "."), actual, b.JSONValues)
+}
+
+func (b *SuccessRuleBo) matchStatusCode(statusCode int) bool {
+	for _, statusCodeRange := range b.StatusCodes {
+		maxCode := statusCodeRange.Max
+		if maxCode < statusCodeRange.Min {
+			maxCode = statusCodeRange.Min
+		}
+		if int32(statusCode) >= statusCodeRange.Min && int32(statusCode) <= maxCode {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupJSONField 按路径查找 JSON 字段，数组使用下标访问
+func lookupJSONField(data any, path []string) (any, bool) {
+	for _, key := range path {
+		switch node := data.(type) {
+		case map[string]any:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			data = value
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			data = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return data, true
+}
+
+// jsonFieldText 字符串取原值，其他类型取 JSON 文本
+func jsonFieldText(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	raw, _ := json.Marshal(value)
+	return string(raw)
+}
+
+// NewDoSuccessRule 将配置文件/API 中的成功判定规则转换为 DO
+func NewDoSuccessRule(successRule *config.SuccessRule) *do.SuccessRule {
+	if pointer.IsNil(successRule) {
+		return nil
+	}
+	statusCodes := make([]*do.StatusCodeRange, 0, len(successRule.GetStatusCodes()))
+	for _, statusCodeRange := range successRule.GetStatusCodes() {
+		statusCodes = append(statusCodes, &do.StatusCodeRange{
+			Min: statusCodeRange.GetMin(),
+			Max: statusCodeRange.GetMax(),
+		})
+	}
+	return &do.SuccessRule{
+		StatusCodes: statusCodes,
+		JSONField:   successRule.GetJsonField(),
+		JSONValues:  successRule.GetJsonValues(),
+	}
+}
+
+// ToConfigSuccessRule 将 DO 中的成功判定规则转换为 API 响应
+func ToConfigSuccessRule(successRule *do.SuccessRule) *config.SuccessRule {
+	if pointer.IsNil(successRule) {
+		return nil
+	}
+	statusCodes := make([]*config.SuccessRule_StatusCodeRange, 0, len(successRule.StatusCodes))
+	for _, statusCodeRange := range successRule.StatusCodes {
+		statusCodes = append(statusCodes, &config.SuccessRule_StatusCodeRange{
+			Min: statusCodeRange.Min,
+			Max: statusCodeRange.Max,
+		})
+	}
+	return &config.SuccessRule{
+		StatusCodes: statusCodes,
+		JsonField:   successRule.JSONField,
+		JsonValues:  successRule.JSONValues,
+	}
+}
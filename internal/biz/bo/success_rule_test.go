@@ -0,0 +1,161 @@
+package bo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/pkg/merr"
+)
+
+func TestSuccessRuleBo_Check(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       *do.SuccessRule
+		statusCode int
+		body       string
+		wantErr    bool
+		wantParams bool // 请求本身有误，不再重试
+	}{
+		{
+			name:       "default 2xx success",
+			statusCode: 204,
+		},
+		{
+			name:       "default 5xx retryable",
+			statusCode: 502,
+			wantErr:    true,
+		},
+		{
+			name:       "4xx not retryable",
+			statusCode: 401,
+			wantErr:    true,
+			wantParams: true,
+		},
+		{
+			name:       "408 retryable",
+			statusCode: 408,
+			wantErr:    true,
+		},
+		{
+			name:       "429 retryable",
+			statusCode: 429,
+			wantErr:    true,
+		},
+		{
+			name:       "custom status range",
+			rule:       &do.SuccessRule{StatusCodes: []*do.StatusCodeRange{{Min: 200, Max: 200}, {Min: 302}}},
+			statusCode: 302,
+		},
+		{
+			name:       "outside custom status range",
+			rule:       &do.SuccessRule{StatusCodes: []*do.StatusCodeRange{{Min: 200, Max: 200}}},
+			statusCode: 201,
+			wantErr:    true,
+		},
+		{
+			name:       "body is not json",
+			rule:       &do.SuccessRule{JSONField: "code"},
+			statusCode: 200,
+			body:       "ok",
+			wantErr:    true,
+		},
+		{
+			name:       "field exists without expected values",
+			rule:       &do.SuccessRule{JSONField: "data.id"},
+			statusCode: 200,
+			body:       `{"data":{"id":null}}`,
+		},
+		{
+			name:       "field not found",
+			rule:       &do.SuccessRule{JSONField: "data.id"},
+			statusCode: 200,
+			body:       `{"data":{}}`,
+			wantErr:    true,
+		},
+		{
+			name:       "number value matched",
+			rule:       &do.SuccessRule{JSONField: "errcode", JSONValues: []string{"0"}},
+			statusCode: 200,
+			body:       `{"errcode":0,"errmsg":"ok"}`,
+		},
+		{
+			name:       "string value matched",
+			rule:       &do.SuccessRule{JSONField: "result.status", JSONValues: []string{"success", "queued"}},
+			statusCode: 200,
+			body:       `{"result":{"status":"queued"}}`,
+		},
+		{
+			name:       "bool value matched",
+			rule:       &do.SuccessRule{JSONField: "ok", JSONValues: []string{"true"}},
+			statusCode: 200,
+			body:       `{"ok":true}`,
+		},
+		{
+			name:       "value not matched",
+			rule:       &do.SuccessRule{JSONField: "errcode", JSONValues: []string{"0"}},
+			statusCode: 200,
+			body:       `{"errcode":40001}`,
+			wantErr:    true,
+		},
+		{
+			name:       "array index matched",
+			rule:       &do.SuccessRule{JSONField: "results.1.code", JSONValues: []string{"OK"}},
+			statusCode: 200,
+			body:       `{"results":[{"code":"FAIL"},{"code":"OK"}]}`,
+		},
+		{
+			name:       "status range checked before json field",
+			rule:       &do.SuccessRule{JSONField: "code", JSONValues: []string{"0"}},
+			statusCode: 500,
+			body:       `{"code":0}`,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewSuccessRuleBo(tt.rule).Check(tt.statusCode, []byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check(%d, %s) error = %v, wantErr %v", tt.statusCode, tt.body, err, tt.wantErr)
+			}
+			if err != nil && merr.IsParams(err) != tt.wantParams {
+				t.Errorf("Check(%d, %s) error = %v, wantParams %v", tt.statusCode, tt.body, err, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestLookupJSONField(t *testing.T) {
+	data := map[string]any{
+		"data": map[string]any{
+			"items": []any{
+				map[string]any{"id": "a"},
+				map[string]any{"id": "b"},
+			},
+			"total": float64(2),
+		},
+	}
+	tests := []struct {
+		name   string
+		path   []string
+		want   any
+		wantOK bool
+	}{
+		{name: "nested object", path: []string{"data", "total"}, want: float64(2), wantOK: true},
+		{name: "array index", path: []string{"data", "items", "1", "id"}, want: "b", wantOK: true},
+		{name: "whole array", path: []string{"data", "items"}, want: data["data"].(map[string]any)["items"], wantOK: true},
+		{name: "missing key", path: []string{"data", "missing"}},
+		{name: "index out of range", path: []string{"data", "items", "2", "id"}},
+		{name: "negative index", path: []string{"data", "items", "-1"}},
+		{name: "non-numeric index", path: []string{"data", "items", "first"}},
+		{name: "descend into scalar", path: []string{"data", "total", "value"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lookupJSONField(data, tt.path)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lookupJSONField(%v) = (%v, %v), want (%v, %v)", tt.path, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -16,17 +16,20 @@ import (
 
 // CreateTemplateBo 创建模板的 BO
 type CreateTemplateBo struct {
-	Name     string
-	App      vobj.TemplateApp
-	JSONData string
+	Name      string
+	App       vobj.TemplateApp
+	JSONData  string
+	Variables []*do.TemplateVariable
+	Note      string
 }
 
 // ToDoTemplate 转换为 DO
 func (c *CreateTemplateBo) ToDoTemplate() *do.Template {
 	return &do.Template{
-		Name:     c.Name,
-		App:      c.App,
-		JSONData: json.RawMessage(c.JSONData),
+		Name:      c.Name,
+		App:       c.App,
+		JSONData:  json.RawMessage(c.JSONData),
+		Variables: c.Variables,
 	}
 }
 
@@ -35,27 +38,36 @@ func NewCreateTemplateBo(req *apiv1.CreateTemplateRequest) (*CreateTemplateBo, e
 	if !json.Valid([]byte(req.JsonData)) {
 		return nil, merr.ErrorParams("invalid json data")
 	}
+	variables, err := NewDoTemplateVariables(req.Variables)
+	if err != nil {
+		return nil, err
+	}
 	return &CreateTemplateBo{
-		Name:     req.Name,
-		App:      vobj.TemplateApp(req.App),
-		JSONData: req.JsonData,
+		Name:      req.Name,
+		App:       vobj.TemplateApp(req.App),
+		JSONData:  req.JsonData,
+		Variables: variables,
+		Note:      req.Note,
 	}, nil
 }
 
 // UpdateTemplateBo 更新模板的 BO
 type UpdateTemplateBo struct {
-	UID      snowflake.ID
-	Name     string
-	App      vobj.TemplateApp
-	JSONData string
+	UID       snowflake.ID
+	Name      string
+	App       vobj.TemplateApp
+	JSONData  string
+	Variables []*do.TemplateVariable
+	Note      string
 }
 
 // ToDoTemplate 转换为 DO
 func (u *UpdateTemplateBo) ToDoTemplate() *do.Template {
 	template := &do.Template{
-		Name:     u.Name,
-		App:      u.App,
-		JSONData: json.RawMessage(u.JSONData),
+		Name:      u.Name,
+		App:       u.App,
+		JSONData:  json.RawMessage(u.JSONData),
+		Variables: u.Variables,
 	}
 	template.WithUID(u.UID)
 	return template
@@ -66,11 +78,17 @@ func NewUpdateTemplateBo(req *apiv1.UpdateTemplateRequest) (*UpdateTemplateBo, e
 	if !json.Valid([]byte(req.JsonData)) {
 		return nil, merr.ErrorParams("invalid json data")
 	}
+	variables, err := NewDoTemplateVariables(req.Variables)
+	if err != nil {
+		return nil, err
+	}
 	return &UpdateTemplateBo{
-		UID:      snowflake.ParseInt64(req.Uid),
-		Name:     req.Name,
-		App:      vobj.TemplateApp(req.App),
-		JSONData: req.JsonData,
+		UID:       snowflake.ParseInt64(req.Uid),
+		Name:      req.Name,
+		App:       vobj.TemplateApp(req.App),
+		JSONData:  req.JsonData,
+		Variables: variables,
+		Note:      req.Note,
 	}, nil
 }
 
@@ -89,11 +107,19 @@ func NewUpdateTemplateStatusBo(req *apiv1.UpdateTemplateStatusRequest) *UpdateTe
 }
 
 // EmailTemplateData Email 模板的数据结构
+// TextBody 为 HTML 邮件的纯文本正文，为空时发送时由 HTML 正文生成
 type EmailTemplateData struct {
-	Subject     string      `json:"subject"`
-	Body        string      `json:"body"`
-	ContentType string      `json:"content_type"`
-	Headers     http.Header `json:"headers,omitempty"`
+	Subject     string               `json:"subject"`
+	Body        string               `json:"body"`
+	TextBody    string               `json:"text_body,omitempty"`
+	ContentType string               `json:"content_type"`
+	Headers     http.Header          `json:"headers,omitempty"`
+	Attachments []*EmailAttachmentBo `json:"attachments,omitempty"`
+}
+
+// IsHTML 正文是否为 HTML，HTML 正文使用 html/template 渲染
+func (d *EmailTemplateData) IsHTML() bool {
+	return isHTMLContentType(d.ContentType)
 }
 
 // WebhookTemplateData Webhook 模板的数据结构
@@ -101,8 +127,9 @@ type WebhookTemplateData string
 
 // SMSTemplateData SMS 模板的数据结构
 type SMSTemplateData struct {
-	Content string            `json:"content"`
-	Params  map[string]string `json:"params,omitempty"`
+	TemplateCode string            `json:"template_code"`
+	Content      string            `json:"content"`
+	Params       map[string]string `json:"params,omitempty"`
 }
 
 // TemplateItemBo 模板项的 BO
@@ -111,7 +138,9 @@ type TemplateItemBo struct {
 	Name      string
 	App       vobj.TemplateApp
 	JSONData  string
+	Variables []*do.TemplateVariable
 	Status    vobj.GlobalStatus
+	Revision  int32
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -149,7 +178,9 @@ func (t *TemplateItemBo) ToAPIV1TemplateItem() *apiv1.TemplateItem {
 		Name:      t.Name,
 		App:       enum.TemplateAPP(t.App),
 		JsonData:  t.JSONData,
+		Variables: ToConfigTemplateVariables(t.Variables),
 		Status:    enum.GlobalStatus(t.Status),
+		Revision:  t.Revision,
 		CreatedAt: t.CreatedAt.Format(time.DateTime),
 		UpdatedAt: t.UpdatedAt.Format(time.DateTime),
 	}
@@ -162,7 +193,9 @@ func NewTemplateItemBo(doTemplate *do.Template) *TemplateItemBo {
 		Name:      doTemplate.Name,
 		App:       doTemplate.App,
 		JSONData:  string(doTemplate.JSONData),
+		Variables: doTemplate.Variables,
 		Status:    doTemplate.Status,
+		Revision:  doTemplate.Revision,
 		CreatedAt: doTemplate.CreatedAt,
 		UpdatedAt: doTemplate.UpdatedAt,
 	}
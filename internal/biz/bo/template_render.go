@@ -0,0 +1,555 @@
+package bo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"unicode/utf8"
+
+	"github.com/aide-family/magicbox/serialize"
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+	"github.com/aide-family/rabbit/pkg/enum"
+	"github.com/aide-family/rabbit/pkg/merr"
+	"github.com/aide-family/rabbit/pkg/tmplfunc"
+)
+
+// TemplateRenderIssueBo 模板渲染的错误或警告，行号和列号从 1 开始，为 0 时表示位置未知
+type TemplateRenderIssueBo struct {
+	Field   string
+	Line    int32
+	Column  int32
+	Message string
+}
+
+func (i *TemplateRenderIssueBo) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", i.Field, i.Line, i.Column, i.Message)
+}
+
+// ToAPIV1TemplateRenderIssue 转换为 API 响应
+func (i *TemplateRenderIssueBo) ToAPIV1TemplateRenderIssue() *apiv1.TemplateRenderIssue {
+	return &apiv1.TemplateRenderIssue{
+		Field:   i.Field,
+		Line:    i.Line,
+		Column:  i.Column,
+		Message: i.Message,
+	}
+}
+
+var (
+	// templateFuncs 所有模板可用的函数，解析时注册，未注册的函数会导致解析错误
+	templateFuncs = tmplfunc.FuncMap()
+	// parseErrorPattern text/template 解析错误的格式，只包含行号
+	parseErrorPattern = regexp.MustCompile(`^template: [^:]*:(\d+): (.*)$`)
+	// locationPattern text/template 执行错误、html/template 转义错误和 ErrorContext 的位置格式
+	locationPattern = regexp.MustCompile(`^(?:template: |html/template:)?[^:]*:(\d+):(\d+)(?:: (.*))?$`)
+)
+
+// templateRenderer 使用同一份数据渲染模板的多个字段，发送和预览共用
+// 渲染失败的字段不会中断其他字段，错误和缺失变量的警告按字段记录
+type templateRenderer struct {
+	data     map[string]any
+	errors   []*TemplateRenderIssueBo
+	warnings []*TemplateRenderIssueBo
+}
+
+func newTemplateRenderer(jsonData []byte) (*templateRenderer, error) {
+	var data map[string]any
+	if err := serialize.JSONUnmarshal(jsonData, &data); err != nil {
+		return nil, merr.ErrorInternal("unmarshal json data failed").WithCause(err)
+	}
+	return &templateRenderer{data: data}, nil
+}
+
+// applyVariables 按模板声明的变量校验数据并补全默认值，不符合声明的变量记录为错误
+func (r *templateRenderer) applyVariables(variables []*do.TemplateVariable) {
+	if len(variables) == 0 {
+		return
+	}
+	if r.data == nil {
+		r.data = make(map[string]any)
+	}
+	r.errors = append(r.errors, applyTemplateVariables(variables, r.data)...)
+}
+
+// err 返回第一个渲染错误
+func (r *templateRenderer) err() error {
+	if len(r.errors) == 0 {
+		return nil
+	}
+	return merr.ErrorParams("execute text template failed").WithCause(r.errors[0])
+}
+
+// render 渲染单个字段，失败时返回空字符串并记录错误
+func (r *templateRenderer) render(field, text string) string {
+	tmpl, err := template.New(field).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		r.errors = append(r.errors, newTemplateParseIssue(field, text, err))
+		return ""
+	}
+	r.checkMissingVariables(field, text, tmpl.Tree)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, r.data); err != nil {
+		r.errors = append(r.errors, newTemplateExecIssue(field, text, err))
+		return ""
+	}
+	return buf.String()
+}
+
+// renderHTML 使用 html/template 渲染单个字段，数据按所在的 HTML 上下文转义，防止 HTML 注入
+func (r *templateRenderer) renderHTML(field, text string) string {
+	tmpl, err := htmltemplate.New(field).Funcs(htmltemplate.FuncMap(templateFuncs)).Parse(text)
+	if err != nil {
+		r.errors = append(r.errors, newTemplateParseIssue(field, text, err))
+		return ""
+	}
+	// 首次执行时才会插入转义函数，检查缺失变量时语法树与模板原文一致
+	r.checkMissingVariables(field, text, tmpl.Tree)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, r.data); err != nil {
+		r.errors = append(r.errors, newTemplateExecIssue(field, text, err))
+		return ""
+	}
+	return buf.String()
+}
+
+// renderEmail 渲染邮件的主题、正文、纯文本正文和模板中定义的附件，HTML 正文按上下文转义数据
+// 模板中的附件可通过模板语法引用数据，例如 {"blob_ref": "invoices/{{ .invoiceNo }}.pdf"}
+func (r *templateRenderer) renderEmail(data *EmailTemplateData) (subject, body, textBody string, attachments []*EmailAttachmentBo) {
+	subject = r.render("subject", data.Subject)
+	if data.IsHTML() {
+		body = r.renderHTML("body", data.Body)
+	} else {
+		body = r.render("body", data.Body)
+	}
+	if strings.TrimSpace(data.TextBody) != "" {
+		textBody = r.render("text_body", data.TextBody)
+	}
+	attachments = make([]*EmailAttachmentBo, 0, len(data.Attachments))
+	for index, attachment := range data.Attachments {
+		rendered := *attachment
+		prefix := fmt.Sprintf("attachments[%d].", index)
+		rendered.Filename = r.render(prefix+"filename", rendered.Filename)
+		rendered.BlobRef = r.render(prefix+"blob_ref", rendered.BlobRef)
+		rendered.ContentID = r.render(prefix+"content_id", rendered.ContentID)
+		attachments = append(attachments, &rendered)
+	}
+	return subject, body, textBody, attachments
+}
+
+// renderSMS 渲染短信内容和服务商模板参数，参数的值同样支持模板语法，例如 {"code": "{{ .code }}"}
+func (r *templateRenderer) renderSMS(data *SMSTemplateData) (content string, params map[string]string) {
+	content = r.render("content", data.Content)
+	params = make(map[string]string, len(data.Params))
+	for key, value := range data.Params {
+		params[key] = r.render("params."+key, value)
+	}
+	return content, params
+}
+
+// renderWebhook 渲染 webhook 请求体，渲染结果必须为合法的 JSON
+func (r *templateRenderer) renderWebhook(data WebhookTemplateData) string {
+	payload := r.render("payload", string(data))
+	if len(r.errors) > 0 {
+		return payload
+	}
+	var syntaxErr *json.SyntaxError
+	if err := json.Unmarshal([]byte(payload), new(any)); errors.As(err, &syntaxErr) {
+		// Offset 为读取到出错字符之后的偏移量，减一指向出错的字符
+		line, column := offsetPosition(payload, int(syntaxErr.Offset)-1)
+		r.errors = append(r.errors, &TemplateRenderIssueBo{Field: "payload", Line: line, Column: column, Message: "rendered payload is not valid json: " + syntaxErr.Error()})
+	} else if err != nil {
+		r.errors = append(r.errors, &TemplateRenderIssueBo{Field: "payload", Message: "rendered payload is not valid json: " + err.Error()})
+	}
+	return payload
+}
+
+// checkMissingVariables 检查模板引用的变量是否存在于数据中，缺失的变量渲染为 "<no value>"
+// 只检查以根数据为起点的引用，即 range/with 之外的 .a.b 和任意位置的 $.a.b
+// 传给 default 等处理缺失值的函数的变量不检查，例如 {{ .severity | default "unknown" }}
+func (r *templateRenderer) checkMissingVariables(field, text string, tree *parse.Tree) {
+	if tree == nil || tree.Root == nil {
+		return
+	}
+	reported := make(map[string]struct{})
+	check := func(node parse.Node, path []string) {
+		name := "." + strings.Join(path, ".")
+		if _, ok := reported[name]; ok || lookupTemplateData(r.data, path) {
+			return
+		}
+		reported[name] = struct{}{}
+		issue := &TemplateRenderIssueBo{Field: field, Message: fmt.Sprintf("variable %s is not set in the json data", name)}
+		// 位置指向引用的最后一段，例如 .a.b 中的 .b，调整为整个引用的起始位置
+		location, _ := tree.ErrorContext(node)
+		if matches := locationPattern.FindStringSubmatch(location); matches != nil {
+			start := parseInt32(matches[2]) - int32(len(node.String())-len(path[len(path)-1])-1)
+			issue.Line, issue.Column = parseInt32(matches[1]), runeColumn(text, parseInt32(matches[1]), start)
+		}
+		r.warnings = append(r.warnings, issue)
+	}
+
+	var walkPipe func(pipe *parse.PipeNode, rooted bool)
+	var walk func(node parse.Node, rooted bool)
+	walkArg := func(arg parse.Node, rooted bool) {
+		switch n := arg.(type) {
+		case *parse.FieldNode:
+			if rooted {
+				check(n, n.Ident)
+			}
+		case *parse.VariableNode:
+			if len(n.Ident) > 1 && n.Ident[0] == "$" {
+				check(n, n.Ident[1:])
+			}
+		case *parse.ChainNode:
+			if pipe, ok := n.Node.(*parse.PipeNode); ok {
+				walkPipe(pipe, rooted)
+			}
+		case *parse.PipeNode:
+			walkPipe(n, rooted)
+		}
+	}
+	walkPipe = func(pipe *parse.PipeNode, rooted bool) {
+		if pipe == nil || handlesMissingValue(pipe) {
+			return
+		}
+		for _, cmd := range pipe.Cmds {
+			for _, arg := range cmd.Args {
+				walkArg(arg, rooted)
+			}
+		}
+	}
+	walk = func(node parse.Node, rooted bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child, rooted)
+			}
+		case *parse.ActionNode:
+			walkPipe(n.Pipe, rooted)
+		case *parse.IfNode:
+			walkPipe(n.Pipe, rooted)
+			walk(n.List, rooted)
+			walk(n.ElseList, rooted)
+		case *parse.RangeNode:
+			// range 和 with 内部的 "." 不再是根数据
+			walkPipe(n.Pipe, rooted)
+			walk(n.List, false)
+			walk(n.ElseList, rooted)
+		case *parse.WithNode:
+			walkPipe(n.Pipe, rooted)
+			walk(n.List, false)
+			walk(n.ElseList, rooted)
+		case *parse.TemplateNode:
+			walkPipe(n.Pipe, rooted)
+		}
+	}
+	walk(tree.Root, true)
+}
+
+// handlesMissingValue 判断管道中是否调用了处理缺失值的函数
+func handlesMissingValue(pipe *parse.PipeNode) bool {
+	for _, cmd := range pipe.Cmds {
+		if len(cmd.Args) == 0 {
+			continue
+		}
+		if identifier, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+			if _, optional := tmplfunc.OptionalFuncs[identifier.Ident]; optional {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lookupTemplateData 判断路径在数据中是否存在，中间值不是对象时无法判断，视为存在
+func lookupTemplateData(data map[string]any, path []string) bool {
+	var value any = data
+	for _, key := range path {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return true
+		}
+		if value, ok = object[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// newTemplateParseIssue 解析错误只包含行号，列号取该行中第一个能复现同一错误的动作的位置
+func newTemplateParseIssue(field, text string, err error) *TemplateRenderIssueBo {
+	matches := parseErrorPattern.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return &TemplateRenderIssueBo{Field: field, Message: err.Error()}
+	}
+	line := parseInt32(matches[1])
+	return &TemplateRenderIssueBo{
+		Field:   field,
+		Line:    line,
+		Column:  parseErrorColumn(field, text, line, err.Error()),
+		Message: matches[2],
+	}
+}
+
+func parseErrorColumn(field, text string, line int32, message string) int32 {
+	lineStart, lineEnd, ok := lineBounds(text, line)
+	if !ok {
+		return 0
+	}
+	firstAction := -1
+	for offset := lineStart; offset < lineEnd; {
+		start := strings.Index(text[offset:lineEnd], "{{")
+		if start < 0 {
+			break
+		}
+		start += offset
+		if firstAction < 0 {
+			firstAction = start
+		}
+		end := lineEnd
+		if closing := strings.Index(text[start:lineEnd], "}}"); closing >= 0 {
+			end = start + closing + 2
+		}
+		if _, err := template.New(field).Funcs(templateFuncs).Parse(text[:end]); err != nil && err.Error() == message {
+			return int32(utf8.RuneCountInString(text[lineStart:start])) + 1
+		}
+		offset = end
+	}
+	if firstAction >= 0 {
+		return int32(utf8.RuneCountInString(text[lineStart:firstAction])) + 1
+	}
+	return 1
+}
+
+// newTemplateExecIssue 执行错误中包含行号和从 0 开始的字节列号
+// html/template 的转义错误格式相同，只有行号的转义错误不解析位置
+func newTemplateExecIssue(field, text string, err error) *TemplateRenderIssueBo {
+	var execErr template.ExecError
+	if errors.As(err, &execErr) {
+		err = execErr.Err
+	}
+	var escapeErr *htmltemplate.Error
+	if errors.As(err, &escapeErr) {
+		err = escapeErr
+	}
+	matches := locationPattern.FindStringSubmatch(err.Error())
+	if matches == nil {
+		// 没有位置的错误只去掉模板名前缀，例如 "html/template:body: ends in a non-text context"
+		message := strings.TrimPrefix(err.Error(), "html/template:"+field+": ")
+		message = strings.TrimPrefix(message, "template: "+field+": ")
+		return &TemplateRenderIssueBo{Field: field, Message: message}
+	}
+	line := parseInt32(matches[1])
+	return &TemplateRenderIssueBo{
+		Field:   field,
+		Line:    line,
+		Column:  runeColumn(text, line, parseInt32(matches[2])),
+		Message: matches[3],
+	}
+}
+
+// lineBounds 返回第 line 行在 text 中的起止字节位置
+func lineBounds(text string, line int32) (start, end int, ok bool) {
+	for i := int32(1); i < line; i++ {
+		next := strings.IndexByte(text[start:], '\n')
+		if next < 0 {
+			return 0, 0, false
+		}
+		start += next + 1
+	}
+	end = len(text)
+	if next := strings.IndexByte(text[start:], '\n'); next >= 0 {
+		end = start + next
+	}
+	return start, end, true
+}
+
+// runeColumn 将行内从 0 开始的字节列号转换为从 1 开始的字符列号
+func runeColumn(text string, line, byteColumn int32) int32 {
+	start, end, ok := lineBounds(text, line)
+	if !ok {
+		return byteColumn + 1
+	}
+	offset := min(start+max(int(byteColumn), 0), end)
+	return int32(utf8.RuneCountInString(text[start:offset])) + 1
+}
+
+// offsetPosition 将字节偏移转换为行号和列号
+func offsetPosition(text string, offset int) (line, column int32) {
+	offset = min(max(offset, 0), len(text))
+	prefix := text[:offset]
+	lineStart := strings.LastIndexByte(prefix, '\n') + 1
+	return int32(strings.Count(prefix, "\n")) + 1, int32(utf8.RuneCountInString(prefix[lineStart:])) + 1
+}
+
+func parseInt32(value string) int32 {
+	number, _ := strconv.ParseInt(value, 10, 32)
+	return int32(number)
+}
+
+// RenderTemplateBo 渲染模板的 BO，UID 不为 0 时渲染已保存的模板，否则渲染请求中未保存的模板内容
+type RenderTemplateBo struct {
+	UID              snowflake.ID
+	Revision         int32
+	App              vobj.TemplateApp
+	TemplateJSONData string
+	Variables        []*do.TemplateVariable
+	JSONData         []byte
+}
+
+// NewRenderTemplateBo 从 API 请求创建 BO
+func NewRenderTemplateBo(req *apiv1.RenderTemplateRequest) (*RenderTemplateBo, error) {
+	if !json.Valid([]byte(req.JsonData)) {
+		return nil, merr.ErrorParams("invalid json data")
+	}
+	return &RenderTemplateBo{
+		UID:      snowflake.ParseInt64(req.Uid),
+		Revision: req.Revision,
+		JSONData: []byte(req.JsonData),
+	}, nil
+}
+
+// NewPreviewTemplateBo 从 API 请求创建 BO
+func NewPreviewTemplateBo(req *apiv1.PreviewTemplateRequest) (*RenderTemplateBo, error) {
+	if !json.Valid([]byte(req.TemplateJsonData)) {
+		return nil, merr.ErrorParams("invalid template json data")
+	}
+	if !json.Valid([]byte(req.JsonData)) {
+		return nil, merr.ErrorParams("invalid json data")
+	}
+	variables, err := NewDoTemplateVariables(req.Variables)
+	if err != nil {
+		return nil, err
+	}
+	return &RenderTemplateBo{
+		App:              vobj.TemplateApp(req.App),
+		TemplateJSONData: req.TemplateJsonData,
+		Variables:        variables,
+		JSONData:         []byte(req.JsonData),
+	}, nil
+}
+
+// ToTemplateItemBo 将未保存的模板内容转换为模板项
+func (r *RenderTemplateBo) ToTemplateItemBo() *TemplateItemBo {
+	return &TemplateItemBo{
+		App:       r.App,
+		JSONData:  r.TemplateJSONData,
+		Variables: r.Variables,
+		Status:    vobj.GlobalStatusEnabled,
+	}
+}
+
+// TemplateRenderResultBo 模板渲染结果，邮件使用 Subject、Body、TextBody 和 ContentType，短信使用 TemplateCode、Body 和 Params，webhook 使用 Payload
+type TemplateRenderResultBo struct {
+	App          vobj.TemplateApp
+	Revision     int32
+	Subject      string
+	Body         string
+	TextBody     string
+	ContentType  string
+	Payload      string
+	TemplateCode string
+	Params       map[string]string
+	Warnings     []*TemplateRenderIssueBo
+	Errors       []*TemplateRenderIssueBo
+}
+
+// RenderTemplate 使用与发送相同的渲染逻辑渲染模板，模板错误记录在结果中而不是返回错误
+func RenderTemplate(templateBo *TemplateItemBo, jsonData []byte) (*TemplateRenderResultBo, error) {
+	renderer, err := newTemplateRenderer(jsonData)
+	if err != nil {
+		return nil, err
+	}
+	result := &TemplateRenderResultBo{App: templateBo.App, Revision: templateBo.Revision}
+	// 与发送时一致，先校验声明的变量并补全默认值，再渲染模板
+	renderer.applyVariables(templateBo.Variables)
+	if !templateBo.Status.IsEnabled() {
+		renderer.warnings = append(renderer.warnings, &TemplateRenderIssueBo{Message: fmt.Sprintf("template %s(%s) is disabled, sending with it will fail", templateBo.Name, templateBo.UID)})
+	}
+	switch {
+	case templateBo.App.IsEmailType():
+		emailTemplateData, err := templateBo.ToEmailTemplateData()
+		if err != nil {
+			return nil, merr.ErrorParams("invalid email template data").WithCause(err)
+		}
+		result.Subject, result.Body, result.TextBody, _ = renderer.renderEmail(emailTemplateData)
+		result.ContentType = emailTemplateData.ContentType
+		// 与发送时一致，HTML 邮件未定义纯文本正文时由 HTML 生成
+		if emailTemplateData.IsHTML() && result.TextBody == "" && len(renderer.errors) == 0 {
+			result.TextBody = HTMLToText(result.Body)
+		}
+	case templateBo.App.IsSMSType():
+		smsTemplateData, err := templateBo.ToSMSTemplateData()
+		if err != nil {
+			return nil, merr.ErrorParams("invalid sms template data").WithCause(err)
+		}
+		result.TemplateCode = smsTemplateData.TemplateCode
+		result.Body, result.Params = renderer.renderSMS(smsTemplateData)
+	case templateBo.App.IsWebhookType():
+		webhookTemplateData, err := templateBo.ToWebhookTemplateData()
+		if err != nil {
+			return nil, merr.ErrorParams("invalid webhook template data").WithCause(err)
+		}
+		result.Payload = renderer.renderWebhook(webhookTemplateData)
+	default:
+		return nil, merr.ErrorParams("invalid template app %s", templateBo.App)
+	}
+	result.Warnings, result.Errors = renderer.warnings, renderer.errors
+	return result, nil
+}
+
+// ToAPIV1RenderTemplateReply 转换为 API 响应
+func (r *TemplateRenderResultBo) ToAPIV1RenderTemplateReply() *apiv1.RenderTemplateReply {
+	warnings := make([]*apiv1.TemplateRenderIssue, 0, len(r.Warnings))
+	for _, warning := range r.Warnings {
+		warnings = append(warnings, warning.ToAPIV1TemplateRenderIssue())
+	}
+	errs := make([]*apiv1.TemplateRenderIssue, 0, len(r.Errors))
+	for _, err := range r.Errors {
+		errs = append(errs, err.ToAPIV1TemplateRenderIssue())
+	}
+	return &apiv1.RenderTemplateReply{
+		App:          enum.TemplateAPP(r.App),
+		Revision:     r.Revision,
+		Subject:      r.Subject,
+		Body:         r.Body,
+		TextBody:     r.TextBody,
+		ContentType:  r.ContentType,
+		Payload:      r.Payload,
+		TemplateCode: r.TemplateCode,
+		Params:       r.Params,
+		Warnings:     warnings,
+		Errors:       errs,
+	}
+}
+
+// ToAPIV1ListTemplateFuncsReply 转换为 API 响应，category 为空时返回全部函数
+func ToAPIV1ListTemplateFuncsReply(category string) *apiv1.ListTemplateFuncsReply {
+	items := make([]*apiv1.TemplateFunc, 0)
+	for _, fn := range tmplfunc.Funcs() {
+		if category != "" && fn.Category != category {
+			continue
+		}
+		items = append(items, &apiv1.TemplateFunc{
+			Name:        fn.Name,
+			Category:    fn.Category,
+			Signature:   fn.Signature,
+			Description: fn.Description,
+			Example:     fn.Example,
+		})
+	}
+	return &apiv1.ListTemplateFuncsReply{Items: items}
+}
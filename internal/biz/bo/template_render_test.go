@@ -0,0 +1,255 @@
+package bo
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func issueStrings(issues []*TemplateRenderIssueBo) []string {
+	list := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		list = append(list, issue.Error())
+	}
+	return list
+}
+
+// assertIssues 按前缀比较，html/template 的上下文描述随 Go 版本变化，只比较位置和错误开头
+func assertIssues(t *testing.T, kind string, got []*TemplateRenderIssueBo, want []string) {
+	t.Helper()
+	gotList := issueStrings(got)
+	matched := len(gotList) == len(want)
+	for i := 0; matched && i < len(want); i++ {
+		matched = strings.HasPrefix(gotList[i], want[i])
+	}
+	if !matched {
+		t.Errorf("%s mismatch\ngot:  %q\nwant: %q", kind, gotList, want)
+	}
+}
+
+func newTestRenderer(t *testing.T, jsonData string) *templateRenderer {
+	t.Helper()
+	renderer, err := newTemplateRenderer([]byte(jsonData))
+	if err != nil {
+		t.Fatalf("new template renderer failed: %v", err)
+	}
+	return renderer
+}
+
+func TestTemplateRenderer_ParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "unclosed action",
+			text: "Hello {{ .name",
+			want: []string{"body:1:7: unclosed action"},
+		},
+		{
+			name: "undefined function on second line",
+			text: "first line\n  {{ nosuch .x }}",
+			want: []string{`body:2:3: function "nosuch" not defined`},
+		},
+		{
+			name: "column counts runes",
+			text: "你好 {{ .a }} {{ end }}",
+			want: []string{"body:1:13: unexpected {{end}}"},
+		},
+		{
+			name: "unexpected eof points at first action of the line",
+			text: "line\nfoo {{ if .a }}x",
+			want: []string{"body:2:5: unexpected EOF"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := newTestRenderer(t, `{"name":"x","a":true}`)
+			if got := renderer.render("body", tt.text); got != "" {
+				t.Errorf("render() = %q, want empty result", got)
+			}
+			assertIssues(t, "errors", renderer.errors, tt.want)
+		})
+	}
+}
+
+func TestTemplateRenderer_ExecErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "index out of range",
+			text: "items: {{ index .list 5 }}",
+			want: []string{`body:1:11: executing "body" at <index .list 5>: error calling index: index out of range: 5`},
+		},
+		{
+			name: "column counts runes on later lines",
+			text: "标题\n数量：{{ index .list 5 }}",
+			want: []string{`body:2:7: executing "body" at <index .list 5>: error calling index: index out of range: 5`},
+		},
+		{
+			name: "function error",
+			text: "{{ div 1 0 }}",
+			want: []string{`body:1:4: executing "body" at <div 1 0>: error calling div: division by zero`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := newTestRenderer(t, `{"list":[1]}`)
+			if got := renderer.render("body", tt.text); got != "" {
+				t.Errorf("render() = %q, want empty result", got)
+			}
+			assertIssues(t, "errors", renderer.errors, tt.want)
+		})
+	}
+}
+
+func TestTemplateRenderer_RenderHTML(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		want       string
+		wantErrors []string
+	}{
+		{
+			name: "escape text",
+			text: "<p>Hello {{ .name }}</p>",
+			want: "<p>Hello &lt;b&gt;Bob&lt;/b&gt;</p>",
+		},
+		{
+			name: "escape url attribute",
+			text: `<a href="{{ .url }}">link</a>`,
+			want: `<a href="#ZgotmplZ">link</a>`,
+		},
+		{
+			name:       "branches end in different contexts",
+			text:       "x\n{{ if .name }}<a href=\"{{ else }}<b>{{ end }}",
+			wantErrors: []string{`body:2:7: {{if}} branches end in different contexts: {stateURL `},
+		},
+		{
+			name:       "ends in non text context has no position",
+			text:       `<a href="{{ .url }}`,
+			wantErrors: []string{`body:0:0: ends in a non-text context: {stateURL `},
+		},
+		{
+			name:       "parse error",
+			text:       "<p>{{ .name </p>",
+			wantErrors: []string{`body:1:4: unexpected "<" in operand`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := newTestRenderer(t, `{"name":"<b>Bob</b>","url":"javascript:alert(1)"}`)
+			if got := renderer.renderHTML("body", tt.text); got != tt.want {
+				t.Errorf("renderHTML() = %q, want %q", got, tt.want)
+			}
+			assertIssues(t, "errors", renderer.errors, tt.wantErrors)
+		})
+	}
+}
+
+func TestTemplateRenderer_MissingVariables(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "all variables set",
+			text: "{{ .name }} {{ .user.email }}",
+		},
+		{
+			name: "nested variable",
+			text: "Hi {{ .user.phone }}",
+			want: []string{"body:1:7: variable .user.phone is not set in the json data"},
+		},
+		{
+			name: "reported once",
+			text: "{{ .missing }}\n{{ .missing }}",
+			want: []string{"body:1:4: variable .missing is not set in the json data"},
+		},
+		{
+			name: "optional functions skip the check",
+			text: `{{ .severity | default "info" }} {{ coalesce .a .b }} {{ if empty .c }}x{{ end }}`,
+		},
+		{
+			name: "range body is scoped to the item",
+			text: "{{ range .items }}{{ .title }}{{ end }}",
+		},
+		{
+			name: "root variable inside range",
+			text: "{{ range .items }}{{ $.footer }}{{ end }}",
+			want: []string{"body:1:22: variable .footer is not set in the json data"},
+		},
+		{
+			name: "with body is scoped and else is not",
+			text: "{{ with .profile }}{{ .avatar }}{{ else }}{{ .fallback }}{{ end }}",
+			want: []string{
+				"body:1:9: variable .profile is not set in the json data",
+				"body:1:46: variable .fallback is not set in the json data",
+			},
+		},
+		{
+			name: "if condition and branches",
+			text: "{{ if .flag }}{{ .yes }}{{ else }}{{ .name }}{{ end }}",
+			want: []string{
+				"body:1:7: variable .flag is not set in the json data",
+				"body:1:18: variable .yes is not set in the json data",
+			},
+		},
+		{
+			name: "non object parent is not checked",
+			text: "{{ .name.first }}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := newTestRenderer(t, `{"name":"Bob","user":{"email":"bob@example.com"},"items":[{"title":"a"}]}`)
+			renderer.render("body", tt.text)
+			assertIssues(t, "warnings", renderer.warnings, tt.want)
+		})
+	}
+}
+
+func TestTemplateRenderer_MissingVariablesHTML(t *testing.T) {
+	renderer := newTestRenderer(t, `{}`)
+	renderer.renderHTML("body", `<a href="{{ .url }}">{{ .title }}</a>`)
+	assertIssues(t, "warnings", renderer.warnings, []string{
+		"body:1:13: variable .url is not set in the json data",
+		"body:1:25: variable .title is not set in the json data",
+	})
+}
+
+func TestTemplateRenderer_RenderWebhook(t *testing.T) {
+	renderer := newTestRenderer(t, `{"value":"text"}`)
+	payload := renderer.renderWebhook(WebhookTemplateData("{\n  \"a\": {{ .value }}\n}"))
+	if want := "{\n  \"a\": text\n}"; payload != want {
+		t.Errorf("renderWebhook() = %q, want %q", payload, want)
+	}
+	assertIssues(t, "errors", renderer.errors, []string{
+		"payload:2:9: rendered payload is not valid json: invalid character 'e' in literal true (expecting 'r')",
+	})
+}
+
+func TestOffsetPosition(t *testing.T) {
+	tests := []struct {
+		text   string
+		offset int
+		want   string
+	}{
+		{text: "abc", offset: 0, want: "1:1"},
+		{text: "abc", offset: 2, want: "1:3"},
+		{text: "ab\ncd", offset: 4, want: "2:2"},
+		{text: "中文\n中文", offset: len("中文\n中"), want: "2:2"},
+		{text: "abc", offset: 10, want: "1:4"},
+	}
+	for _, tt := range tests {
+		line, column := offsetPosition(tt.text, tt.offset)
+		if got := fmt.Sprintf("%d:%d", line, column); got != tt.want {
+			t.Errorf("offsetPosition(%q, %d) = %s, want %s", tt.text, tt.offset, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package bo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+	"github.com/aide-family/rabbit/pkg/enum"
+)
+
+// TemplateRevisionItemBo 模板版本项的 BO
+type TemplateRevisionItemBo struct {
+	TemplateUID snowflake.ID
+	Revision    int32
+	Name        string
+	App         vobj.TemplateApp
+	JSONData    string
+	Variables   []*do.TemplateVariable
+	Note        string
+	Creator     snowflake.ID
+	CreatedAt   time.Time
+}
+
+// NewTemplateRevisionItemBo 从 DO 创建 BO
+func NewTemplateRevisionItemBo(doRevision *do.TemplateRevision) *TemplateRevisionItemBo {
+	return &TemplateRevisionItemBo{
+		TemplateUID: doRevision.TemplateUID,
+		Revision:    doRevision.Revision,
+		Name:        doRevision.Name,
+		App:         doRevision.App,
+		JSONData:    string(doRevision.JSONData),
+		Variables:   doRevision.Variables,
+		Note:        doRevision.Note,
+		Creator:     doRevision.Creator,
+		CreatedAt:   doRevision.CreatedAt,
+	}
+}
+
+// ToDoTemplate 转换为更新模板内容的 DO，用于回滚到该版本
+func (t *TemplateRevisionItemBo) ToDoTemplate() *do.Template {
+	template := &do.Template{
+		Name:      t.Name,
+		App:       t.App,
+		JSONData:  json.RawMessage(t.JSONData),
+		Variables: t.Variables,
+	}
+	template.WithUID(t.TemplateUID)
+	return template
+}
+
+// ToAPIV1TemplateRevisionItem 转换为 API 响应
+func (t *TemplateRevisionItemBo) ToAPIV1TemplateRevisionItem() *apiv1.TemplateRevisionItem {
+	return &apiv1.TemplateRevisionItem{
+		TemplateUID: t.TemplateUID.Int64(),
+		Revision:    t.Revision,
+		Name:        t.Name,
+		App:         enum.TemplateAPP(t.App),
+		JsonData:    t.JSONData,
+		Variables:   ToConfigTemplateVariables(t.Variables),
+		Note:        t.Note,
+		Creator:     t.Creator.Int64(),
+		CreatedAt:   t.CreatedAt.Format(time.DateTime),
+	}
+}
+
+// ListTemplateRevisionBo 模板版本列表查询的 BO
+type ListTemplateRevisionBo struct {
+	*PageRequestBo
+	TemplateUID snowflake.ID
+}
+
+// NewListTemplateRevisionBo 从 API 请求创建 BO
+func NewListTemplateRevisionBo(req *apiv1.ListTemplateRevisionRequest) *ListTemplateRevisionBo {
+	return &ListTemplateRevisionBo{
+		PageRequestBo: NewPageRequestBo(req.Page, req.PageSize),
+		TemplateUID:   snowflake.ParseInt64(req.Uid),
+	}
+}
+
+// ToAPIV1ListTemplateRevisionReply 转换为 API 响应
+func ToAPIV1ListTemplateRevisionReply(pageResponseBo *PageResponseBo[*TemplateRevisionItemBo]) *apiv1.ListTemplateRevisionReply {
+	items := make([]*apiv1.TemplateRevisionItem, 0, len(pageResponseBo.GetItems()))
+	for _, item := range pageResponseBo.GetItems() {
+		items = append(items, item.ToAPIV1TemplateRevisionItem())
+	}
+	return &apiv1.ListTemplateRevisionReply{
+		Items:    items,
+		Total:    pageResponseBo.GetTotal(),
+		Page:     pageResponseBo.GetPage(),
+		PageSize: pageResponseBo.GetPageSize(),
+	}
+}
+
+// DiffTemplateRevisionBo 对比两个模板版本的 BO
+type DiffTemplateRevisionBo struct {
+	TemplateUID  snowflake.ID
+	FromRevision int32
+	ToRevision   int32
+}
+
+// NewDiffTemplateRevisionBo 从 API 请求创建 BO
+func NewDiffTemplateRevisionBo(req *apiv1.DiffTemplateRevisionRequest) *DiffTemplateRevisionBo {
+	return &DiffTemplateRevisionBo{
+		TemplateUID:  snowflake.ParseInt64(req.Uid),
+		FromRevision: req.FromRevision,
+		ToRevision:   req.ToRevision,
+	}
+}
+
+// TemplateRevisionDiffBo 两个模板版本的对比结果
+type TemplateRevisionDiffBo struct {
+	From *TemplateRevisionItemBo
+	To   *TemplateRevisionItemBo
+	Diff string
+}
+
+// NewTemplateRevisionDiffBo 逐行对比两个版本，JSON 内容先格式化再对比
+func NewTemplateRevisionDiffBo(from, to *TemplateRevisionItemBo) *TemplateRevisionDiffBo {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "--- revision %d\n+++ revision %d\n", from.Revision, to.Revision)
+	if from.Name != to.Name {
+		fmt.Fprintf(&builder, "-name: %s\n+name: %s\n", from.Name, to.Name)
+	}
+	if from.App != to.App {
+		fmt.Fprintf(&builder, "-app: %s\n+app: %s\n", from.App, to.App)
+	}
+	for _, line := range diffLines(splitTemplateJSON(from.JSONData), splitTemplateJSON(to.JSONData)) {
+		builder.WriteString(line)
+		builder.WriteString("\n")
+	}
+	fromVariables, _ := json.Marshal(from.Variables)
+	toVariables, _ := json.Marshal(to.Variables)
+	if len(from.Variables)+len(to.Variables) > 0 && !bytes.Equal(fromVariables, toVariables) {
+		fmt.Fprintf(&builder, "-variables: %s\n+variables: %s\n", fromVariables, toVariables)
+	}
+	return &TemplateRevisionDiffBo{From: from, To: to, Diff: builder.String()}
+}
+
+// ToAPIV1DiffTemplateRevisionReply 转换为 API 响应
+func (d *TemplateRevisionDiffBo) ToAPIV1DiffTemplateRevisionReply() *apiv1.DiffTemplateRevisionReply {
+	return &apiv1.DiffTemplateRevisionReply{
+		From: d.From.ToAPIV1TemplateRevisionItem(),
+		To:   d.To.ToAPIV1TemplateRevisionItem(),
+		Diff: d.Diff,
+	}
+}
+
+// splitTemplateJSON 格式化 JSON 后按行拆分，格式化失败时按原文拆分
+func splitTemplateJSON(jsonData string) []string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(jsonData), "", "  "); err != nil {
+		return strings.Split(jsonData, "\n")
+	}
+	return strings.Split(buf.String(), "\n")
+}
+
+// diffLines 基于最长公共子序列输出逐行差异，删除的行以 "-" 开头，新增的行以 "+" 开头，相同的行以 " " 开头
+func diffLines(from, to []string) []string {
+	// lcs[i][j] 为 from[i:] 与 to[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	lines := make([]string, 0, len(from)+len(to))
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			lines = append(lines, " "+from[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "-"+from[i])
+			i++
+		default:
+			lines = append(lines, "+"+to[j])
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		lines = append(lines, "-"+from[i])
+	}
+	for ; j < len(to); j++ {
+		lines = append(lines, "+"+to[j])
+	}
+	return lines
+}
+
+// RollbackTemplateBo 回滚模板的 BO
+type RollbackTemplateBo struct {
+	UID      snowflake.ID
+	Revision int32
+	Note     string
+}
+
+// NewRollbackTemplateBo 从 API 请求创建 BO
+func NewRollbackTemplateBo(req *apiv1.RollbackTemplateRequest) *RollbackTemplateBo {
+	return &RollbackTemplateBo{
+		UID:      snowflake.ParseInt64(req.Uid),
+		Revision: req.Revision,
+		Note:     req.Note,
+	}
+}
+
+// RollbackNote 回滚生成的新版本的变更说明
+func (r *RollbackTemplateBo) RollbackNote() string {
+	note := fmt.Sprintf("rollback to revision %d", r.Revision)
+	if r.Note != "" {
+		note += ": " + r.Note
+	}
+	return note
+}
@@ -0,0 +1,312 @@
+package bo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/aide-family/magicbox/serialize"
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/aide-family/rabbit/internal/biz/do"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+	"github.com/aide-family/rabbit/pkg/config"
+	"github.com/aide-family/rabbit/pkg/merr"
+)
+
+// 模板变量支持的类型，与 JSON Schema 的类型名称一致
+const (
+	TemplateVariableTypeString  = "string"
+	TemplateVariableTypeNumber  = "number"
+	TemplateVariableTypeInteger = "integer"
+	TemplateVariableTypeBoolean = "boolean"
+	TemplateVariableTypeObject  = "object"
+	TemplateVariableTypeArray   = "array"
+)
+
+var templateVariableTypes = map[string]struct{}{
+	TemplateVariableTypeString:  {},
+	TemplateVariableTypeNumber:  {},
+	TemplateVariableTypeInteger: {},
+	TemplateVariableTypeBoolean: {},
+	TemplateVariableTypeObject:  {},
+	TemplateVariableTypeArray:   {},
+}
+
+// NewDoTemplateVariables 将配置文件/API 中的模板变量转换为 DO，并校验名称、类型和默认值
+// 返回值不为 nil，更新模板时可以清空已声明的变量
+func NewDoTemplateVariables(variables []*config.TemplateVariable) ([]*do.TemplateVariable, error) {
+	doVariables := make([]*do.TemplateVariable, 0, len(variables))
+	names := make(map[string]struct{}, len(variables))
+	for index, variable := range variables {
+		name := strings.TrimSpace(variable.GetName())
+		if name == "" || strings.Contains(name, "..") || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+			return nil, merr.ErrorParams("variables[%d]: invalid variable name %q", index, variable.GetName())
+		}
+		if _, ok := names[name]; ok {
+			return nil, merr.ErrorParams("variables[%d]: duplicate variable %s", index, name)
+		}
+		names[name] = struct{}{}
+		variableType := strings.ToLower(strings.TrimSpace(variable.GetType()))
+		if _, ok := templateVariableTypes[variableType]; variableType != "" && !ok {
+			return nil, merr.ErrorParams("variables[%d]: invalid type %q of variable %s, expected one of string, number, integer, boolean, object, array", index, variable.GetType(), name)
+		}
+		doVariable := &do.TemplateVariable{
+			Name:        name,
+			Type:        variableType,
+			Required:    variable.GetRequired(),
+			Description: variable.GetDescription(),
+		}
+		if defaultValue := strings.TrimSpace(variable.GetDefaultValue()); defaultValue != "" {
+			var value any
+			if err := json.Unmarshal([]byte(defaultValue), &value); err != nil {
+				return nil, merr.ErrorParams("variables[%d]: default value of variable %s is not valid json", index, name).WithCause(err)
+			}
+			if !matchTemplateVariableType(variableType, value) {
+				return nil, merr.ErrorParams("variables[%d]: default value of variable %s must be %s", index, name, variableType)
+			}
+			doVariable.Default = json.RawMessage(defaultValue)
+		}
+		doVariables = append(doVariables, doVariable)
+	}
+	return doVariables, nil
+}
+
+// ToConfigTemplateVariables 将 DO 中的模板变量转换为 API 响应
+func ToConfigTemplateVariables(variables []*do.TemplateVariable) []*config.TemplateVariable {
+	configVariables := make([]*config.TemplateVariable, 0, len(variables))
+	for _, variable := range variables {
+		configVariables = append(configVariables, &config.TemplateVariable{
+			Name:         variable.Name,
+			Type:         variable.Type,
+			Required:     variable.Required,
+			DefaultValue: string(variable.Default),
+			Description:  variable.Description,
+		})
+	}
+	return configVariables
+}
+
+// applyTemplateVariables 按声明的变量校验数据，缺失的变量写入默认值
+// 返回所有不符合声明的变量，Field 为 jsonData 中的变量路径
+func applyTemplateVariables(variables []*do.TemplateVariable, data map[string]any) []*TemplateRenderIssueBo {
+	issues := make([]*TemplateRenderIssueBo, 0)
+	for _, variable := range variables {
+		path := strings.Split(variable.Name, ".")
+		value, exists, reachable := lookupTemplateVariable(data, path)
+		field := "jsonData." + variable.Name
+		if !reachable {
+			issues = append(issues, &TemplateRenderIssueBo{Field: field, Message: fmt.Sprintf("parent of variable %s is not an object", variable.Name)})
+			continue
+		}
+		if !exists || value == nil {
+			if len(variable.Default) > 0 {
+				var defaultValue any
+				if err := json.Unmarshal(variable.Default, &defaultValue); err == nil {
+					setTemplateVariable(data, path, defaultValue)
+					continue
+				}
+			}
+			if variable.Required {
+				issues = append(issues, &TemplateRenderIssueBo{Field: field, Message: fmt.Sprintf("variable %s is required", variable.Name)})
+			}
+			continue
+		}
+		if !matchTemplateVariableType(variable.Type, value) {
+			issues = append(issues, &TemplateRenderIssueBo{Field: field, Message: fmt.Sprintf("variable %s must be %s, got %s", variable.Name, variable.Type, templateValueType(value))})
+		}
+	}
+	return issues
+}
+
+// ApplyTemplateVariables 按模板声明的变量校验 jsonData 并补全默认值，返回补全后的 jsonData
+// 未声明变量时原样返回，校验失败时返回的错误中包含所有不符合声明的变量
+func ApplyTemplateVariables(variables []*do.TemplateVariable, jsonData []byte) ([]byte, error) {
+	if len(variables) == 0 {
+		return jsonData, nil
+	}
+	data := make(map[string]any)
+	if len(jsonData) > 0 {
+		if err := serialize.JSONUnmarshal(jsonData, &data); err != nil {
+			return nil, merr.ErrorParams("json data must be an object").WithCause(err)
+		}
+		if data == nil {
+			data = make(map[string]any)
+		}
+	}
+	if issues := applyTemplateVariables(variables, data); len(issues) > 0 {
+		messages := make([]string, 0, len(issues))
+		metadata := make(map[string]string, len(issues))
+		for _, issue := range issues {
+			messages = append(messages, issue.Message)
+			metadata[issue.Field] = issue.Message
+		}
+		return nil, merr.ErrorParams("invalid json data: %s", strings.Join(messages, "; ")).WithMetadata(metadata)
+	}
+	applied, err := serialize.JSONMarshal(data)
+	if err != nil {
+		return nil, merr.ErrorInternal("marshal json data failed").WithCause(err)
+	}
+	return applied, nil
+}
+
+// lookupTemplateVariable 查找路径对应的值，reachable 为 false 表示中间值存在但不是对象
+func lookupTemplateVariable(data map[string]any, path []string) (value any, exists, reachable bool) {
+	object := data
+	for index, key := range path {
+		value, exists = object[key]
+		if !exists || index == len(path)-1 {
+			return value, exists, true
+		}
+		if value == nil {
+			return nil, false, true
+		}
+		next, ok := value.(map[string]any)
+		if !ok {
+			return nil, false, false
+		}
+		object = next
+	}
+	return nil, false, true
+}
+
+// setTemplateVariable 写入路径对应的值，缺失的中间对象自动创建
+func setTemplateVariable(data map[string]any, path []string, value any) {
+	object := data
+	for _, key := range path[:len(path)-1] {
+		next, ok := object[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			object[key] = next
+		}
+		object = next
+	}
+	object[path[len(path)-1]] = value
+}
+
+// matchTemplateVariableType 判断 JSON 解码后的值是否符合变量类型，类型为空时不校验
+func matchTemplateVariableType(variableType string, value any) bool {
+	switch variableType {
+	case "":
+		return true
+	case TemplateVariableTypeInteger:
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	default:
+		return templateValueType(value) == variableType
+	}
+}
+
+// templateValueType 返回 JSON 解码后的值对应的类型名称
+func templateValueType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return TemplateVariableTypeString
+	case float64:
+		return TemplateVariableTypeNumber
+	case bool:
+		return TemplateVariableTypeBoolean
+	case map[string]any:
+		return TemplateVariableTypeObject
+	case []any:
+		return TemplateVariableTypeArray
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// templateJSONSchema JSON Schema(draft 2020-12) 的子集，只包含由模板变量生成的字段
+type templateJSONSchema struct {
+	Schema      string                         `json:"$schema,omitempty"`
+	Title       string                         `json:"title,omitempty"`
+	Description string                         `json:"description,omitempty"`
+	Type        string                         `json:"type,omitempty"`
+	Default     json.RawMessage                `json:"default,omitempty"`
+	Properties  map[string]*templateJSONSchema `json:"properties,omitempty"`
+	Required    []string                       `json:"required,omitempty"`
+}
+
+// property 返回嵌套的属性，不存在时创建为对象
+func (s *templateJSONSchema) property(name string) *templateJSONSchema {
+	if s.Properties == nil {
+		s.Properties = make(map[string]*templateJSONSchema)
+	}
+	property, ok := s.Properties[name]
+	if !ok {
+		property = &templateJSONSchema{Type: TemplateVariableTypeObject}
+		s.Properties[name] = property
+	}
+	return property
+}
+
+// require 将属性加入必填列表，已存在时忽略
+func (s *templateJSONSchema) require(name string) {
+	for _, required := range s.Required {
+		if required == name {
+			return
+		}
+	}
+	s.Required = append(s.Required, name)
+}
+
+// TemplateSchemaBo 模板声明的变量及由其生成的 JSON Schema
+type TemplateSchemaBo struct {
+	UID        snowflake.ID
+	Revision   int32
+	Variables  []*do.TemplateVariable
+	JSONSchema string
+}
+
+// NewTemplateSchemaBo 由模板声明的变量生成 JSON Schema，嵌套变量生成嵌套对象
+// 有默认值的变量不会被标记为必填，发送时缺失会自动补全
+func NewTemplateSchemaBo(templateBo *TemplateItemBo) (*TemplateSchemaBo, error) {
+	root := &templateJSONSchema{
+		Schema: "https://json-schema.org/draft/2020-12/schema",
+		Title:  templateBo.Name,
+		Type:   TemplateVariableTypeObject,
+	}
+	for _, variable := range templateBo.Variables {
+		path := strings.Split(variable.Name, ".")
+		parent := root
+		for _, key := range path[:len(path)-1] {
+			if variable.Required && len(variable.Default) == 0 {
+				parent.require(key)
+			}
+			parent = parent.property(key)
+			// 嵌套变量的上级已声明为其他类型时，以对象为准
+			parent.Type = TemplateVariableTypeObject
+		}
+		property := parent.property(path[len(path)-1])
+		if property.Properties == nil {
+			property.Type = variable.Type
+		}
+		property.Description = variable.Description
+		property.Default = variable.Default
+		if variable.Required && len(variable.Default) == 0 {
+			parent.require(path[len(path)-1])
+		}
+	}
+	jsonSchema, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, merr.ErrorInternal("marshal json schema failed").WithCause(err)
+	}
+	return &TemplateSchemaBo{
+		UID:        templateBo.UID,
+		Revision:   templateBo.Revision,
+		Variables:  templateBo.Variables,
+		JSONSchema: string(jsonSchema),
+	}, nil
+}
+
+// ToAPIV1GetTemplateSchemaReply 转换为 API 响应
+func (s *TemplateSchemaBo) ToAPIV1GetTemplateSchemaReply() *apiv1.GetTemplateSchemaReply {
+	return &apiv1.GetTemplateSchemaReply{
+		Uid:        s.UID.Int64(),
+		Revision:   s.Revision,
+		Variables:  ToConfigTemplateVariables(s.Variables),
+		JsonSchema: s.JSONSchema,
+	}
+}
@@ -2,6 +2,7 @@ package bo
 
 import (
 	"encoding/json"
+	"net/http"
 	"time"
 
 	"github.com/aide-family/magicbox/message"
@@ -19,54 +20,74 @@ import (
 )
 
 type CreateWebhookBo struct {
-	App     vobj.WebhookApp
-	Name    string
-	URL     string
-	Method  vobj.HTTPMethod
-	Headers map[string]string
-	Secret  string
+	App         vobj.WebhookApp
+	Name        string
+	URL         string
+	Method      vobj.HTTPMethod
+	Headers     map[string]string
+	Secret      string
+	RetryPolicy *do.RetryPolicy
+	RateLimit   *do.RateLimit
+	SuccessRule *do.SuccessRule
+	Signature   *do.Signature
 }
 
 func (b *CreateWebhookBo) ToDoWebhookConfig() *do.WebhookConfig {
 	return &do.WebhookConfig{
-		App:     b.App,
-		Name:    b.Name,
-		URL:     b.URL,
-		Method:  b.Method,
-		Headers: safety.NewMap(b.Headers),
-		Secret:  strutil.EncryptString(b.Secret),
+		App:         b.App,
+		Name:        b.Name,
+		URL:         b.URL,
+		Method:      b.Method,
+		Headers:     safety.NewMap(b.Headers),
+		Secret:      strutil.EncryptString(b.Secret),
+		RetryPolicy: b.RetryPolicy,
+		RateLimit:   b.RateLimit,
+		SuccessRule: b.SuccessRule,
+		Signature:   b.Signature,
 	}
 }
 
 func NewCreateWebhookBo(req *apiv1.CreateWebhookRequest) *CreateWebhookBo {
 	return &CreateWebhookBo{
-		App:     vobj.WebhookApp(req.App),
-		Name:    req.Name,
-		URL:     req.Url,
-		Method:  vobj.HTTPMethod(req.Method),
-		Headers: req.Headers,
-		Secret:  req.Secret,
+		App:         vobj.WebhookApp(req.App),
+		Name:        req.Name,
+		URL:         req.Url,
+		Method:      vobj.HTTPMethod(req.Method),
+		Headers:     req.Headers,
+		Secret:      req.Secret,
+		RetryPolicy: NewDoRetryPolicy(req.RetryPolicy),
+		RateLimit:   NewDoRateLimit(req.RateLimit),
+		SuccessRule: NewDoSuccessRule(req.SuccessRule),
+		Signature:   NewDoSignature(req.Signature),
 	}
 }
 
 type UpdateWebhookBo struct {
-	UID     snowflake.ID
-	App     vobj.WebhookApp
-	Name    string
-	URL     string
-	Method  vobj.HTTPMethod
-	Headers map[string]string
-	Secret  string
+	UID         snowflake.ID
+	App         vobj.WebhookApp
+	Name        string
+	URL         string
+	Method      vobj.HTTPMethod
+	Headers     map[string]string
+	Secret      string
+	RetryPolicy *do.RetryPolicy
+	RateLimit   *do.RateLimit
+	SuccessRule *do.SuccessRule
+	Signature   *do.Signature
 }
 
 func (b *UpdateWebhookBo) ToDoWebhookConfig() *do.WebhookConfig {
 	webhookConfig := &do.WebhookConfig{
-		App:     b.App,
-		Name:    b.Name,
-		URL:     b.URL,
-		Method:  b.Method,
-		Headers: safety.NewMap(b.Headers),
-		Secret:  strutil.EncryptString(b.Secret),
+		App:         b.App,
+		Name:        b.Name,
+		URL:         b.URL,
+		Method:      b.Method,
+		Headers:     safety.NewMap(b.Headers),
+		Secret:      strutil.EncryptString(b.Secret),
+		RetryPolicy: b.RetryPolicy,
+		RateLimit:   b.RateLimit,
+		SuccessRule: b.SuccessRule,
+		Signature:   b.Signature,
 	}
 	webhookConfig.WithUID(b.UID)
 	return webhookConfig
@@ -74,13 +95,17 @@ func (b *UpdateWebhookBo) ToDoWebhookConfig() *do.WebhookConfig {
 
 func NewUpdateWebhookBo(req *apiv1.UpdateWebhookRequest) *UpdateWebhookBo {
 	return &UpdateWebhookBo{
-		UID:     snowflake.ParseInt64(req.Uid),
-		App:     vobj.WebhookApp(req.App),
-		Name:    req.Name,
-		URL:     req.Url,
-		Method:  vobj.HTTPMethod(req.Method),
-		Headers: req.Headers,
-		Secret:  req.Secret,
+		UID:         snowflake.ParseInt64(req.Uid),
+		App:         vobj.WebhookApp(req.App),
+		Name:        req.Name,
+		URL:         req.Url,
+		Method:      vobj.HTTPMethod(req.Method),
+		Headers:     req.Headers,
+		Secret:      req.Secret,
+		RetryPolicy: NewDoRetryPolicy(req.RetryPolicy),
+		RateLimit:   NewDoRateLimit(req.RateLimit),
+		SuccessRule: NewDoSuccessRule(req.SuccessRule),
+		Signature:   NewDoSignature(req.Signature),
 	}
 }
 
@@ -99,16 +124,20 @@ func NewUpdateWebhookStatusBo(req *apiv1.UpdateWebhookStatusRequest) *UpdateWebh
 var _ hook.Config = (*WebhookItemBo)(nil)
 
 type WebhookItemBo struct {
-	UID       snowflake.ID      `json:"uid"`
-	App       vobj.WebhookApp   `json:"app"`
-	Name      string            `json:"name"`
-	URL       string            `json:"url"`
-	Method    vobj.HTTPMethod   `json:"method"`
-	Headers   map[string]string `json:"headers"`
-	Secret    string            `json:"secret"`
-	Status    vobj.GlobalStatus `json:"status"`
-	CreatedAt time.Time         `json:"-"`
-	UpdatedAt time.Time         `json:"-"`
+	UID         snowflake.ID      `json:"uid"`
+	App         vobj.WebhookApp   `json:"app"`
+	Name        string            `json:"name"`
+	URL         string            `json:"url"`
+	Method      vobj.HTTPMethod   `json:"method"`
+	Headers     map[string]string `json:"headers"`
+	Secret      string            `json:"secret"`
+	Status      vobj.GlobalStatus `json:"status"`
+	RetryPolicy *do.RetryPolicy   `json:"retry_policy,omitempty"`
+	RateLimit   *do.RateLimit     `json:"rate_limit,omitempty"`
+	SuccessRule *do.SuccessRule   `json:"success_rule,omitempty"`
+	Signature   *do.Signature     `json:"signature,omitempty"`
+	CreatedAt   time.Time         `json:"-"`
+	UpdatedAt   time.Time         `json:"-"`
 }
 
 // GetSecret implements hook.Config.
@@ -121,33 +150,64 @@ func (b *WebhookItemBo) GetURL() string {
 	return b.URL
 }
 
+// GetMethod 返回请求方法，未配置时为 POST
+func (b *WebhookItemBo) GetMethod() string {
+	if !b.Method.Exist() || b.Method.IsUnknown() {
+		return http.MethodPost
+	}
+	return b.Method.String()
+}
+
+// GetHeaders 返回自定义请求头
+func (b *WebhookItemBo) GetHeaders() map[string]string {
+	return b.Headers
+}
+
+// GetSuccessRule 返回成功判定规则
+func (b *WebhookItemBo) GetSuccessRule() *SuccessRuleBo {
+	return NewSuccessRuleBo(b.SuccessRule)
+}
+
+// GetSignature 返回请求签名配置，未开启时返回 nil
+func (b *WebhookItemBo) GetSignature() *SignatureBo {
+	return NewSignatureBo(b.Secret, b.Signature)
+}
+
 func NewWebhookItemBo(doWebhook *do.WebhookConfig) *WebhookItemBo {
 	return &WebhookItemBo{
-		UID:       doWebhook.UID,
-		App:       doWebhook.App,
-		Name:      doWebhook.Name,
-		URL:       doWebhook.URL,
-		Method:    doWebhook.Method,
-		Headers:   doWebhook.Headers.Map(),
-		Secret:    string(doWebhook.Secret),
-		Status:    doWebhook.Status,
-		CreatedAt: doWebhook.CreatedAt,
-		UpdatedAt: doWebhook.UpdatedAt,
+		UID:         doWebhook.UID,
+		App:         doWebhook.App,
+		Name:        doWebhook.Name,
+		URL:         doWebhook.URL,
+		Method:      doWebhook.Method,
+		Headers:     doWebhook.Headers.Map(),
+		Secret:      string(doWebhook.Secret),
+		Status:      doWebhook.Status,
+		RetryPolicy: doWebhook.RetryPolicy,
+		RateLimit:   doWebhook.RateLimit,
+		SuccessRule: doWebhook.SuccessRule,
+		Signature:   doWebhook.Signature,
+		CreatedAt:   doWebhook.CreatedAt,
+		UpdatedAt:   doWebhook.UpdatedAt,
 	}
 }
 
 func (b *WebhookItemBo) ToAPIV1WebhookItem() *apiv1.WebhookItem {
 	return &apiv1.WebhookItem{
-		Uid:       b.UID.Int64(),
-		App:       enum.WebhookAPP(b.App),
-		Name:      b.Name,
-		Url:       b.URL,
-		Method:    enum.HTTPMethod(b.Method),
-		Headers:   b.Headers,
-		Secret:    b.Secret,
-		Status:    enum.GlobalStatus(b.Status),
-		CreatedAt: b.CreatedAt.Format(time.DateTime),
-		UpdatedAt: b.UpdatedAt.Format(time.DateTime),
+		Uid:         b.UID.Int64(),
+		App:         enum.WebhookAPP(b.App),
+		Name:        b.Name,
+		Url:         b.URL,
+		Method:      enum.HTTPMethod(b.Method),
+		Headers:     b.Headers,
+		Secret:      b.Secret,
+		Status:      enum.GlobalStatus(b.Status),
+		CreatedAt:   b.CreatedAt.Format(time.DateTime),
+		UpdatedAt:   b.UpdatedAt.Format(time.DateTime),
+		RetryPolicy: ToConfigRetryPolicy(b.RetryPolicy),
+		RateLimit:   ToConfigRateLimit(b.RateLimit),
+		SuccessRule: ToConfigSuccessRule(b.SuccessRule),
+		Signature:   ToConfigSignature(b.Signature),
 	}
 }
 
@@ -276,8 +336,15 @@ func ToAPIV1SelectWebhookReply(params *SelectWebhookReplyParams) *apiv1.SelectWe
 }
 
 type SendWebhookBo struct {
-	UID  snowflake.ID `json:"uid"`
-	Data string       `json:"data"`
+	UID              snowflake.ID         `json:"uid"`
+	Data             string               `json:"data"`
+	SendAt           time.Time            `json:"-"`
+	IdempotencyKey   string               `json:"-"`
+	Priority         vobj.MessagePriority `json:"-"`
+	CallbackURL      string               `json:"-"`
+	TemplateUID      snowflake.ID         `json:"-"`
+	TemplateRevision int32                `json:"-"`
+	Tags             []string             `json:"-"`
 }
 
 // Message implements message.Message.
@@ -297,26 +364,46 @@ func (b *SendWebhookBo) ToMessageLog(webhookConfig *WebhookItemBo) (*do.MessageL
 	if err != nil {
 		return nil, err
 	}
-	return &do.MessageLog{
-		SendAt:  time.Now(),
-		Message: strutil.EncryptString(messageBytes),
-		Config:  strutil.EncryptString(webhookConfigBytes),
-		Type:    vobj.MessageTypeWebhook,
-		Status:  vobj.MessageStatusPending,
-	}, nil
+	sendAt := b.SendAt
+	if sendAt.IsZero() {
+		sendAt = time.Now()
+	}
+	messageLog := &do.MessageLog{
+		SendAt:           sendAt,
+		Message:          strutil.EncryptString(messageBytes),
+		Config:           strutil.EncryptString(webhookConfigBytes),
+		Type:             vobj.MessageTypeWebhook,
+		Status:           vobj.MessageStatusPending,
+		IdempotencyKey:   b.IdempotencyKey,
+		Priority:         b.Priority,
+		CallbackURL:      b.CallbackURL,
+		TemplateRevision: b.TemplateRevision,
+	}
+	return messageLog.WithSearchFields(nil, webhookConfig.UID, b.TemplateUID, "", b.Tags), nil
 }
 
 func NewSendWebhookBo(req *apiv1.SendWebhookRequest) *SendWebhookBo {
 	return &SendWebhookBo{
-		UID:  snowflake.ParseInt64(req.Uid),
-		Data: req.Data,
+		UID:            snowflake.ParseInt64(req.Uid),
+		Data:           req.Data,
+		SendAt:         NewSendAt(req.SendAtUnix, req.DelaySeconds),
+		IdempotencyKey: req.IdempotencyKey,
+		Priority:       vobj.MessagePriority(req.Priority).Normalize(),
+		CallbackURL:    req.CallbackUrl,
+		Tags:           req.Tags,
 	}
 }
 
 type SendWebhookWithTemplateBo struct {
-	UID         snowflake.ID
-	TemplateUID snowflake.ID
-	JSONData    []byte
+	UID              snowflake.ID
+	TemplateUID      snowflake.ID
+	TemplateRevision int32
+	JSONData         []byte
+	SendAt           time.Time
+	IdempotencyKey   string
+	Priority         vobj.MessagePriority
+	CallbackURL      string
+	Tags             []string
 }
 
 func NewSendWebhookWithTemplateBo(req *apiv1.SendWebhookWithTemplateRequest) (*SendWebhookWithTemplateBo, error) {
@@ -324,9 +411,15 @@ func NewSendWebhookWithTemplateBo(req *apiv1.SendWebhookWithTemplateRequest) (*S
 		return nil, merr.ErrorParams("invalid json data")
 	}
 	return &SendWebhookWithTemplateBo{
-		UID:         snowflake.ParseInt64(req.Uid),
-		TemplateUID: snowflake.ParseInt64(req.TemplateUID),
-		JSONData:    []byte(req.JsonData),
+		UID:              snowflake.ParseInt64(req.Uid),
+		TemplateUID:      snowflake.ParseInt64(req.TemplateUID),
+		TemplateRevision: req.TemplateRevision,
+		JSONData:         []byte(req.JsonData),
+		SendAt:           NewSendAt(req.SendAtUnix, req.DelaySeconds),
+		IdempotencyKey:   req.IdempotencyKey,
+		Priority:         vobj.MessagePriority(req.Priority).Normalize(),
+		CallbackURL:      req.CallbackUrl,
+		Tags:             req.Tags,
 	}, nil
 }
 
@@ -341,18 +434,24 @@ func (b *SendWebhookWithTemplateBo) ToSendWebhookBo(templateDo *TemplateItemBo)
 	if err != nil {
 		return nil, err
 	}
-	var jsonData map[string]any
-	if err := serialize.JSONUnmarshal(b.JSONData, &jsonData); err != nil {
-		return nil, merr.ErrorInternal("unmarshal json data failed").WithCause(err)
-	}
-
-	bodyData, err := strutil.ExecuteTextTemplate(string(webhookTemplateData), jsonData)
+	renderer, err := newTemplateRenderer(b.JSONData)
 	if err != nil {
-		return nil, merr.ErrorParams("execute text template failed").WithCause(err)
+		return nil, err
+	}
+	bodyData := renderer.renderWebhook(webhookTemplateData)
+	if err := renderer.err(); err != nil {
+		return nil, err
 	}
 
 	return &SendWebhookBo{
-		UID:  b.UID,
-		Data: bodyData,
+		UID:              b.UID,
+		Data:             bodyData,
+		SendAt:           b.SendAt,
+		IdempotencyKey:   b.IdempotencyKey,
+		Priority:         b.Priority,
+		CallbackURL:      b.CallbackURL,
+		TemplateUID:      templateDo.UID,
+		TemplateRevision: templateDo.Revision,
+		Tags:             b.Tags,
 	}, nil
 }
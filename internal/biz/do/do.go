@@ -18,9 +18,15 @@ func Models() []any {
 		&Namespace{},
 		&WebhookConfig{},
 		&EmailConfig{},
+		&SMSConfig{},
 		&Template{},
+		&TemplateRevision{},
 		&MessageLog{},
 		&MessageRetryLog{},
+		&MessageCallbackLog{},
+		&RateLimitBucket{},
+		&IdempotencyKey{},
+		&JobLease{},
 	}
 }
 
@@ -37,6 +43,10 @@ func (b *BaseModel) BeforeCreate(tx *gorm.DB) (err error) {
 	if b.Creator == 0 {
 		b.WithCreator(tx.Statement.Context)
 	}
+	// 已预先生成 UID 时保留，例如按 UID 时间分表的消息日志
+	if b.UID != 0 {
+		return
+	}
 
 	node, err := snowflake.NewNode(hello.NodeID())
 	if err != nil {
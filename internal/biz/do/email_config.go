@@ -15,6 +15,9 @@ type EmailConfig struct {
 	Username string                `gorm:"column:username;type:varchar(255);not null"`
 	Password strutil.EncryptString `gorm:"column:password;type:varchar(512);not null"`
 	Status   vobj.GlobalStatus     `gorm:"column:status;type:tinyint(2);not null;default:0"`
+
+	RetryPolicy *RetryPolicy `gorm:"column:retry_policy;type:json;serializer:json"`
+	RateLimit   *RateLimit   `gorm:"column:rate_limit;type:json;serializer:json"`
 }
 
 func (EmailConfig) TableName() string {
@@ -0,0 +1,25 @@
+package do
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+const (
+	TableNameIdempotencyKey = "idempotency_keys"
+)
+
+// IdempotencyKey 数据库模式下幂等键与消息日志的映射，命名空间和幂等键联合唯一
+type IdempotencyKey struct {
+	BaseModel
+
+	Namespace  string       `gorm:"column:namespace;type:varchar(100);not null;uniqueIndex:uk__idempotency_keys__namespace__key"`
+	Key        string       `gorm:"column:key;type:varchar(128);not null;uniqueIndex:uk__idempotency_keys__namespace__key"`
+	MessageUID snowflake.ID `gorm:"column:message_uid;type:bigint(20) unsigned;not null"`
+	ExpiredAt  time.Time    `gorm:"column:expired_at;type:datetime;not null"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return TableNameIdempotencyKey
+}
@@ -0,0 +1,22 @@
+package do
+
+import (
+	"time"
+)
+
+const (
+	TableNameJobLease = "job_leases"
+)
+
+// JobLease 集群中只允许一个节点执行的后台任务租约，保存在主库
+type JobLease struct {
+	ID         uint32    `gorm:"column:id;type:int unsigned;primaryKey;autoIncrement"`
+	Name       string    `gorm:"column:name;type:varchar(64);not null;uniqueIndex"`
+	Owner      string    `gorm:"column:owner;type:varchar(64);not null;default:''"`
+	LeaseUntil time.Time `gorm:"column:lease_until;type:datetime;not null"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;type:datetime;not null;"`
+}
+
+func (JobLease) TableName() string {
+	return TableNameJobLease
+}
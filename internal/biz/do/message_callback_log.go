@@ -0,0 +1,40 @@
+package do
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+)
+
+const (
+	TableNameMessageCallbackLog = "message_callback_logs"
+)
+
+// MessageCallbackLog 一次状态回调推送的记录
+type MessageCallbackLog struct {
+	NamespaceModel
+
+	MessageLogID snowflake.ID       `gorm:"column:message_log_id;type:bigint(20);not null;index"`
+	Status       vobj.MessageStatus `gorm:"column:status;type:tinyint(2);not null;default:0"`
+	URL          string             `gorm:"column:url;type:varchar(512);not null"`
+	Attempt      int32              `gorm:"column:attempt;type:int(11);not null;default:0"`
+	StatusCode   int32              `gorm:"column:status_code;type:int(11);not null;default:0"`
+	Success      bool               `gorm:"column:success;type:tinyint(1);not null;default:0"`
+	Error        string             `gorm:"column:error;type:text;not null"`
+	CallbackAt   time.Time          `gorm:"column:callback_at;type:datetime;not null"`
+	// NextAttemptAt 推送失败等待重试时的下一次推送时间，为空时不再重试，轮询协程抢占后设置为租约到期时间
+	NextAttemptAt *time.Time `gorm:"column:next_attempt_at;type:datetime;index"`
+	// Payload 等待重试时保存推送的事件内容，重试时原样推送
+	Payload string `gorm:"column:payload;type:mediumtext"`
+}
+
+func (MessageCallbackLog) TableName() string {
+	return TableNameMessageCallbackLog
+}
+
+// GenMessageCallbackLogFileName 文件存储模式下按周切分的回调日志名称
+func GenMessageCallbackLogFileName(namespace string, callbackAt time.Time) string {
+	return genWeeklyTableName(TableNameMessageCallbackLog, namespace, callbackAt)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/aide-family/magicbox/strutil"
+	"github.com/bwmarrin/snowflake"
 	"gorm.io/gorm"
 
 	"github.com/aide-family/rabbit/internal/biz/vobj"
@@ -17,22 +18,131 @@ const (
 type MessageLog struct {
 	NamespaceModel
 
-	SendAt     time.Time             `gorm:"column:send_at;type:datetime;not null"`
-	Message    strutil.EncryptString `gorm:"column:message;type:text;not null"`
-	Config     strutil.EncryptString `gorm:"column:config;type:text;not null"`
-	Type       vobj.MessageType      `gorm:"column:type;type:tinyint(2);not null;default:0"`
-	Status     vobj.MessageStatus    `gorm:"column:status;type:tinyint(2);not null;default:0"`
-	RetryTotal int32                 `gorm:"column:retry_total;type:int(11);not null;default:0"`
-	LastError  string                `gorm:"column:last_error;type:text;not null"`
+	SendAt         time.Time             `gorm:"column:send_at;type:datetime;not null"`
+	Message        strutil.EncryptString `gorm:"column:message;type:longtext;not null"`
+	Config         strutil.EncryptString `gorm:"column:config;type:text;not null"`
+	Type           vobj.MessageType      `gorm:"column:type;type:tinyint(2);not null;default:0"`
+	Status         vobj.MessageStatus    `gorm:"column:status;type:tinyint(2);not null;default:0"`
+	RetryTotal     int32                 `gorm:"column:retry_total;type:int(11);not null;default:0"`
+	LastError      string                `gorm:"column:last_error;type:text;not null"`
+	IdempotencyKey string                `gorm:"column:idempotency_key;type:varchar(128);not null;default:'';index"`
+	Priority       vobj.MessagePriority  `gorm:"column:priority;type:tinyint(2);not null;default:2"`
+	LeaseOwner     string                `gorm:"column:lease_owner;type:varchar(64);not null;default:''"`
+	LeaseUntil     *time.Time            `gorm:"column:lease_until;type:datetime"`
+	CallbackURL    string                `gorm:"column:callback_url;type:varchar(512);not null;default:''"`
+	// PrevStatus 最近一次状态变更前的状态，StatusChangedAt 最近一次状态变更的时间，用于跨节点推送状态变更
+	PrevStatus      vobj.MessageStatus `gorm:"column:prev_status;type:tinyint(2);not null;default:0"`
+	StatusChangedAt *time.Time         `gorm:"column:status_changed_at;type:datetime(3);index"`
+	// TemplateRevision 渲染时使用的模板版本号
+	TemplateRevision int32 `gorm:"column:template_revision;type:int(11);not null;default:0"`
+	// 以下为明文保存的检索字段，不包含消息内容和配置中的密钥
+	Recipients  string       `gorm:"column:recipients;type:varchar(1024);not null;default:''"`
+	ConfigUID   snowflake.ID `gorm:"column:config_uid;type:bigint(20) unsigned;not null;default:0;index"`
+	TemplateUID snowflake.ID `gorm:"column:template_uid;type:bigint(20) unsigned;not null;default:0;index"`
+	Subject     string       `gorm:"column:subject;type:varchar(255);not null;default:''"`
+	Tags        string       `gorm:"column:tags;type:varchar(512);not null;default:''"`
 }
 
 func (m *MessageLog) TableName() string {
 	return TableNameMessageLog
 }
 
+// WithSearchFields 设置检索字段，收件人和标签不区分大小写，超出列长度的部分被丢弃
+func (m *MessageLog) WithSearchFields(recipients []string, configUID, templateUID snowflake.ID, subject string, tags []string) *MessageLog {
+	m.Recipients = joinSearchValues(recipients, maxRecipientsLength)
+	m.ConfigUID = configUID
+	m.TemplateUID = templateUID
+	m.Subject = truncateRunes(strings.TrimSpace(subject), maxSubjectLength)
+	m.Tags = joinSearchValues(tags, maxTagsLength)
+	return m
+}
+
+// HasRecipient 判断收件人列表中是否包含指定收件人
+func (m *MessageLog) HasRecipient(recipient string) bool {
+	return strings.Contains(m.Recipients, SearchValue(recipient))
+}
+
+// HasTags 判断是否包含全部指定标签
+func (m *MessageLog) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		if !strings.Contains(m.Tags, SearchValue(tag)) {
+			return false
+		}
+	}
+	return true
+}
+
+// 检索字段的最大长度，与列长度一致
+const (
+	maxRecipientsLength = 1024
+	maxSubjectLength    = 255
+	maxTagsLength       = 512
+)
+
+// joinSearchValues 将多个检索值拼接为 ",a,b," 的形式，查询时使用 LIKE '%,a,%' 匹配单个完整的值
+func joinSearchValues(values []string, limit int) string {
+	var builder strings.Builder
+	for _, value := range values {
+		value = normalizeSearchValue(value)
+		if value == "" {
+			continue
+		}
+		if builder.Len() == 0 {
+			builder.WriteString(",")
+		}
+		if builder.Len()+len(value)+1 > limit {
+			break
+		}
+		builder.WriteString(value)
+		builder.WriteString(",")
+	}
+	if builder.Len() <= 1 {
+		return ""
+	}
+	return builder.String()
+}
+
+// SearchValue 返回单个检索值在拼接后字段中的形式
+func SearchValue(value string) string {
+	return "," + normalizeSearchValue(value) + ","
+}
+
+// SplitSearchValues 将拼接后的检索字段还原为多个值
+func SplitSearchValues(value string) []string {
+	return strutil.SplitSkipEmpty(value, ",")
+}
+
+func normalizeSearchValue(value string) string {
+	return strings.ToLower(strings.TrimSpace(strings.ReplaceAll(value, ",", "")))
+}
+
+func truncateRunes(value string, limit int) string {
+	if runes := []rune(value); len(runes) > limit {
+		return string(runes[:limit])
+	}
+	return value
+}
+
 func GenMessageLogTableName(namespace string, sendAt time.Time) string {
-	weekStart := getFirstMonday(sendAt)
-	return strings.Join([]string{TableNameMessageLog, namespace, weekStart.Format("20060102")}, "__")
+	return genWeeklyTableName(TableNameMessageLog, namespace, sendAt)
+}
+
+func genWeeklyTableName(tableName string, namespace string, date time.Time) string {
+	weekStart := getFirstMonday(date)
+	return strings.Join([]string{tableName, namespace, weekStart.Format("20060102")}, "__")
+}
+
+// ParseWeeklyTableName 解析按周切分的表名或文件名，返回命名空间和周一的日期
+func ParseWeeklyTableName(tableName, name string) (namespace string, weekStart time.Time, ok bool) {
+	parts := strings.Split(name, "__")
+	if len(parts) != 3 || parts[0] != tableName {
+		return "", time.Time{}, false
+	}
+	weekStart, err := time.ParseInLocation("20060102", parts[2], time.Local)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return parts[1], weekStart, true
 }
 
 func GenMessageLogTableNames(tx *gorm.DB, namespace string, startAt time.Time, endAt time.Time) []string {
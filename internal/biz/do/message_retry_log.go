@@ -6,6 +6,10 @@ import (
 	"github.com/bwmarrin/snowflake"
 )
 
+const (
+	TableNameMessageRetryLog = "message_retry_logs"
+)
+
 type MessageRetryLog struct {
 	NamespaceModel
 
@@ -15,5 +19,10 @@ type MessageRetryLog struct {
 }
 
 func (MessageRetryLog) TableName() string {
-	return "message_retry_logs"
+	return TableNameMessageRetryLog
+}
+
+// GenMessageRetryLogFileName 文件存储模式下按周切分的重试日志名称
+func GenMessageRetryLogFileName(namespace string, retryAt time.Time) string {
+	return genWeeklyTableName(TableNameMessageRetryLog, namespace, retryAt)
 }
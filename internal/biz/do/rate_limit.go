@@ -0,0 +1,27 @@
+package do
+
+import "time"
+
+const (
+	TableNameRateLimitBucket = "rate_limit_buckets"
+)
+
+// RateLimit 令牌桶限流配置，每个 Period 内最多发送 Limit 条，Burst 为桶容量
+type RateLimit struct {
+	Limit  int32         `json:"limit,omitempty"`
+	Period time.Duration `json:"period,omitempty"`
+	Burst  int32         `json:"burst,omitempty"`
+}
+
+// RateLimitBucket 数据库模式下集群共享的令牌桶状态
+type RateLimitBucket struct {
+	NamespaceModel
+
+	Key         string    `gorm:"column:key;type:varchar(128);not null;uniqueIndex"`
+	Tokens      float64   `gorm:"column:tokens;type:double;not null;default:0"`
+	RefreshedAt time.Time `gorm:"column:refreshed_at;type:datetime(3);not null"`
+}
+
+func (RateLimitBucket) TableName() string {
+	return TableNameRateLimitBucket
+}
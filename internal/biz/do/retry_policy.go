@@ -0,0 +1,12 @@
+package do
+
+import "time"
+
+// RetryPolicy 消息发送失败后的重试策略，nil 字段表示继承上一级（命名空间/全局）配置，零值也会覆盖上一级配置
+type RetryPolicy struct {
+	MaxAttempts *int32         `json:"max_attempts,omitempty"`
+	BaseDelay   *time.Duration `json:"base_delay,omitempty"`
+	MaxDelay    *time.Duration `json:"max_delay,omitempty"`
+	Multiplier  *float64       `json:"multiplier,omitempty"`
+	Jitter      *float64       `json:"jitter,omitempty"`
+}
@@ -0,0 +1,8 @@
+package do
+
+// Signature 通用 webhook 请求签名配置
+type Signature struct {
+	Enabled         bool   `json:"enabled"`
+	SignatureHeader string `json:"signature_header,omitempty"`
+	TimestampHeader string `json:"timestamp_header,omitempty"`
+}
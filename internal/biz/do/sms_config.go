@@ -0,0 +1,28 @@
+package do
+
+import (
+	"github.com/aide-family/magicbox/strutil"
+
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+)
+
+type SMSConfig struct {
+	NamespaceModel
+
+	Name            string                `gorm:"column:name;type:varchar(100);not null;uniqueIndex"`
+	Provider        vobj.SMSProvider      `gorm:"column:provider;type:tinyint(2);not null;default:0"`
+	AccessKeyID     string                `gorm:"column:access_key_id;type:varchar(255);not null;default:''"`
+	AccessKeySecret strutil.EncryptString `gorm:"column:access_key_secret;type:varchar(512);not null;default:''"`
+	SignName        string                `gorm:"column:sign_name;type:varchar(100);not null"`
+	Endpoint        string                `gorm:"column:endpoint;type:varchar(255);not null;default:''"`
+	Region          string                `gorm:"column:region;type:varchar(64);not null;default:''"`
+	AppID           string                `gorm:"column:app_id;type:varchar(100);not null;default:''"`
+	Status          vobj.GlobalStatus     `gorm:"column:status;type:tinyint(2);not null;default:0"`
+
+	RetryPolicy *RetryPolicy `gorm:"column:retry_policy;type:json;serializer:json"`
+	RateLimit   *RateLimit   `gorm:"column:rate_limit;type:json;serializer:json"`
+}
+
+func (SMSConfig) TableName() string {
+	return "sms_configs"
+}
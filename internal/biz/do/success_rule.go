@@ -0,0 +1,14 @@
+package do
+
+// SuccessRule 通用 webhook 响应的成功判定规则
+type SuccessRule struct {
+	StatusCodes []*StatusCodeRange `json:"status_codes,omitempty"`
+	JSONField   string             `json:"json_field,omitempty"`
+	JSONValues  []string           `json:"json_values,omitempty"`
+}
+
+// StatusCodeRange 状态码范围，包含 Min 和 Max
+type StatusCodeRange struct {
+	Min int32 `json:"min"`
+	Max int32 `json:"max"`
+}
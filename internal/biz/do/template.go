@@ -17,12 +17,30 @@ type Template struct {
 	App      vobj.TemplateApp  `gorm:"column:app;type:tinyint(2);not null;default:0"`
 	JSONData json.RawMessage   `gorm:"column:json_data;type:json;not null"`
 	Status   vobj.GlobalStatus `gorm:"column:status;type:tinyint(2);not null;default:0"`
+	// Revision 当前内容对应的版本号，为 0 表示尚未记录版本
+	Revision int32 `gorm:"column:revision;type:int(11);not null;default:0"`
+	// Variables 声明的输入变量，为空时不校验 jsonData
+	Variables []*TemplateVariable `gorm:"column:variables;type:json;serializer:json"`
 }
 
 func (Template) TableName() string {
 	return "templates"
 }
 
+// NewRevision 以当前内容生成指定版本号的版本记录
+func (t *Template) NewRevision(revision int32, note string) *TemplateRevision {
+	return &TemplateRevision{
+		NamespaceModel: NamespaceModel{Namespace: t.Namespace},
+		TemplateUID:    t.UID,
+		Revision:       revision,
+		Name:           t.Name,
+		App:            t.App,
+		JSONData:       t.JSONData,
+		Variables:      t.Variables,
+		Note:           note,
+	}
+}
+
 func (t *Template) BeforeCreate(tx *gorm.DB) (err error) {
 	if err = t.NamespaceModel.BeforeCreate(tx); err != nil {
 		return
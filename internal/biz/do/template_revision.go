@@ -0,0 +1,30 @@
+package do
+
+import (
+	"encoding/json"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+)
+
+const (
+	TableNameTemplateRevision = "template_revisions"
+)
+
+// TemplateRevision 模板每次变更后的不可变快照，作者为 BaseModel.Creator
+type TemplateRevision struct {
+	NamespaceModel
+
+	TemplateUID snowflake.ID        `gorm:"column:template_uid;type:bigint(20) unsigned;not null;uniqueIndex:uk_template_revision"`
+	Revision    int32               `gorm:"column:revision;type:int(11);not null;uniqueIndex:uk_template_revision"`
+	Name        string              `gorm:"column:name;type:varchar(100);not null"`
+	App         vobj.TemplateApp    `gorm:"column:app;type:tinyint(2);not null;default:0"`
+	JSONData    json.RawMessage     `gorm:"column:json_data;type:json;not null"`
+	Variables   []*TemplateVariable `gorm:"column:variables;type:json;serializer:json"`
+	Note        string              `gorm:"column:note;type:varchar(255);not null;default:''"`
+}
+
+func (TemplateRevision) TableName() string {
+	return TableNameTemplateRevision
+}
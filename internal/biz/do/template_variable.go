@@ -0,0 +1,12 @@
+package do
+
+import "encoding/json"
+
+// TemplateVariable 模板声明的输入变量，Name 以 . 分隔表示嵌套字段
+type TemplateVariable struct {
+	Name        string          `json:"name"`
+	Type        string          `json:"type,omitempty"`
+	Required    bool            `json:"required,omitempty"`
+	Default     json.RawMessage `json:"default,omitempty"`
+	Description string          `json:"description,omitempty"`
+}
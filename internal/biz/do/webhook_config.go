@@ -17,6 +17,11 @@ type WebhookConfig struct {
 	Headers *safety.Map[string, string] `gorm:"column:headers;type:json;"`
 	Secret  strutil.EncryptString       `gorm:"column:secret;type:varchar(512);not null"`
 	Status  vobj.GlobalStatus           `gorm:"column:status;type:tinyint(2);not null;default:0"`
+
+	RetryPolicy *RetryPolicy `gorm:"column:retry_policy;type:json;serializer:json"`
+	RateLimit   *RateLimit   `gorm:"column:rate_limit;type:json;serializer:json"`
+	SuccessRule *SuccessRule `gorm:"column:success_rule;type:json;serializer:json"`
+	Signature   *Signature   `gorm:"column:signature;type:json;serializer:json"`
 }
 
 func (WebhookConfig) TableName() string {
@@ -3,9 +3,12 @@ package biz
 import (
 	"context"
 
+	"github.com/bwmarrin/snowflake"
 	klog "github.com/go-kratos/kratos/v2/log"
 
 	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/internal/biz/repository"
 	"github.com/aide-family/rabbit/pkg/merr"
 )
 
@@ -14,10 +17,12 @@ func NewEmail(
 	templateBiz *Template,
 	messageLogBiz *MessageLog,
 	jobBiz *Job,
+	attachmentRepo repository.Attachment,
 	helper *klog.Helper,
 ) *Email {
 	return &Email{
 		emailConfigBiz: emailConfigBiz,
+		attachmentRepo: attachmentRepo,
 		messageLogBiz:  messageLogBiz,
 		jobBiz:         jobBiz,
 		templateBiz:    templateBiz,
@@ -30,43 +35,108 @@ type Email struct {
 	templateBiz    *Template
 	messageLogBiz  *MessageLog
 	jobBiz         *Job
+	attachmentRepo repository.Attachment
 	helper         *klog.Helper
 }
 
-func (e *Email) AppendEmailMessage(ctx context.Context, req *bo.SendEmailBo) error {
-	// 获取邮箱配置
-	emailConfig, err := e.emailConfigBiz.GetEmailConfig(ctx, req.UID)
+func (e *Email) AppendEmailMessage(ctx context.Context, req *bo.SendEmailBo) (snowflake.ID, error) {
+	messageLog, err := e.newMessageLog(ctx, req)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	messageLog, err := req.ToMessageLog(emailConfig)
+	uid, created, err := e.messageLogBiz.createMessageLog(ctx, messageLog)
 	if err != nil {
 		e.helper.Errorw("msg", "create message log failed", "error", err)
-		return merr.ErrorInternal("generate message log failed").WithCause(err)
+		return 0, merr.ErrorInternal("create message log failed").WithCause(err)
 	}
-	if err := e.messageLogBiz.createMessageLog(ctx, messageLog); err != nil {
-		e.helper.Errorw("msg", "create message log failed", "error", err)
-		return merr.ErrorInternal("create message log failed").WithCause(err)
+	// 幂等键重复，返回原消息，不再重复入队
+	if !created {
+		e.helper.Debugw("msg", "duplicate idempotency key, return origin message", "idempotencyKey", req.IdempotencyKey, "uid", uid)
+		return uid, nil
 	}
 
-	if err := e.jobBiz.AppendMessage(ctx, messageLog.UID); err != nil {
+	if err := e.jobBiz.ScheduleMessage(ctx, messageLog.UID, messageLog.SendAt, messageLog.Priority); err != nil {
 		e.helper.Errorw("msg", "append email message failed", "error", err, "uid", messageLog.UID)
-		return merr.ErrorInternal("append email message failed").WithCause(err)
+		return 0, merr.ErrorInternal("append email message failed").WithCause(err)
 	}
 
-	return nil
+	return uid, nil
+}
+
+func (e *Email) AppendEmailMessageWithTemplate(ctx context.Context, req *bo.SendEmailWithTemplateBo) (snowflake.ID, error) {
+	sendEmailBo, err := e.renderTemplate(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	return e.AppendEmailMessage(ctx, sendEmailBo)
 }
 
-func (e *Email) AppendEmailMessageWithTemplate(ctx context.Context, req *bo.SendEmailWithTemplateBo) error {
+// newMessageLog 获取邮箱配置并生成消息日志，不写入存储
+func (e *Email) newMessageLog(ctx context.Context, req *bo.SendEmailBo) (*do.MessageLog, error) {
+	if err := e.messageLogBiz.checkSendAt(req.SendAt); err != nil {
+		return nil, err
+	}
+	// 获取邮箱配置
+	emailConfig, err := e.emailConfigBiz.GetEmailConfig(ctx, req.UID)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.loadAttachments(ctx, req.Attachments); err != nil {
+		return nil, err
+	}
+	messageLog, err := req.ToMessageLog(emailConfig)
+	if err != nil {
+		e.helper.Errorw("msg", "create message log failed", "error", err)
+		return nil, merr.ErrorInternal("generate message log failed").WithCause(err)
+	}
+	return messageLog, nil
+}
+
+// renderTemplate 使用模板渲染邮件内容
+func (e *Email) renderTemplate(ctx context.Context, req *bo.SendEmailWithTemplateBo) (*bo.SendEmailBo, error) {
 	// 获取模板
-	templateBo, err := e.templateBiz.GetTemplate(ctx, req.TemplateUID)
+	templateBo, err := e.templateBiz.GetTemplateWithRevision(ctx, req.TemplateUID, req.TemplateRevision)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// 按模板声明的变量校验数据并补全默认值，校验失败时不生成消息日志
+	if req.JSONData, err = bo.ApplyTemplateVariables(templateBo.Variables, req.JSONData); err != nil {
+		return nil, err
 	}
 	sendEmailBo, err := req.ToSendEmailBo(templateBo)
 	if err != nil {
 		e.helper.Errorw("msg", "convert template to email template data failed", "error", err)
-		return merr.ErrorInternal("convert template to email template data failed")
+		return nil, merr.ErrorInternal("convert template to email template data failed")
 	}
-	return e.AppendEmailMessage(ctx, sendEmailBo)
+	return sendEmailBo, nil
+}
+
+// loadAttachments 校验附件大小并读取 blobRef 引用的文件，附件内容随消息日志保存，重试时发送相同内容
+func (e *Email) loadAttachments(ctx context.Context, attachments []*bo.EmailAttachmentBo) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+	maxSize, err := e.attachmentRepo.GetAttachmentMaxSize(ctx)
+	if err != nil {
+		e.helper.Errorw("msg", "get attachment max size failed", "error", err)
+		return merr.ErrorInternal("get attachment max size failed").WithCause(err)
+	}
+	var totalSize int64
+	for _, attachment := range attachments {
+		if err := attachment.Validate(); err != nil {
+			return err
+		}
+		if len(attachment.Content) == 0 {
+			content, err := e.attachmentRepo.GetAttachmentBlob(ctx, attachment.BlobRef, maxSize-totalSize)
+			if err != nil {
+				return err
+			}
+			attachment.Content = content
+		}
+		totalSize += int64(len(attachment.Content))
+		if totalSize > maxSize {
+			return merr.ErrorParams("total attachment size exceeds the limit of %d bytes", maxSize)
+		}
+	}
+	return nil
 }
@@ -2,11 +2,14 @@ package biz
 
 import (
 	"context"
+	"time"
 
 	"github.com/bwmarrin/snowflake"
 	klog "github.com/go-kratos/kratos/v2/log"
 
+	"github.com/aide-family/rabbit/internal/biz/bo"
 	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/biz/vobj"
 )
 
 func NewJob(
@@ -24,6 +27,15 @@ type Job struct {
 	messageRepo repository.Message
 }
 
-func (e *Job) AppendMessage(ctx context.Context, messageUID snowflake.ID) error {
-	return e.messageRepo.AppendMessage(ctx, messageUID)
+func (e *Job) AppendMessage(ctx context.Context, messageUID snowflake.ID, priority vobj.MessagePriority) error {
+	return e.messageRepo.AppendMessage(ctx, messageUID, priority)
+}
+
+func (e *Job) ScheduleMessage(ctx context.Context, messageUID snowflake.ID, sendAt time.Time, priority vobj.MessagePriority) error {
+	return e.messageRepo.ScheduleMessage(ctx, messageUID, sendAt, priority)
+}
+
+// ListClusterNodes 获取当前节点分发消息时各集群节点的健康状态
+func (e *Job) ListClusterNodes(ctx context.Context) *bo.ClusterNodesBo {
+	return e.messageRepo.ListClusterNodes(ctx)
 }
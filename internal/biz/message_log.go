@@ -2,6 +2,8 @@ package biz
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/bwmarrin/snowflake"
 	klog "github.com/go-kratos/kratos/v2/log"
@@ -10,16 +12,30 @@ import (
 	"github.com/aide-family/rabbit/internal/biz/do"
 	"github.com/aide-family/rabbit/internal/biz/repository"
 	"github.com/aide-family/rabbit/internal/biz/vobj"
+	"github.com/aide-family/rabbit/internal/conf"
 	"github.com/aide-family/rabbit/pkg/merr"
+	"github.com/aide-family/rabbit/pkg/middler"
 )
 
 func NewMessageLog(
+	bc *conf.Bootstrap,
 	messageLogRepo repository.MessageLog,
+	callbackRepo repository.MessageCallback,
+	watcherRepo repository.MessageLogWatcher,
+	retentionRepo repository.MessageRetention,
 	jobBiz *Job,
 	helper *klog.Helper,
 ) *MessageLog {
+	recoveryWindow := bc.GetJobCore().GetRecoveryWindow().AsDuration()
+	if recoveryWindow <= 0 {
+		recoveryWindow = 7 * 24 * time.Hour
+	}
 	return &MessageLog{
+		recoveryWindow: recoveryWindow,
 		messageLogRepo: messageLogRepo,
+		callbackRepo:   callbackRepo,
+		watcherRepo:    watcherRepo,
+		retentionRepo:  retentionRepo,
 		jobBiz:         jobBiz,
 		helper:         klog.NewHelper(klog.With(helper.Logger(), "biz", "messageLog")),
 	}
@@ -28,7 +44,11 @@ func NewMessageLog(
 type MessageLog struct {
 	helper         *klog.Helper
 	messageLogRepo repository.MessageLog
+	callbackRepo   repository.MessageCallback
+	watcherRepo    repository.MessageLogWatcher
+	retentionRepo  repository.MessageRetention
 	jobBiz         *Job
+	recoveryWindow time.Duration // 启动恢复和抢占消息时扫描的时间窗口
 }
 
 func (m *MessageLog) ListMessageLog(ctx context.Context, req *bo.ListMessageLogBo) (*bo.PageResponseBo[*bo.MessageLogItemBo], error) {
@@ -68,7 +88,10 @@ func (m *MessageLog) RetryMessage(ctx context.Context, uid snowflake.ID) error {
 	if messageLog.Status.IsSent() || messageLog.Status.IsSending() || messageLog.Status.IsCancelled() {
 		return nil
 	}
-	if err := m.jobBiz.AppendMessage(ctx, uid); err != nil {
+	if messageLog.Status.IsDeadLetter() {
+		return m.requeueDeadLetter(ctx, uid, messageLog.Priority)
+	}
+	if err := m.jobBiz.AppendMessage(ctx, uid, messageLog.Priority); err != nil {
 		m.helper.Errorw("msg", "append message failed", "error", err, "uid", uid)
 		return merr.ErrorInternal("append message failed")
 	}
@@ -96,10 +119,191 @@ func (m *MessageLog) CancelMessage(ctx context.Context, uid snowflake.ID) error
 		m.helper.Warnw("msg", "message status is not sending, message cancelled failed", "uid", uid)
 		return merr.ErrorNotFound("cancel message failed, the status of this message has changed.")
 	}
+	messageLog.Status = vobj.MessageStatusCancelled
+	m.callbackRepo.Notify(ctx, bo.NewMessageCallbackEventBo(middler.GetNamespace(ctx), bo.NewMessageLogItemBo(messageLog)))
+	return nil
+}
+
+func (m *MessageLog) ListDeadLetter(ctx context.Context, req *bo.ListMessageLogBo) (*bo.PageResponseBo[*bo.MessageLogItemBo], error) {
+	req.Status = vobj.MessageStatusDeadLetter
+	return m.ListMessageLog(ctx, req)
+}
+
+func (m *MessageLog) GetDeadLetter(ctx context.Context, uid snowflake.ID) (*bo.DeadLetterItemBo, error) {
+	messageLog, err := m.GetMessageLog(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if !messageLog.Status.IsDeadLetter() {
+		return nil, merr.ErrorNotFound("dead letter %d not found", uid.Int64())
+	}
+	retryLogs, err := m.messageLogRepo.ListMessageRetryLog(ctx, uid)
+	if err != nil {
+		m.helper.Errorw("msg", "list message retry log failed", "error", err, "uid", uid)
+		return nil, merr.ErrorInternal("list message retry log failed")
+	}
+	retryLogItems := make([]*bo.MessageRetryLogItemBo, 0, len(retryLogs))
+	for _, retryLog := range retryLogs {
+		retryLogItems = append(retryLogItems, bo.NewMessageRetryLogItemBo(retryLog))
+	}
+	return &bo.DeadLetterItemBo{MessageLogItemBo: messageLog, RetryLogs: retryLogItems}, nil
+}
+
+func (m *MessageLog) ListMessageCallbackLog(ctx context.Context, uid snowflake.ID) ([]*bo.MessageCallbackLogItemBo, error) {
+	if _, err := m.GetMessageLog(ctx, uid); err != nil {
+		return nil, err
+	}
+	callbackLogs, err := m.messageLogRepo.ListMessageCallbackLog(ctx, uid)
+	if err != nil {
+		m.helper.Errorw("msg", "list message callback log failed", "error", err, "uid", uid)
+		return nil, merr.ErrorInternal("list message callback log failed")
+	}
+	callbackLogItems := make([]*bo.MessageCallbackLogItemBo, 0, len(callbackLogs))
+	for _, callbackLog := range callbackLogs {
+		callbackLogItems = append(callbackLogItems, bo.NewMessageCallbackLogItemBo(callbackLog))
+	}
+	return callbackLogItems, nil
+}
+
+// PurgeMessageLogs 归档并删除当前命名空间已过期的按周消息日志，DryRun 时只预览
+func (m *MessageLog) PurgeMessageLogs(ctx context.Context, req *bo.PurgeMessageLogBo) (*bo.PurgeMessageLogResultBo, error) {
+	result, err := m.retentionRepo.PurgeMessageLogs(ctx, req)
+	if err != nil {
+		if merr.IsNotFound(err) {
+			return nil, err
+		}
+		m.helper.Errorw("msg", "purge message logs failed", "error", err, "dryRun", req.DryRun)
+		return nil, merr.ErrorInternal("purge message logs failed").WithCause(err)
+	}
+	return result, nil
+}
+
+// ExportMessageLogs 按过滤条件将消息日志逐条写入 w，RedactSecrets 时隐藏配置快照中的密钥
+func (m *MessageLog) ExportMessageLogs(ctx context.Context, req *bo.ExportMessageLogBo, w io.Writer) error {
+	exporter := bo.NewMessageLogExporter(req.Format, w)
+	err := m.messageLogRepo.ExportMessageLogs(ctx, req.ListMessageLogBo, func(messageLog *do.MessageLog) error {
+		item := bo.NewMessageLogItemBo(messageLog)
+		if req.RedactSecrets {
+			item.RedactConfig()
+		}
+		return exporter.Write(item)
+	})
+	if err == nil {
+		err = exporter.Flush()
+	}
+	if err != nil {
+		m.helper.Errorw("msg", "export message logs failed", "error", err, "format", req.Format)
+		return merr.ErrorInternal("export message logs failed").WithCause(err)
+	}
 	return nil
 }
 
-func (m *MessageLog) createMessageLog(ctx context.Context, messageLog *do.MessageLog) error {
-	// TODO 区分数据存储实现， 如果未启用数据库，则使用文件存储
-	return m.messageLogRepo.CreateMessageLog(ctx, messageLog)
+// WatchMessageLogs 订阅当前命名空间的消息状态变更，ctx 结束时取消订阅
+// 数据库模式下变更来自共享的消息日志表，包含集群中全部节点上的变更
+func (m *MessageLog) WatchMessageLogs(ctx context.Context, req *bo.WatchMessageLogBo) <-chan *bo.MessageLogEventBo {
+	return m.watcherRepo.Watch(ctx, req)
+}
+
+func (m *MessageLog) CountDeadLetter(ctx context.Context, startAt, endAt time.Time) (int64, error) {
+	req := &bo.ListMessageLogBo{
+		PageRequestBo: bo.NewPageRequestBo(1, 1),
+		StartAt:       startAt,
+		EndAt:         endAt,
+		Status:        vobj.MessageStatusDeadLetter,
+	}
+	pageResponseBo, err := m.messageLogRepo.ListMessageLog(ctx, req)
+	if err != nil {
+		m.helper.Errorw("msg", "count dead letter failed", "error", err)
+		return 0, merr.ErrorInternal("count dead letter failed")
+	}
+	return pageResponseBo.GetTotal(), nil
+}
+
+// RequeueDeadLetter 批量将死信重新入队，指定 UID 时只处理指定的死信，否则处理时间范围内的全部死信
+func (m *MessageLog) RequeueDeadLetter(ctx context.Context, req *bo.RequeueDeadLetterBo) (*bo.RequeueDeadLetterResultBo, error) {
+	uids := req.UIDs
+	if len(uids) == 0 {
+		deadLetterUIDs, err := m.listDeadLetterUIDs(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		uids = deadLetterUIDs
+	}
+	result := &bo.RequeueDeadLetterResultBo{FailedUIDs: make([]snowflake.ID, 0)}
+	for _, uid := range uids {
+		messageLog, err := m.messageLogRepo.GetMessageLog(ctx, uid)
+		if err != nil {
+			m.helper.Warnw("msg", "get dead letter failed", "error", err, "uid", uid)
+			result.FailedUIDs = append(result.FailedUIDs, uid)
+			continue
+		}
+		if err := m.requeueDeadLetter(ctx, uid, messageLog.Priority); err != nil {
+			m.helper.Warnw("msg", "requeue dead letter failed", "error", err, "uid", uid)
+			result.FailedUIDs = append(result.FailedUIDs, uid)
+			continue
+		}
+		result.Total++
+	}
+	return result, nil
+}
+
+// listDeadLetterUIDs 先收集全部死信的 UID，避免重新入队修改状态后分页错位
+func (m *MessageLog) listDeadLetterUIDs(ctx context.Context, req *bo.RequeueDeadLetterBo) ([]snowflake.ID, error) {
+	const pageSize = 200
+	uids := make([]snowflake.ID, 0)
+	for page := int32(1); ; page++ {
+		listReq := &bo.ListMessageLogBo{
+			PageRequestBo: bo.NewPageRequestBo(page, pageSize),
+			StartAt:       req.StartAt,
+			EndAt:         req.EndAt,
+			Status:        vobj.MessageStatusDeadLetter,
+			Type:          req.Type,
+		}
+		pageResponseBo, err := m.messageLogRepo.ListMessageLog(ctx, listReq)
+		if err != nil {
+			m.helper.Errorw("msg", "list dead letter failed", "error", err)
+			return nil, merr.ErrorInternal("list dead letter failed")
+		}
+		for _, item := range pageResponseBo.GetItems() {
+			uids = append(uids, item.UID)
+		}
+		if len(pageResponseBo.GetItems()) < pageSize || int64(len(uids)) >= pageResponseBo.GetTotal() {
+			return uids, nil
+		}
+	}
+}
+
+// requeueDeadLetter 清零重试次数后重新入队，重新获得完整的重试预算
+func (m *MessageLog) requeueDeadLetter(ctx context.Context, uid snowflake.ID, priority vobj.MessagePriority) error {
+	success, err := m.messageLogRepo.ResetMessageLogRetryIf(ctx, uid, vobj.MessageStatusDeadLetter, vobj.MessageStatusPending)
+	if err != nil {
+		m.helper.Errorw("msg", "reset dead letter failed", "error", err, "uid", uid)
+		return merr.ErrorInternal("requeue dead letter failed")
+	}
+	if !success {
+		return merr.ErrorNotFound("requeue dead letter failed, the status of this message has changed.")
+	}
+	if err := m.jobBiz.AppendMessage(ctx, uid, priority); err != nil {
+		m.helper.Errorw("msg", "append message failed", "error", err, "uid", uid)
+		return merr.ErrorInternal("append message failed")
+	}
+	return nil
+}
+
+// checkSendAt 启动恢复和抢占只扫描恢复窗口内创建的消息，发送时间超过窗口的消息在到期时可能不会被发送
+func (m *MessageLog) checkSendAt(sendAt time.Time) error {
+	if sendAt.After(time.Now().Add(m.recoveryWindow)) {
+		return merr.ErrorParams("sendAt must be within %s from now", m.recoveryWindow)
+	}
+	return nil
+}
+
+// createMessageLog 创建消息日志，幂等键重复时返回原消息 UID 且 created 为 false
+func (m *MessageLog) createMessageLog(ctx context.Context, messageLog *do.MessageLog) (snowflake.ID, bool, error) {
+	return m.messageLogRepo.CreateMessageLogIdempotent(ctx, messageLog)
+}
+
+// createMessageLogs 在同一事务中批量创建消息日志，返回幂等键重复的消息下标到原消息 UID 的映射
+func (m *MessageLog) createMessageLogs(ctx context.Context, messageLogs []*do.MessageLog) (map[int]snowflake.ID, error) {
+	return m.messageLogRepo.CreateMessageLogsIdempotent(ctx, messageLogs)
 }
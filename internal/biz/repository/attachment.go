@@ -0,0 +1,10 @@
+package repository
+
+import "context"
+
+type Attachment interface {
+	// GetAttachmentBlob 读取当前命名空间附件存储中的文件，超过 maxSize 时返回参数错误
+	GetAttachmentBlob(ctx context.Context, blobRef string, maxSize int64) ([]byte, error)
+	// GetAttachmentMaxSize 获取当前命名空间单封邮件附件总大小上限
+	GetAttachmentMaxSize(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type JobLease interface {
+	// TryAcquire 抢占或续约后台任务租约，租约有效期内只有持有者能续约，文件模式下各节点数据独立，总是成功
+	TryAcquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+}
@@ -2,13 +2,22 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/bwmarrin/snowflake"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/vobj"
 )
 
 type Message interface {
-	AppendMessage(ctx context.Context, messageUID snowflake.ID) error
+	// AppendMessage 将消息放入优先级对应的分发队列
+	AppendMessage(ctx context.Context, messageUID snowflake.ID, priority vobj.MessagePriority) error
+	// ScheduleMessage 在 sendAt 到达后将消息放入队列，sendAt 已过期时立即放入
+	ScheduleMessage(ctx context.Context, messageUID snowflake.ID, sendAt time.Time, priority vobj.MessagePriority) error
 	SendMessage(ctx context.Context, messageUID snowflake.ID) error
+	// ListClusterNodes 获取分发消息时各集群节点的健康状态
+	ListClusterNodes(ctx context.Context) *bo.ClusterNodesBo
 	Stop(ctx context.Context) error
 	Start(ctx context.Context) error
 }
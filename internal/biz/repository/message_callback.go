@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+)
+
+type MessageCallback interface {
+	// Notify 将消息状态事件推送到回调地址，推送在后台进行，失败时按回调重试策略重试
+	Notify(ctx context.Context, event *bo.MessageCallbackEventBo)
+}
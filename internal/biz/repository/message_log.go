@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/bwmarrin/snowflake"
 
@@ -12,10 +13,48 @@ import (
 
 type MessageLog interface {
 	CreateMessageLog(ctx context.Context, messageLog *do.MessageLog) error
+	// CreateMessageLogIdempotent 按消息日志的幂等键创建消息日志，有效期内幂等键已存在时不创建，返回原消息 UID 且 created 为 false
+	CreateMessageLogIdempotent(ctx context.Context, messageLog *do.MessageLog) (uid snowflake.ID, created bool, err error)
+	// CreateMessageLogsIdempotent 在同一事务中批量创建消息日志，返回幂等键已存在的消息下标到原消息 UID 的映射
+	CreateMessageLogsIdempotent(ctx context.Context, messageLogs []*do.MessageLog) (duplicates map[int]snowflake.ID, err error)
 	ListMessageLog(ctx context.Context, req *bo.ListMessageLogBo) (*bo.PageResponseBo[*do.MessageLog], error)
+	// ExportMessageLogs 按列表的过滤条件逐条读取消息日志，按周升序分批读取，不在内存中缓存全部结果
+	ExportMessageLogs(ctx context.Context, req *bo.ListMessageLogBo, write func(*do.MessageLog) error) error
 	GetMessageLog(ctx context.Context, uid snowflake.ID) (*do.MessageLog, error)
 	// GetMessageLogWithLock 使用 SELECT FOR UPDATE 获取消息日志并加锁，用于分布式锁场景
 	GetMessageLogWithLock(ctx context.Context, uid snowflake.ID) (*do.MessageLog, error)
 	// UpdateMessageLogStatusIf 条件更新消息状态，只有当前状态匹配时才更新，用于实现 CAS 操作
 	UpdateMessageLogStatusIf(ctx context.Context, uid snowflake.ID, oldStatus, newStatus vobj.MessageStatus) (bool, error)
+	// UpdateMessageLogRetryIf 条件更新消息状态，同时累加重试次数并记录最后一次错误，用于发送失败后的 CAS 操作
+	UpdateMessageLogRetryIf(ctx context.Context, uid snowflake.ID, oldStatus, newStatus vobj.MessageStatus, lastError string) (bool, error)
+	// UpdateMessageLogSentIf 条件更新消息状态为已发送并记录部分收件人发送失败的错误，不累加重试次数
+	UpdateMessageLogSentIf(ctx context.Context, uid snowflake.ID, oldStatus vobj.MessageStatus, lastError string) (bool, error)
+	// ResetMessageLogRetryIf 条件更新消息状态并清零重试次数，用于死信重新入队
+	ResetMessageLogRetryIf(ctx context.Context, uid snowflake.ID, oldStatus, newStatus vobj.MessageStatus) (bool, error)
+	// ListUnfinishedMessageLog 查询 startAt 之后创建的待发送、发送中和等待重试的消息，用于启动时恢复
+	ListUnfinishedMessageLog(ctx context.Context, startAt time.Time) ([]*do.MessageLog, error)
+	// ClaimMessageLogs 抢占 startAt 之后创建、已到期且没有有效租约的待发送和等待重试的消息，以及租约已过期的发送中消息，抢占的消息租约持续到 leaseUntil
+	ClaimMessageLogs(ctx context.Context, startAt time.Time, owner string, leaseUntil time.Time, limit int) ([]*do.MessageLog, error)
+	// UpdateMessageLogLease 设置消息的租约到期时间，到期前不会被抢占，用于延迟发送、重试退避和发送中消息的超时接管
+	UpdateMessageLogLease(ctx context.Context, uid snowflake.ID, leaseUntil time.Time) error
+	// ListStatusChangedMessageLog 查询 startAt 之后创建、最近一次状态变更不早于 changedAfter 的消息，按状态变更时间升序，用于跨节点推送状态变更
+	ListStatusChangedMessageLog(ctx context.Context, startAt, changedAfter time.Time, limit int) ([]*do.MessageLog, error)
+	// CreateMessageRetryLog 记录一次发送失败的重试日志
+	CreateMessageRetryLog(ctx context.Context, retryLog *do.MessageRetryLog) error
+	// ListMessageRetryLog 查询消息的全部重试日志，按重试时间升序
+	ListMessageRetryLog(ctx context.Context, messageLogUID snowflake.ID) ([]*do.MessageRetryLog, error)
+	// CreateMessageCallbackLog 记录一次状态回调推送
+	CreateMessageCallbackLog(ctx context.Context, callbackLog *do.MessageCallbackLog) error
+	// ListMessageCallbackLog 查询消息的全部状态回调推送记录，按推送时间升序
+	ListMessageCallbackLog(ctx context.Context, messageLogUID snowflake.ID) ([]*do.MessageCallbackLog, error)
+	// ListPendingMessageCallbackLog 查询当前命名空间下一次推送时间不晚于 before 的回调日志，按下一次推送时间升序
+	ListPendingMessageCallbackLog(ctx context.Context, before time.Time, limit int) ([]*do.MessageCallbackLog, error)
+	// UpdateMessageCallbackLogNextAttemptIf 条件更新回调日志的下一次推送时间，只有当前值等于 oldNextAttemptAt 时才更新，用于抢占和完成重试
+	UpdateMessageCallbackLogNextAttemptIf(ctx context.Context, uid snowflake.ID, oldNextAttemptAt time.Time, newNextAttemptAt *time.Time) (bool, error)
+	// ListMessageLogWeeks 列出当前命名空间按周切分的消息日志，按周升序
+	ListMessageLogWeeks(ctx context.Context) ([]*bo.MessageLogWeekBo, error)
+	// ExportMessageLogWeek 逐条读取一周的日志，source 为记录所属的表名或文件名，用于归档
+	ExportMessageLogWeek(ctx context.Context, week *bo.MessageLogWeekBo, write func(source string, record any) error) error
+	// PurgeMessageLogWeek 删除一周的日志，数据库模式下删除消息日志表和同一周的重试、回调日志，文件模式下删除日志文件
+	PurgeMessageLogWeek(ctx context.Context, week *bo.MessageLogWeekBo) error
 }
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+)
+
+// MessageLogWatcher 消息状态变更订阅，分发当前节点的订阅者
+// 数据库模式下事件由轮询共享的消息日志表产生，包含全部节点上的变更；文件模式下事件在状态更新时产生
+type MessageLogWatcher interface {
+	// Publish 广播一次状态变更，不会阻塞调用方
+	Publish(event *bo.MessageLogEventBo)
+	// Watch 订阅满足条件的状态变更，ctx 结束、订阅者消费过慢或服务关闭时关闭通道
+	Watch(ctx context.Context, filter *bo.WatchMessageLogBo) <-chan *bo.MessageLogEventBo
+	// HasWatcher 是否存在订阅者，没有订阅者时可以跳过事件的构造
+	HasWatcher() bool
+	// Namespaces 存在订阅者的命名空间
+	Namespaces() []string
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+)
+
+type MessageRetention interface {
+	// PurgeMessageLogs 按保留策略归档并删除当前命名空间已过期的按周消息日志，DryRun 时只列出将被清理的数据
+	PurgeMessageLogs(ctx context.Context, req *bo.PurgeMessageLogBo) (*bo.PurgeMessageLogResultBo, error)
+}
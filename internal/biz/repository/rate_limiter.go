@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+)
+
+type RateLimiter interface {
+	// Reserve 从令牌桶中预留一个令牌，返回预留的令牌可用前需要等待的时间
+	// 等待中的预留超过桶容量时不预留，reserved 为 false，返回可以再次预留前需要等待的时间
+	Reserve(ctx context.Context, rateLimit *bo.RateLimitBo) (wait time.Duration, reserved bool, err error)
+}
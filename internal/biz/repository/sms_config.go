@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+)
+
+type SMSConfig interface {
+	CreateSMSConfig(ctx context.Context, req *do.SMSConfig) error
+	UpdateSMSConfig(ctx context.Context, req *do.SMSConfig) error
+	UpdateSMSConfigStatus(ctx context.Context, uid snowflake.ID, status vobj.GlobalStatus) error
+	DeleteSMSConfig(ctx context.Context, uid snowflake.ID) error
+	GetSMSConfig(ctx context.Context, uid snowflake.ID) (*do.SMSConfig, error)
+	GetSMSConfigByName(ctx context.Context, name string) (*do.SMSConfig, error)
+	ListSMSConfig(ctx context.Context, req *bo.ListSMSConfigBo) (*bo.PageResponseBo[*do.SMSConfig], error)
+	SelectSMSConfig(ctx context.Context, req *bo.SelectSMSConfigBo) (*bo.SelectSMSConfigResult, error)
+}
@@ -11,12 +11,16 @@ import (
 )
 
 type Template interface {
-	CreateTemplate(ctx context.Context, req *do.Template) error
-	UpdateTemplate(ctx context.Context, req *do.Template) error
+	// CreateTemplate 创建模板并记录第 1 个版本
+	CreateTemplate(ctx context.Context, req *do.Template, note string) error
+	// UpdateTemplate 更新模板并记录新版本，新版本号写回 req.Revision
+	UpdateTemplate(ctx context.Context, req *do.Template, note string) error
 	UpdateTemplateStatus(ctx context.Context, uid snowflake.ID, status vobj.GlobalStatus) error
 	DeleteTemplate(ctx context.Context, uid snowflake.ID) error
 	GetTemplate(ctx context.Context, uid snowflake.ID) (*do.Template, error)
 	GetTemplateByName(ctx context.Context, name string) (*do.Template, error)
 	ListTemplate(ctx context.Context, req *bo.ListTemplateBo) (*bo.PageResponseBo[*do.Template], error)
 	SelectTemplate(ctx context.Context, req *bo.SelectTemplateBo) (*bo.SelectTemplateResult, error)
+	GetTemplateRevision(ctx context.Context, templateUID snowflake.ID, revision int32) (*do.TemplateRevision, error)
+	ListTemplateRevision(ctx context.Context, req *bo.ListTemplateRevisionBo) (*bo.PageResponseBo[*do.TemplateRevision], error)
 }
@@ -0,0 +1,120 @@
+package biz
+
+import (
+	"context"
+
+	klog "github.com/go-kratos/kratos/v2/log"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/pkg/merr"
+)
+
+func NewSender(
+	emailBiz *Email,
+	webhookBiz *Webhook,
+	messageLogBiz *MessageLog,
+	jobBiz *Job,
+	helper *klog.Helper,
+) *Sender {
+	return &Sender{
+		emailBiz:      emailBiz,
+		webhookBiz:    webhookBiz,
+		messageLogBiz: messageLogBiz,
+		jobBiz:        jobBiz,
+		helper:        klog.NewHelper(klog.With(helper.Logger(), "biz", "sender")),
+	}
+}
+
+type Sender struct {
+	emailBiz      *Email
+	webhookBiz    *Webhook
+	messageLogBiz *MessageLog
+	jobBiz        *Job
+	helper        *klog.Helper
+}
+
+// SendBatch 为每个目标生成一条消息日志，校验通过的目标在同一事务中写入后入队
+// 单个目标校验失败只记录在该目标的结果中，不影响其他目标
+func (s *Sender) SendBatch(ctx context.Context, req *bo.SendBatchBo) ([]*bo.SendBatchResultBo, error) {
+	results := make([]*bo.SendBatchResultBo, 0, len(req.Targets))
+	messageLogs := make([]*do.MessageLog, 0, len(req.Targets))
+	messageLogResults := make([]*bo.SendBatchResultBo, 0, len(req.Targets))
+	for index, target := range req.Targets {
+		result := &bo.SendBatchResultBo{Index: index, Type: target.Type, UID: target.UID}
+		results = append(results, result)
+		messageLog, err := s.newMessageLog(ctx, req, index)
+		if err != nil {
+			result.Error = err
+			continue
+		}
+		messageLogs = append(messageLogs, messageLog)
+		messageLogResults = append(messageLogResults, result)
+	}
+	if len(messageLogs) == 0 {
+		return results, nil
+	}
+
+	duplicates, err := s.messageLogBiz.createMessageLogs(ctx, messageLogs)
+	if err != nil {
+		s.helper.Errorw("msg", "create message logs failed", "error", err)
+		return nil, merr.ErrorInternal("create message logs failed").WithCause(err)
+	}
+	for index, messageLog := range messageLogs {
+		result := messageLogResults[index]
+		// 幂等键重复，返回原消息，不再重复入队
+		if originUID, ok := duplicates[index]; ok {
+			result.MessageUID = originUID
+			continue
+		}
+		result.MessageUID = messageLog.UID
+		if err := s.jobBiz.ScheduleMessage(ctx, messageLog.UID, messageLog.SendAt, messageLog.Priority); err != nil {
+			s.helper.Errorw("msg", "append batch message failed", "error", err, "uid", messageLog.UID)
+			result.Error = merr.ErrorInternal("append message failed").WithCause(err)
+		}
+	}
+	return results, nil
+}
+
+// newMessageLog 按目标类型和是否使用模板生成消息日志
+func (s *Sender) newMessageLog(ctx context.Context, req *bo.SendBatchBo, index int) (*do.MessageLog, error) {
+	target := req.Targets[index]
+	switch {
+	case target.Type.IsEmail():
+		sendEmailBo, err := s.newSendEmailBo(ctx, req, index)
+		if err != nil {
+			return nil, err
+		}
+		return s.emailBiz.newMessageLog(ctx, sendEmailBo)
+	case target.Type.IsWebhook():
+		sendWebhookBo, err := s.newSendWebhookBo(ctx, req, index)
+		if err != nil {
+			return nil, err
+		}
+		return s.webhookBiz.newMessageLog(ctx, sendWebhookBo)
+	default:
+		return nil, merr.ErrorParams("unsupported target type %s", target.Type)
+	}
+}
+
+func (s *Sender) newSendEmailBo(ctx context.Context, req *bo.SendBatchBo, index int) (*bo.SendEmailBo, error) {
+	if req.Targets[index].TemplateUID == 0 {
+		return req.ToSendEmailBo(index)
+	}
+	sendEmailWithTemplateBo, err := req.ToSendEmailWithTemplateBo(index)
+	if err != nil {
+		return nil, err
+	}
+	return s.emailBiz.renderTemplate(ctx, sendEmailWithTemplateBo)
+}
+
+func (s *Sender) newSendWebhookBo(ctx context.Context, req *bo.SendBatchBo, index int) (*bo.SendWebhookBo, error) {
+	if req.Targets[index].TemplateUID == 0 {
+		return req.ToSendWebhookBo(index)
+	}
+	sendWebhookWithTemplateBo, err := req.ToSendWebhookWithTemplateBo(index)
+	if err != nil {
+		return nil, err
+	}
+	return s.webhookBiz.renderTemplate(ctx, sendWebhookWithTemplateBo)
+}
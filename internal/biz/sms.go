@@ -0,0 +1,105 @@
+package biz
+
+import (
+	"context"
+
+	"github.com/bwmarrin/snowflake"
+	klog "github.com/go-kratos/kratos/v2/log"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/pkg/merr"
+)
+
+func NewSMS(
+	smsConfigBiz *SMSConfig,
+	templateBiz *Template,
+	messageLogBiz *MessageLog,
+	jobBiz *Job,
+	helper *klog.Helper,
+) *SMS {
+	return &SMS{
+		smsConfigBiz:  smsConfigBiz,
+		messageLogBiz: messageLogBiz,
+		jobBiz:        jobBiz,
+		templateBiz:   templateBiz,
+		helper:        klog.NewHelper(klog.With(helper.Logger(), "biz", "sms")),
+	}
+}
+
+type SMS struct {
+	smsConfigBiz  *SMSConfig
+	templateBiz   *Template
+	messageLogBiz *MessageLog
+	jobBiz        *Job
+	helper        *klog.Helper
+}
+
+func (s *SMS) AppendSMSMessage(ctx context.Context, req *bo.SendSMSBo) (snowflake.ID, error) {
+	messageLog, err := s.newMessageLog(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	uid, created, err := s.messageLogBiz.createMessageLog(ctx, messageLog)
+	if err != nil {
+		s.helper.Errorw("msg", "create message log failed", "error", err)
+		return 0, merr.ErrorInternal("create message log failed").WithCause(err)
+	}
+	// 幂等键重复，返回原消息，不再重复入队
+	if !created {
+		s.helper.Debugw("msg", "duplicate idempotency key, return origin message", "idempotencyKey", req.IdempotencyKey, "uid", uid)
+		return uid, nil
+	}
+
+	if err := s.jobBiz.ScheduleMessage(ctx, messageLog.UID, messageLog.SendAt, messageLog.Priority); err != nil {
+		s.helper.Errorw("msg", "append sms message failed", "error", err, "uid", messageLog.UID)
+		return 0, merr.ErrorInternal("append sms message failed").WithCause(err)
+	}
+
+	return uid, nil
+}
+
+func (s *SMS) AppendSMSMessageWithTemplate(ctx context.Context, req *bo.SendSMSWithTemplateBo) (snowflake.ID, error) {
+	sendSMSBo, err := s.renderTemplate(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	return s.AppendSMSMessage(ctx, sendSMSBo)
+}
+
+// newMessageLog 获取短信配置并生成消息日志，不写入存储
+func (s *SMS) newMessageLog(ctx context.Context, req *bo.SendSMSBo) (*do.MessageLog, error) {
+	if err := s.messageLogBiz.checkSendAt(req.SendAt); err != nil {
+		return nil, err
+	}
+	// 获取短信配置
+	smsConfig, err := s.smsConfigBiz.GetSMSConfig(ctx, req.UID)
+	if err != nil {
+		return nil, err
+	}
+	messageLog, err := req.ToMessageLog(smsConfig)
+	if err != nil {
+		s.helper.Errorw("msg", "create message log failed", "error", err)
+		return nil, merr.ErrorInternal("generate message log failed").WithCause(err)
+	}
+	return messageLog, nil
+}
+
+// renderTemplate 使用模板渲染短信内容
+func (s *SMS) renderTemplate(ctx context.Context, req *bo.SendSMSWithTemplateBo) (*bo.SendSMSBo, error) {
+	// 获取模板
+	templateBo, err := s.templateBiz.GetTemplateWithRevision(ctx, req.TemplateUID, req.TemplateRevision)
+	if err != nil {
+		return nil, err
+	}
+	// 按模板声明的变量校验数据并补全默认值，校验失败时不生成消息日志
+	if req.JSONData, err = bo.ApplyTemplateVariables(templateBo.Variables, req.JSONData); err != nil {
+		return nil, err
+	}
+	sendSMSBo, err := req.ToSendSMSBo(templateBo)
+	if err != nil {
+		s.helper.Errorw("msg", "convert template to sms template data failed", "error", err)
+		return nil, err
+	}
+	return sendSMSBo, nil
+}
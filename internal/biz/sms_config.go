@@ -0,0 +1,116 @@
+package biz
+
+import (
+	"context"
+
+	"github.com/bwmarrin/snowflake"
+	klog "github.com/go-kratos/kratos/v2/log"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/pkg/merr"
+)
+
+func NewSMSConfig(
+	smsConfigRepo repository.SMSConfig,
+	helper *klog.Helper,
+) *SMSConfig {
+	return &SMSConfig{
+		smsConfigRepo: smsConfigRepo,
+		helper:        klog.NewHelper(klog.With(helper.Logger(), "biz", "sms_config")),
+	}
+}
+
+type SMSConfig struct {
+	helper        *klog.Helper
+	smsConfigRepo repository.SMSConfig
+}
+
+func (c *SMSConfig) CreateSMSConfig(ctx context.Context, req *bo.CreateSMSConfigBo) error {
+	doSMSConfig := req.ToDoSMSConfig()
+	if _, err := c.smsConfigRepo.GetSMSConfigByName(ctx, doSMSConfig.Name); err == nil {
+		return merr.ErrorParams("sms config %s already exists", doSMSConfig.Name)
+	} else if !merr.IsNotFound(err) {
+		c.helper.Errorw("msg", "check sms config exists failed", "error", err, "name", doSMSConfig.Name)
+		return merr.ErrorInternal("create sms config %s failed", doSMSConfig.Name).WithCause(err)
+	}
+	if err := c.smsConfigRepo.CreateSMSConfig(ctx, doSMSConfig); err != nil {
+		c.helper.Errorw("msg", "create sms config failed", "error", err, "name", doSMSConfig.Name)
+		return merr.ErrorInternal("create sms config %s failed", doSMSConfig.Name).WithCause(err)
+	}
+	return nil
+}
+
+func (c *SMSConfig) UpdateSMSConfig(ctx context.Context, req *bo.UpdateSMSConfigBo) error {
+	doSMSConfig := req.ToDoSMSConfig()
+	existSMSConfig, err := c.smsConfigRepo.GetSMSConfigByName(ctx, doSMSConfig.Name)
+	if err != nil && !merr.IsNotFound(err) {
+		c.helper.Errorw("msg", "check sms config exists failed", "error", err, "name", doSMSConfig.Name)
+		return merr.ErrorInternal("update sms config %s failed", doSMSConfig.Name).WithCause(err)
+	} else if existSMSConfig != nil && existSMSConfig.UID != doSMSConfig.UID {
+		return merr.ErrorParams("sms config %s already exists", doSMSConfig.Name)
+	}
+	if err := c.smsConfigRepo.UpdateSMSConfig(ctx, doSMSConfig); err != nil {
+		c.helper.Errorw("msg", "update sms config failed", "error", err, "name", doSMSConfig.Name)
+		return merr.ErrorInternal("update sms config %s failed", doSMSConfig.Name).WithCause(err)
+	}
+	return nil
+}
+
+func (c *SMSConfig) UpdateSMSConfigStatus(ctx context.Context, req *bo.UpdateSMSConfigStatusBo) error {
+	if err := c.smsConfigRepo.UpdateSMSConfigStatus(ctx, req.UID, req.Status); err != nil {
+		c.helper.Errorw("msg", "update sms config status failed", "error", err, "uid", req.UID)
+		return merr.ErrorInternal("update sms config status %s failed", req.UID).WithCause(err)
+	}
+	return nil
+}
+
+func (c *SMSConfig) DeleteSMSConfig(ctx context.Context, uid snowflake.ID) error {
+	if err := c.smsConfigRepo.DeleteSMSConfig(ctx, uid); err != nil {
+		c.helper.Errorw("msg", "delete sms config failed", "error", err, "uid", uid)
+		return merr.ErrorInternal("delete sms config %s failed", uid).WithCause(err)
+	}
+	return nil
+}
+
+func (c *SMSConfig) GetSMSConfig(ctx context.Context, uid snowflake.ID) (*bo.SMSConfigItemBo, error) {
+	doSMSConfig, err := c.smsConfigRepo.GetSMSConfig(ctx, uid)
+	if err != nil {
+		if merr.IsNotFound(err) {
+			return nil, err
+		}
+		c.helper.Errorw("msg", "get sms config failed", "error", err, "uid", uid)
+		return nil, merr.ErrorInternal("get sms config %s failed", uid).WithCause(err)
+	}
+	return bo.NewSMSConfigItemBo(doSMSConfig), nil
+}
+
+func (c *SMSConfig) ListSMSConfig(ctx context.Context, req *bo.ListSMSConfigBo) (*bo.PageResponseBo[*bo.SMSConfigItemBo], error) {
+	pageResponseBo, err := c.smsConfigRepo.ListSMSConfig(ctx, req)
+	if err != nil {
+		c.helper.Errorw("msg", "list sms config failed", "error", err, "req", req)
+		return nil, merr.ErrorInternal("list sms config failed").WithCause(err)
+	}
+	items := make([]*bo.SMSConfigItemBo, 0, len(pageResponseBo.GetItems()))
+	for _, item := range pageResponseBo.GetItems() {
+		items = append(items, bo.NewSMSConfigItemBo(item))
+	}
+	return bo.NewPageResponseBo(pageResponseBo.PageRequestBo, items), nil
+}
+
+func (c *SMSConfig) SelectSMSConfig(ctx context.Context, req *bo.SelectSMSConfigBo) (*bo.SelectSMSConfigBoResult, error) {
+	result, err := c.smsConfigRepo.SelectSMSConfig(ctx, req)
+	if err != nil {
+		c.helper.Errorw("msg", "select sms config failed", "error", err, "req", req)
+		return nil, merr.ErrorInternal("select sms config failed").WithCause(err)
+	}
+	items := make([]*bo.SMSConfigItemSelectBo, 0, len(result.Items))
+	for _, item := range result.Items {
+		items = append(items, bo.NewSMSConfigItemSelectBo(item))
+	}
+	return &bo.SelectSMSConfigBoResult{
+		Items:   items,
+		Total:   result.Total,
+		LastUID: result.LastUID,
+	}, nil
+}
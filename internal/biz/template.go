@@ -34,7 +34,7 @@ func (t *Template) CreateTemplate(ctx context.Context, req *bo.CreateTemplateBo)
 		t.helper.Errorw("msg", "check template exists failed", "error", err, "name", doTemplate.Name)
 		return merr.ErrorInternal("create template %s failed", doTemplate.Name).WithCause(err)
 	}
-	if err := t.templateRepo.CreateTemplate(ctx, doTemplate); err != nil {
+	if err := t.templateRepo.CreateTemplate(ctx, doTemplate, req.Note); err != nil {
 		t.helper.Errorw("msg", "create template failed", "error", err, "name", doTemplate.Name)
 		return merr.ErrorInternal("create template %s failed", doTemplate.Name).WithCause(err)
 	}
@@ -50,7 +50,10 @@ func (t *Template) UpdateTemplate(ctx context.Context, req *bo.UpdateTemplateBo)
 	} else if existTemplate != nil && existTemplate.UID != doTemplate.UID {
 		return merr.ErrorParams("template %s already exists", doTemplate.Name)
 	}
-	if err := t.templateRepo.UpdateTemplate(ctx, doTemplate); err != nil {
+	if err := t.templateRepo.UpdateTemplate(ctx, doTemplate, req.Note); err != nil {
+		if merr.IsNotFound(err) {
+			return err
+		}
 		t.helper.Errorw("msg", "update template failed", "error", err, "uid", doTemplate.UID)
 		return merr.ErrorInternal("update template %s failed", doTemplate.UID).WithCause(err)
 	}
@@ -114,3 +117,106 @@ func (t *Template) SelectTemplate(ctx context.Context, req *bo.SelectTemplateBo)
 		LastUID: result.LastUID,
 	}, nil
 }
+
+// GetTemplateWithRevision 获取模板指定版本的内容，revision 为 0 时使用当前版本，状态始终以当前模板为准
+func (t *Template) GetTemplateWithRevision(ctx context.Context, uid snowflake.ID, revision int32) (*bo.TemplateItemBo, error) {
+	templateBo, err := t.GetTemplate(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if revision == 0 || revision == templateBo.Revision {
+		return templateBo, nil
+	}
+	revisionBo, err := t.getTemplateRevision(ctx, uid, revision)
+	if err != nil {
+		return nil, err
+	}
+	templateBo.Name = revisionBo.Name
+	templateBo.App = revisionBo.App
+	templateBo.JSONData = revisionBo.JSONData
+	templateBo.Variables = revisionBo.Variables
+	templateBo.Revision = revisionBo.Revision
+	return templateBo, nil
+}
+
+func (t *Template) ListTemplateRevision(ctx context.Context, req *bo.ListTemplateRevisionBo) (*bo.PageResponseBo[*bo.TemplateRevisionItemBo], error) {
+	pageResponseBo, err := t.templateRepo.ListTemplateRevision(ctx, req)
+	if err != nil {
+		t.helper.Errorw("msg", "list template revision failed", "error", err, "uid", req.TemplateUID)
+		return nil, merr.ErrorInternal("list template %s revision failed", req.TemplateUID).WithCause(err)
+	}
+	items := make([]*bo.TemplateRevisionItemBo, 0, len(pageResponseBo.GetItems()))
+	for _, item := range pageResponseBo.GetItems() {
+		items = append(items, bo.NewTemplateRevisionItemBo(item))
+	}
+	return bo.NewPageResponseBo(pageResponseBo.PageRequestBo, items), nil
+}
+
+func (t *Template) DiffTemplateRevision(ctx context.Context, req *bo.DiffTemplateRevisionBo) (*bo.TemplateRevisionDiffBo, error) {
+	from, err := t.getTemplateRevision(ctx, req.TemplateUID, req.FromRevision)
+	if err != nil {
+		return nil, err
+	}
+	to, err := t.getTemplateRevision(ctx, req.TemplateUID, req.ToRevision)
+	if err != nil {
+		return nil, err
+	}
+	return bo.NewTemplateRevisionDiffBo(from, to), nil
+}
+
+// RollbackTemplate 以指定版本的内容生成新版本，历史版本保持不变，返回新版本号
+func (t *Template) RollbackTemplate(ctx context.Context, req *bo.RollbackTemplateBo) (int32, error) {
+	revisionBo, err := t.getTemplateRevision(ctx, req.UID, req.Revision)
+	if err != nil {
+		return 0, err
+	}
+	doTemplate := revisionBo.ToDoTemplate()
+	existTemplate, err := t.templateRepo.GetTemplateByName(ctx, doTemplate.Name)
+	if err != nil && !merr.IsNotFound(err) {
+		t.helper.Errorw("msg", "check template exists failed", "error", err, "name", doTemplate.Name)
+		return 0, merr.ErrorInternal("rollback template %s failed", req.UID).WithCause(err)
+	} else if existTemplate != nil && existTemplate.UID != doTemplate.UID {
+		return 0, merr.ErrorParams("template %s already exists", doTemplate.Name)
+	}
+	if err := t.templateRepo.UpdateTemplate(ctx, doTemplate, req.RollbackNote()); err != nil {
+		if merr.IsNotFound(err) {
+			return 0, err
+		}
+		t.helper.Errorw("msg", "rollback template failed", "error", err, "uid", req.UID, "revision", req.Revision)
+		return 0, merr.ErrorInternal("rollback template %s failed", req.UID).WithCause(err)
+	}
+	return doTemplate.Revision, nil
+}
+
+func (t *Template) getTemplateRevision(ctx context.Context, uid snowflake.ID, revision int32) (*bo.TemplateRevisionItemBo, error) {
+	doRevision, err := t.templateRepo.GetTemplateRevision(ctx, uid, revision)
+	if err != nil {
+		if merr.IsNotFound(err) {
+			return nil, merr.ErrorNotFound("template %s revision %d not found", uid, revision)
+		}
+		t.helper.Errorw("msg", "get template revision failed", "error", err, "uid", uid, "revision", revision)
+		return nil, merr.ErrorInternal("get template %s revision %d failed", uid, revision).WithCause(err)
+	}
+	return bo.NewTemplateRevisionItemBo(doRevision), nil
+}
+
+// RenderTemplate 使用示例数据渲染已保存或未保存的模板，不发送消息
+func (t *Template) RenderTemplate(ctx context.Context, req *bo.RenderTemplateBo) (*bo.TemplateRenderResultBo, error) {
+	templateBo := req.ToTemplateItemBo()
+	if req.UID != 0 {
+		var err error
+		if templateBo, err = t.GetTemplateWithRevision(ctx, req.UID, req.Revision); err != nil {
+			return nil, err
+		}
+	}
+	return bo.RenderTemplate(templateBo, req.JSONData)
+}
+
+// GetTemplateSchema 获取模板指定版本声明的变量及由其生成的 JSON Schema，用于生成表单
+func (t *Template) GetTemplateSchema(ctx context.Context, uid snowflake.ID, revision int32) (*bo.TemplateSchemaBo, error) {
+	templateBo, err := t.GetTemplateWithRevision(ctx, uid, revision)
+	if err != nil {
+		return nil, err
+	}
+	return bo.NewTemplateSchemaBo(templateBo)
+}
@@ -0,0 +1,11 @@
+package vobj
+
+//go:generate stringer -type=ClusterNodeState -linecomment -output=cluster_node_state__string.go
+type ClusterNodeState int8
+
+const (
+	ClusterNodeStateUnknown     ClusterNodeState = iota // 未知
+	ClusterNodeStateHealthy                             // 健康
+	ClusterNodeStateCircuitOpen                         // 熔断
+	ClusterNodeStateHalfOpen                            // 探测中
+)
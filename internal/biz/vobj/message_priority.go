@@ -0,0 +1,20 @@
+package vobj
+
+//go:generate stringer -type=MessagePriority -linecomment -output=message_priority__string.go
+type MessagePriority int8
+
+const (
+	MessagePriorityUnknown  MessagePriority = iota // 未知
+	MessagePriorityLow                             // 低
+	MessagePriorityNormal                          // 普通
+	MessagePriorityHigh                            // 高
+	MessagePriorityCritical                        // 紧急
+)
+
+// Normalize 未设置或无效的优先级按普通优先级处理
+func (p MessagePriority) Normalize() MessagePriority {
+	if p < MessagePriorityLow || p > MessagePriorityCritical {
+		return MessagePriorityNormal
+	}
+	return p
+}
@@ -0,0 +1,12 @@
+package vobj
+
+//go:generate stringer -type=SMSProvider -linecomment -output=sms_provider__string.go
+type SMSProvider int8
+
+const (
+	SMSProviderUnknown SMSProvider = iota // 未知
+	SMSProviderMock                       // 本地模拟
+	SMSProviderAliyun                     // 阿里云
+	SMSProviderTencent                    // 腾讯云
+	SMSProviderHuawei                     // 华为云
+)
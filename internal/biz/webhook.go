@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 
+	"github.com/bwmarrin/snowflake"
 	klog "github.com/go-kratos/kratos/v2/log"
 	"gorm.io/gorm"
 
 	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/do"
 	"github.com/aide-family/rabbit/pkg/merr"
 )
 
@@ -35,48 +37,79 @@ type Webhook struct {
 	helper           *klog.Helper
 }
 
-func (w *Webhook) AppendWebhookMessage(ctx context.Context, req *bo.SendWebhookBo) error {
+func (w *Webhook) AppendWebhookMessage(ctx context.Context, req *bo.SendWebhookBo) (snowflake.ID, error) {
+	messageLog, err := w.newMessageLog(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	uid, created, err := w.messageLogBiz.createMessageLog(ctx, messageLog)
+	if err != nil {
+		w.helper.Errorw("msg", "create message log failed", "error", err)
+		return 0, merr.ErrorInternal("create message log failed").WithCause(err)
+	}
+	// 幂等键重复，返回原消息，不再重复入队
+	if !created {
+		w.helper.Debugw("msg", "duplicate idempotency key, return origin message", "idempotencyKey", req.IdempotencyKey, "uid", uid)
+		return uid, nil
+	}
+
+	if err := w.jobBiz.ScheduleMessage(ctx, messageLog.UID, messageLog.SendAt, messageLog.Priority); err != nil {
+		w.helper.Errorw("msg", "append webhook message failed", "error", err, "uid", messageLog.UID)
+		return 0, merr.ErrorInternal("append webhook message failed").WithCause(err)
+	}
+
+	return uid, nil
+}
+
+func (w *Webhook) AppendWebhookMessageWithTemplate(ctx context.Context, req *bo.SendWebhookWithTemplateBo) (snowflake.ID, error) {
+	sendWebhookBo, err := w.renderTemplate(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	return w.AppendWebhookMessage(ctx, sendWebhookBo)
+}
+
+// newMessageLog 获取 webhook 配置并生成消息日志，不写入存储
+func (w *Webhook) newMessageLog(ctx context.Context, req *bo.SendWebhookBo) (*do.MessageLog, error) {
+	if err := w.messageLogBiz.checkSendAt(req.SendAt); err != nil {
+		return nil, err
+	}
 	// 获取webhook配置
 	webhookConfig, err := w.webhookConfigBiz.GetWebhook(ctx, req.UID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return merr.ErrorParams("webhook config not found")
+			return nil, merr.ErrorParams("webhook config not found")
 		}
 		w.helper.Errorw("msg", "get webhook config failed", "error", err)
-		return merr.ErrorInternal("get webhook config failed").WithCause(err)
+		return nil, merr.ErrorInternal("get webhook config failed").WithCause(err)
 	}
 	messageLog, err := req.ToMessageLog(webhookConfig)
 	if err != nil {
 		w.helper.Errorw("msg", "create message log failed", "error", err)
-		return merr.ErrorInternal("generate message log failed").WithCause(err)
-	}
-	if err := w.messageLogBiz.createMessageLog(ctx, messageLog); err != nil {
-		w.helper.Errorw("msg", "create message log failed", "error", err)
-		return merr.ErrorInternal("create message log failed").WithCause(err)
+		return nil, merr.ErrorInternal("generate message log failed").WithCause(err)
 	}
-
-	if err := w.jobBiz.AppendMessage(ctx, messageLog.UID); err != nil {
-		w.helper.Errorw("msg", "append webhook message failed", "error", err, "uid", messageLog.UID)
-		return merr.ErrorInternal("append webhook message failed").WithCause(err)
-	}
-
-	return nil
+	return messageLog, nil
 }
 
-func (w *Webhook) AppendWebhookMessageWithTemplate(ctx context.Context, req *bo.SendWebhookWithTemplateBo) error {
+// renderTemplate 使用模板渲染 webhook 内容
+func (w *Webhook) renderTemplate(ctx context.Context, req *bo.SendWebhookWithTemplateBo) (*bo.SendWebhookBo, error) {
 	// 获取模板
-	templateDo, err := w.templateBiz.GetTemplate(ctx, req.TemplateUID)
+	templateDo, err := w.templateBiz.GetTemplateWithRevision(ctx, req.TemplateUID, req.TemplateRevision)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return merr.ErrorParams("template not found")
+			return nil, merr.ErrorParams("template not found")
 		}
 		w.helper.Errorw("msg", "get template failed", "error", err)
-		return merr.ErrorInternal("get template failed")
+		return nil, merr.ErrorInternal("get template failed")
+	}
+	// 按模板声明的变量校验数据并补全默认值，校验失败时不生成消息日志
+	if req.JSONData, err = bo.ApplyTemplateVariables(templateDo.Variables, req.JSONData); err != nil {
+		return nil, err
 	}
 	sendWebhookBo, err := req.ToSendWebhookBo(templateDo)
 	if err != nil {
 		w.helper.Errorw("msg", "convert template to webhook template data failed", "error", err)
-		return merr.ErrorInternal("convert template to webhook template data failed")
+		return nil, merr.ErrorInternal("convert template to webhook template data failed")
 	}
-	return w.AppendWebhookMessage(ctx, sendWebhookBo)
+	return sendWebhookBo, nil
 }
@@ -18,10 +18,11 @@ const (
 	KeyWebhooks   = "webhooks"
 	KeyEmails     = "emails"
 	KeyTemplates  = "templates"
+	KeySMS        = "sms"
 )
 
 var (
-	keys           = []string{KeyNamespaces, KeyWebhooks, KeyEmails, KeyTemplates}
+	keys           = []string{KeyNamespaces, KeyWebhooks, KeyEmails, KeyTemplates, KeySMS}
 	fileConfigOnce sync.Once
 )
 
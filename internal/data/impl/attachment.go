@@ -0,0 +1,104 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/aide-family/magicbox/load"
+	"github.com/aide-family/magicbox/strutil"
+	klog "github.com/go-kratos/kratos/v2/log"
+
+	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/conf"
+	"github.com/aide-family/rabbit/pkg/merr"
+	"github.com/aide-family/rabbit/pkg/middler"
+)
+
+// namespaceMetadataAttachmentMaxSize 命名空间元数据中单独设置附件大小上限(字节)的键
+const namespaceMetadataAttachmentMaxSize = "attachmentMaxSize"
+
+func NewAttachmentRepository(bc *conf.Bootstrap, namespaceRepo repository.Namespace, helper *klog.Helper) repository.Attachment {
+	baseDir := bc.GetAttachmentPath()
+	if strutil.IsNotEmpty(baseDir) {
+		baseDir = load.ExpandHomeDir(baseDir)
+	}
+	return &attachmentRepositoryImpl{
+		baseDir:       baseDir,
+		maxSize:       bc.GetAttachmentMaxSize(),
+		namespaceRepo: namespaceRepo,
+		helper:        klog.NewHelper(klog.With(helper.Logger(), "impl", "attachment")),
+	}
+}
+
+type attachmentRepositoryImpl struct {
+	baseDir       string
+	maxSize       int64
+	namespaceRepo repository.Namespace
+	helper        *klog.Helper
+}
+
+// GetAttachmentBlob implements repository.Attachment.
+func (a *attachmentRepositoryImpl) GetAttachmentBlob(ctx context.Context, blobRef string, maxSize int64) ([]byte, error) {
+	if strutil.IsEmpty(a.baseDir) {
+		return nil, merr.ErrorParams("attachment storage is not configured, blobRef %s is not supported", blobRef)
+	}
+	namespace := middler.GetNamespace(ctx)
+	// os.Root 限制只能访问命名空间目录内的文件，拒绝 ../ 和绝对路径
+	root, err := os.OpenRoot(filepath.Join(a.baseDir, namespace))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, merr.ErrorNotFound("attachment %s not found", blobRef)
+		}
+		a.helper.Errorw("msg", "open attachment storage failed", "error", err, "namespace", namespace)
+		return nil, merr.ErrorInternal("open attachment storage failed").WithCause(err)
+	}
+	defer root.Close()
+
+	file, err := root.Open(filepath.Clean(blobRef))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, merr.ErrorNotFound("attachment %s not found", blobRef)
+		}
+		return nil, merr.ErrorParams("invalid attachment blobRef %s", blobRef).WithCause(err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		a.helper.Errorw("msg", "read attachment failed", "error", err, "blobRef", blobRef)
+		return nil, merr.ErrorInternal("read attachment %s failed", blobRef).WithCause(err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, merr.ErrorParams("attachment %s exceeds the size limit of %d bytes", blobRef, maxSize)
+	}
+	return data, nil
+}
+
+// GetAttachmentMaxSize implements repository.Attachment.
+func (a *attachmentRepositoryImpl) GetAttachmentMaxSize(ctx context.Context) (int64, error) {
+	namespace, err := a.namespaceRepo.GetNamespaceByName(ctx, middler.GetNamespace(ctx))
+	if err != nil {
+		if merr.IsNotFound(err) {
+			return a.maxSize, nil
+		}
+		return 0, err
+	}
+	if namespace.Metadata == nil {
+		return a.maxSize, nil
+	}
+	value, ok := namespace.Metadata.Get(namespaceMetadataAttachmentMaxSize)
+	if !ok || strutil.IsEmpty(value) {
+		return a.maxSize, nil
+	}
+	maxSize, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || maxSize < 0 {
+		a.helper.Warnw("msg", "invalid namespace attachment max size, use default", "namespace", namespace.Name, "value", value)
+		return a.maxSize, nil
+	}
+	return maxSize, nil
+}
@@ -0,0 +1,47 @@
+package dbimpl
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gen/field"
+	"gorm.io/gorm/clause"
+
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/data"
+)
+
+// NewJobLeaseRepository 数据库模式下租约保存在主库，集群内同一时间只有一个节点持有
+func NewJobLeaseRepository(d *data.Data) repository.JobLease {
+	return &jobLeaseRepositoryImpl{
+		d: d,
+	}
+}
+
+type jobLeaseRepositoryImpl struct {
+	d *data.Data
+}
+
+// TryAcquire implements repository.JobLease.
+// 先按条件续约或接管过期的租约，租约记录不存在时再插入，并发插入由唯一索引保证只有一个节点成功
+func (r *jobLeaseRepositoryImpl) TryAcquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	jobLease := r.d.MainQuery(ctx).JobLease
+	result, err := jobLease.WithContext(ctx).
+		Where(jobLease.Name.Eq(name), field.Or(jobLease.Owner.Eq(owner), jobLease.LeaseUntil.Lt(now))).
+		UpdateSimple(jobLease.Owner.Value(owner), jobLease.LeaseUntil.Value(now.Add(ttl)), jobLease.UpdatedAt.Value(now))
+	if err != nil {
+		return false, err
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	lease := &do.JobLease{Name: name, Owner: owner, LeaseUntil: now.Add(ttl), UpdatedAt: now}
+	created := r.d.MainDB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(lease)
+	if created.Error != nil {
+		return false, created.Error
+	}
+	return created.RowsAffected > 0, nil
+}
@@ -4,14 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/aide-family/magicbox/hello"
 	"github.com/aide-family/magicbox/pointer"
 	"github.com/aide-family/magicbox/safety"
+	"github.com/aide-family/magicbox/strutil"
 	"github.com/bwmarrin/snowflake"
 	klog "github.com/go-kratos/kratos/v2/log"
 	"gorm.io/gen"
+	"gorm.io/gen/field"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
@@ -26,23 +31,44 @@ import (
 )
 
 func NewMessageLogRepository(bc *conf.Bootstrap, d *data.Data, helper *klog.Helper) repository.MessageLog {
+	idempotencyWindow := bc.GetJobCore().GetIdempotencyWindow().AsDuration()
+	if idempotencyWindow <= 0 {
+		idempotencyWindow = 24 * time.Hour
+	}
+	recoveryWindow := bc.GetJobCore().GetRecoveryWindow().AsDuration()
+	if recoveryWindow <= 0 {
+		recoveryWindow = 7 * 24 * time.Hour
+	}
 	return &messageLogRepositoryImpl{
-		helper: klog.NewHelper(klog.With(helper.Logger(), "data", "dbimpl.messageLogRepository")),
-		d:      d,
-		cache:  safety.NewMap(make(map[string]struct{})),
+		helper:            klog.NewHelper(klog.With(helper.Logger(), "data", "dbimpl.messageLogRepository")),
+		d:                 d,
+		cache:             safety.NewMap(make(map[string]struct{})),
+		idempotencyWindow: idempotencyWindow,
+		recoveryWindow:    recoveryWindow,
 	}
 }
 
 type messageLogRepositoryImpl struct {
-	helper *klog.Helper
-	d      *data.Data
-	cache  *safety.Map[string, struct{}]
+	helper            *klog.Helper
+	d                 *data.Data
+	cache             *safety.Map[string, struct{}]
+	migrateLock       sync.Mutex
+	idempotencyWindow time.Duration
+	recoveryWindow    time.Duration // 发送时间最晚为创建后的恢复窗口，按发送时间查询时需要向前多扫描的时间
 }
 
 func (m *messageLogRepositoryImpl) getTableName(ctx context.Context, req *do.MessageLog) (string, error) {
 	namespace := middler.GetNamespace(ctx)
 	req.WithNamespace(namespace)
-	tableName := do.GenMessageLogTableName(namespace, req.SendAt)
+	// 预先生成 UID，按 UID 的时间分表，保证定时消息与查询时使用同一张表
+	if req.UID == 0 {
+		node, err := snowflake.NewNode(hello.NodeID())
+		if err != nil {
+			return "", err
+		}
+		req.WithUID(node.Generate())
+	}
+	tableName := do.GenMessageLogTableName(namespace, time.UnixMilli(req.UID.Time()))
 
 	if _, ok := m.cache.Get(tableName); ok {
 		return tableName, nil
@@ -59,11 +85,62 @@ func (m *messageLogRepositoryImpl) getTableName(ctx context.Context, req *do.Mes
 			return "", err
 		}
 	}
-	m.cache.Set(tableName, struct{}{})
+	if err := m.migrateTable(ctx, namespace, tableName); err != nil {
+		return "", err
+	}
 
 	return tableName, nil
 }
 
+// checkTable 检查 UID 所在的周表是否存在，不存在时返回 gorm.ErrRecordNotFound
+func (m *messageLogRepositoryImpl) checkTable(ctx context.Context, namespace, tableName string) error {
+	if _, ok := m.cache.Get(tableName); ok {
+		return nil
+	}
+	if !do.HasTable(m.d.BizDB(ctx, namespace), tableName) {
+		return gorm.ErrRecordNotFound
+	}
+	return m.migrateTable(ctx, namespace, tableName)
+}
+
+// listTableNames 返回时间范围内已存在的周表
+func (m *messageLogRepositoryImpl) listTableNames(ctx context.Context, namespace string, startAt, endAt time.Time) ([]string, error) {
+	tableNames := do.GenMessageLogTableNames(m.d.BizDB(ctx, namespace), namespace, startAt, endAt)
+	for _, tableName := range tableNames {
+		if err := m.migrateTable(ctx, namespace, tableName); err != nil {
+			return nil, err
+		}
+	}
+	return tableNames, nil
+}
+
+// listTableNamesBySendAt 返回可能包含发送时间在范围内的消息的周表
+// 周表按 UID 即创建时间切分，定时消息的发送时间最晚为创建后的恢复窗口，起始时间需要向前扩展一个恢复窗口
+func (m *messageLogRepositoryImpl) listTableNamesBySendAt(ctx context.Context, namespace string, startAt, endAt time.Time) ([]string, error) {
+	return m.listTableNames(ctx, namespace, startAt.Add(-m.recoveryWindow), endAt)
+}
+
+// migrateTable 进程内首次访问周表时同步表结构
+// 周表只在不存在时创建，升级后旧周表缺少新增的列，写入和跨周的 UNION ALL 查询都会失败
+// 改表语句会隐式提交事务，使用事务外的连接执行
+func (m *messageLogRepositoryImpl) migrateTable(ctx context.Context, namespace, tableName string) error {
+	if _, ok := m.cache.Get(tableName); ok {
+		return nil
+	}
+	m.migrateLock.Lock()
+	defer m.migrateLock.Unlock()
+	if _, ok := m.cache.Get(tableName); ok {
+		return nil
+	}
+	bizDB := m.d.BizDB(data.WithoutTransaction(ctx), namespace)
+	if err := bizDB.Table(tableName).AutoMigrate(&do.MessageLog{}); err != nil {
+		m.helper.Errorw("msg", "migrate message log table failed", "error", err, "table", tableName)
+		return err
+	}
+	m.cache.Set(tableName, struct{}{})
+	return nil
+}
+
 // CreateMessageLog implements repository.MessageLog.
 func (m *messageLogRepositoryImpl) CreateMessageLog(ctx context.Context, req *do.MessageLog) error {
 	tableName, err := m.getTableName(ctx, req)
@@ -75,6 +152,101 @@ func (m *messageLogRepositoryImpl) CreateMessageLog(ctx context.Context, req *do
 	return wrappers.Create(req)
 }
 
+// CreateMessageLogIdempotent implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) CreateMessageLogIdempotent(ctx context.Context, req *do.MessageLog) (snowflake.ID, bool, error) {
+	duplicates, err := m.CreateMessageLogsIdempotent(ctx, []*do.MessageLog{req})
+	if err != nil {
+		return 0, false, err
+	}
+	if originUID, ok := duplicates[0]; ok {
+		return originUID, false, nil
+	}
+	return req.UID, true, nil
+}
+
+// CreateMessageLogsIdempotent implements repository.MessageLog.
+// 幂等键与消息日志在同一事务中写入，并发请求依赖 (namespace, key) 唯一索引只保留一条
+func (m *messageLogRepositoryImpl) CreateMessageLogsIdempotent(ctx context.Context, reqs []*do.MessageLog) (map[int]snowflake.ID, error) {
+	namespace := middler.GetNamespace(ctx)
+	// 建表语句会隐式提交事务，需要在事务外准备分表
+	tableNames := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		tableName, err := m.getTableName(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		tableNames = append(tableNames, tableName)
+	}
+	duplicates := make(map[int]snowflake.ID)
+	err := m.d.BizDB(ctx, namespace).Transaction(func(tx *gorm.DB) error {
+		txCtx := data.WithBizTransaction(ctx, tx, namespace)
+		for index, req := range reqs {
+			if strutil.IsNotEmpty(req.IdempotencyKey) {
+				originUID, err := m.saveIdempotencyKey(txCtx, tx, namespace, req)
+				if err != nil {
+					return err
+				}
+				if originUID != 0 {
+					duplicates[index] = originUID
+					continue
+				}
+			}
+			if err := m.d.BizQueryWithTable(txCtx, namespace, tableNames[index]).MessageLog.WithContext(txCtx).Create(req); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return duplicates, nil
+}
+
+// saveIdempotencyKey 在事务中记录幂等键，幂等键在有效期内已存在时返回原消息 UID
+func (m *messageLogRepositoryImpl) saveIdempotencyKey(txCtx context.Context, tx *gorm.DB, namespace string, req *do.MessageLog) (snowflake.ID, error) {
+	idempotencyKey := m.d.BizQuery(txCtx, namespace).IdempotencyKey
+	wheres := []gen.Condition{
+		idempotencyKey.Namespace.Eq(namespace),
+		idempotencyKey.Key.Eq(req.IdempotencyKey),
+	}
+	now := time.Now()
+	record, err := idempotencyKey.WithContext(txCtx).Where(wheres...).Clauses(clause.Locking{Strength: "UPDATE"}).First()
+	switch {
+	case err == nil && record.ExpiredAt.After(now):
+		return record.MessageUID, nil
+	case err == nil:
+		// 幂等键已过期，指向新的消息日志
+		_, err = idempotencyKey.WithContext(txCtx).Where(idempotencyKey.ID.Eq(record.ID)).UpdateSimple(
+			idempotencyKey.MessageUID.Value(req.UID.Int64()),
+			idempotencyKey.ExpiredAt.Value(now.Add(m.idempotencyWindow)),
+		)
+		return 0, err
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		record = &do.IdempotencyKey{
+			Namespace:  namespace,
+			Key:        req.IdempotencyKey,
+			MessageUID: req.UID,
+			ExpiredAt:  now.Add(m.idempotencyWindow),
+		}
+		result := tx.WithContext(txCtx).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return 0, result.Error
+		}
+		if result.RowsAffected > 0 {
+			return 0, nil
+		}
+		// 并发请求已写入相同的幂等键
+		record, err = idempotencyKey.WithContext(txCtx).Where(wheres...).First()
+		if err != nil {
+			return 0, err
+		}
+		return record.MessageUID, nil
+	default:
+		return 0, err
+	}
+}
+
 // ListMessageLog implements repository.MessageLog.
 func (m *messageLogRepositoryImpl) ListMessageLog(ctx context.Context, req *bo.ListMessageLogBo) (*bo.PageResponseBo[*do.MessageLog], error) {
 	namespace := middler.GetNamespace(ctx)
@@ -87,13 +259,13 @@ func (m *messageLogRepositoryImpl) ListMessageLog(ctx context.Context, req *bo.L
 	}
 	bizDB := m.d.BizDB(ctx, namespace)
 
-	tableNames := do.GenMessageLogTableNames(bizDB, namespace, req.StartAt, req.EndAt)
+	tableNames, err := m.listTableNamesBySendAt(ctx, namespace, req.StartAt, req.EndAt)
+	if err != nil {
+		return nil, err
+	}
 	if len(tableNames) == 0 {
 		return bo.NewPageResponseBo[*do.MessageLog](req.PageRequestBo, nil), nil
 	}
-	for _, tableName := range tableNames {
-		m.cache.Set(tableName, struct{}{})
-	}
 	tables := make([]any, 0, len(tableNames))
 	unionAllSQL := make([]string, 0, len(tableNames))
 	for _, tableName := range tableNames {
@@ -108,16 +280,8 @@ func (m *messageLogRepositoryImpl) ListMessageLog(ctx context.Context, req *bo.L
 	}
 
 	messageLog := m.d.BizQuery(ctx, namespace).MessageLog.As(do.TableNameMessageLog)
-
-	wrappers = wrappers.Where(messageLog.SendAt.Gte(req.StartAt))
-	wrappers = wrappers.Where(messageLog.SendAt.Lte(req.EndAt))
-	wrappers = wrappers.Where(messageLog.Namespace.Eq(namespace))
-
-	if req.Status.Exist() && !req.Status.IsUnknown() {
-		wrappers = wrappers.Where(messageLog.Status.Eq(req.Status.GetValue()))
-	}
-	if req.Type.Exist() && !req.Type.IsUnknown() {
-		wrappers = wrappers.Where(messageLog.Type.Eq(req.Type.GetValue()))
+	for _, condition := range m.listConditions(ctx, namespace, do.TableNameMessageLog, req) {
+		wrappers = wrappers.Where(condition)
 	}
 	if pointer.IsNotNil(req.PageRequestBo) {
 		var total int64
@@ -134,12 +298,87 @@ func (m *messageLogRepositoryImpl) ListMessageLog(ctx context.Context, req *bo.L
 	return bo.NewPageResponseBo(req.PageRequestBo, messageLogs), nil
 }
 
+// listConditions 消息日志列表和导出共用的过滤条件，tableName 为查询时使用的表名或别名
+func (m *messageLogRepositoryImpl) listConditions(ctx context.Context, namespace, tableName string, req *bo.ListMessageLogBo) []gen.Condition {
+	messageLog := m.d.BizQuery(ctx, namespace).MessageLog.As(tableName)
+	conditions := []gen.Condition{
+		messageLog.SendAt.Gte(req.StartAt),
+		messageLog.SendAt.Lte(req.EndAt),
+		messageLog.Namespace.Eq(namespace),
+	}
+	if req.Status.Exist() && !req.Status.IsUnknown() {
+		conditions = append(conditions, messageLog.Status.Eq(req.Status.GetValue()))
+	}
+	if req.Type.Exist() && !req.Type.IsUnknown() {
+		conditions = append(conditions, messageLog.Type.Eq(req.Type.GetValue()))
+	}
+	// 收件人和标签以 ",a,b," 的形式保存，按完整的值匹配
+	if strutil.IsNotEmpty(req.Recipient) {
+		conditions = append(conditions, containsCondition(messageLog.Recipients, do.SearchValue(req.Recipient)))
+	}
+	if req.ConfigUID != 0 {
+		conditions = append(conditions, messageLog.ConfigUID.Eq(req.ConfigUID.Int64()))
+	}
+	if req.TemplateUID != 0 {
+		conditions = append(conditions, messageLog.TemplateUID.Eq(req.TemplateUID.Int64()))
+	}
+	if strutil.IsNotEmpty(req.Subject) {
+		conditions = append(conditions, containsCondition(messageLog.Subject, req.Subject))
+	}
+	for _, tag := range req.Tags {
+		conditions = append(conditions, containsCondition(messageLog.Tags, do.SearchValue(tag)))
+	}
+	return conditions
+}
+
+// likeEscaper 转义 LIKE 模式中的转义符和通配符
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsCondition 字段包含 value 的条件，value 中的 % 和 _ 按字面值匹配
+// 转义符通过参数传入，不受 NO_BACKSLASH_ESCAPES 影响
+func containsCondition(column field.String, value string) gen.Condition {
+	return field.NewUnsafeFieldRaw("? LIKE ? ESCAPE ?", column.RawExpr(), "%"+likeEscaper.Replace(value)+"%", `\`)
+}
+
+// ExportMessageLogs implements repository.MessageLog.
+// 逐张周表按主键分批读取，避免多表 UNION 和一次性加载全部结果
+func (m *messageLogRepositoryImpl) ExportMessageLogs(ctx context.Context, req *bo.ListMessageLogBo, write func(*do.MessageLog) error) error {
+	const batchSize = 500
+	namespace := middler.GetNamespace(ctx)
+	if req.StartAt.IsZero() {
+		req.StartAt = time.Now().AddDate(0, 0, -7)
+	}
+	if req.EndAt.IsZero() {
+		req.EndAt = time.Now()
+	}
+	tableNames, err := m.listTableNamesBySendAt(ctx, namespace, req.StartAt, req.EndAt)
+	if err != nil {
+		return err
+	}
+	for _, tableName := range tableNames {
+		messageLog := m.d.BizQueryWithTable(ctx, namespace, tableName).MessageLog
+		var messageLogs []*do.MessageLog
+		err := messageLog.WithContext(ctx).Where(m.listConditions(ctx, namespace, tableName, req)...).FindInBatches(&messageLogs, batchSize, func(tx gen.Dao, batch int) error {
+			for _, item := range messageLogs {
+				if err := write(item); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetMessageLog implements repository.MessageLog.
 func (m *messageLogRepositoryImpl) GetMessageLog(ctx context.Context, uid snowflake.ID) (*do.MessageLog, error) {
 	namespace := middler.GetNamespace(ctx)
 	tableName := do.GenMessageLogTableName(namespace, time.UnixMilli(uid.Time()))
-	if _, ok := m.cache.Get(tableName); !ok && !do.HasTable(m.d.BizDB(ctx, namespace), tableName) {
-		return nil, gorm.ErrRecordNotFound
+	if err := m.checkTable(ctx, namespace, tableName); err != nil {
+		return nil, err
 	}
 
 	bizQuery := m.d.BizQueryWithTable(ctx, namespace, tableName)
@@ -166,8 +405,8 @@ func (m *messageLogRepositoryImpl) GetMessageLog(ctx context.Context, uid snowfl
 func (m *messageLogRepositoryImpl) GetMessageLogWithLock(ctx context.Context, uid snowflake.ID) (*do.MessageLog, error) {
 	namespace := middler.GetNamespace(ctx)
 	tableName := do.GenMessageLogTableName(namespace, time.UnixMilli(uid.Time()))
-	if _, ok := m.cache.Get(tableName); !ok && !do.HasTable(m.d.BizDB(ctx, namespace), tableName) {
-		return nil, gorm.ErrRecordNotFound
+	if err := m.checkTable(ctx, namespace, tableName); err != nil {
+		return nil, err
 	}
 
 	messageLog := m.d.BizQueryWithTable(ctx, namespace, tableName).MessageLog
@@ -193,8 +432,8 @@ func (m *messageLogRepositoryImpl) GetMessageLogWithLock(ctx context.Context, ui
 func (m *messageLogRepositoryImpl) UpdateMessageLogStatusIf(ctx context.Context, uid snowflake.ID, oldStatus, newStatus vobj.MessageStatus) (bool, error) {
 	namespace := middler.GetNamespace(ctx)
 	tableName := do.GenMessageLogTableName(namespace, time.UnixMilli(uid.Time()))
-	if _, ok := m.cache.Get(tableName); !ok && !do.HasTable(m.d.BizDB(ctx, namespace), tableName) {
-		return false, gorm.ErrRecordNotFound
+	if err := m.checkTable(ctx, namespace, tableName); err != nil {
+		return false, err
 	}
 
 	messageLog := m.d.BizQueryWithTable(ctx, namespace, tableName).MessageLog
@@ -206,9 +445,486 @@ func (m *messageLogRepositoryImpl) UpdateMessageLogStatusIf(ctx context.Context,
 		messageLogTable.Status.Eq(oldStatus.GetValue()),
 	}
 	wrappers = wrappers.Where(wheres...)
-	result, err := wrappers.Update(messageLogTable.Status, newStatus)
+	now := time.Now()
+	result, err := wrappers.UpdateSimple(
+		messageLogTable.Status.Value(newStatus.GetValue()),
+		messageLogTable.PrevStatus.Value(oldStatus.GetValue()),
+		messageLogTable.StatusChangedAt.Value(now),
+		messageLogTable.UpdatedAt.Value(now),
+	)
 	if err != nil {
 		return false, err
 	}
 	return result.RowsAffected > 0, nil
 }
+
+// UpdateMessageLogRetryIf implements repository.MessageLog.
+// 条件更新消息状态，同时累加重试次数并记录最后一次错误
+func (m *messageLogRepositoryImpl) UpdateMessageLogRetryIf(ctx context.Context, uid snowflake.ID, oldStatus, newStatus vobj.MessageStatus, lastError string) (bool, error) {
+	namespace := middler.GetNamespace(ctx)
+	tableName := do.GenMessageLogTableName(namespace, time.UnixMilli(uid.Time()))
+	if err := m.checkTable(ctx, namespace, tableName); err != nil {
+		return false, err
+	}
+
+	messageLog := m.d.BizQueryWithTable(ctx, namespace, tableName).MessageLog
+	messageLogTable := messageLog.As(tableName)
+	wrappers := messageLog.WithContext(ctx)
+	wheres := []gen.Condition{
+		messageLogTable.UID.Eq(uid.Int64()),
+		messageLogTable.Namespace.Eq(namespace),
+		messageLogTable.Status.Eq(oldStatus.GetValue()),
+	}
+	wrappers = wrappers.Where(wheres...)
+	result, err := wrappers.UpdateSimple(
+		messageLogTable.Status.Value(newStatus.GetValue()),
+		messageLogTable.PrevStatus.Value(oldStatus.GetValue()),
+		messageLogTable.StatusChangedAt.Value(time.Now()),
+		messageLogTable.RetryTotal.Add(1),
+		messageLogTable.LastError.Value(lastError),
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateMessageLogSentIf implements repository.MessageLog.
+// 条件更新消息状态为已发送，同时记录部分收件人发送失败的错误
+func (m *messageLogRepositoryImpl) UpdateMessageLogSentIf(ctx context.Context, uid snowflake.ID, oldStatus vobj.MessageStatus, lastError string) (bool, error) {
+	namespace := middler.GetNamespace(ctx)
+	tableName := do.GenMessageLogTableName(namespace, time.UnixMilli(uid.Time()))
+	if err := m.checkTable(ctx, namespace, tableName); err != nil {
+		return false, err
+	}
+
+	messageLog := m.d.BizQueryWithTable(ctx, namespace, tableName).MessageLog
+	messageLogTable := messageLog.As(tableName)
+	wrappers := messageLog.WithContext(ctx)
+	wheres := []gen.Condition{
+		messageLogTable.UID.Eq(uid.Int64()),
+		messageLogTable.Namespace.Eq(namespace),
+		messageLogTable.Status.Eq(oldStatus.GetValue()),
+	}
+	wrappers = wrappers.Where(wheres...)
+	result, err := wrappers.UpdateSimple(
+		messageLogTable.Status.Value(vobj.MessageStatusSent.GetValue()),
+		messageLogTable.PrevStatus.Value(oldStatus.GetValue()),
+		messageLogTable.StatusChangedAt.Value(time.Now()),
+		messageLogTable.LastError.Value(lastError),
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ResetMessageLogRetryIf implements repository.MessageLog.
+// 条件更新消息状态并清零重试次数
+func (m *messageLogRepositoryImpl) ResetMessageLogRetryIf(ctx context.Context, uid snowflake.ID, oldStatus, newStatus vobj.MessageStatus) (bool, error) {
+	namespace := middler.GetNamespace(ctx)
+	tableName := do.GenMessageLogTableName(namespace, time.UnixMilli(uid.Time()))
+	if err := m.checkTable(ctx, namespace, tableName); err != nil {
+		return false, err
+	}
+
+	messageLog := m.d.BizQueryWithTable(ctx, namespace, tableName).MessageLog
+	messageLogTable := messageLog.As(tableName)
+	wrappers := messageLog.WithContext(ctx)
+	wheres := []gen.Condition{
+		messageLogTable.UID.Eq(uid.Int64()),
+		messageLogTable.Namespace.Eq(namespace),
+		messageLogTable.Status.Eq(oldStatus.GetValue()),
+	}
+	wrappers = wrappers.Where(wheres...)
+	result, err := wrappers.UpdateSimple(
+		messageLogTable.Status.Value(newStatus.GetValue()),
+		messageLogTable.PrevStatus.Value(oldStatus.GetValue()),
+		messageLogTable.StatusChangedAt.Value(time.Now()),
+		messageLogTable.RetryTotal.Zero(),
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListUnfinishedMessageLog implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) ListUnfinishedMessageLog(ctx context.Context, startAt time.Time) ([]*do.MessageLog, error) {
+	namespace := middler.GetNamespace(ctx)
+	tableNames, err := m.listTableNames(ctx, namespace, startAt, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	unfinishedStatus := []int8{
+		vobj.MessageStatusPending.GetValue(),
+		vobj.MessageStatusSending.GetValue(),
+		vobj.MessageStatusFailed.GetValue(),
+	}
+
+	messageLogs := make([]*do.MessageLog, 0)
+	for _, tableName := range tableNames {
+		messageLog := m.d.BizQueryWithTable(ctx, namespace, tableName).MessageLog
+		messageLogTable := messageLog.As(tableName)
+		wheres := []gen.Condition{
+			messageLogTable.Namespace.Eq(namespace),
+			messageLogTable.Status.In(unfinishedStatus...),
+		}
+		items, err := messageLog.WithContext(ctx).Where(wheres...).Order(messageLogTable.SendAt).Find()
+		if err != nil {
+			return nil, err
+		}
+		messageLogs = append(messageLogs, items...)
+	}
+	return messageLogs, nil
+}
+
+// ClaimMessageLogs implements repository.MessageLog.
+// 使用 FOR UPDATE SKIP LOCKED 锁定候选行并写入租约，多个节点并发抢占时互不阻塞且不会重复领取
+// 租约过期的发送中消息说明持有节点已宕机，抢占时重置为待处理
+func (m *messageLogRepositoryImpl) ClaimMessageLogs(ctx context.Context, startAt time.Time, owner string, leaseUntil time.Time, limit int) ([]*do.MessageLog, error) {
+	namespace := middler.GetNamespace(ctx)
+	now := time.Now()
+	tableNames, err := m.listTableNames(ctx, namespace, startAt, now)
+	if err != nil {
+		return nil, err
+	}
+	claimStatus := []int8{
+		vobj.MessageStatusPending.GetValue(),
+		vobj.MessageStatusFailed.GetValue(),
+	}
+
+	messageLogs := make([]*do.MessageLog, 0, limit)
+	for _, tableName := range tableNames {
+		if len(messageLogs) >= limit {
+			break
+		}
+		err := m.d.BizDB(ctx, namespace).Transaction(func(tx *gorm.DB) error {
+			txCtx := data.WithBizTransaction(ctx, tx, namespace)
+			messageLog := m.d.BizQueryWithTable(txCtx, namespace, tableName).MessageLog
+			messageLogTable := messageLog.As(tableName)
+			wheres := []gen.Condition{
+				messageLogTable.Namespace.Eq(namespace),
+				field.Or(
+					field.And(
+						messageLogTable.Status.In(claimStatus...),
+						messageLogTable.SendAt.Lte(now),
+						field.Or(messageLogTable.LeaseUntil.IsNull(), messageLogTable.LeaseUntil.Lte(now)),
+					),
+					field.And(
+						messageLogTable.Status.Eq(vobj.MessageStatusSending.GetValue()),
+						messageLogTable.LeaseUntil.Lte(now),
+					),
+				),
+			}
+			items, err := messageLog.WithContext(txCtx).
+				Where(wheres...).
+				Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Order(messageLogTable.Priority.Desc(), messageLogTable.SendAt).
+				Limit(limit - len(messageLogs)).
+				Find()
+			if err != nil || len(items) == 0 {
+				return err
+			}
+			uids := make([]int64, 0, len(items))
+			sendingUIDs := make([]int64, 0)
+			for _, item := range items {
+				uids = append(uids, item.UID.Int64())
+				if item.Status.IsSending() {
+					sendingUIDs = append(sendingUIDs, item.UID.Int64())
+					item.Status = vobj.MessageStatusPending
+				}
+			}
+			_, err = messageLog.WithContext(txCtx).
+				Where(messageLogTable.Namespace.Eq(namespace), messageLogTable.UID.In(uids...)).
+				UpdateSimple(messageLogTable.LeaseOwner.Value(owner), messageLogTable.LeaseUntil.Value(leaseUntil))
+			if err != nil {
+				return err
+			}
+			if len(sendingUIDs) > 0 {
+				_, err = messageLog.WithContext(txCtx).
+					Where(messageLogTable.Namespace.Eq(namespace), messageLogTable.UID.In(sendingUIDs...), messageLogTable.Status.Eq(vobj.MessageStatusSending.GetValue())).
+					UpdateSimple(
+						messageLogTable.Status.Value(vobj.MessageStatusPending.GetValue()),
+						messageLogTable.PrevStatus.Value(vobj.MessageStatusSending.GetValue()),
+						messageLogTable.StatusChangedAt.Value(now),
+					)
+				if err != nil {
+					return err
+				}
+			}
+			messageLogs = append(messageLogs, items...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return messageLogs, nil
+}
+
+// UpdateMessageLogLease implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) UpdateMessageLogLease(ctx context.Context, uid snowflake.ID, leaseUntil time.Time) error {
+	namespace := middler.GetNamespace(ctx)
+	tableName := do.GenMessageLogTableName(namespace, time.UnixMilli(uid.Time()))
+	if err := m.checkTable(ctx, namespace, tableName); err != nil {
+		return err
+	}
+
+	messageLog := m.d.BizQueryWithTable(ctx, namespace, tableName).MessageLog
+	messageLogTable := messageLog.As(tableName)
+	wheres := []gen.Condition{
+		messageLogTable.UID.Eq(uid.Int64()),
+		messageLogTable.Namespace.Eq(namespace),
+	}
+	_, err := messageLog.WithContext(ctx).Where(wheres...).UpdateSimple(
+		messageLogTable.LeaseOwner.Value(""),
+		messageLogTable.LeaseUntil.Value(leaseUntil),
+	)
+	return err
+}
+
+// ListStatusChangedMessageLog implements repository.MessageLog.
+// 每张周表按状态变更时间的索引查询，合并后按状态变更时间排序并截取 limit 条
+func (m *messageLogRepositoryImpl) ListStatusChangedMessageLog(ctx context.Context, startAt, changedAfter time.Time, limit int) ([]*do.MessageLog, error) {
+	namespace := middler.GetNamespace(ctx)
+	tableNames, err := m.listTableNames(ctx, namespace, startAt, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	messageLogs := make([]*do.MessageLog, 0)
+	for _, tableName := range tableNames {
+		messageLog := m.d.BizQueryWithTable(ctx, namespace, tableName).MessageLog
+		messageLogTable := messageLog.As(tableName)
+		wheres := []gen.Condition{
+			messageLogTable.Namespace.Eq(namespace),
+			messageLogTable.StatusChangedAt.Gte(changedAfter),
+		}
+		items, err := messageLog.WithContext(ctx).Where(wheres...).Order(messageLogTable.StatusChangedAt).Limit(limit).Find()
+		if err != nil {
+			return nil, err
+		}
+		messageLogs = append(messageLogs, items...)
+	}
+	sort.SliceStable(messageLogs, func(i, j int) bool {
+		return messageLogs[i].StatusChangedAt.Before(*messageLogs[j].StatusChangedAt)
+	})
+	if len(messageLogs) > limit {
+		messageLogs = messageLogs[:limit]
+	}
+	return messageLogs, nil
+}
+
+// CreateMessageRetryLog implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) CreateMessageRetryLog(ctx context.Context, retryLog *do.MessageRetryLog) error {
+	namespace := middler.GetNamespace(ctx)
+	retryLog.WithNamespace(namespace)
+	messageRetryLog := m.d.BizQuery(ctx, namespace).MessageRetryLog
+	return messageRetryLog.WithContext(ctx).Create(retryLog)
+}
+
+// ListMessageRetryLog implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) ListMessageRetryLog(ctx context.Context, messageLogUID snowflake.ID) ([]*do.MessageRetryLog, error) {
+	namespace := middler.GetNamespace(ctx)
+	messageRetryLog := m.d.BizQuery(ctx, namespace).MessageRetryLog
+	wrappers := messageRetryLog.WithContext(ctx)
+	wheres := []gen.Condition{
+		messageRetryLog.Namespace.Eq(namespace),
+		messageRetryLog.MessageLogID.Eq(messageLogUID.Int64()),
+	}
+	return wrappers.Where(wheres...).Order(messageRetryLog.RetryAt).Find()
+}
+
+// CreateMessageCallbackLog implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) CreateMessageCallbackLog(ctx context.Context, callbackLog *do.MessageCallbackLog) error {
+	namespace := middler.GetNamespace(ctx)
+	callbackLog.WithNamespace(namespace)
+	messageCallbackLog := m.d.BizQuery(ctx, namespace).MessageCallbackLog
+	return messageCallbackLog.WithContext(ctx).Create(callbackLog)
+}
+
+// ListMessageCallbackLog implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) ListMessageCallbackLog(ctx context.Context, messageLogUID snowflake.ID) ([]*do.MessageCallbackLog, error) {
+	namespace := middler.GetNamespace(ctx)
+	messageCallbackLog := m.d.BizQuery(ctx, namespace).MessageCallbackLog
+	wrappers := messageCallbackLog.WithContext(ctx)
+	wheres := []gen.Condition{
+		messageCallbackLog.Namespace.Eq(namespace),
+		messageCallbackLog.MessageLogID.Eq(messageLogUID.Int64()),
+	}
+	return wrappers.Where(wheres...).Order(messageCallbackLog.CallbackAt).Find()
+}
+
+// ListPendingMessageCallbackLog implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) ListPendingMessageCallbackLog(ctx context.Context, before time.Time, limit int) ([]*do.MessageCallbackLog, error) {
+	namespace := middler.GetNamespace(ctx)
+	messageCallbackLog := m.d.BizQuery(ctx, namespace).MessageCallbackLog
+	wrappers := messageCallbackLog.WithContext(ctx)
+	wheres := []gen.Condition{
+		messageCallbackLog.Namespace.Eq(namespace),
+		messageCallbackLog.NextAttemptAt.Lte(before),
+	}
+	return wrappers.Where(wheres...).Order(messageCallbackLog.NextAttemptAt).Limit(limit).Find()
+}
+
+// UpdateMessageCallbackLogNextAttemptIf implements repository.MessageLog.
+// 多个节点同时抢占同一条回调日志时只有一个节点更新成功
+func (m *messageLogRepositoryImpl) UpdateMessageCallbackLogNextAttemptIf(ctx context.Context, uid snowflake.ID, oldNextAttemptAt time.Time, newNextAttemptAt *time.Time) (bool, error) {
+	namespace := middler.GetNamespace(ctx)
+	messageCallbackLog := m.d.BizQuery(ctx, namespace).MessageCallbackLog
+	wrappers := messageCallbackLog.WithContext(ctx)
+	wheres := []gen.Condition{
+		messageCallbackLog.UID.Eq(uid.Int64()),
+		messageCallbackLog.Namespace.Eq(namespace),
+		messageCallbackLog.NextAttemptAt.Eq(oldNextAttemptAt),
+	}
+	result, err := wrappers.Where(wheres...).Update(messageCallbackLog.NextAttemptAt, newNextAttemptAt)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListMessageLogWeeks implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) ListMessageLogWeeks(ctx context.Context) ([]*bo.MessageLogWeekBo, error) {
+	namespace := middler.GetNamespace(ctx)
+	bizDB := m.d.BizDB(ctx, namespace)
+	tableNames, err := bizDB.Migrator().GetTables()
+	if err != nil {
+		return nil, err
+	}
+
+	unfinishedStatus := []int8{
+		vobj.MessageStatusPending.GetValue(),
+		vobj.MessageStatusSending.GetValue(),
+		vobj.MessageStatusFailed.GetValue(),
+	}
+
+	weeks := make([]*bo.MessageLogWeekBo, 0)
+	for _, tableName := range tableNames {
+		tableNamespace, weekStart, ok := do.ParseWeeklyTableName(do.TableNameMessageLog, tableName)
+		if !ok || tableNamespace != namespace {
+			continue
+		}
+		messageLog := m.d.BizQueryWithTable(ctx, namespace, tableName).MessageLog
+		messageLogTable := messageLog.As(tableName)
+		total, err := messageLog.WithContext(ctx).Where(messageLogTable.Namespace.Eq(namespace)).Count()
+		if err != nil {
+			return nil, err
+		}
+		unfinished, err := messageLog.WithContext(ctx).Where(messageLogTable.Namespace.Eq(namespace), messageLogTable.Status.In(unfinishedStatus...)).Count()
+		if err != nil {
+			return nil, err
+		}
+		weeks = append(weeks, &bo.MessageLogWeekBo{
+			Namespace: namespace,
+			WeekStart: weekStart,
+			Sources: []string{
+				tableName,
+				do.GenMessageRetryLogFileName(namespace, weekStart),
+				do.GenMessageCallbackLogFileName(namespace, weekStart),
+			},
+			Total:      total,
+			Unfinished: unfinished,
+		})
+	}
+	sort.Slice(weeks, func(i, j int) bool {
+		return weeks[i].WeekStart.Before(weeks[j].WeekStart)
+	})
+	return weeks, nil
+}
+
+// ExportMessageLogWeek implements repository.MessageLog.
+// 重试和回调日志不分表，按记录时间落在该周内导出
+func (m *messageLogRepositoryImpl) ExportMessageLogWeek(ctx context.Context, week *bo.MessageLogWeekBo, write func(source string, record any) error) error {
+	const batchSize = 500
+	namespace := week.Namespace
+	tableName := do.GenMessageLogTableName(namespace, week.WeekStart)
+	if do.HasTable(m.d.BizDB(ctx, namespace), tableName) {
+		messageLog := m.d.BizQueryWithTable(ctx, namespace, tableName).MessageLog
+		messageLogTable := messageLog.As(tableName)
+		var messageLogs []*do.MessageLog
+		err := messageLog.WithContext(ctx).Where(messageLogTable.Namespace.Eq(namespace)).FindInBatches(&messageLogs, batchSize, func(tx gen.Dao, batch int) error {
+			for _, item := range messageLogs {
+				if err := write(tableName, item); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	retryLogName := do.GenMessageRetryLogFileName(namespace, week.WeekStart)
+	messageRetryLog := m.d.BizQuery(ctx, namespace).MessageRetryLog
+	var retryLogs []*do.MessageRetryLog
+	retryWheres := []gen.Condition{
+		messageRetryLog.Namespace.Eq(namespace),
+		messageRetryLog.RetryAt.Gte(week.WeekStart),
+		messageRetryLog.RetryAt.Lt(week.WeekEnd()),
+	}
+	err := messageRetryLog.WithContext(ctx).Where(retryWheres...).FindInBatches(&retryLogs, batchSize, func(tx gen.Dao, batch int) error {
+		for _, item := range retryLogs {
+			if err := write(retryLogName, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	callbackLogName := do.GenMessageCallbackLogFileName(namespace, week.WeekStart)
+	messageCallbackLog := m.d.BizQuery(ctx, namespace).MessageCallbackLog
+	var callbackLogs []*do.MessageCallbackLog
+	callbackWheres := []gen.Condition{
+		messageCallbackLog.Namespace.Eq(namespace),
+		messageCallbackLog.CallbackAt.Gte(week.WeekStart),
+		messageCallbackLog.CallbackAt.Lt(week.WeekEnd()),
+	}
+	return messageCallbackLog.WithContext(ctx).Where(callbackWheres...).FindInBatches(&callbackLogs, batchSize, func(tx gen.Dao, batch int) error {
+		for _, item := range callbackLogs {
+			if err := write(callbackLogName, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PurgeMessageLogWeek implements repository.MessageLog.
+// 重试和回调日志直接物理删除，不使用软删除
+func (m *messageLogRepositoryImpl) PurgeMessageLogWeek(ctx context.Context, week *bo.MessageLogWeekBo) error {
+	namespace := week.Namespace
+	messageRetryLog := m.d.BizQuery(ctx, namespace).MessageRetryLog
+	retryWheres := []gen.Condition{
+		messageRetryLog.Namespace.Eq(namespace),
+		messageRetryLog.RetryAt.Gte(week.WeekStart),
+		messageRetryLog.RetryAt.Lt(week.WeekEnd()),
+	}
+	if _, err := messageRetryLog.WithContext(ctx).Unscoped().Where(retryWheres...).Delete(); err != nil {
+		return err
+	}
+
+	messageCallbackLog := m.d.BizQuery(ctx, namespace).MessageCallbackLog
+	callbackWheres := []gen.Condition{
+		messageCallbackLog.Namespace.Eq(namespace),
+		messageCallbackLog.CallbackAt.Gte(week.WeekStart),
+		messageCallbackLog.CallbackAt.Lt(week.WeekEnd()),
+	}
+	if _, err := messageCallbackLog.WithContext(ctx).Unscoped().Where(callbackWheres...).Delete(); err != nil {
+		return err
+	}
+
+	tableName := do.GenMessageLogTableName(namespace, week.WeekStart)
+	m.cache.Delete(tableName)
+	bizDB := m.d.BizDB(ctx, namespace)
+	if !do.HasTable(bizDB, tableName) {
+		return nil
+	}
+	return bizDB.Migrator().DropTable(tableName)
+}
@@ -0,0 +1,72 @@
+package dbimpl
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/data"
+	"github.com/aide-family/rabbit/pkg/middler"
+)
+
+// NewRateLimiterRepository 数据库模式下令牌桶保存在数据库中，集群内所有节点共享同一个令牌桶
+func NewRateLimiterRepository(d *data.Data) repository.RateLimiter {
+	return &rateLimiterRepositoryImpl{
+		d: d,
+	}
+}
+
+type rateLimiterRepositoryImpl struct {
+	d *data.Data
+}
+
+// Reserve implements repository.RateLimiter.
+// 使用 SELECT FOR UPDATE 锁定令牌桶，保证多个节点并发预留令牌时计数准确
+func (r *rateLimiterRepositoryImpl) Reserve(ctx context.Context, rateLimit *bo.RateLimitBo) (time.Duration, bool, error) {
+	namespace := middler.GetNamespace(ctx)
+	var wait time.Duration
+	var reserved bool
+	err := r.d.BizDB(ctx, namespace).Transaction(func(tx *gorm.DB) error {
+		txCtx := data.WithBizTransaction(ctx, tx, namespace)
+		rateLimitBucket := r.d.BizQuery(txCtx, namespace).RateLimitBucket
+		wrappers := rateLimitBucket.WithContext(txCtx)
+
+		bucket, err := wrappers.Where(rateLimitBucket.Namespace.Eq(namespace), rateLimitBucket.Key.Eq(rateLimit.Key)).
+			Clauses(clause.Locking{Strength: "UPDATE"}).First()
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			// 首次使用时创建令牌桶，并发创建时忽略冲突后重新加锁读取
+			newBucket := &do.RateLimitBucket{Key: rateLimit.Key, Tokens: rateLimit.Capacity, RefreshedAt: time.Now()}
+			newBucket.WithNamespace(namespace)
+			if err := wrappers.Clauses(clause.OnConflict{DoNothing: true}).Create(newBucket); err != nil {
+				return err
+			}
+			bucket, err = wrappers.Where(rateLimitBucket.Namespace.Eq(namespace), rateLimitBucket.Key.Eq(rateLimit.Key)).
+				Clauses(clause.Locking{Strength: "UPDATE"}).First()
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		var tokens float64
+		tokens, wait, reserved = rateLimit.Reserve(bucket.Tokens, bucket.RefreshedAt, now)
+		_, err = rateLimitBucket.WithContext(txCtx).Where(rateLimitBucket.ID.Eq(bucket.ID)).UpdateSimple(
+			rateLimitBucket.Tokens.Value(tokens),
+			rateLimitBucket.RefreshedAt.Value(now),
+		)
+		return err
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	return wait, reserved, nil
+}
@@ -0,0 +1,173 @@
+// Package dbimpl is the implementation of the sms config repository for database
+package dbimpl
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aide-family/magicbox/pointer"
+	"github.com/aide-family/magicbox/strutil"
+	"github.com/bwmarrin/snowflake"
+	"gorm.io/gorm"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+	"github.com/aide-family/rabbit/internal/data"
+	"github.com/aide-family/rabbit/pkg/merr"
+	"github.com/aide-family/rabbit/pkg/middler"
+)
+
+func NewSMSConfigRepository(d *data.Data) repository.SMSConfig {
+	return &smsConfigRepositoryImpl{
+		d: d,
+	}
+}
+
+type smsConfigRepositoryImpl struct {
+	d *data.Data
+}
+
+// DeleteSMSConfig implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) DeleteSMSConfig(ctx context.Context, uid snowflake.ID) error {
+	namespace := middler.GetNamespace(ctx)
+	smsConfig := e.d.BizQuery(ctx, namespace).SMSConfig
+	wrappers := smsConfig.WithContext(ctx).Where(smsConfig.Namespace.Eq(namespace), smsConfig.UID.Eq(uid.Int64()))
+	_, err := wrappers.Delete()
+	return err
+}
+
+// GetSMSConfig implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) GetSMSConfig(ctx context.Context, uid snowflake.ID) (*do.SMSConfig, error) {
+	namespace := middler.GetNamespace(ctx)
+	smsConfig := e.d.BizQuery(ctx, namespace).SMSConfig
+	wrappers := smsConfig.WithContext(ctx).Where(smsConfig.Namespace.Eq(namespace), smsConfig.UID.Eq(uid.Int64()))
+	smsConfigDo, err := wrappers.First()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, merr.ErrorNotFound("sms config %s not found", uid)
+		}
+		return nil, err
+	}
+	return smsConfigDo, nil
+}
+
+// GetSMSConfigByName implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) GetSMSConfigByName(ctx context.Context, name string) (*do.SMSConfig, error) {
+	namespace := middler.GetNamespace(ctx)
+	smsConfig := e.d.BizQuery(ctx, namespace).SMSConfig
+	wrappers := smsConfig.WithContext(ctx).Where(smsConfig.Namespace.Eq(namespace), smsConfig.Name.Eq(name))
+	smsConfigDo, err := wrappers.First()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, merr.ErrorNotFound("sms config %s not found", name)
+		}
+		return nil, err
+	}
+	return smsConfigDo, nil
+}
+
+// ListSMSConfig implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) ListSMSConfig(ctx context.Context, req *bo.ListSMSConfigBo) (*bo.PageResponseBo[*do.SMSConfig], error) {
+	namespace := middler.GetNamespace(ctx)
+	smsConfig := e.d.BizQuery(ctx, namespace).SMSConfig
+	wrappers := smsConfig.WithContext(ctx).Where(smsConfig.Namespace.Eq(namespace))
+	if strutil.IsNotEmpty(req.Keyword) {
+		wrappers = wrappers.Where(smsConfig.Name.Like("%" + req.Keyword + "%"))
+	}
+	if req.Status.Exist() && !req.Status.IsUnknown() {
+		wrappers = wrappers.Where(smsConfig.Status.Eq(req.Status.GetValue()))
+	}
+	if pointer.IsNotNil(req.PageRequestBo) {
+		total, err := wrappers.Count()
+		if err != nil {
+			return nil, err
+		}
+		req.WithTotal(total)
+		wrappers = wrappers.Limit(req.Limit()).Offset(req.Offset())
+	}
+	smsConfigs, err := wrappers.Order(smsConfig.CreatedAt.Desc()).Find()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			pageRequestBo := bo.NewPageRequestBo(req.Page, req.PageSize)
+			pageRequestBo.WithTotal(0)
+			req.PageRequestBo = pageRequestBo
+			return bo.NewPageResponseBo(req.PageRequestBo, []*do.SMSConfig{}), nil
+		}
+		return nil, err
+	}
+	return bo.NewPageResponseBo(req.PageRequestBo, smsConfigs), nil
+}
+
+// SelectSMSConfig implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) SelectSMSConfig(ctx context.Context, req *bo.SelectSMSConfigBo) (*bo.SelectSMSConfigResult, error) {
+	namespace := middler.GetNamespace(ctx)
+	smsConfig := e.d.BizQuery(ctx, namespace).SMSConfig
+	wrappers := smsConfig.WithContext(ctx).Where(smsConfig.Namespace.Eq(namespace))
+
+	if strutil.IsNotEmpty(req.Keyword) {
+		wrappers = wrappers.Where(smsConfig.Name.Like("%" + req.Keyword + "%"))
+	}
+	if req.Status.Exist() && !req.Status.IsUnknown() {
+		wrappers = wrappers.Where(smsConfig.Status.Eq(req.Status.GetValue()))
+	}
+
+	// 获取总数
+	total, err := wrappers.Count()
+	if err != nil {
+		return nil, err
+	}
+
+	// 游标分页：如果提供了lastUID，则查询UID小于lastUID的记录
+	if req.LastUID > 0 {
+		wrappers = wrappers.Where(smsConfig.UID.Lt(req.LastUID.Int64()))
+	}
+
+	// 限制返回数量
+	wrappers = wrappers.Limit(int(req.Limit))
+
+	// 按UID倒序排列（snowflake ID按时间生成，与CreatedAt一致）
+	smsConfigs, err := wrappers.Order(smsConfig.UID.Desc()).Find()
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取最后一个UID，用于下次分页
+	var lastUID snowflake.ID
+	if len(smsConfigs) > 0 {
+		lastUID = smsConfigs[len(smsConfigs)-1].UID
+	}
+
+	return &bo.SelectSMSConfigResult{
+		Items:   smsConfigs,
+		Total:   total,
+		LastUID: lastUID,
+	}, nil
+}
+
+// CreateSMSConfig implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) CreateSMSConfig(ctx context.Context, req *do.SMSConfig) error {
+	namespace := middler.GetNamespace(ctx)
+	smsConfig := e.d.BizQuery(ctx, namespace).SMSConfig
+	wrappers := smsConfig.WithContext(ctx)
+	return wrappers.Create(req)
+}
+
+// UpdateSMSConfig implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) UpdateSMSConfig(ctx context.Context, req *do.SMSConfig) error {
+	namespace := middler.GetNamespace(ctx)
+	smsConfig := e.d.BizQuery(ctx, namespace).SMSConfig
+	wrappers := smsConfig.WithContext(ctx).Where(smsConfig.UID.Eq(req.UID.Int64()), smsConfig.Namespace.Eq(namespace))
+	_, err := wrappers.Updates(req)
+	return err
+}
+
+// UpdateSMSConfigStatus implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) UpdateSMSConfigStatus(ctx context.Context, uid snowflake.ID, status vobj.GlobalStatus) error {
+	namespace := middler.GetNamespace(ctx)
+	smsConfig := e.d.BizQuery(ctx, namespace).SMSConfig
+	wrappers := smsConfig.WithContext(ctx).Where(smsConfig.Namespace.Eq(namespace), smsConfig.UID.Eq(uid.Int64()))
+	_, err := wrappers.Update(smsConfig.Status, status)
+	return err
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/aide-family/magicbox/strutil"
 	"github.com/bwmarrin/snowflake"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/aide-family/rabbit/internal/biz/bo"
 	"github.com/aide-family/rabbit/internal/biz/do"
@@ -29,18 +30,53 @@ type templateRepositoryImpl struct {
 }
 
 // CreateTemplate implements repository.Template.
-func (t *templateRepositoryImpl) CreateTemplate(ctx context.Context, req *do.Template) error {
+func (t *templateRepositoryImpl) CreateTemplate(ctx context.Context, req *do.Template, note string) error {
 	namespace := middler.GetNamespace(ctx)
-	template := t.d.BizQuery(ctx, namespace).Template
-	return template.WithContext(ctx).Create(req)
+	return t.d.BizDB(ctx, namespace).Transaction(func(tx *gorm.DB) error {
+		txCtx := data.WithBizTransaction(ctx, tx, namespace)
+		bizQuery := t.d.BizQuery(txCtx, namespace)
+		req.Revision = 1
+		if err := bizQuery.Template.WithContext(txCtx).Create(req); err != nil {
+			return err
+		}
+		return bizQuery.TemplateRevision.WithContext(txCtx).Create(req.NewRevision(req.Revision, note))
+	})
 }
 
-func (t *templateRepositoryImpl) UpdateTemplate(ctx context.Context, req *do.Template) error {
+// UpdateTemplate implements repository.Template.
+// 锁定模板行后递增版本号，模板内容和版本记录在同一事务中写入
+func (t *templateRepositoryImpl) UpdateTemplate(ctx context.Context, req *do.Template, note string) error {
 	namespace := middler.GetNamespace(ctx)
-	template := t.d.BizQuery(ctx, namespace).Template
-	wrappers := template.WithContext(ctx).Where(template.Namespace.Eq(namespace), template.UID.Eq(req.UID.Int64()))
-	_, err := wrappers.Updates(req)
-	return err
+	return t.d.BizDB(ctx, namespace).Transaction(func(tx *gorm.DB) error {
+		txCtx := data.WithBizTransaction(ctx, tx, namespace)
+		bizQuery := t.d.BizQuery(txCtx, namespace)
+		template := bizQuery.Template
+		wrappers := template.WithContext(txCtx).Where(template.Namespace.Eq(namespace), template.UID.Eq(req.UID.Int64()))
+		current, err := wrappers.Clauses(clause.Locking{Strength: "UPDATE"}).First()
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return merr.ErrorNotFound("template %s not found", req.UID)
+			}
+			return err
+		}
+		templateRevision := bizQuery.TemplateRevision.WithContext(txCtx)
+		// 启用版本记录前创建的模板没有版本，先将当前内容保存为第 1 个版本
+		if current.Revision == 0 {
+			current.Revision = 1
+			initial := current.NewRevision(current.Revision, "initial revision")
+			initial.Creator, initial.CreatedAt = current.Creator, current.UpdatedAt
+			if err := templateRevision.Create(initial); err != nil {
+				return err
+			}
+		}
+		req.Revision = current.Revision + 1
+		// 按结构体更新时会跳过零值字段，指定更新的列，变量为空的版本回滚后模板也不再声明变量，与版本内容一致
+		if _, err := wrappers.Select(template.Name, template.App, template.JSONData, template.Variables, template.Revision).Updates(req); err != nil {
+			return err
+		}
+		req.WithNamespace(namespace)
+		return templateRevision.Create(req.NewRevision(req.Revision, note))
+	})
 }
 
 // UpdateTemplateStatus implements repository.Template.
@@ -168,3 +204,45 @@ func (t *templateRepositoryImpl) SelectTemplate(ctx context.Context, req *bo.Sel
 		LastUID: lastUID,
 	}, nil
 }
+
+// GetTemplateRevision implements repository.Template.
+func (t *templateRepositoryImpl) GetTemplateRevision(ctx context.Context, templateUID snowflake.ID, revision int32) (*do.TemplateRevision, error) {
+	namespace := middler.GetNamespace(ctx)
+	templateRevision := t.d.BizQuery(ctx, namespace).TemplateRevision
+	wrappers := templateRevision.WithContext(ctx).Where(
+		templateRevision.Namespace.Eq(namespace),
+		templateRevision.TemplateUID.Eq(templateUID.Int64()),
+		templateRevision.Revision.Eq(revision),
+	)
+	revisionDo, err := wrappers.First()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, merr.ErrorNotFound("template %s revision %d not found", templateUID, revision)
+		}
+		return nil, err
+	}
+	return revisionDo, nil
+}
+
+// ListTemplateRevision implements repository.Template.
+func (t *templateRepositoryImpl) ListTemplateRevision(ctx context.Context, req *bo.ListTemplateRevisionBo) (*bo.PageResponseBo[*do.TemplateRevision], error) {
+	namespace := middler.GetNamespace(ctx)
+	templateRevision := t.d.BizQuery(ctx, namespace).TemplateRevision
+	wrappers := templateRevision.WithContext(ctx).Where(
+		templateRevision.Namespace.Eq(namespace),
+		templateRevision.TemplateUID.Eq(req.TemplateUID.Int64()),
+	)
+	if pointer.IsNotNil(req.PageRequestBo) {
+		total, err := wrappers.Count()
+		if err != nil {
+			return nil, err
+		}
+		req.WithTotal(total)
+		wrappers = wrappers.Limit(req.Limit()).Offset(req.Offset())
+	}
+	revisions, err := wrappers.Order(templateRevision.Revision.Desc()).Find()
+	if err != nil {
+		return nil, err
+	}
+	return bo.NewPageResponseBo(req.PageRequestBo, revisions), nil
+}
@@ -74,12 +74,14 @@ func (e *emailConfigRepositoryImpl) toDoEmailConfig(emailConfig *conf.Config_Ema
 				UpdatedAt: updatedAt,
 			},
 		},
-		Name:     emailConfig.GetName(),
-		Host:     emailConfig.GetHost(),
-		Port:     emailConfig.GetPort(),
-		Username: emailConfig.GetUsername(),
-		Password: strutil.EncryptString(emailConfig.GetPassword()),
-		Status:   vobj.GlobalStatus(emailConfig.GetStatus()),
+		Name:        emailConfig.GetName(),
+		Host:        emailConfig.GetHost(),
+		Port:        emailConfig.GetPort(),
+		Username:    emailConfig.GetUsername(),
+		Password:    strutil.EncryptString(emailConfig.GetPassword()),
+		Status:      vobj.GlobalStatus(emailConfig.GetStatus()),
+		RetryPolicy: bo.NewDoRetryPolicy(emailConfig.GetRetryPolicy()),
+		RateLimit:   bo.NewDoRateLimit(emailConfig.GetRateLimit()),
 	}
 }
 
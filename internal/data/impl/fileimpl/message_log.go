@@ -3,6 +3,7 @@ package fileimpl
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -30,8 +31,17 @@ import (
 
 const (
 	logFileSuffix = ".log"
+	// maxLogLineSize 单行日志的最大长度，邮件附件随消息保存，单行可能远超 bufio.Scanner 默认的 64KB
+	maxLogLineSize = 64 << 20
 )
 
+// newLineScanner 创建按行读取日志文件的 Scanner
+func newLineScanner(file *os.File) *bufio.Scanner {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLogLineSize)
+	return scanner
+}
+
 func NewMessageLogRepository(bc *conf.Bootstrap, d *data.Data, helper *klog.Helper) repository.MessageLog {
 	repo := &messageLogRepositoryImpl{
 		helper:        klog.NewHelper(klog.With(helper.Logger(), "data", "fileimpl.messageLogRepository")),
@@ -40,6 +50,16 @@ func NewMessageLogRepository(bc *conf.Bootstrap, d *data.Data, helper *klog.Help
 		fileMutex:     &sync.Mutex{},
 		lastIDByDate:  safety.NewSyncMap(make(map[string]uint32)),
 		codec:         encoding.GetCodec("json"),
+
+		idempotencyKeys:   safety.NewSyncMap(make(map[string]*idempotencyRecord)),
+		idempotencyMutex:  &sync.Mutex{},
+		idempotencyWindow: bc.GetJobCore().GetIdempotencyWindow().AsDuration(),
+
+		pendingCallbacks: safety.NewSyncMap(make(map[snowflake.ID]*do.MessageCallbackLog)),
+		callbackMutex:    &sync.Mutex{},
+	}
+	if repo.idempotencyWindow <= 0 {
+		repo.idempotencyWindow = 24 * time.Hour
 	}
 	repo.baseDir = bc.GetMessageLogPath()
 	if strutil.IsEmpty(repo.baseDir) {
@@ -54,6 +74,7 @@ func NewMessageLogRepository(bc *conf.Bootstrap, d *data.Data, helper *klog.Help
 
 	// 启动时加载数据
 	repo.loadMessageLogs()
+	repo.loadPendingCallbacks()
 
 	return repo
 }
@@ -72,6 +93,35 @@ type messageLogRepositoryImpl struct {
 	baseDir       string
 	lastIDByDate  *safety.SyncMap[string, uint32] // 按namespace和weekStart存储的lastID，格式：namespace__weekStart -> lastID
 	codec         encoding.Codec
+
+	idempotencyKeys   *safety.SyncMap[string, *idempotencyRecord] // 幂等键到消息的映射，格式：namespace__key -> idempotencyRecord
+	idempotencyMutex  *sync.Mutex
+	idempotencyWindow time.Duration
+
+	pendingCallbacks *safety.SyncMap[snowflake.ID, *do.MessageCallbackLog] // 等待重试的回调日志，回调日志文件只追加写入，抢占和完成重试只更新内存
+	callbackMutex    *sync.Mutex
+}
+
+// idempotencyRecord 幂等键对应的消息日志
+type idempotencyRecord struct {
+	messageUID snowflake.ID
+	createdAt  time.Time
+}
+
+func genIdempotencyKey(namespace, key string) string {
+	return namespace + "__" + key
+}
+
+// setIdempotencyRecord 记录幂等键，同一幂等键保留最新创建的消息
+func (m *messageLogRepositoryImpl) setIdempotencyRecord(msgLog *do.MessageLog) {
+	if strutil.IsEmpty(msgLog.IdempotencyKey) {
+		return
+	}
+	key := genIdempotencyKey(msgLog.Namespace, msgLog.IdempotencyKey)
+	if existing, ok := m.idempotencyKeys.Get(key); ok && existing.createdAt.After(msgLog.CreatedAt) {
+		return
+	}
+	m.idempotencyKeys.Set(key, &idempotencyRecord{messageUID: msgLog.UID, createdAt: msgLog.CreatedAt})
 }
 
 // getNamespaceMap 获取或创建指定命名空间的 UID 到文件位置的映射
@@ -195,7 +245,7 @@ func (m *messageLogRepositoryImpl) loadFile(filePath string) error {
 	key := namespace + "__" + weekStartStr
 
 	var maxID uint32
-	scanner := bufio.NewScanner(file)
+	scanner := newLineScanner(file)
 	lineNum := 0
 	for scanner.Scan() {
 		lineNum++
@@ -215,6 +265,8 @@ func (m *messageLogRepositoryImpl) loadFile(filePath string) error {
 		if msgLog.ID > maxID {
 			maxID = msgLog.ID
 		}
+		msgLog.WithNamespace(namespace)
+		m.setIdempotencyRecord(&msgLog)
 
 		// 获取该命名空间的 map
 		nsMap := m.getNamespaceMap(namespace)
@@ -323,7 +375,7 @@ func (m *messageLogRepositoryImpl) countLines(filePath string) (int, error) {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	scanner := newLineScanner(file)
 	count := 0
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -350,7 +402,7 @@ func (m *messageLogRepositoryImpl) readMessageLogFromFile(location *fileLocation
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	scanner := newLineScanner(file)
 	currentLine := 0
 	for scanner.Scan() {
 		currentLine++
@@ -439,6 +491,44 @@ func (m *messageLogRepositoryImpl) CreateMessageLog(ctx context.Context, message
 	return nil
 }
 
+// CreateMessageLogIdempotent implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) CreateMessageLogIdempotent(ctx context.Context, messageLog *do.MessageLog) (snowflake.ID, bool, error) {
+	duplicates, err := m.CreateMessageLogsIdempotent(ctx, []*do.MessageLog{messageLog})
+	if err != nil {
+		return 0, false, err
+	}
+	if originUID, ok := duplicates[0]; ok {
+		return originUID, false, nil
+	}
+	return messageLog.UID, true, nil
+}
+
+// CreateMessageLogsIdempotent implements repository.MessageLog.
+// 文件实现没有事务，按顺序追加写入；启动时从日志文件重建幂等键映射，有效期按消息创建时间计算
+func (m *messageLogRepositoryImpl) CreateMessageLogsIdempotent(ctx context.Context, messageLogs []*do.MessageLog) (map[int]snowflake.ID, error) {
+	m.idempotencyMutex.Lock()
+	defer m.idempotencyMutex.Unlock()
+
+	duplicates := make(map[int]snowflake.ID)
+	for index, messageLog := range messageLogs {
+		if strutil.IsEmpty(messageLog.Namespace) {
+			messageLog.WithNamespace(middler.GetNamespace(ctx))
+		}
+		if strutil.IsNotEmpty(messageLog.IdempotencyKey) {
+			key := genIdempotencyKey(messageLog.Namespace, messageLog.IdempotencyKey)
+			if record, ok := m.idempotencyKeys.Get(key); ok && record.createdAt.Add(m.idempotencyWindow).After(time.Now()) {
+				duplicates[index] = record.messageUID
+				continue
+			}
+		}
+		if err := m.CreateMessageLog(ctx, messageLog); err != nil {
+			return nil, err
+		}
+		m.setIdempotencyRecord(messageLog)
+	}
+	return duplicates, nil
+}
+
 // GetMessageLog implements repository.MessageLog.
 func (m *messageLogRepositoryImpl) GetMessageLog(ctx context.Context, uid snowflake.ID) (*do.MessageLog, error) {
 	namespace := middler.GetNamespace(ctx)
@@ -542,19 +632,59 @@ func (m *messageLogRepositoryImpl) ListMessageLog(ctx context.Context, req *bo.L
 	return bo.NewPageResponseBo(req.PageRequestBo, allLogs), nil
 }
 
+// ExportMessageLogs implements repository.MessageLog.
+// 按周升序逐个文件、逐行读取，不在内存中缓存全部结果
+func (m *messageLogRepositoryImpl) ExportMessageLogs(ctx context.Context, req *bo.ListMessageLogBo, write func(*do.MessageLog) error) error {
+	namespace := middler.GetNamespace(ctx)
+	files, err := m.findHistoryFiles()
+	if err != nil {
+		return fmt.Errorf("failed to find log files: %w", err)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].date.Before(files[j].date)
+	})
+	for _, file := range files {
+		if file.namespace != namespace {
+			continue
+		}
+		if !req.StartAt.IsZero() && !req.StartAt.Before(file.date.AddDate(0, 0, 7)) {
+			continue
+		}
+		if !req.EndAt.IsZero() && req.EndAt.Before(file.date) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := m.scanLogsFromFile(file.path, namespace, req, write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // readAllLogsFromFile 从文件中读取所有符合条件的消息日志
 func (m *messageLogRepositoryImpl) readAllLogsFromFile(filePath string, namespace string, req *bo.ListMessageLogBo) ([]*do.MessageLog, error) {
+	var logs []*do.MessageLog
+	err := m.scanLogsFromFile(filePath, namespace, req, func(msgLog *do.MessageLog) error {
+		logs = append(logs, msgLog)
+		return nil
+	})
+	return logs, err
+}
+
+// scanLogsFromFile 逐行读取文件中符合条件的消息日志
+func (m *messageLogRepositoryImpl) scanLogsFromFile(filePath string, namespace string, req *bo.ListMessageLogBo, fn func(*do.MessageLog) error) error {
 	file, err := os.Open(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, nil
+			return nil
 		}
-		return nil, err
+		return err
 	}
 	defer file.Close()
 
-	var logs []*do.MessageLog
-	scanner := bufio.NewScanner(file)
+	scanner := newLineScanner(file)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
@@ -585,15 +715,20 @@ func (m *messageLogRepositoryImpl) readAllLogsFromFile(filePath string, namespac
 		if req.Type.Exist() && !req.Type.IsUnknown() && msgLog.Type != req.Type {
 			continue
 		}
+		if !req.MatchSearchFields(&msgLog) {
+			continue
+		}
 
-		logs = append(logs, &msgLog)
+		if err := fn(&msgLog); err != nil {
+			return err
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file %s: %w", filePath, err)
+		return fmt.Errorf("error reading file %s: %w", filePath, err)
 	}
 
-	return logs, nil
+	return nil
 }
 
 // updateMessageLogInFile 更新文件中指定 UID 的消息日志
@@ -633,7 +768,7 @@ func (m *messageLogRepositoryImpl) updateMessageLogInFile(msgLog *do.MessageLog)
 	defer file.Close()
 
 	// 读取所有行
-	scanner := bufio.NewScanner(file)
+	scanner := newLineScanner(file)
 	var lines []string
 	currentLine := 0
 
@@ -765,8 +900,90 @@ func (m *messageLogRepositoryImpl) UpdateMessageLogStatusIf(ctx context.Context,
 	}
 
 	// 更新状态
-	msgLog.Status = newStatus
+	msgLog.Status, msgLog.PrevStatus = newStatus, oldStatus
+	msgLog.UpdatedAt = time.Now()
+	msgLog.StatusChangedAt = &msgLog.UpdatedAt
+
+	// 更新文件中的对应行
+	if err := m.updateMessageLogInFile(msgLog); err != nil {
+		return false, fmt.Errorf("failed to update message log in file: %w", err)
+	}
+
+	return true, nil
+}
+
+// UpdateMessageLogRetryIf implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) UpdateMessageLogRetryIf(ctx context.Context, uid snowflake.ID, oldStatus vobj.MessageStatus, newStatus vobj.MessageStatus, lastError string) (bool, error) {
+	namespace := middler.GetNamespace(ctx)
+
+	// 从指定命名空间的 map 中查找
+	nsMap, ok := m.uidToLocation.Get(namespace)
+	if !ok {
+		return false, merr.ErrorNotFound("message log %d not found", uid.Int64())
+	}
+
+	location, ok := nsMap.Get(uid)
+	if !ok {
+		return false, merr.ErrorNotFound("message log %d not found", uid.Int64())
+	}
+
+	// 从文件读取数据
+	msgLog, err := m.readMessageLogFromFile(location)
+	if err != nil {
+		return false, err
+	}
+
+	// 检查当前状态是否匹配
+	if msgLog.Status != oldStatus {
+		return false, nil
+	}
+
+	// 更新状态、重试次数和最后一次错误
+	msgLog.Status, msgLog.PrevStatus = newStatus, oldStatus
+	msgLog.RetryTotal++
+	msgLog.LastError = lastError
+	msgLog.UpdatedAt = time.Now()
+	msgLog.StatusChangedAt = &msgLog.UpdatedAt
+
+	// 更新文件中的对应行
+	if err := m.updateMessageLogInFile(msgLog); err != nil {
+		return false, fmt.Errorf("failed to update message log in file: %w", err)
+	}
+
+	return true, nil
+}
+
+// UpdateMessageLogSentIf implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) UpdateMessageLogSentIf(ctx context.Context, uid snowflake.ID, oldStatus vobj.MessageStatus, lastError string) (bool, error) {
+	namespace := middler.GetNamespace(ctx)
+
+	// 从指定命名空间的 map 中查找
+	nsMap, ok := m.uidToLocation.Get(namespace)
+	if !ok {
+		return false, merr.ErrorNotFound("message log %d not found", uid.Int64())
+	}
+
+	location, ok := nsMap.Get(uid)
+	if !ok {
+		return false, merr.ErrorNotFound("message log %d not found", uid.Int64())
+	}
+
+	// 从文件读取数据
+	msgLog, err := m.readMessageLogFromFile(location)
+	if err != nil {
+		return false, err
+	}
+
+	// 检查当前状态是否匹配
+	if msgLog.Status != oldStatus {
+		return false, nil
+	}
+
+	// 更新状态和最后一次错误
+	msgLog.Status, msgLog.PrevStatus = vobj.MessageStatusSent, oldStatus
+	msgLog.LastError = lastError
 	msgLog.UpdatedAt = time.Now()
+	msgLog.StatusChangedAt = &msgLog.UpdatedAt
 
 	// 更新文件中的对应行
 	if err := m.updateMessageLogInFile(msgLog); err != nil {
@@ -775,3 +992,484 @@ func (m *messageLogRepositoryImpl) UpdateMessageLogStatusIf(ctx context.Context,
 
 	return true, nil
 }
+
+// ResetMessageLogRetryIf implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) ResetMessageLogRetryIf(ctx context.Context, uid snowflake.ID, oldStatus vobj.MessageStatus, newStatus vobj.MessageStatus) (bool, error) {
+	namespace := middler.GetNamespace(ctx)
+
+	// 从指定命名空间的 map 中查找
+	nsMap, ok := m.uidToLocation.Get(namespace)
+	if !ok {
+		return false, merr.ErrorNotFound("message log %d not found", uid.Int64())
+	}
+
+	location, ok := nsMap.Get(uid)
+	if !ok {
+		return false, merr.ErrorNotFound("message log %d not found", uid.Int64())
+	}
+
+	// 从文件读取数据
+	msgLog, err := m.readMessageLogFromFile(location)
+	if err != nil {
+		return false, err
+	}
+
+	// 检查当前状态是否匹配
+	if msgLog.Status != oldStatus {
+		return false, nil
+	}
+
+	// 更新状态并清零重试次数
+	msgLog.Status, msgLog.PrevStatus = newStatus, oldStatus
+	msgLog.RetryTotal = 0
+	msgLog.UpdatedAt = time.Now()
+	msgLog.StatusChangedAt = &msgLog.UpdatedAt
+
+	// 更新文件中的对应行
+	if err := m.updateMessageLogInFile(msgLog); err != nil {
+		return false, fmt.Errorf("failed to update message log in file: %w", err)
+	}
+
+	return true, nil
+}
+
+// ListUnfinishedMessageLog implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) ListUnfinishedMessageLog(ctx context.Context, startAt time.Time) ([]*do.MessageLog, error) {
+	namespace := middler.GetNamespace(ctx)
+
+	files, err := m.findHistoryFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find log files: %w", err)
+	}
+
+	messageLogs := make([]*do.MessageLog, 0)
+	for _, file := range files {
+		// 只处理属于当前 namespace 的文件，跳过整周都早于 startAt 的文件
+		if file.namespace != namespace || file.date.AddDate(0, 0, 7).Before(startAt) {
+			continue
+		}
+		fileLogs, err := m.readAllLogsFromFile(file.path, namespace, &bo.ListMessageLogBo{})
+		if err != nil {
+			m.helper.Warnf("failed to read logs from file %s: %v", file.path, err)
+			continue
+		}
+		for _, msgLog := range fileLogs {
+			if msgLog.Status.IsPending() || msgLog.Status.IsSending() || msgLog.Status.IsFailed() {
+				messageLogs = append(messageLogs, msgLog)
+			}
+		}
+	}
+
+	sort.Slice(messageLogs, func(i, j int) bool {
+		return messageLogs[i].SendAt.Before(messageLogs[j].SendAt)
+	})
+	return messageLogs, nil
+}
+
+// ClaimMessageLogs implements repository.MessageLog.
+// 文件模式只在单节点内分发消息，不支持 OUTBOX 抢占
+func (m *messageLogRepositoryImpl) ClaimMessageLogs(ctx context.Context, startAt time.Time, owner string, leaseUntil time.Time, limit int) ([]*do.MessageLog, error) {
+	return nil, merr.ErrorParamsNotSupportFileConfig()
+}
+
+// UpdateMessageLogLease implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) UpdateMessageLogLease(ctx context.Context, uid snowflake.ID, leaseUntil time.Time) error {
+	return merr.ErrorParamsNotSupportFileConfig()
+}
+
+// ListStatusChangedMessageLog implements repository.MessageLog.
+// 文件模式只有一个节点，状态变更在进程内推送
+func (m *messageLogRepositoryImpl) ListStatusChangedMessageLog(ctx context.Context, startAt, changedAfter time.Time, limit int) ([]*do.MessageLog, error) {
+	return nil, merr.ErrorParamsNotSupportFileConfig()
+}
+
+// CreateMessageRetryLog implements repository.MessageLog.
+// 重试日志追加写入 message_retry_logs__{namespace}__{weekStart}.log
+func (m *messageLogRepositoryImpl) CreateMessageRetryLog(ctx context.Context, retryLog *do.MessageRetryLog) error {
+	node, err := snowflake.NewNode(hello.NodeID())
+	if err != nil {
+		return err
+	}
+	retryLog.CreatedAt = time.Now()
+	retryLog.UpdatedAt = retryLog.CreatedAt
+	retryLog.WithCreator(ctx)
+	retryLog.WithUID(node.Generate())
+	if strutil.IsEmpty(retryLog.Namespace) {
+		retryLog.WithNamespace(middler.GetNamespace(ctx))
+	}
+	if retryLog.RetryAt.IsZero() {
+		retryLog.RetryAt = retryLog.CreatedAt
+	}
+
+	filePath := filepath.Join(m.baseDir, do.GenMessageRetryLogFileName(retryLog.Namespace, retryLog.RetryAt)+logFileSuffix)
+	if err := m.appendLogLine(filePath, retryLog); err != nil {
+		return fmt.Errorf("failed to write message retry log: %w", err)
+	}
+	return nil
+}
+
+// ListMessageRetryLog implements repository.MessageLog.
+// 重试一定发生在消息创建之后，只需要扫描消息创建当周及之后的重试日志文件
+func (m *messageLogRepositoryImpl) ListMessageRetryLog(ctx context.Context, messageLogUID snowflake.ID) ([]*do.MessageRetryLog, error) {
+	namespace := middler.GetNamespace(ctx)
+	firstFileName := do.GenMessageRetryLogFileName(namespace, time.UnixMilli(messageLogUID.Time())) + logFileSuffix
+	fileNames, err := m.findWeeklyLogFiles(do.TableNameMessageRetryLog, namespace, firstFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	retryLogs := make([]*do.MessageRetryLog, 0)
+	for _, name := range fileNames {
+		fileRetryLogs, err := readLogsFromFile(m, filepath.Join(m.baseDir, name), func(retryLog *do.MessageRetryLog) bool {
+			return retryLog.MessageLogID == messageLogUID
+		})
+		if err != nil {
+			m.helper.Warnf("failed to read retry logs from file %s: %v", name, err)
+			continue
+		}
+		retryLogs = append(retryLogs, fileRetryLogs...)
+	}
+
+	sort.Slice(retryLogs, func(i, j int) bool {
+		return retryLogs[i].RetryAt.Before(retryLogs[j].RetryAt)
+	})
+	return retryLogs, nil
+}
+
+// CreateMessageCallbackLog implements repository.MessageLog.
+// 回调日志追加写入 message_callback_logs__{namespace}__{weekStart}.log
+func (m *messageLogRepositoryImpl) CreateMessageCallbackLog(ctx context.Context, callbackLog *do.MessageCallbackLog) error {
+	node, err := snowflake.NewNode(hello.NodeID())
+	if err != nil {
+		return err
+	}
+	callbackLog.CreatedAt = time.Now()
+	callbackLog.UpdatedAt = callbackLog.CreatedAt
+	callbackLog.WithCreator(ctx)
+	callbackLog.WithUID(node.Generate())
+	if strutil.IsEmpty(callbackLog.Namespace) {
+		callbackLog.WithNamespace(middler.GetNamespace(ctx))
+	}
+	if callbackLog.CallbackAt.IsZero() {
+		callbackLog.CallbackAt = callbackLog.CreatedAt
+	}
+
+	filePath := filepath.Join(m.baseDir, do.GenMessageCallbackLogFileName(callbackLog.Namespace, callbackLog.CallbackAt)+logFileSuffix)
+	if err := m.appendLogLine(filePath, callbackLog); err != nil {
+		return fmt.Errorf("failed to write message callback log: %w", err)
+	}
+	if callbackLog.NextAttemptAt != nil {
+		pending := *callbackLog
+		m.pendingCallbacks.Set(pending.UID, &pending)
+	}
+	return nil
+}
+
+// ListMessageCallbackLog implements repository.MessageLog.
+// 回调一定发生在消息创建之后，只需要扫描消息创建当周及之后的回调日志文件
+func (m *messageLogRepositoryImpl) ListMessageCallbackLog(ctx context.Context, messageLogUID snowflake.ID) ([]*do.MessageCallbackLog, error) {
+	namespace := middler.GetNamespace(ctx)
+	firstFileName := do.GenMessageCallbackLogFileName(namespace, time.UnixMilli(messageLogUID.Time())) + logFileSuffix
+	fileNames, err := m.findWeeklyLogFiles(do.TableNameMessageCallbackLog, namespace, firstFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	callbackLogs := make([]*do.MessageCallbackLog, 0)
+	for _, name := range fileNames {
+		fileCallbackLogs, err := readLogsFromFile(m, filepath.Join(m.baseDir, name), func(callbackLog *do.MessageCallbackLog) bool {
+			return callbackLog.MessageLogID == messageLogUID
+		})
+		if err != nil {
+			m.helper.Warnf("failed to read callback logs from file %s: %v", name, err)
+			continue
+		}
+		callbackLogs = append(callbackLogs, fileCallbackLogs...)
+	}
+
+	sort.Slice(callbackLogs, func(i, j int) bool {
+		return callbackLogs[i].CallbackAt.Before(callbackLogs[j].CallbackAt)
+	})
+	return callbackLogs, nil
+}
+
+// ListPendingMessageCallbackLog implements repository.MessageLog.
+func (m *messageLogRepositoryImpl) ListPendingMessageCallbackLog(ctx context.Context, before time.Time, limit int) ([]*do.MessageCallbackLog, error) {
+	namespace := middler.GetNamespace(ctx)
+	callbackLogs := make([]*do.MessageCallbackLog, 0)
+	m.pendingCallbacks.Range(func(_ snowflake.ID, callbackLog *do.MessageCallbackLog) bool {
+		if callbackLog.Namespace == namespace && !callbackLog.NextAttemptAt.After(before) {
+			pending := *callbackLog
+			callbackLogs = append(callbackLogs, &pending)
+		}
+		return true
+	})
+	sort.Slice(callbackLogs, func(i, j int) bool {
+		return callbackLogs[i].NextAttemptAt.Before(*callbackLogs[j].NextAttemptAt)
+	})
+	if len(callbackLogs) > limit {
+		callbackLogs = callbackLogs[:limit]
+	}
+	return callbackLogs, nil
+}
+
+// UpdateMessageCallbackLogNextAttemptIf implements repository.MessageLog.
+// 只更新内存中的待重试回调，重启后按回调日志文件重新判断是否需要重试
+func (m *messageLogRepositoryImpl) UpdateMessageCallbackLogNextAttemptIf(ctx context.Context, uid snowflake.ID, oldNextAttemptAt time.Time, newNextAttemptAt *time.Time) (bool, error) {
+	m.callbackMutex.Lock()
+	defer m.callbackMutex.Unlock()
+
+	callbackLog, ok := m.pendingCallbacks.Get(uid)
+	if !ok || callbackLog.Namespace != middler.GetNamespace(ctx) || !callbackLog.NextAttemptAt.Equal(oldNextAttemptAt) {
+		return false, nil
+	}
+	if newNextAttemptAt == nil {
+		m.pendingCallbacks.Delete(uid)
+		return true, nil
+	}
+	pending := *callbackLog
+	pending.NextAttemptAt = newNextAttemptAt
+	m.pendingCallbacks.Set(uid, &pending)
+	return true, nil
+}
+
+// loadPendingCallbacks 从回调日志文件中找出等待重试的回调
+// 设置了下一次推送时间且没有下一次推送记录的回调视为等待重试，进程在重试前退出时重启后继续重试
+func (m *messageLogRepositoryImpl) loadPendingCallbacks() {
+	entries, err := os.ReadDir(m.baseDir)
+	if err != nil {
+		m.helper.Warnf("failed to read directory %s: %v", m.baseDir, err)
+		return
+	}
+	type attemptKey struct {
+		messageLogID snowflake.ID
+		status       vobj.MessageStatus
+		attempt      int32
+	}
+	attempts := make(map[attemptKey]struct{})
+	pending := make([]*do.MessageCallbackLog, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, do.TableNameMessageCallbackLog+"__") || !strings.HasSuffix(name, logFileSuffix) {
+			continue
+		}
+		callbackLogs, err := readLogsFromFile(m, filepath.Join(m.baseDir, name), func(*do.MessageCallbackLog) bool { return true })
+		if err != nil {
+			m.helper.Warnf("failed to read callback logs from file %s: %v", name, err)
+			continue
+		}
+		for _, callbackLog := range callbackLogs {
+			attempts[attemptKey{callbackLog.MessageLogID, callbackLog.Status, callbackLog.Attempt}] = struct{}{}
+			if callbackLog.NextAttemptAt != nil {
+				pending = append(pending, callbackLog)
+			}
+		}
+	}
+	for _, callbackLog := range pending {
+		if _, retried := attempts[attemptKey{callbackLog.MessageLogID, callbackLog.Status, callbackLog.Attempt + 1}]; retried {
+			continue
+		}
+		m.pendingCallbacks.Set(callbackLog.UID, callbackLog)
+	}
+}
+
+// appendLogLine 将一条日志序列化后追加写入文件
+func (m *messageLogRepositoryImpl) appendLogLine(filePath string, value any) error {
+	dataBytes, err := m.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log: %w", err)
+	}
+
+	m.fileMutex.Lock()
+	defer m.fileMutex.Unlock()
+
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(dataBytes, '\n')); err != nil {
+		return fmt.Errorf("failed to write to log file %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// findWeeklyLogFiles 查找命名空间下不早于 firstFileName 的按周切分日志文件
+func (m *messageLogRepositoryImpl) findWeeklyLogFiles(tableName, namespace, firstFileName string) ([]string, error) {
+	prefix := strings.Join([]string{tableName, namespace, ""}, "__")
+	entries, err := os.ReadDir(m.baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", m.baseDir, err)
+	}
+
+	fileNames := make([]string, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, logFileSuffix) || name < firstFileName {
+			continue
+		}
+		fileNames = append(fileNames, name)
+	}
+	return fileNames, nil
+}
+
+// readLogsFromFile 从文件中逐行读取日志，只保留 match 返回 true 的记录
+func readLogsFromFile[T any](m *messageLogRepositoryImpl, filePath string, match func(*T) bool) ([]*T, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var logs []*T
+	scanner := newLineScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var item T
+		if err := m.codec.Unmarshal([]byte(line), &item); err != nil {
+			m.helper.Warnf("failed to unmarshal line in %s: %v", filePath, err)
+			continue
+		}
+		if !match(&item) {
+			continue
+		}
+		logs = append(logs, &item)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file %s: %w", filePath, err)
+	}
+
+	return logs, nil
+}
+
+// weeklyLogTables 文件模式下按周切分的日志种类
+var weeklyLogTables = []string{do.TableNameMessageLog, do.TableNameMessageRetryLog, do.TableNameMessageCallbackLog}
+
+// ListMessageLogWeeks implements repository.MessageLog.
+// 同一周的消息、重试和回调日志文件归为一周
+func (m *messageLogRepositoryImpl) ListMessageLogWeeks(ctx context.Context) ([]*bo.MessageLogWeekBo, error) {
+	namespace := middler.GetNamespace(ctx)
+	entries, err := os.ReadDir(m.baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read directory %s: %w", m.baseDir, err)
+	}
+
+	weekByStart := make(map[time.Time]*bo.MessageLogWeekBo)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, logFileSuffix) {
+			continue
+		}
+		baseName := strings.TrimSuffix(name, logFileSuffix)
+		for _, tableName := range weeklyLogTables {
+			fileNamespace, weekStart, ok := do.ParseWeeklyTableName(tableName, baseName)
+			if !ok || fileNamespace != namespace {
+				continue
+			}
+			week, exists := weekByStart[weekStart]
+			if !exists {
+				week = &bo.MessageLogWeekBo{Namespace: namespace, WeekStart: weekStart}
+				weekByStart[weekStart] = week
+			}
+			week.Sources = append(week.Sources, baseName)
+			if tableName == do.TableNameMessageLog {
+				total, err := m.countLines(filepath.Join(m.baseDir, name))
+				if err != nil {
+					return nil, fmt.Errorf("failed to count lines of %s: %w", name, err)
+				}
+				week.Total = int64(total)
+				err = m.scanLogsFromFile(filepath.Join(m.baseDir, name), namespace, &bo.ListMessageLogBo{}, func(msgLog *do.MessageLog) error {
+					if msgLog.Status.IsPending() || msgLog.Status.IsSending() || msgLog.Status.IsFailed() {
+						week.Unfinished++
+					}
+					return nil
+				})
+				if err != nil {
+					return nil, fmt.Errorf("failed to count unfinished logs of %s: %w", name, err)
+				}
+			}
+		}
+	}
+
+	weeks := make([]*bo.MessageLogWeekBo, 0, len(weekByStart))
+	for _, week := range weekByStart {
+		sort.Strings(week.Sources)
+		weeks = append(weeks, week)
+	}
+	sort.Slice(weeks, func(i, j int) bool {
+		return weeks[i].WeekStart.Before(weeks[j].WeekStart)
+	})
+	return weeks, nil
+}
+
+// ExportMessageLogWeek implements repository.MessageLog.
+// 文件中的每一行原样导出
+func (m *messageLogRepositoryImpl) ExportMessageLogWeek(ctx context.Context, week *bo.MessageLogWeekBo, write func(source string, record any) error) error {
+	for _, source := range week.Sources {
+		if err := m.exportLogFile(source, write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *messageLogRepositoryImpl) exportLogFile(source string, write func(source string, record any) error) error {
+	filePath := filepath.Join(m.baseDir, source+logFileSuffix)
+	file, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := newLineScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := write(source, json.RawMessage(line)); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading file %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// PurgeMessageLogWeek implements repository.MessageLog.
+// 删除文件后同时清理内存中指向该文件的 UID 索引
+func (m *messageLogRepositoryImpl) PurgeMessageLogWeek(ctx context.Context, week *bo.MessageLogWeekBo) error {
+	m.fileMutex.Lock()
+	defer m.fileMutex.Unlock()
+
+	for _, source := range week.Sources {
+		filePath := filepath.Join(m.baseDir, source+logFileSuffix)
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove log file %s: %w", filePath, err)
+		}
+		if _, _, ok := do.ParseWeeklyTableName(do.TableNameMessageLog, source); !ok {
+			continue
+		}
+		m.getNamespaceMap(week.Namespace).DeleteFunc(func(_ snowflake.ID, location *fileLocation) bool {
+			return location.filePath == filePath
+		})
+		m.lastIDByDate.Delete(week.Namespace + "__" + week.WeekStart.Format("20060102"))
+	}
+	return nil
+}
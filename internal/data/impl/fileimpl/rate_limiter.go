@@ -0,0 +1,57 @@
+package fileimpl
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aide-family/magicbox/safety"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/data"
+	"github.com/aide-family/rabbit/pkg/middler"
+)
+
+// NewRateLimiterRepository 文件模式下只有单节点，令牌桶保存在内存中
+func NewRateLimiterRepository(d *data.Data) repository.RateLimiter {
+	return &rateLimiterRepositoryImpl{
+		d:       d,
+		buckets: safety.NewSyncMap(make(map[string]*rateLimitBucket)),
+	}
+}
+
+type rateLimitBucket struct {
+	mu          sync.Mutex
+	tokens      float64
+	refreshedAt time.Time
+}
+
+type rateLimiterRepositoryImpl struct {
+	d       *data.Data
+	mu      sync.Mutex
+	buckets *safety.SyncMap[string, *rateLimitBucket]
+}
+
+// Reserve implements repository.RateLimiter.
+func (r *rateLimiterRepositoryImpl) Reserve(ctx context.Context, rateLimit *bo.RateLimitBo) (time.Duration, bool, error) {
+	bucket := r.getBucket(middler.GetNamespace(ctx) + "__" + rateLimit.Key)
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+
+	now := time.Now()
+	tokens, wait, reserved := rateLimit.Reserve(bucket.tokens, bucket.refreshedAt, now)
+	bucket.tokens, bucket.refreshedAt = tokens, now
+	return wait, reserved, nil
+}
+
+func (r *rateLimiterRepositoryImpl) getBucket(key string) *rateLimitBucket {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	bucket, ok := r.buckets.Get(key)
+	if !ok {
+		bucket = &rateLimitBucket{}
+		r.buckets.Set(key, bucket)
+	}
+	return bucket
+}
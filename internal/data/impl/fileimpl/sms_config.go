@@ -0,0 +1,209 @@
+// Package fileimpl is the implementation of the sms config repository for file config
+package fileimpl
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/aide-family/magicbox/safety"
+	"github.com/aide-family/magicbox/strutil"
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+	"github.com/aide-family/rabbit/internal/conf"
+	"github.com/aide-family/rabbit/internal/data"
+	"github.com/aide-family/rabbit/pkg/merr"
+	"github.com/aide-family/rabbit/pkg/middler"
+)
+
+func NewSMSConfigRepository(d *data.Data) repository.SMSConfig {
+	e := &smsConfigRepositoryImpl{
+		d:          d,
+		smsConfigs: d.GetFileConfig().GetSms(),
+	}
+	e.initSMSConfigs()
+	d.RegisterReloadFunc(data.KeySMS, func() {
+		e.initSMSConfigs()
+	})
+	return e
+}
+
+type smsConfigRepositoryImpl struct {
+	d                  *data.Data
+	smsConfigs         []*conf.Config_SMS
+	smsConfigsWithUID  *safety.SyncMap[string, *safety.SyncMap[snowflake.ID, *do.SMSConfig]]
+	smsConfigsWithName *safety.SyncMap[string, *safety.SyncMap[string, *do.SMSConfig]]
+}
+
+func (e *smsConfigRepositoryImpl) initSMSConfigs() {
+	e.smsConfigsWithUID = safety.NewSyncMap(make(map[string]*safety.SyncMap[snowflake.ID, *do.SMSConfig]))
+	e.smsConfigsWithName = safety.NewSyncMap(make(map[string]*safety.SyncMap[string, *do.SMSConfig]))
+	for _, smsConfig := range e.smsConfigs {
+		namespace := smsConfig.GetNamespace()
+		uid := snowflake.ParseInt64(smsConfig.GetUid())
+		name := smsConfig.GetName()
+		if _, ok := e.smsConfigsWithUID.Get(namespace); !ok {
+			e.smsConfigsWithUID.Set(namespace, safety.NewSyncMap(map[snowflake.ID]*do.SMSConfig{}))
+			e.smsConfigsWithName.Set(namespace, safety.NewSyncMap(map[string]*do.SMSConfig{}))
+		}
+		item := e.toDoSMSConfig(smsConfig)
+		if namespaceSMSConfigsByName, ok := e.smsConfigsWithName.Get(namespace); ok {
+			namespaceSMSConfigsByName.Set(name, item)
+		}
+		if namespaceSMSConfigsByUID, ok := e.smsConfigsWithUID.Get(namespace); ok {
+			namespaceSMSConfigsByUID.Set(uid, item)
+		}
+	}
+}
+
+func (e *smsConfigRepositoryImpl) toDoSMSConfig(smsConfig *conf.Config_SMS) *do.SMSConfig {
+	createdAt, _ := time.Parse(time.DateTime, smsConfig.GetCreatedAt())
+	updatedAt, _ := time.Parse(time.DateTime, smsConfig.GetUpdatedAt())
+	return &do.SMSConfig{
+		NamespaceModel: do.NamespaceModel{
+			Namespace: smsConfig.GetNamespace(),
+			BaseModel: do.BaseModel{
+				ID:        smsConfig.GetId(),
+				UID:       snowflake.ParseInt64(smsConfig.GetUid()),
+				CreatedAt: createdAt,
+				UpdatedAt: updatedAt,
+			},
+		},
+		Name:            smsConfig.GetName(),
+		Provider:        vobj.SMSProvider(smsConfig.GetProvider()),
+		AccessKeyID:     smsConfig.GetAccessKeyId(),
+		AccessKeySecret: strutil.EncryptString(smsConfig.GetAccessKeySecret()),
+		SignName:        smsConfig.GetSignName(),
+		Endpoint:        smsConfig.GetEndpoint(),
+		Region:          smsConfig.GetRegion(),
+		AppID:           smsConfig.GetAppId(),
+		Status:          vobj.GlobalStatus(smsConfig.GetStatus()),
+		RetryPolicy:     bo.NewDoRetryPolicy(smsConfig.GetRetryPolicy()),
+		RateLimit:       bo.NewDoRateLimit(smsConfig.GetRateLimit()),
+	}
+}
+
+// CreateSMSConfig implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) CreateSMSConfig(ctx context.Context, req *do.SMSConfig) error {
+	return merr.ErrorParamsNotSupportFileConfig()
+}
+
+// DeleteSMSConfig implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) DeleteSMSConfig(ctx context.Context, uid snowflake.ID) error {
+	return merr.ErrorParamsNotSupportFileConfig()
+}
+
+// GetSMSConfig implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) GetSMSConfig(ctx context.Context, uid snowflake.ID) (*do.SMSConfig, error) {
+	namespace := middler.GetNamespace(ctx)
+	smsConfigWithUID, ok := e.smsConfigsWithUID.Get(namespace)
+	if !ok {
+		return nil, merr.ErrorNotFound("sms config not found")
+	}
+	smsConfig, ok := smsConfigWithUID.Get(uid)
+	if !ok {
+		return nil, merr.ErrorNotFound("sms config not found")
+	}
+	return smsConfig, nil
+}
+
+// GetSMSConfigByName implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) GetSMSConfigByName(ctx context.Context, name string) (*do.SMSConfig, error) {
+	namespace := middler.GetNamespace(ctx)
+	smsConfigWithName, ok := e.smsConfigsWithName.Get(namespace)
+	if !ok {
+		return nil, merr.ErrorNotFound("sms config not found")
+	}
+	smsConfig, ok := smsConfigWithName.Get(name)
+	if !ok {
+		return nil, merr.ErrorNotFound("sms config not found")
+	}
+	return smsConfig, nil
+}
+
+// ListSMSConfig implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) ListSMSConfig(ctx context.Context, req *bo.ListSMSConfigBo) (*bo.PageResponseBo[*do.SMSConfig], error) {
+	namespace := middler.GetNamespace(ctx)
+	smsConfigWithUID, ok := e.smsConfigsWithUID.Get(namespace)
+	if !ok {
+		pageRequestBo := bo.NewPageRequestBo(req.Page, req.PageSize)
+		pageRequestBo.WithTotal(0)
+		req.PageRequestBo = pageRequestBo
+		return bo.NewPageResponseBo(req.PageRequestBo, []*do.SMSConfig{}), nil
+	}
+	smsConfigs := make([]*do.SMSConfig, 0, smsConfigWithUID.Len())
+	for _, smsConfig := range smsConfigWithUID.Values() {
+		if strutil.IsNotEmpty(req.Keyword) && !strings.Contains(smsConfig.Name, req.Keyword) {
+			continue
+		}
+		if req.Status.Exist() && !req.Status.IsUnknown() && smsConfig.Status != req.Status {
+			continue
+		}
+		smsConfigs = append(smsConfigs, smsConfig)
+	}
+	total := int64(len(smsConfigs))
+	pageRequestBo := bo.NewPageRequestBo(req.Page, req.PageSize)
+	pageRequestBo.WithTotal(total)
+	req.PageRequestBo = pageRequestBo
+	sort.Slice(smsConfigs, func(i, j int) bool {
+		return smsConfigs[i].UID < smsConfigs[j].UID
+	})
+	return bo.NewPageResponseBo(req.PageRequestBo, smsConfigs), nil
+}
+
+// SelectSMSConfig implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) SelectSMSConfig(ctx context.Context, req *bo.SelectSMSConfigBo) (*bo.SelectSMSConfigResult, error) {
+	namespace := middler.GetNamespace(ctx)
+	smsConfigWithUID, ok := e.smsConfigsWithUID.Get(namespace)
+	if !ok {
+		return &bo.SelectSMSConfigResult{
+			Items:   []*do.SMSConfig{},
+			Total:   0,
+			LastUID: 0,
+		}, nil
+	}
+	smsConfigs := make([]*do.SMSConfig, 0, smsConfigWithUID.Len())
+	for _, smsConfig := range smsConfigWithUID.Values() {
+		if strutil.IsNotEmpty(req.Keyword) && !strings.Contains(smsConfig.Name, req.Keyword) {
+			continue
+		}
+		if req.Status.Exist() && !req.Status.IsUnknown() && smsConfig.Status != req.Status {
+			continue
+		}
+		if req.LastUID > 0 && smsConfig.UID >= req.LastUID {
+			continue
+		}
+		smsConfigs = append(smsConfigs, smsConfig)
+	}
+	total := int64(len(smsConfigs))
+	sort.Slice(smsConfigs, func(i, j int) bool {
+		return smsConfigs[i].UID > smsConfigs[j].UID
+	})
+	if int32(len(smsConfigs)) > req.Limit {
+		smsConfigs = smsConfigs[:req.Limit]
+	}
+	var lastUID snowflake.ID
+	if len(smsConfigs) > 0 {
+		lastUID = smsConfigs[len(smsConfigs)-1].UID
+	}
+	return &bo.SelectSMSConfigResult{
+		Items:   smsConfigs,
+		Total:   total,
+		LastUID: lastUID,
+	}, nil
+}
+
+// UpdateSMSConfig implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) UpdateSMSConfig(ctx context.Context, req *do.SMSConfig) error {
+	return merr.ErrorParamsNotSupportFileConfig()
+}
+
+// UpdateSMSConfigStatus implements repository.SMSConfig.
+func (e *smsConfigRepositoryImpl) UpdateSMSConfigStatus(ctx context.Context, uid snowflake.ID, status vobj.GlobalStatus) error {
+	return merr.ErrorParamsNotSupportFileConfig()
+}
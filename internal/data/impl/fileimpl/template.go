@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/bwmarrin/snowflake"
+	klog "github.com/go-kratos/kratos/v2/log"
 
 	"github.com/aide-family/magicbox/safety"
 	"github.com/aide-family/magicbox/strutil"
@@ -22,8 +23,9 @@ import (
 	"github.com/aide-family/rabbit/pkg/middler"
 )
 
-func NewTemplateRepository(d *data.Data) repository.Template {
+func NewTemplateRepository(d *data.Data, helper *klog.Helper) repository.Template {
 	t := &templateRepositoryImpl{
+		helper:    klog.NewHelper(klog.With(helper.Logger(), "data", "fileimpl.templateRepository")),
 		d:         d,
 		templates: d.GetFileConfig().GetTemplates(),
 	}
@@ -35,6 +37,7 @@ func NewTemplateRepository(d *data.Data) repository.Template {
 }
 
 type templateRepositoryImpl struct {
+	helper            *klog.Helper
 	d                 *data.Data
 	templates         []*conf.Config_Template
 	templatesWithUID  *safety.SyncMap[string, *safety.SyncMap[snowflake.ID, *do.Template]]
@@ -67,6 +70,11 @@ func (t *templateRepositoryImpl) toDoTemplate(template *conf.Config_Template) *d
 	createdAt, _ := time.Parse(time.DateTime, template.GetCreatedAt())
 	updatedAt, _ := time.Parse(time.DateTime, template.GetUpdatedAt())
 	jsonData := json.RawMessage(template.GetJsonData())
+	// 配置文件中声明的变量无效时不校验 jsonData，与未声明变量的模板行为一致
+	variables, err := bo.NewDoTemplateVariables(template.GetVariables())
+	if err != nil {
+		t.helper.Warnw("msg", "invalid template variables in file config, ignore variables", "error", err, "namespace", template.GetNamespace(), "name", template.GetName())
+	}
 	return &do.Template{
 		NamespaceModel: do.NamespaceModel{
 			Namespace: template.GetNamespace(),
@@ -77,20 +85,21 @@ func (t *templateRepositoryImpl) toDoTemplate(template *conf.Config_Template) *d
 				UpdatedAt: updatedAt,
 			},
 		},
-		Name:     template.GetName(),
-		App:      vobj.TemplateApp(template.GetApp()),
-		JSONData: jsonData,
-		Status:   vobj.GlobalStatus(template.GetStatus()),
+		Name:      template.GetName(),
+		App:       vobj.TemplateApp(template.GetApp()),
+		JSONData:  jsonData,
+		Variables: variables,
+		Status:    vobj.GlobalStatus(template.GetStatus()),
 	}
 }
 
 // CreateTemplate implements repository.Template.
-func (t *templateRepositoryImpl) CreateTemplate(ctx context.Context, req *do.Template) error {
+func (t *templateRepositoryImpl) CreateTemplate(ctx context.Context, req *do.Template, note string) error {
 	return merr.ErrorParamsNotSupportFileConfig()
 }
 
 // UpdateTemplate implements repository.Template.
-func (t *templateRepositoryImpl) UpdateTemplate(ctx context.Context, req *do.Template) error {
+func (t *templateRepositoryImpl) UpdateTemplate(ctx context.Context, req *do.Template, note string) error {
 	return merr.ErrorParamsNotSupportFileConfig()
 }
 
@@ -209,3 +218,17 @@ func (t *templateRepositoryImpl) SelectTemplate(ctx context.Context, req *bo.Sel
 		LastUID: lastUID,
 	}, nil
 }
+
+// GetTemplateRevision implements repository.Template.
+// 文件配置的模板不记录版本
+func (t *templateRepositoryImpl) GetTemplateRevision(ctx context.Context, templateUID snowflake.ID, revision int32) (*do.TemplateRevision, error) {
+	return nil, merr.ErrorNotFound("template %s revision %d not found", templateUID, revision)
+}
+
+// ListTemplateRevision implements repository.Template.
+func (t *templateRepositoryImpl) ListTemplateRevision(ctx context.Context, req *bo.ListTemplateRevisionBo) (*bo.PageResponseBo[*do.TemplateRevision], error) {
+	pageRequestBo := bo.NewPageRequestBo(req.Page, req.PageSize)
+	pageRequestBo.WithTotal(0)
+	req.PageRequestBo = pageRequestBo
+	return bo.NewPageResponseBo(req.PageRequestBo, []*do.TemplateRevision{}), nil
+}
@@ -76,13 +76,17 @@ func (w *webhookConfigRepositoryImpl) toDoWebhookConfig(webhookConfig *conf.Conf
 				UpdatedAt: updatedAt,
 			},
 		},
-		App:     vobj.WebhookApp(webhookConfig.GetApp()),
-		Name:    webhookConfig.GetName(),
-		URL:     webhookConfig.GetUrl(),
-		Method:  vobj.HTTPMethod(webhookConfig.GetMethod()),
-		Headers: headers,
-		Secret:  strutil.EncryptString(webhookConfig.GetSecret()),
-		Status:  vobj.GlobalStatus(webhookConfig.GetStatus()),
+		App:         vobj.WebhookApp(webhookConfig.GetApp()),
+		Name:        webhookConfig.GetName(),
+		URL:         webhookConfig.GetUrl(),
+		Method:      vobj.HTTPMethod(webhookConfig.GetMethod()),
+		Headers:     headers,
+		Secret:      strutil.EncryptString(webhookConfig.GetSecret()),
+		Status:      vobj.GlobalStatus(webhookConfig.GetStatus()),
+		RetryPolicy: bo.NewDoRetryPolicy(webhookConfig.GetRetryPolicy()),
+		RateLimit:   bo.NewDoRateLimit(webhookConfig.GetRateLimit()),
+		SuccessRule: bo.NewDoSuccessRule(webhookConfig.GetSuccessRule()),
+		Signature:   bo.NewDoSignature(webhookConfig.GetSignature()),
 	}
 }
 
@@ -7,10 +7,17 @@ import "github.com/google/wire"
 var ProviderSetImpl = wire.NewSet(
 	NewHealthRepository,
 	NewEmailConfigRepository,
+	NewSMSConfigRepository,
 	NewMessageLogRepository,
+	NewMessageLogWatcher,
 	NewNamespaceRepository,
 	NewWebhookConfigRepository,
 	NewTemplateRepository,
 	NewMessageRepository,
+	NewMessageCallbackRepository,
+	NewMessageRetentionRepository,
+	NewJobLeaseRepository,
 	NewTransactionRepository,
+	NewRateLimiterRepository,
+	NewAttachmentRepository,
 )
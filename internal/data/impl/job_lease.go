@@ -0,0 +1,16 @@
+package impl
+
+import (
+	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/data"
+	"github.com/aide-family/rabbit/internal/data/impl/dbimpl"
+	"github.com/aide-family/rabbit/internal/data/impl/fileimpl"
+)
+
+func NewJobLeaseRepository(d *data.Data) repository.JobLease {
+	newRepo := fileimpl.NewJobLeaseRepository
+	if d.UseDatabase() {
+		newRepo = dbimpl.NewJobLeaseRepository
+	}
+	return newRepo(d)
+}
@@ -2,17 +2,21 @@ package impl
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/aide-family/magicbox/hello"
+	"github.com/aide-family/magicbox/pointer"
 	"github.com/aide-family/magicbox/safety"
 	"github.com/aide-family/magicbox/strutil"
 	"github.com/bwmarrin/snowflake"
 	klog "github.com/go-kratos/kratos/v2/log"
 
 	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/do"
 	"github.com/aide-family/rabbit/internal/biz/repository"
 	"github.com/aide-family/rabbit/internal/biz/vobj"
 	"github.com/aide-family/rabbit/internal/conf"
@@ -20,7 +24,9 @@ import (
 	"github.com/aide-family/rabbit/internal/data/impl/sender"
 	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
 	"github.com/aide-family/rabbit/pkg/connect"
+	"github.com/aide-family/rabbit/pkg/enum"
 	"github.com/aide-family/rabbit/pkg/merr"
+	"github.com/aide-family/rabbit/pkg/middler"
 )
 
 func NewMessageRepository(
@@ -28,28 +34,52 @@ func NewMessageRepository(
 	d *data.Data,
 	transactionRepo repository.Transaction,
 	messageLogRepo repository.MessageLog,
+	namespaceRepo repository.Namespace,
+	rateLimiterRepo repository.RateLimiter,
+	callbackRepo repository.MessageCallback,
 	helper *klog.Helper,
 ) repository.Message {
 	jobCoreConf := bc.GetJobCore()
 	clusterConfig := bc.GetCluster()
 	clusterEndpoints := strutil.SplitSkipEmpty(clusterConfig.GetEndpoints(), ",")
 	messageRepo := &messageRepositoryImpl{
-		d:               d,
-		bc:              bc,
-		transactionRepo: transactionRepo,
-		messageLogRepo:  messageLogRepo,
-		helper:          klog.NewHelper(klog.With(helper.Logger(), "impl", "message")),
-		messageChan:     make(chan *messageTask, jobCoreConf.GetBufferSize()),
-		senders:         safety.NewSyncMap(make(map[vobj.MessageType]repository.MessageSender)),
-		stopChan:        make(chan struct{}),
-		wg:              sync.WaitGroup{},
-		workerTotal:     int(jobCoreConf.GetWorkerTotal()),
-		timeout:         jobCoreConf.GetTimeout().AsDuration(),
-		clusters:        make([]sender.Sender, 0, len(clusterEndpoints)),
+		d:                    d,
+		bc:                   bc,
+		transactionRepo:      transactionRepo,
+		messageLogRepo:       messageLogRepo,
+		namespaceRepo:        namespaceRepo,
+		rateLimiterRepo:      rateLimiterRepo,
+		callbackRepo:         callbackRepo,
+		helper:               klog.NewHelper(klog.With(helper.Logger(), "impl", "message")),
+		lanes:                newMessageLanes(jobCoreConf.GetBufferSize()),
+		scheduler:            newMessageScheduler(),
+		senders:              safety.NewSyncMap(make(map[vobj.MessageType]repository.MessageSender)),
+		stopChan:             make(chan struct{}),
+		wg:                   sync.WaitGroup{},
+		workerTotal:          int(jobCoreConf.GetWorkerTotal()),
+		timeout:              jobCoreConf.GetTimeout().AsDuration(),
+		retryPolicy:          bo.NewDoRetryPolicy(jobCoreConf.GetRetryPolicy()),
+		recoveryWindow:       jobCoreConf.GetRecoveryWindow().AsDuration(),
+		spillPollInterval:    jobCoreConf.GetSpillPollInterval().AsDuration(),
+		spilledNamespaces:    safety.NewSyncMap(make(map[string]context.Context)),
+		dispatchMode:         jobCoreConf.GetDispatchMode(),
+		outboxPollInterval:   jobCoreConf.GetOutboxPollInterval().AsDuration(),
+		outboxLease:          jobCoreConf.GetOutboxLease().AsDuration(),
+		outboxBatchSize:      int(jobCoreConf.GetOutboxBatchSize()),
+		outboxOwner:          hello.ID(),
+		outboxWakeup:         make(chan struct{}, 1),
+		clusters:             make([]*clusterNode, 0, len(clusterEndpoints)),
+		clusterMaxFailures:   int32(jobCoreConf.GetClusterFailureThreshold()),
+		clusterOpenDuration:  jobCoreConf.GetClusterOpenDuration().AsDuration(),
+		webhookAppRateLimits: safety.NewSyncMap(make(map[vobj.WebhookApp]*do.RateLimit)),
+	}
+	for _, appRateLimit := range jobCoreConf.GetWebhookAppRateLimits() {
+		messageRepo.webhookAppRateLimits.Set(vobj.WebhookApp(appRateLimit.GetApp()), bo.NewDoRateLimit(appRateLimit.GetRateLimit()))
 	}
 
 	// 注册发送器
-	messageRepo.registerSenders(sender.NewEmailSender(helper), sender.NewWebhookSender(helper))
+	messageRepo.registerSenders(sender.NewEmailSender(helper), sender.NewWebhookSender(helper), sender.NewSMSSender(helper))
+	messageRepo.registerQueueMetrics()
 
 	messageRepo.Start(context.Background())
 
@@ -65,6 +95,7 @@ func NewMessageRepository(
 type messageTask struct {
 	ctx        context.Context
 	messageUID snowflake.ID
+	priority   vobj.MessagePriority
 }
 
 type messageRepositoryImpl struct {
@@ -72,15 +103,37 @@ type messageRepositoryImpl struct {
 	bc              *conf.Bootstrap
 	transactionRepo repository.Transaction
 	messageLogRepo  repository.MessageLog
+	namespaceRepo   repository.Namespace
+	rateLimiterRepo repository.RateLimiter
+	callbackRepo    repository.MessageCallback
 	helper          *klog.Helper
-	messageChan     chan *messageTask
+	lanes           *messageLanes     // 按优先级划分的分发队列
+	scheduler       *messageScheduler // 定时发送和重试退避的延迟队列
 	senders         *safety.SyncMap[vobj.MessageType, repository.MessageSender]
 	stopChan        chan struct{}
 	wg              sync.WaitGroup
 	workerTotal     int // 工作协程数量,默认1个
 	timeout         time.Duration
+	retryPolicy     *do.RetryPolicy // 全局默认重试策略
+	recoveryWindow  time.Duration   // 启动时恢复未完成消息的时间窗口
+
+	spillPollInterval time.Duration                            // 拉取溢出消息的间隔
+	spilledNamespaces *safety.SyncMap[string, context.Context] // 存在溢出消息的命名空间
+
+	dispatchMode       conf.JobCore_DispatchMode // 数据库模式下的集群分发方式
+	outboxPollInterval time.Duration             // OUTBOX 模式轮询到期消息的间隔
+	outboxLease        time.Duration             // OUTBOX 模式抢占消息的租约时长
+	outboxBatchSize    int                       // OUTBOX 模式每个命名空间单次最多抢占的消息数
+	outboxOwner        string                    // OUTBOX 模式租约持有者，即当前节点 ID
+	outboxWakeup       chan struct{}             // 有新消息时唤醒 OUTBOX 轮询协程
 
-	clusters        []sender.Sender
+	webhookAppRateLimits *safety.SyncMap[vobj.WebhookApp, *do.RateLimit] // 各 webhook 应用的默认限流
+
+	clusterMaxFailures  int32         // 集群节点连续失败多少次后熔断
+	clusterOpenDuration time.Duration // 集群节点熔断的持续时间
+	localFallbackTotal  atomic.Int64  // 没有可用节点时回退到当前节点发送的消息数
+
+	clusters        []*clusterNode
 	clusterInitOnce sync.Once
 	stopOnce        sync.Once    // 确保Stop只执行一次
 	clustersMu      sync.RWMutex // 保护clusters的并发访问
@@ -96,6 +149,10 @@ func (m *messageRepositoryImpl) Start(ctx context.Context) error {
 	for workerID := 0; workerID < m.workerTotal; workerID++ {
 		m.worker(ctx, workerID)
 	}
+	m.runScheduler(ctx)
+	m.runSpillPoller(ctx)
+	m.recoverMessages(ctx)
+	m.runOutboxPoller(ctx)
 	return nil
 }
 
@@ -106,7 +163,7 @@ func (m *messageRepositoryImpl) Stop(ctx context.Context) error {
 		stopped = true
 		close(m.stopChan)
 		m.wg.Wait()
-		close(m.messageChan)
+		m.lanes.close()
 		m.helper.Debug("msg", "message bus stopped")
 	})
 
@@ -132,17 +189,23 @@ func (m *messageRepositoryImpl) Stop(ctx context.Context) error {
 	}
 }
 
-// worker 处理消息的工作协程
+// worker 处理消息的工作协程，按优先级队列的加权轮询顺序取消息
 func (m *messageRepositoryImpl) worker(ctx context.Context, workerID int) {
 	m.wg.Go(func() {
+		cursor := workerID
 		for {
 			select {
-			case task, ok := <-m.messageChan:
-				if !ok {
-					m.helper.Debugw("msg", "message bus worker stopped by message channel closed", "worker", workerID)
-					return
-				}
-				m.waitProcessMessage(task.ctx, task.messageUID)
+			case <-m.stopChan:
+				m.helper.Debugw("msg", "message bus worker stopped by stop channel", "worker", workerID)
+				return
+			default:
+			}
+			if task, ok := m.lanes.pop(&cursor); ok {
+				m.waitProcessMessage(task.ctx, task.messageUID, task.priority)
+				continue
+			}
+			select {
+			case <-m.lanes.notify:
 			case <-m.stopChan:
 				m.helper.Debugw("msg", "message bus worker stopped by stop channel", "worker", workerID)
 				return
@@ -154,36 +217,149 @@ func (m *messageRepositoryImpl) worker(ctx context.Context, workerID int) {
 	})
 }
 
-func (m *messageRepositoryImpl) waitProcessMessage(ctx context.Context, messageUID snowflake.ID) {
-	req := &apiv1.JobSendMessageRequest{
-		Uid: messageUID.Int64(),
-	}
-	// notice: 没有使用外部存储，不允许使用集群模式， 避免消息无法共享到其他节点
-	if m.d.UseDatabase() {
-		m.initClusters()
+// runScheduler 启动调度协程，将到期的延迟消息放入工作队列
+func (m *messageRepositoryImpl) runScheduler(ctx context.Context) {
+	const idleInterval = time.Minute
+	m.wg.Go(func() {
+		timer := time.NewTimer(idleInterval)
+		defer timer.Stop()
+		for {
+			for _, task := range m.scheduler.popDue(time.Now()) {
+				if err := m.AppendMessage(task.ctx, task.messageUID, task.priority); err != nil {
+					m.helper.Warnw("msg", "append scheduled message failed, try again later", "error", err, "uid", task.messageUID)
+					task.sendAt = time.Now().Add(time.Second)
+					m.scheduler.push(task)
+				}
+			}
+
+			wait := idleInterval
+			if nextSendAt, ok := m.scheduler.nextSendAt(); ok {
+				wait = time.Until(nextSendAt)
+			}
+			timer.Reset(wait)
 
-		clustersIdx := make([]int, 0, len(m.clusters))
-		for i := range m.clusters {
-			clustersIdx = append(clustersIdx, i)
+			select {
+			case <-timer.C:
+			case <-m.scheduler.wakeup:
+			case <-m.stopChan:
+				m.helper.Debug("msg", "message scheduler stopped by stop channel")
+				return
+			case <-ctx.Done():
+				m.helper.Debug("msg", "message scheduler stopped by context done")
+				return
+			}
 		}
+	})
+}
 
-		// 打乱副本，避免修改原始slice
-		rand.Shuffle(len(clustersIdx), func(i, j int) {
-			clustersIdx[i], clustersIdx[j] = clustersIdx[j], clustersIdx[i]
-		})
-
-		// 按打乱后的顺序尝试发送，失败则重试下一个节点
-		for _, clusterIdx := range clustersIdx {
-			m.clustersMu.RLock()
-			cluster := m.clusters[clusterIdx]
-			m.clustersMu.RUnlock()
-			reply, err := cluster.SendMessage(ctx, req)
+// recoverMessages 恢复重启前未完成的消息，包括等待重试的失败消息
+// 多个节点同时启动时会重复入队，由 SendMessage 中的行锁和 CAS 保证每条消息只发送一次
+func (m *messageRepositoryImpl) recoverMessages(ctx context.Context) {
+	if m.recoveryWindow <= 0 {
+		m.recoveryWindow = 7 * 24 * time.Hour
+	}
+	m.wg.Go(func() {
+		namespaces, err := listAllNamespaces(ctx, m.namespaceRepo)
+		if err != nil {
+			m.helper.Errorw("msg", "list namespaces failed, skip recover messages", "error", err)
+			return
+		}
+		startAt := time.Now().Add(-m.recoveryWindow)
+		for _, namespace := range namespaces {
+			namespaceCtx := middler.WithNamespace(ctx, namespace.Name)
+			messageLogs, err := m.messageLogRepo.ListUnfinishedMessageLog(namespaceCtx, startAt)
 			if err != nil {
-				m.helper.Errorw("msg", "send message failed", "error", err, "uid", messageUID, "reply", reply, "cluster", cluster)
+				m.helper.Errorw("msg", "list unfinished message logs failed", "error", err, "namespace", namespace.Name)
 				continue
 			}
+			for _, messageLog := range messageLogs {
+				select {
+				case <-m.stopChan:
+					return
+				default:
+				}
+				if messageLog.Status.IsSending() {
+					m.recoverSendingMessage(namespaceCtx, messageLog)
+					continue
+				}
+				// OUTBOX 模式下待发送和等待重试的消息由轮询协程抢占
+				if m.useOutbox() {
+					continue
+				}
+				m.scheduler.push(&scheduledTask{ctx: namespaceCtx, messageUID: messageLog.UID, sendAt: messageLog.SendAt, priority: messageLog.Priority})
+			}
+			if len(messageLogs) > 0 {
+				m.helper.Infow("msg", "recover unfinished messages", "namespace", namespace.Name, "total", len(messageLogs))
+			}
+		}
+	})
+}
+
+// recoverSendingMessage 恢复卡在发送中的消息
+// 消息可能正在由其他存活节点发送，只有超过发送超时和租约仍未更新的消息才会被重置为待处理
+func (m *messageRepositoryImpl) recoverSendingMessage(ctx context.Context, messageLog *do.MessageLog) {
+	m.wg.Go(func() {
+		timer := time.NewTimer(time.Until(m.sendingExpireAt(messageLog)))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-m.stopChan:
 			return
 		}
+
+		latest, err := m.messageLogRepo.GetMessageLog(ctx, messageLog.UID)
+		if err != nil {
+			m.helper.Errorw("msg", "get message log failed", "error", err, "uid", messageLog.UID)
+			return
+		}
+		if !latest.Status.IsSending() || m.sendingExpireAt(latest).After(time.Now()) {
+			return
+		}
+		success, err := m.messageLogRepo.UpdateMessageLogStatusIf(ctx, messageLog.UID, vobj.MessageStatusSending, vobj.MessageStatusPending)
+		if err != nil {
+			m.helper.Errorw("msg", "reset sending message to pending failed", "error", err, "uid", messageLog.UID)
+			return
+		}
+		if !success {
+			return
+		}
+		m.scheduler.push(&scheduledTask{ctx: ctx, messageUID: messageLog.UID, sendAt: latest.SendAt, priority: latest.Priority})
+	})
+}
+
+// listAllNamespaces 获取全部命名空间
+func listAllNamespaces(ctx context.Context, namespaceRepo repository.Namespace) ([]*do.Namespace, error) {
+	const pageSize = 100
+	namespaces := make([]*do.Namespace, 0)
+	for page := int32(1); ; page++ {
+		req := &bo.ListNamespaceBo{PageRequestBo: bo.NewPageRequestBo(page, pageSize)}
+		pageResponseBo, err := namespaceRepo.ListNamespace(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		items := pageResponseBo.GetItems()
+		namespaces = append(namespaces, items...)
+		if len(items) == 0 || int64(len(namespaces)) >= pageResponseBo.GetTotal() {
+			return namespaces, nil
+		}
+	}
+}
+
+func (m *messageRepositoryImpl) waitProcessMessage(ctx context.Context, messageUID snowflake.ID, priority vobj.MessagePriority) {
+	req := &apiv1.JobSendMessageRequest{
+		Uid:      messageUID.Int64(),
+		Priority: enum.MessagePriority(priority),
+	}
+	// notice: 没有使用外部存储，不允许使用集群模式， 避免消息无法共享到其他节点
+	// OUTBOX 模式下消息由当前节点抢占，直接在当前节点发送
+	if m.d.UseDatabase() && !m.useOutbox() {
+		m.initClusters()
+
+		// 熔断中的节点直接跳过，避免每条消息都等待故障节点超时
+		if m.sendToCluster(ctx, req) {
+			return
+		}
+		m.localFallbackTotal.Add(1)
 		m.helper.Debugw("msg", "no cluster available to send message, use local node", "uid", messageUID)
 	}
 	// 如果未启用数据库或者全部节点都失败，则直接使用当前节点发送消息
@@ -195,6 +371,8 @@ func (m *messageRepositoryImpl) waitProcessMessage(ctx context.Context, messageU
 func (m *messageRepositoryImpl) SendMessage(ctx context.Context, messageUID snowflake.ID) error {
 	// 在事务中使用 SELECT FOR UPDATE 获取分布式锁
 	var newMessage *bo.MessageLogItemBo
+	var scheduledSendAt time.Time
+	var priority vobj.MessagePriority
 	err := m.transactionRepo.Transaction(ctx, func(transactionCtx context.Context) error {
 		// 使用 SELECT FOR UPDATE 获取行锁，确保同一时间只有一个节点能处理该消息
 		lockedMessage, err := m.messageLogRepo.GetMessageLogWithLock(transactionCtx, messageUID)
@@ -206,15 +384,22 @@ func (m *messageRepositoryImpl) SendMessage(ctx context.Context, messageUID snow
 			return merr.ErrorInternal("get message log with lock failed").WithCause(err)
 		}
 
-		// 如果消息已经发送或正在发送，直接返回
-		if lockedMessage.Status.IsSent() || lockedMessage.Status.IsSending() {
-			m.helper.Debugw("msg", "message status is not sent or sending, skip update status", "uid", messageUID, "status", lockedMessage.Status)
+		// 只有待处理或失败的消息才需要发送
+		if !lockedMessage.Status.IsPending() && !lockedMessage.Status.IsFailed() {
+			m.helper.Debugw("msg", "message status is not pending or failed, skip update status", "uid", messageUID, "status", lockedMessage.Status)
+			return nil
+		}
+
+		priority = lockedMessage.Priority
+		// 未到发送时间的消息重新放回延迟队列
+		if lockedMessage.SendAt.After(time.Now()) {
+			scheduledSendAt = lockedMessage.SendAt
 			return nil
 		}
 
 		// 使用 CAS 操作原子性地更新状态为发送中
 		// 只有当前状态为待处理或失败时才更新为发送中
-		result, err := m.messageLogRepo.UpdateMessageLogStatusIf(transactionCtx, messageUID, vobj.MessageStatusPending, vobj.MessageStatusSending)
+		result, err := m.messageLogRepo.UpdateMessageLogStatusIf(transactionCtx, messageUID, lockedMessage.Status, vobj.MessageStatusSending)
 		if err != nil {
 			return merr.ErrorInternal("update message status to sending failed").WithCause(err)
 		}
@@ -222,6 +407,12 @@ func (m *messageRepositoryImpl) SendMessage(ctx context.Context, messageUID snow
 			m.helper.Debugw("msg", "message already processed or status update failed", "uid", messageUID)
 			return nil
 		}
+		// 发送中的消息持有租约，节点在发送完成前宕机时租约过期后由其他节点接管
+		if m.d.UseDatabase() {
+			if err := m.messageLogRepo.UpdateMessageLogLease(transactionCtx, messageUID, m.sendingLeaseUntil(0)); err != nil {
+				return merr.ErrorInternal("update message lease failed").WithCause(err)
+			}
+		}
 
 		newMessage = bo.NewMessageLogItemBo(lockedMessage)
 		return nil
@@ -231,15 +422,97 @@ func (m *messageRepositoryImpl) SendMessage(ctx context.Context, messageUID snow
 		return err
 	}
 
+	if !scheduledSendAt.IsZero() {
+		m.helper.Debugw("msg", "message is not due yet, schedule it", "uid", messageUID, "sendAt", scheduledSendAt)
+		return m.ScheduleMessage(ctx, messageUID, scheduledSendAt, priority)
+	}
+
 	// 如果消息已经被处理或者状态更新失败，直接返回
 	if newMessage == nil {
 		return nil
 	}
 
+	// 状态更新为发送中之后、在行锁之外取令牌，CAS 失败的消息不会消耗令牌，取令牌也不会延长行锁的持有时间
+	// 超过渠道限流的消息按预留的顺序延迟发送，而不是直接失败
+	wait, reserved := m.rateLimitWait(ctx, newMessage)
+	if !reserved {
+		return m.releaseRateLimitedMessage(ctx, newMessage, time.Now().Add(wait))
+	}
+	if wait > 0 {
+		return m.delayProcessMessage(ctx, newMessage, wait)
+	}
+
 	// 在事务外处理消息发送（发送操作可能需要较长时间，不应该在数据库事务中执行）
 	return m.processMessage(ctx, newMessage)
 }
 
+// delayProcessMessage 等待预留的令牌可用后发送，等待期间消息保持发送中，租约延长到等待结束并发送完成
+// 发送前重新检查状态和租约，租约已被其他节点接管时不再发送，避免重复发送
+// 服务停止时不再发送，消息在租约过期后由其他节点或下次启动时的恢复流程重新投递
+func (m *messageRepositoryImpl) delayProcessMessage(ctx context.Context, message *bo.MessageLogItemBo, wait time.Duration) error {
+	leaseUntil := m.sendingLeaseUntil(wait)
+	if m.d.UseDatabase() {
+		if err := m.messageLogRepo.UpdateMessageLogLease(ctx, message.UID, leaseUntil); err != nil {
+			m.helper.Errorw("msg", "extend rate limited message lease failed", "error", err, "uid", message.UID)
+			return m.releaseRateLimitedMessage(ctx, message, time.Now().Add(wait))
+		}
+	}
+	m.helper.Debugw("msg", "message is rate limited, send it after the reserved token is available", "uid", message.UID, "wait", wait)
+	ctx = safety.CopyValueCtx(ctx)
+	m.wg.Go(func() {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-m.stopChan:
+			m.helper.Debugw("msg", "message bus is stopped, skip rate limited message", "uid", message.UID)
+			return
+		}
+		latest, err := m.messageLogRepo.GetMessageLog(ctx, message.UID)
+		if err != nil {
+			m.helper.Errorw("msg", "get rate limited message failed", "error", err, "uid", message.UID)
+			return
+		}
+		if !latest.Status.IsSending() || (m.d.UseDatabase() && (pointer.IsNil(latest.LeaseUntil) || !latest.LeaseUntil.Equal(leaseUntil))) {
+			m.helper.Debugw("msg", "rate limited message is taken over, skip it", "uid", message.UID, "status", latest.Status)
+			return
+		}
+		if err := m.processMessage(ctx, message); err != nil {
+			m.helper.Errorw("msg", "process rate limited message failed", "error", err, "uid", message.UID)
+		}
+	})
+	return nil
+}
+
+// sendingLeaseUntil 发送中消息的租约到期时间，覆盖等待令牌的时间和一次发送超时
+// 数据库按秒保存时间，向上取整到秒，条件比较时才能精确匹配
+func (m *messageRepositoryImpl) sendingLeaseUntil(wait time.Duration) time.Time {
+	return time.Now().Add(wait + m.timeout + time.Second).Truncate(time.Second)
+}
+
+// sendingExpireAt 发送中的消息超过该时间仍未完成时视为节点已宕机，可以重置为待处理
+func (m *messageRepositoryImpl) sendingExpireAt(messageLog *do.MessageLog) time.Time {
+	expireAt := messageLog.UpdatedAt.Add(m.timeout)
+	if pointer.IsNotNil(messageLog.LeaseUntil) && messageLog.LeaseUntil.After(expireAt) {
+		return *messageLog.LeaseUntil
+	}
+	return expireAt
+}
+
+// releaseRateLimitedMessage 令牌桶的预留已满，消息恢复为原状态，到可以预留时再重新处理
+func (m *messageRepositoryImpl) releaseRateLimitedMessage(ctx context.Context, message *bo.MessageLogItemBo, sendAt time.Time) error {
+	success, err := m.messageLogRepo.UpdateMessageLogStatusIf(ctx, message.UID, vobj.MessageStatusSending, message.Status)
+	if err != nil {
+		return merr.ErrorInternal("release rate limited message failed").WithCause(err)
+	}
+	if !success {
+		m.helper.Debugw("msg", "message status is not sending, skip release rate limited message", "uid", message.UID)
+		return nil
+	}
+	m.helper.Debugw("msg", "message is rate limited, schedule it", "uid", message.UID, "sendAt", sendAt)
+	return m.ScheduleMessage(ctx, message.UID, sendAt, message.Priority)
+}
+
 // processMessage 处理消息
 func (m *messageRepositoryImpl) processMessage(ctx context.Context, message *bo.MessageLogItemBo) error {
 	if message.Status.IsSent() || message.Status.IsSending() {
@@ -247,70 +520,194 @@ func (m *messageRepositoryImpl) processMessage(ctx context.Context, message *bo.
 		return nil
 	}
 	message.Status = vobj.MessageStatusSending
-	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	// 超时只作用于发送本身，发送超时后仍需更新状态、记录重试和推送回调，不能使用已超时的上下文
+	ctx = context.WithoutCancel(ctx)
+	sendCtx, cancel := context.WithTimeout(ctx, m.timeout)
 	defer cancel()
 
 	senderType := message.Type
 	sender, ok := m.senders.Get(senderType)
 	if !ok {
 		m.helper.Debugw("msg", "sender not found", "type", senderType, "uid", message.UID)
-		if _, err := m.messageLogRepo.UpdateMessageLogStatusIf(ctx, message.UID, vobj.MessageStatusSending, vobj.MessageStatusFailed); err != nil {
-			m.helper.Errorw("msg", "update message status to failed failed", "error", err, "uid", message.UID)
-		}
-		return merr.ErrorParams("sender not supported")
+		err := merr.ErrorParams("sender not supported")
+		m.handleSendFailed(ctx, message, err)
+		return err
 	}
 
-	// 发送消息
-	if err := sender.Send(ctx, message); err != nil {
+	// 发送消息，部分收件人发送失败时视为已发送，重试会向已成功的收件人重复发送
+	var partialErr *bo.PartialSendError
+	if err := sender.Send(sendCtx, message); err != nil && !errors.As(err, &partialErr) {
 		m.helper.Errorw("msg", "send message failed", "error", err, "uid", message.UID, "type", senderType)
-		success, updateErr := m.messageLogRepo.UpdateMessageLogStatusIf(ctx, message.UID, vobj.MessageStatusSending, vobj.MessageStatusFailed)
-		if updateErr != nil {
-			m.helper.Errorw("msg", "update message status to failed failed", "error", updateErr, "uid", message.UID)
-		}
-		if !success {
-			m.helper.Debugw("msg", "message status is not sending, message sent failed", "uid", message.UID, "type", senderType)
-		}
+		m.handleSendFailed(ctx, message, err)
 		return merr.ErrorInternal("send message failed").WithCause(err)
 	}
 
 	// 更新状态为已发送
-	success, err := m.messageLogRepo.UpdateMessageLogStatusIf(ctx, message.UID, vobj.MessageStatusSending, vobj.MessageStatusSent)
+	var success bool
+	var err error
+	if partialErr != nil {
+		m.helper.Warnw("msg", "message sent to some recipients failed", "error", partialErr, "uid", message.UID, "type", senderType)
+		message.LastError = partialErr.Error()
+		success, err = m.messageLogRepo.UpdateMessageLogSentIf(ctx, message.UID, vobj.MessageStatusSending, partialErr.Error())
+	} else {
+		success, err = m.messageLogRepo.UpdateMessageLogStatusIf(ctx, message.UID, vobj.MessageStatusSending, vobj.MessageStatusSent)
+	}
 	if err != nil {
 		m.helper.Errorw("msg", "update message status to sent failed", "error", err, "uid", message.UID)
 		return merr.ErrorInternal("update message status to sent failed")
 	}
 	if !success {
 		m.helper.Debugw("msg", "message status is not sending, message sent successfully", "uid", message.UID, "type", senderType)
+		return nil
 	}
+	message.Status = vobj.MessageStatusSent
+	m.notifyStatus(ctx, message)
 	return nil
 }
 
+// handleSendFailed 记录本次失败的重试日志，重试次数未用尽时标记为失败并按退避策略重新投递
+// 重试次数用尽或遇到不可重试的错误时进入死信，等待人工处理
+func (m *messageRepositoryImpl) handleSendFailed(ctx context.Context, message *bo.MessageLogItemBo, sendErr error) {
+	attempt := message.RetryTotal + 1
+	retryLog := &do.MessageRetryLog{
+		MessageLogID: message.UID,
+		RetryAt:      time.Now(),
+		Error:        sendErr.Error(),
+	}
+	if err := m.messageLogRepo.CreateMessageRetryLog(ctx, retryLog); err != nil {
+		m.helper.Errorw("msg", "create message retry log failed", "error", err, "uid", message.UID)
+	}
+
+	retryPolicy := m.getRetryPolicy(ctx, message)
+	newStatus := vobj.MessageStatusDeadLetter
+	if !isNonRetryableError(sendErr) && retryPolicy.CanRetry(attempt) {
+		newStatus = vobj.MessageStatusFailed
+	}
+	success, err := m.messageLogRepo.UpdateMessageLogRetryIf(ctx, message.UID, vobj.MessageStatusSending, newStatus, sendErr.Error())
+	if err != nil {
+		m.helper.Errorw("msg", "update message retry status failed", "error", err, "uid", message.UID, "status", newStatus)
+		return
+	}
+	if !success {
+		m.helper.Debugw("msg", "message status is not sending, message sent failed", "uid", message.UID, "type", message.Type)
+		return
+	}
+	message.Status, message.RetryTotal, message.LastError = newStatus, attempt, sendErr.Error()
+	// 等待重试的失败不是最终状态，只在进入死信时推送状态回调
+	if newStatus.IsDeadLetter() {
+		m.helper.Warnw("msg", "message moved to dead letter", "uid", message.UID, "attempt", attempt, "maxAttempts", retryPolicy.MaxAttempts, "error", sendErr)
+		m.notifyStatus(ctx, message)
+		return
+	}
+
+	delay := retryPolicy.NextDelay(attempt)
+	m.helper.Debugw("msg", "message will be retried", "uid", message.UID, "attempt", attempt, "delay", delay)
+	if err := m.ScheduleMessage(ctx, message.UID, time.Now().Add(delay), message.Priority); err != nil {
+		m.helper.Errorw("msg", "schedule retry message failed", "error", err, "uid", message.UID)
+	}
+}
+
+// notifyStatus 将消息的最新状态推送到状态回调地址
+func (m *messageRepositoryImpl) notifyStatus(ctx context.Context, message *bo.MessageLogItemBo) {
+	m.callbackRepo.Notify(ctx, bo.NewMessageCallbackEventBo(middler.GetNamespace(ctx), message))
+}
+
+// rateLimitWait 从渠道配置的令牌桶中预留令牌，返回预留的令牌可用前需要等待的时间，预留已满时 reserved 为 false
+// 渠道配置未设置限流时使用 webhook 应用的默认限流，限流器异常时不限流
+func (m *messageRepositoryImpl) rateLimitWait(ctx context.Context, message *bo.MessageLogItemBo) (wait time.Duration, reserved bool) {
+	config := message.ConfigSnapshot()
+	rateLimit := config.RateLimit
+	if pointer.IsNil(rateLimit) && message.Type.IsWebhook() {
+		rateLimit, _ = m.webhookAppRateLimits.Get(config.App)
+	}
+	key := fmt.Sprintf("%d:%d", message.Type.GetValue(), config.UID.Int64())
+	rateLimitBo := bo.NewRateLimitBo(key, rateLimit)
+	if pointer.IsNil(rateLimitBo) {
+		return 0, true
+	}
+	wait, reserved, err := m.rateLimiterRepo.Reserve(ctx, rateLimitBo)
+	if err != nil {
+		m.helper.Warnw("msg", "reserve rate limit token failed, skip rate limit", "error", err, "uid", message.UID, "key", key)
+		return 0, true
+	}
+	return wait, reserved
+}
+
+// isNonRetryableError 参数类错误（配置无效、配置已禁用、不支持的消息类型、webhook 地址无效或返回 4xx 等）重试也不会成功
+func isNonRetryableError(err error) bool {
+	return merr.IsParams(err)
+}
+
+// getRetryPolicy 合并全局、命名空间和渠道配置的重试策略，优先级依次升高
+func (m *messageRepositoryImpl) getRetryPolicy(ctx context.Context, message *bo.MessageLogItemBo) *bo.RetryPolicyBo {
+	var namespaceRetryPolicy *do.RetryPolicy
+	namespace, err := m.namespaceRepo.GetNamespaceByName(ctx, middler.GetNamespace(ctx))
+	if err != nil {
+		m.helper.Warnw("msg", "get namespace failed, ignore namespace retry policy", "error", err, "uid", message.UID)
+	} else if pointer.IsNotNil(namespace.Metadata) {
+		namespaceRetryPolicy = bo.NewRetryPolicyFromMetadata(namespace.Metadata.Map())
+	}
+	return bo.NewRetryPolicyBo(m.retryPolicy, namespaceRetryPolicy, message.ConfigSnapshot().RetryPolicy)
+}
+
 // AppendMessage implements repository.Message.
-func (m *messageRepositoryImpl) AppendMessage(ctx context.Context, messageUID snowflake.ID) error {
+// 消息日志已经持久化，队列满时消息留在存储中等待拉取，视为已受理
+func (m *messageRepositoryImpl) AppendMessage(ctx context.Context, messageUID snowflake.ID, priority vobj.MessagePriority) error {
 	// 将消息放入channel异步处理
 	select {
 	case <-m.stopChan:
 		// channel已关闭，返回错误
 		m.helper.Debugw("msg", "message channel is closed, cannot append message", "uid", messageUID)
 		return merr.ErrorInternal("message channel is closed")
-	case m.messageChan <- &messageTask{ctx: safety.CopyValueCtx(ctx), messageUID: messageUID}:
-		m.helper.Debugw("msg", "message appended to channel", "uid", messageUID)
+	default:
+	}
+	// OUTBOX 模式下消息统一由轮询协程抢占，唤醒轮询协程即可
+	if m.useOutbox() {
+		m.wakeupOutbox()
 		return nil
+	}
+	if m.enqueue(ctx, messageUID, priority) {
+		m.helper.Debugw("msg", "message appended to channel", "uid", messageUID, "priority", priority)
+		return nil
+	}
+	m.spillMessage(ctx, messageUID)
+	return nil
+}
+
+// ScheduleMessage implements repository.Message.
+func (m *messageRepositoryImpl) ScheduleMessage(ctx context.Context, messageUID snowflake.ID, sendAt time.Time, priority vobj.MessagePriority) error {
+	// OUTBOX 模式下通过租约延迟抢占，延迟期间任意节点宕机都不影响消息发送
+	if m.useOutbox() {
+		return m.scheduleOutboxMessage(ctx, messageUID, sendAt)
+	}
+	if !sendAt.After(time.Now()) {
+		return m.AppendMessage(ctx, messageUID, priority)
+	}
+	select {
+	case <-m.stopChan:
+		m.helper.Debugw("msg", "message scheduler is stopped, cannot schedule message", "uid", messageUID)
+		return merr.ErrorInternal("message scheduler is stopped")
 	default:
-		// channel满了,返回错误
-		m.helper.Debugw("msg", "message channel is full", "uid", messageUID)
-		return merr.ErrorInternal("message channel is full")
 	}
+	m.scheduler.push(&scheduledTask{ctx: safety.CopyValueCtx(ctx), messageUID: messageUID, sendAt: sendAt, priority: priority})
+	m.helper.Debugw("msg", "message scheduled", "uid", messageUID, "sendAt", sendAt)
+	return nil
 }
 
 func (m *messageRepositoryImpl) initClusters() {
 	m.clusterInitOnce.Do(func() {
+		if m.clusterMaxFailures <= 0 {
+			m.clusterMaxFailures = 3
+		}
+		if m.clusterOpenDuration <= 0 {
+			m.clusterOpenDuration = 30 * time.Second
+		}
 		clusterConfig := m.bc.GetCluster()
 		clusterEndpoints := strutil.SplitSkipEmpty(clusterConfig.GetEndpoints(), ",")
 		clusterTimeout := clusterConfig.GetTimeout().AsDuration()
 		clusterName := clusterConfig.GetName()
 		protocol := clusterConfig.GetProtocol().String()
-		clusters := make([]sender.Sender, 0, len(clusterEndpoints))
+		clusters := make([]*clusterNode, 0, len(clusterEndpoints))
 		for idx, clusterEndpoint := range clusterEndpoints {
 			opts := []connect.InitOption{
 				connect.WithDiscovery(m.d.Registry()),
@@ -345,7 +742,7 @@ func (m *messageRepositoryImpl) initClusters() {
 			// 为每个端点生成唯一的键，避免覆盖之前的关闭函数
 			closeKey := fmt.Sprintf("jobClient.%d.%s", idx, clusterEndpoint)
 			m.d.AppendClose(closeKey, closeFunc)
-			clusters = append(clusters, clusterSender)
+			clusters = append(clusters, newClusterNode(clusterEndpoint, clusterSender))
 		}
 		// 加锁更新clusters
 		m.clustersMu.Lock()
@@ -0,0 +1,265 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aide-family/magicbox/pointer"
+	"github.com/aide-family/magicbox/safety"
+	"github.com/aide-family/magicbox/serialize"
+	"github.com/aide-family/magicbox/strutil"
+	klog "github.com/go-kratos/kratos/v2/log"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/conf"
+	"github.com/aide-family/rabbit/internal/data"
+	"github.com/aide-family/rabbit/pkg/middler"
+)
+
+const (
+	// callbackResponseBodyLimit 记录回调失败响应体的上限
+	callbackResponseBodyLimit = 1 << 10
+	// callbackPollInterval 轮询到期的待重试回调的间隔
+	callbackPollInterval = 5 * time.Second
+	// callbackPollBatchSize 每个命名空间每次轮询最多重试的回调数量
+	callbackPollBatchSize = 100
+)
+
+// errCallbackStopped 回调已停止，状态事件记录为待重试，由其他节点或重启后重试
+var errCallbackStopped = errors.New("message callback is stopped")
+
+func NewMessageCallbackRepository(
+	bc *conf.Bootstrap,
+	d *data.Data,
+	messageLogRepo repository.MessageLog,
+	namespaceRepo repository.Namespace,
+	helper *klog.Helper,
+) repository.MessageCallback {
+	callbackConf := bc.GetJobCore().GetCallback()
+	timeout := callbackConf.GetTimeout().AsDuration()
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	// 回调默认最多推送 5 次，其余字段使用内置的默认重试策略
+	callbackMaxAttempts := int32(5)
+	retryPolicy := bo.NewRetryPolicyBo(&do.RetryPolicy{MaxAttempts: &callbackMaxAttempts}, bo.NewDoRetryPolicy(callbackConf.GetRetryPolicy()))
+	callbackRepo := &messageCallbackRepositoryImpl{
+		messageLogRepo: messageLogRepo,
+		namespaceRepo:  namespaceRepo,
+		helper:         klog.NewHelper(klog.With(helper.Logger(), "impl", "messageCallback")),
+		client:         &http.Client{Timeout: timeout},
+		secret:         callbackConf.GetSecret(),
+		retryPolicy:    retryPolicy,
+		lease:          2 * timeout,
+		stopChan:       make(chan struct{}),
+	}
+	callbackRepo.runRetryPoller()
+	d.AppendClose("messageCallbackRepository", callbackRepo.stop)
+	return callbackRepo
+}
+
+type messageCallbackRepositoryImpl struct {
+	messageLogRepo repository.MessageLog
+	namespaceRepo  repository.Namespace
+	helper         *klog.Helper
+	client         *http.Client
+	secret         string            // 默认签名密钥
+	retryPolicy    *bo.RetryPolicyBo // 推送失败的重试策略
+	lease          time.Duration     // 抢占待重试回调的租约时长，节点在租约内没有完成重试时由其他节点接管
+	stopChan       chan struct{}
+	stopOnce       sync.Once
+	wg             sync.WaitGroup
+}
+
+// callbackTarget 生效的回调地址和签名密钥
+type callbackTarget struct {
+	url    string
+	secret string
+}
+
+// Notify implements repository.MessageCallback.
+// 回调地址优先使用发送请求中指定的地址，其次使用命名空间 metadata 的 callback.url，都为空时不推送
+// 第一次推送立即执行，失败后在回调日志中记录下一次推送时间，由轮询协程重试，进程重启不会丢失待重试的回调
+func (m *messageCallbackRepositoryImpl) Notify(ctx context.Context, event *bo.MessageCallbackEventBo) {
+	target := m.resolveTarget(ctx, event.GetCallbackURL())
+	if strutil.IsEmpty(target.url) {
+		return
+	}
+	body, err := serialize.JSONMarshal(event)
+	if err != nil {
+		m.helper.Errorw("msg", "marshal status event failed", "error", err, "uid", event.UID)
+		return
+	}
+	callbackCtx := safety.CopyValueCtx(ctx)
+	callbackLog := &do.MessageCallbackLog{
+		MessageLogID: event.UID,
+		Status:       event.GetStatus(),
+		URL:          target.url,
+		Attempt:      1,
+	}
+	select {
+	case <-m.stopChan:
+		m.helper.Warnw("msg", "message callback is stopped, save status event for retry", "uid", event.UID, "status", event.Status)
+		callbackLog.Attempt = 0
+		m.record(callbackCtx, callbackLog, body, 0, errCallbackStopped)
+		return
+	default:
+	}
+	m.wg.Go(func() {
+		m.deliver(callbackCtx, callbackLog, target.secret, body)
+	})
+}
+
+// resolveTarget 签名密钥不保存在回调日志中，重试时按命名空间当前的配置重新获取
+func (m *messageCallbackRepositoryImpl) resolveTarget(ctx context.Context, callbackURL string) *callbackTarget {
+	target := &callbackTarget{url: callbackURL, secret: m.secret}
+	namespace, err := m.namespaceRepo.GetNamespaceByName(ctx, middler.GetNamespace(ctx))
+	if err != nil {
+		m.helper.Warnw("msg", "get namespace failed, ignore namespace callback config", "error", err, "namespace", middler.GetNamespace(ctx))
+		return target
+	}
+	if pointer.IsNil(namespace.Metadata) {
+		return target
+	}
+	metadata := namespace.Metadata.Map()
+	if strutil.IsEmpty(target.url) {
+		target.url = metadata[bo.MetadataKeyCallbackURL]
+	}
+	if secret := metadata[bo.MetadataKeyCallbackSecret]; strutil.IsNotEmpty(secret) {
+		target.secret = secret
+	}
+	return target
+}
+
+// deliver 推送一次状态事件并记录回调日志
+func (m *messageCallbackRepositoryImpl) deliver(ctx context.Context, callbackLog *do.MessageCallbackLog, secret string, body []byte) {
+	statusCode, err := m.post(ctx, callbackLog.URL, secret, body)
+	m.record(ctx, callbackLog, body, statusCode, err)
+}
+
+// record 记录一次推送结果，失败且重试次数未用尽时记录下一次推送时间和事件内容
+func (m *messageCallbackRepositoryImpl) record(ctx context.Context, callbackLog *do.MessageCallbackLog, body []byte, statusCode int, err error) {
+	callbackLog.StatusCode, callbackLog.Success, callbackLog.CallbackAt = int32(statusCode), err == nil, time.Now()
+	if err != nil {
+		callbackLog.Error = err.Error()
+		if m.retryPolicy.CanRetry(callbackLog.Attempt) {
+			// 数据库按秒保存时间，截断后条件更新时才能精确匹配
+			nextAttemptAt := callbackLog.CallbackAt.Add(m.retryPolicy.NextDelay(callbackLog.Attempt)).Truncate(time.Second)
+			callbackLog.NextAttemptAt, callbackLog.Payload = &nextAttemptAt, string(body)
+		} else {
+			m.helper.Warnw("msg", "status callback failed, give up", "error", err, "uid", callbackLog.MessageLogID, "status", callbackLog.Status, "attempt", callbackLog.Attempt)
+		}
+	}
+	if createErr := m.messageLogRepo.CreateMessageCallbackLog(ctx, callbackLog); createErr != nil {
+		m.helper.Errorw("msg", "create message callback log failed", "error", createErr, "uid", callbackLog.MessageLogID)
+	}
+}
+
+// runRetryPoller 定时重试各命名空间到期的回调
+func (m *messageCallbackRepositoryImpl) runRetryPoller() {
+	const namespaceRefreshInterval = time.Minute
+	m.wg.Go(func() {
+		ctx := context.Background()
+		ticker := time.NewTicker(callbackPollInterval)
+		defer ticker.Stop()
+		var namespaces []*do.Namespace
+		var refreshedAt time.Time
+		for {
+			select {
+			case <-ticker.C:
+			case <-m.stopChan:
+				m.helper.Debug("msg", "message callback retry poller stopped")
+				return
+			}
+			if time.Since(refreshedAt) >= namespaceRefreshInterval {
+				items, err := listAllNamespaces(ctx, m.namespaceRepo)
+				if err != nil {
+					m.helper.Errorw("msg", "list namespaces failed, use cached namespaces", "error", err)
+				} else {
+					namespaces, refreshedAt = items, time.Now()
+				}
+			}
+			for _, namespace := range namespaces {
+				m.retryPending(middler.WithNamespace(ctx, namespace.Name))
+			}
+		}
+	})
+}
+
+// retryPending 抢占当前命名空间到期的待重试回调并重新推送
+// 抢占时将下一次推送时间设置为租约到期时间，推送完成后清空，节点在推送完成前退出时租约到期后由其他节点重试
+func (m *messageCallbackRepositoryImpl) retryPending(ctx context.Context) {
+	callbackLogs, err := m.messageLogRepo.ListPendingMessageCallbackLog(ctx, time.Now(), callbackPollBatchSize)
+	if err != nil {
+		m.helper.Errorw("msg", "list pending message callback logs failed", "error", err, "namespace", middler.GetNamespace(ctx))
+		return
+	}
+	for _, pending := range callbackLogs {
+		select {
+		case <-m.stopChan:
+			return
+		default:
+		}
+		leaseUntil := time.Now().Add(m.lease).Truncate(time.Second)
+		claimed, err := m.messageLogRepo.UpdateMessageCallbackLogNextAttemptIf(ctx, pending.UID, *pending.NextAttemptAt, &leaseUntil)
+		if err != nil {
+			m.helper.Errorw("msg", "claim pending message callback log failed", "error", err, "uid", pending.UID)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		callbackLog := &do.MessageCallbackLog{
+			MessageLogID: pending.MessageLogID,
+			Status:       pending.Status,
+			URL:          pending.URL,
+			Attempt:      pending.Attempt + 1,
+		}
+		m.deliver(ctx, callbackLog, m.resolveTarget(ctx, pending.URL).secret, []byte(pending.Payload))
+		if _, err := m.messageLogRepo.UpdateMessageCallbackLogNextAttemptIf(ctx, pending.UID, leaseUntil, nil); err != nil {
+			m.helper.Errorw("msg", "finish pending message callback log failed", "error", err, "uid", pending.UID)
+		}
+	}
+}
+
+// post 发送一次状态事件，2xx 响应视为成功
+func (m *messageCallbackRepositoryImpl) post(ctx context.Context, url, secret string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if strutil.IsNotEmpty(secret) {
+		sign := bo.NewSignatureBo(secret, &do.Signature{Enabled: true})
+		for key, value := range sign.Headers(body, time.Now()) {
+			req.Header.Set(key, value)
+		}
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, callbackResponseBodyLimit))
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, respBody)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+func (m *messageCallbackRepositoryImpl) stop() error {
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+		m.wg.Wait()
+	})
+	return nil
+}
@@ -0,0 +1,255 @@
+package impl
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+	"github.com/aide-family/rabbit/internal/conf"
+	"github.com/aide-family/rabbit/internal/data/impl/sender"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+)
+
+// dispatchModeLocal 文件模式下不使用集群，消息只在当前节点发送
+const dispatchModeLocal = "LOCAL"
+
+// latencyDecay 转发耗时指数移动平均中最新一次耗时的权重
+const latencyDecay = 0.2
+
+// clusterNode 集群节点的转发客户端和健康状态
+// 连续失败达到阈值后熔断，熔断期间由后台协程定时调用节点的健康检查接口，检查成功恢复健康，失败继续熔断
+type clusterNode struct {
+	endpoint string
+	sender   sender.Sender
+
+	mu                  sync.Mutex
+	state               vobj.ClusterNodeState
+	inflight            int64
+	successTotal        int64
+	failureTotal        int64
+	consecutiveFailures int32
+	avgLatency          time.Duration
+	lastError           string
+	lastErrorAt         time.Time
+	openedAt            time.Time
+}
+
+func newClusterNode(endpoint string, clusterSender sender.Sender) *clusterNode {
+	return &clusterNode{
+		endpoint: endpoint,
+		sender:   clusterSender,
+		state:    vobj.ClusterNodeStateHealthy,
+	}
+}
+
+// available 节点当前是否可以接收消息，熔断和探测中的节点不接收消息
+func (n *clusterNode) available() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.state.IsHealthy()
+}
+
+// acquire 占用一次转发
+func (n *clusterNode) acquire() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.state.IsHealthy() {
+		return false
+	}
+	n.inflight++
+	return true
+}
+
+// release 记录一次转发的结果，连续失败达到阈值时打开熔断，opened 为 true 时需要启动健康检查探测
+func (n *clusterNode) release(latency time.Duration, err error, failureThreshold int32) (opened bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.inflight--
+	if err == nil {
+		n.successTotal++
+		n.consecutiveFailures = 0
+		n.state = vobj.ClusterNodeStateHealthy
+		if n.avgLatency == 0 {
+			n.avgLatency = latency
+		} else {
+			n.avgLatency = time.Duration(latencyDecay*float64(latency) + (1-latencyDecay)*float64(n.avgLatency))
+		}
+		return false
+	}
+	n.failureTotal++
+	n.consecutiveFailures++
+	n.lastError = err.Error()
+	n.lastErrorAt = time.Now()
+	// 熔断后仍在转发中的消息失败时不重复打开熔断
+	if n.state.IsHealthy() && n.consecutiveFailures >= failureThreshold {
+		n.state = vobj.ClusterNodeStateCircuitOpen
+		n.openedAt = n.lastErrorAt
+		return true
+	}
+	return false
+}
+
+// startProbe 开始一次健康检查探测，熔断前仍在转发中的消息成功时节点已经恢复健康，不再探测
+func (n *clusterNode) startProbe() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.state.IsHealthy() {
+		return false
+	}
+	n.state = vobj.ClusterNodeStateHalfOpen
+	return true
+}
+
+// finishProbe 记录健康检查的结果，检查成功时恢复健康并返回 true，失败时重新计算熔断时间
+func (n *clusterNode) finishProbe(err error) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err == nil {
+		n.state = vobj.ClusterNodeStateHealthy
+		n.consecutiveFailures = 0
+		return true
+	}
+	n.state = vobj.ClusterNodeStateCircuitOpen
+	n.lastError = err.Error()
+	n.lastErrorAt = time.Now()
+	n.openedAt = n.lastErrorAt
+	return false
+}
+
+// score 节点排序依据，转发中消息少、平均耗时短的节点优先
+func (n *clusterNode) score() (int64, time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.inflight, n.avgLatency
+}
+
+func (n *clusterNode) snapshot(openDuration time.Duration) *bo.ClusterNodeItemBo {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	item := &bo.ClusterNodeItemBo{
+		Endpoint:            n.endpoint,
+		State:               n.state,
+		Inflight:            n.inflight,
+		SuccessTotal:        n.successTotal,
+		FailureTotal:        n.failureTotal,
+		ConsecutiveFailures: n.consecutiveFailures,
+		AvgLatency:          n.avgLatency,
+		LastError:           n.lastError,
+		LastErrorAt:         n.lastErrorAt,
+	}
+	if !n.state.IsHealthy() {
+		item.OpenedAt = n.openedAt
+		item.NextProbeAt = n.openedAt.Add(openDuration)
+	}
+	return item
+}
+
+// selectClusterNodes 返回当前健康的节点，按转发中消息数和平均耗时排序
+// 先打乱顺序，条件相同的节点之间随机分配
+func (m *messageRepositoryImpl) selectClusterNodes() []*clusterNode {
+	m.clustersMu.RLock()
+	nodes := make([]*clusterNode, 0, len(m.clusters))
+	for _, node := range m.clusters {
+		if node.available() {
+			nodes = append(nodes, node)
+		}
+	}
+	m.clustersMu.RUnlock()
+
+	rand.Shuffle(len(nodes), func(i, j int) {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	})
+	type nodeScore struct {
+		inflight int64
+		latency  time.Duration
+	}
+	scores := make(map[*clusterNode]nodeScore, len(nodes))
+	for _, node := range nodes {
+		inflight, latency := node.score()
+		scores[node] = nodeScore{inflight: inflight, latency: latency}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := scores[nodes[i]], scores[nodes[j]]
+		if a.inflight != b.inflight {
+			return a.inflight < b.inflight
+		}
+		return a.latency < b.latency
+	})
+	return nodes
+}
+
+// sendToCluster 依次尝试可用节点转发消息，全部失败或没有可用节点时返回 false
+func (m *messageRepositoryImpl) sendToCluster(ctx context.Context, req *apiv1.JobSendMessageRequest) bool {
+	for _, node := range m.selectClusterNodes() {
+		if !node.acquire() {
+			continue
+		}
+		startAt := time.Now()
+		reply, err := node.sender.SendMessage(ctx, req)
+		if node.release(time.Since(startAt), err, m.clusterMaxFailures) {
+			m.helper.Warnw("msg", "cluster node circuit opened", "cluster", node.endpoint, "error", err)
+			m.probeClusterNode(node)
+		}
+		if err != nil {
+			m.helper.Errorw("msg", "send message failed", "error", err, "uid", req.GetUid(), "reply", reply, "cluster", node.endpoint)
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// probeClusterNode 熔断期间每隔熔断时长调用一次节点的健康检查接口，检查成功后恢复健康并结束
+// 探测不占用业务消息，节点未恢复时消息不会被转发到该节点
+func (m *messageRepositoryImpl) probeClusterNode(node *clusterNode) {
+	m.wg.Go(func() {
+		ticker := time.NewTicker(m.clusterOpenDuration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-m.stopChan:
+				return
+			}
+			if !node.startProbe() {
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), m.clusterOpenDuration)
+			err := node.sender.HealthCheck(ctx)
+			cancel()
+			if node.finishProbe(err) {
+				m.helper.Infow("msg", "cluster node recovered", "cluster", node.endpoint)
+				return
+			}
+			m.helper.Debugw("msg", "cluster node health check failed", "cluster", node.endpoint, "error", err)
+		}
+	})
+}
+
+// ListClusterNodes implements repository.Message.
+func (m *messageRepositoryImpl) ListClusterNodes(ctx context.Context) *bo.ClusterNodesBo {
+	clusterNodesBo := &bo.ClusterNodesBo{
+		DispatchMode:       conf.JobCore_RPC.String(),
+		LocalFallbackTotal: m.localFallbackTotal.Load(),
+	}
+	switch {
+	case !m.d.UseDatabase():
+		clusterNodesBo.DispatchMode = dispatchModeLocal
+		return clusterNodesBo
+	case m.useOutbox():
+		clusterNodesBo.DispatchMode = conf.JobCore_OUTBOX.String()
+		return clusterNodesBo
+	}
+	m.initClusters()
+	m.clustersMu.RLock()
+	defer m.clustersMu.RUnlock()
+	clusterNodesBo.Nodes = make([]*bo.ClusterNodeItemBo, 0, len(m.clusters))
+	for _, node := range m.clusters {
+		clusterNodesBo.Nodes = append(clusterNodesBo.Nodes, node.snapshot(m.clusterOpenDuration))
+	}
+	return clusterNodesBo
+}
@@ -0,0 +1,155 @@
+package impl
+
+import (
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+)
+
+// lanePriorities 分发队列的优先级，从高到低
+var lanePriorities = []vobj.MessagePriority{
+	vobj.MessagePriorityCritical,
+	vobj.MessagePriorityHigh,
+	vobj.MessagePriorityNormal,
+	vobj.MessagePriorityLow,
+}
+
+// laneWeights 各优先级队列的分发权重，队列都有积压时按权重比例分发
+var laneWeights = map[vobj.MessagePriority]int{
+	vobj.MessagePriorityCritical: 8,
+	vobj.MessagePriorityHigh:     4,
+	vobj.MessagePriorityNormal:   2,
+	vobj.MessagePriorityLow:      1,
+}
+
+// laneNames 监控指标中的优先级标签
+var laneNames = map[vobj.MessagePriority]string{
+	vobj.MessagePriorityCritical: "critical",
+	vobj.MessagePriorityHigh:     "high",
+	vobj.MessagePriorityNormal:   "normal",
+	vobj.MessagePriorityLow:      "low",
+}
+
+// messageLanes 按优先级划分的分发队列
+// worker 按加权轮询顺序取消息，轮到的队列为空时按优先级从高到低取，低优先级消息按权重保证最低份额，不会被饿死
+type messageLanes struct {
+	lanes    map[vobj.MessagePriority]chan *messageTask
+	schedule []vobj.MessagePriority // 加权轮询顺序
+	notify   chan struct{}          // 有新消息时唤醒空闲的 worker
+}
+
+func newMessageLanes(size uint32) *messageLanes {
+	l := &messageLanes{
+		lanes:    make(map[vobj.MessagePriority]chan *messageTask, len(lanePriorities)),
+		schedule: newLaneSchedule(),
+		notify:   make(chan struct{}, 1),
+	}
+	for _, priority := range lanePriorities {
+		l.lanes[priority] = make(chan *messageTask, size)
+	}
+	return l
+}
+
+// newLaneSchedule 使用平滑加权轮询生成一轮分发顺序，避免同一优先级连续占用
+func newLaneSchedule() []vobj.MessagePriority {
+	total := 0
+	for _, weight := range laneWeights {
+		total += weight
+	}
+	current := make(map[vobj.MessagePriority]int, len(lanePriorities))
+	schedule := make([]vobj.MessagePriority, 0, total)
+	for range total {
+		var selected vobj.MessagePriority
+		for _, priority := range lanePriorities {
+			current[priority] += laneWeights[priority]
+			if selected == vobj.MessagePriorityUnknown || current[priority] > current[selected] {
+				selected = priority
+			}
+		}
+		current[selected] -= total
+		schedule = append(schedule, selected)
+	}
+	return schedule
+}
+
+// push 非阻塞地放入优先级对应的队列，队列已满时返回 false
+func (l *messageLanes) push(task *messageTask) bool {
+	task.priority = task.priority.Normalize()
+	select {
+	case l.lanes[task.priority] <- task:
+		l.wakeup()
+		return true
+	default:
+		return false
+	}
+}
+
+// pop 非阻塞地取出下一条消息，cursor 为 worker 在加权轮询顺序中的位置
+func (l *messageLanes) pop(cursor *int) (*messageTask, bool) {
+	scheduled := l.schedule[*cursor%len(l.schedule)]
+	*cursor++
+	if task, ok := l.tryPop(scheduled); ok {
+		return task, true
+	}
+	for _, priority := range lanePriorities {
+		if task, ok := l.tryPop(priority); ok {
+			return task, true
+		}
+	}
+	return nil, false
+}
+
+func (l *messageLanes) tryPop(priority vobj.MessagePriority) (*messageTask, bool) {
+	select {
+	case task, ok := <-l.lanes[priority]:
+		if !ok {
+			return nil, false
+		}
+		// 还有积压时继续唤醒其他空闲的 worker
+		if l.depth() > 0 {
+			l.wakeup()
+		}
+		return task, true
+	default:
+		return nil, false
+	}
+}
+
+func (l *messageLanes) wakeup() {
+	select {
+	case l.notify <- struct{}{}:
+	default:
+	}
+}
+
+// depth 全部队列的积压数量
+func (l *messageLanes) depth() int {
+	total := 0
+	for _, lane := range l.lanes {
+		total += len(lane)
+	}
+	return total
+}
+
+// full 全部队列都已满
+func (l *messageLanes) full() bool {
+	for _, lane := range l.lanes {
+		if len(lane) < cap(lane) {
+			return false
+		}
+	}
+	return true
+}
+
+// free 全部队列的剩余容量
+func (l *messageLanes) free() int {
+	total := 0
+	for _, lane := range l.lanes {
+		total += cap(lane) - len(lane)
+	}
+	return total
+}
+
+func (l *messageLanes) close() {
+	for _, lane := range l.lanes {
+		close(lane)
+	}
+}
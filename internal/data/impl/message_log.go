@@ -1,19 +1,198 @@
 package impl
 
 import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
 	klog "github.com/go-kratos/kratos/v2/log"
 
+	"github.com/aide-family/rabbit/internal/biz/bo"
 	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/biz/vobj"
 	"github.com/aide-family/rabbit/internal/conf"
 	"github.com/aide-family/rabbit/internal/data"
 	"github.com/aide-family/rabbit/internal/data/impl/dbimpl"
 	"github.com/aide-family/rabbit/internal/data/impl/fileimpl"
+	"github.com/aide-family/rabbit/pkg/middler"
+)
+
+const (
+	// messageLogWatchPollInterval 数据库模式下轮询状态变更的间隔
+	messageLogWatchPollInterval = time.Second
+	// messageLogWatchPollBatchSize 每个命名空间每次轮询最多读取的状态变更数
+	messageLogWatchPollBatchSize = 1000
+	// messageLogWatchLag 每次轮询向前多读取的时间，覆盖节点之间的时钟偏差和事务提交的延迟
+	messageLogWatchLag = 5 * time.Second
 )
 
-func NewMessageLogRepository(bc *conf.Bootstrap, d *data.Data, helper *klog.Helper) repository.MessageLog {
+func NewMessageLogRepository(bc *conf.Bootstrap, d *data.Data, watcher repository.MessageLogWatcher, helper *klog.Helper) repository.MessageLog {
 	newRepo := fileimpl.NewMessageLogRepository
 	if d.UseDatabase() {
 		newRepo = dbimpl.NewMessageLogRepository
 	}
-	return newRepo(bc, d, helper)
+	recoveryWindow := bc.GetJobCore().GetRecoveryWindow().AsDuration()
+	if recoveryWindow <= 0 {
+		recoveryWindow = 7 * 24 * time.Hour
+	}
+	repo := &watchedMessageLogRepository{
+		MessageLog:     newRepo(bc, d, helper),
+		watcher:        watcher,
+		helper:         klog.NewHelper(klog.With(helper.Logger(), "impl", "messageLog")),
+		polling:        d.UseDatabase(),
+		recoveryWindow: recoveryWindow,
+		stopChan:       make(chan struct{}),
+	}
+	if repo.polling {
+		repo.runWatchPoller()
+		d.AppendClose("messageLogWatchPoller", repo.stop)
+	}
+	return repo
+}
+
+// watchedMessageLogRepository 向订阅者广播消息状态变更
+// 文件模式下在状态更新成功后广播；数据库模式下消息可能由任意节点发送，轮询消息日志表中的状态变更时间广播全部节点上的变更
+type watchedMessageLogRepository struct {
+	repository.MessageLog
+	watcher        repository.MessageLogWatcher
+	helper         *klog.Helper
+	polling        bool          // 是否通过轮询消息日志表产生事件
+	recoveryWindow time.Duration // 轮询的周表范围，与消息恢复的时间窗口一致
+	stopChan       chan struct{}
+	stopOnce       sync.Once
+	wg             sync.WaitGroup
+}
+
+// UpdateMessageLogStatusIf implements repository.MessageLog.
+func (m *watchedMessageLogRepository) UpdateMessageLogStatusIf(ctx context.Context, uid snowflake.ID, oldStatus, newStatus vobj.MessageStatus) (bool, error) {
+	updated, err := m.MessageLog.UpdateMessageLogStatusIf(ctx, uid, oldStatus, newStatus)
+	if updated {
+		m.publish(ctx, uid, oldStatus)
+	}
+	return updated, err
+}
+
+// UpdateMessageLogRetryIf implements repository.MessageLog.
+func (m *watchedMessageLogRepository) UpdateMessageLogRetryIf(ctx context.Context, uid snowflake.ID, oldStatus, newStatus vobj.MessageStatus, lastError string) (bool, error) {
+	updated, err := m.MessageLog.UpdateMessageLogRetryIf(ctx, uid, oldStatus, newStatus, lastError)
+	if updated {
+		m.publish(ctx, uid, oldStatus)
+	}
+	return updated, err
+}
+
+// UpdateMessageLogSentIf implements repository.MessageLog.
+func (m *watchedMessageLogRepository) UpdateMessageLogSentIf(ctx context.Context, uid snowflake.ID, oldStatus vobj.MessageStatus, lastError string) (bool, error) {
+	updated, err := m.MessageLog.UpdateMessageLogSentIf(ctx, uid, oldStatus, lastError)
+	if updated {
+		m.publish(ctx, uid, oldStatus)
+	}
+	return updated, err
+}
+
+// ResetMessageLogRetryIf implements repository.MessageLog.
+func (m *watchedMessageLogRepository) ResetMessageLogRetryIf(ctx context.Context, uid snowflake.ID, oldStatus, newStatus vobj.MessageStatus) (bool, error) {
+	updated, err := m.MessageLog.ResetMessageLogRetryIf(ctx, uid, oldStatus, newStatus)
+	if updated {
+		m.publish(ctx, uid, oldStatus)
+	}
+	return updated, err
+}
+
+// publish 读取变更后的消息日志并广播，没有订阅者或由轮询产生事件时跳过
+func (m *watchedMessageLogRepository) publish(ctx context.Context, uid snowflake.ID, oldStatus vobj.MessageStatus) {
+	if m.polling || !m.watcher.HasWatcher() {
+		return
+	}
+	messageLog, err := m.MessageLog.GetMessageLog(ctx, uid)
+	if err != nil {
+		m.helper.Warnw("msg", "get message log failed, skip status event", "error", err, "uid", uid)
+		return
+	}
+	m.watcher.Publish(bo.NewMessageLogEventBo(middler.GetNamespace(ctx), oldStatus, messageLog))
+}
+
+// runWatchPoller 定时读取存在订阅者的命名空间的状态变更并广播
+// 每个命名空间从开始订阅的时间起按状态变更时间递增读取，每次向前多读取一段时间，重复读取的变更按 UID 和变更时间去重
+// 两次轮询之间同一消息的多次变更合并为最后一次
+func (m *watchedMessageLogRepository) runWatchPoller() {
+	m.wg.Go(func() {
+		ctx := context.Background()
+		ticker := time.NewTicker(messageLogWatchPollInterval)
+		defer ticker.Stop()
+		watermarks := make(map[string]time.Time)
+		published := make(map[string]map[snowflake.ID]time.Time)
+		for {
+			select {
+			case <-ticker.C:
+			case <-m.stopChan:
+				m.helper.Debug("msg", "message log watch poller stopped")
+				return
+			}
+			namespaces := m.watcher.Namespaces()
+			for namespace := range watermarks {
+				if !slices.Contains(namespaces, namespace) {
+					delete(watermarks, namespace)
+					delete(published, namespace)
+				}
+			}
+			for _, namespace := range namespaces {
+				watermark, ok := watermarks[namespace]
+				if !ok {
+					watermark, published[namespace] = time.Now(), make(map[snowflake.ID]time.Time)
+				}
+				watermarks[namespace] = m.pollStatusChanges(middler.WithNamespace(ctx, namespace), watermark, published[namespace])
+			}
+		}
+	})
+}
+
+// pollStatusChanges 广播 watermark 之前一段时间以来的状态变更，返回下一次轮询的起始时间
+// 一批读满时从这一批最后的变更时间继续读取，变更较多时不会反复读取同一批
+func (m *watchedMessageLogRepository) pollStatusChanges(ctx context.Context, watermark time.Time, published map[snowflake.ID]time.Time) time.Time {
+	namespace := middler.GetNamespace(ctx)
+	startAt := time.Now().Add(-m.recoveryWindow)
+	changedAfter := watermark.Add(-messageLogWatchLag)
+	for {
+		messageLogs, err := m.MessageLog.ListStatusChangedMessageLog(ctx, startAt, changedAfter, messageLogWatchPollBatchSize)
+		if err != nil {
+			m.helper.Errorw("msg", "list status changed message logs failed", "error", err, "namespace", namespace)
+			break
+		}
+		for _, messageLog := range messageLogs {
+			changedAt := *messageLog.StatusChangedAt
+			if publishedAt, ok := published[messageLog.UID]; ok && !changedAt.After(publishedAt) {
+				continue
+			}
+			published[messageLog.UID] = changedAt
+			m.watcher.Publish(bo.NewMessageLogEventBo(namespace, messageLog.PrevStatus, messageLog))
+			if changedAt.After(watermark) {
+				watermark = changedAt
+			}
+		}
+		if len(messageLogs) < messageLogWatchPollBatchSize {
+			break
+		}
+		lastChangedAt := *messageLogs[len(messageLogs)-1].StatusChangedAt
+		if !lastChangedAt.After(changedAfter) {
+			break
+		}
+		changedAfter = lastChangedAt
+	}
+	for uid, publishedAt := range published {
+		if publishedAt.Before(watermark.Add(-messageLogWatchLag)) {
+			delete(published, uid)
+		}
+	}
+	return watermark
+}
+
+func (m *watchedMessageLogRepository) stop() error {
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+		m.wg.Wait()
+	})
+	return nil
 }
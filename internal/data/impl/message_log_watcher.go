@@ -0,0 +1,121 @@
+package impl
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	klog "github.com/go-kratos/kratos/v2/log"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/data"
+)
+
+// messageLogWatchBuffer 每个订阅者缓冲的事件数，缓冲满时断开订阅者，由客户端重新订阅
+const messageLogWatchBuffer = 256
+
+func NewMessageLogWatcher(d *data.Data, helper *klog.Helper) repository.MessageLogWatcher {
+	watcher := &messageLogWatcherImpl{
+		helper:      klog.NewHelper(klog.With(helper.Logger(), "impl", "messageLogWatcher")),
+		subscribers: make(map[*messageLogSubscriber]struct{}),
+	}
+	d.AppendClose("messageLogWatcher", watcher.close)
+	return watcher
+}
+
+type messageLogWatcherImpl struct {
+	helper      *klog.Helper
+	mu          sync.RWMutex
+	subscribers map[*messageLogSubscriber]struct{}
+	closed      bool
+}
+
+type messageLogSubscriber struct {
+	filter *bo.WatchMessageLogBo
+	events chan *bo.MessageLogEventBo
+}
+
+// Publish implements repository.MessageLogWatcher.
+func (w *messageLogWatcherImpl) Publish(event *bo.MessageLogEventBo) {
+	var lagging []*messageLogSubscriber
+	w.mu.RLock()
+	for subscriber := range w.subscribers {
+		if !subscriber.filter.Match(event) {
+			continue
+		}
+		select {
+		case subscriber.events <- event:
+		default:
+			lagging = append(lagging, subscriber)
+		}
+	}
+	w.mu.RUnlock()
+
+	for _, subscriber := range lagging {
+		w.helper.Warnw("msg", "message log watcher is lagging behind, disconnect", "namespace", subscriber.filter.Namespace)
+		w.unsubscribe(subscriber)
+	}
+}
+
+// Watch implements repository.MessageLogWatcher.
+func (w *messageLogWatcherImpl) Watch(ctx context.Context, filter *bo.WatchMessageLogBo) <-chan *bo.MessageLogEventBo {
+	subscriber := &messageLogSubscriber{
+		filter: filter,
+		events: make(chan *bo.MessageLogEventBo, messageLogWatchBuffer),
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		close(subscriber.events)
+		return subscriber.events
+	}
+	w.subscribers[subscriber] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		w.unsubscribe(subscriber)
+	}()
+	return subscriber.events
+}
+
+// HasWatcher implements repository.MessageLogWatcher.
+func (w *messageLogWatcherImpl) HasWatcher() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return len(w.subscribers) > 0
+}
+
+// Namespaces implements repository.MessageLogWatcher.
+func (w *messageLogWatcherImpl) Namespaces() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	namespaces := make([]string, 0, len(w.subscribers))
+	for subscriber := range w.subscribers {
+		if !slices.Contains(namespaces, subscriber.filter.Namespace) {
+			namespaces = append(namespaces, subscriber.filter.Namespace)
+		}
+	}
+	return namespaces
+}
+
+// unsubscribe 移除订阅者并关闭事件通道，重复调用时忽略
+func (w *messageLogWatcherImpl) unsubscribe(subscriber *messageLogSubscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.subscribers[subscriber]; !ok {
+		return
+	}
+	delete(w.subscribers, subscriber)
+	close(subscriber.events)
+}
+
+func (w *messageLogWatcherImpl) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	for subscriber := range w.subscribers {
+		delete(w.subscribers, subscriber)
+		close(subscriber.events)
+	}
+	return nil
+}
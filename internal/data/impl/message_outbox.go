@@ -0,0 +1,113 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/aide-family/rabbit/internal/biz/do"
+	"github.com/aide-family/rabbit/internal/conf"
+	"github.com/aide-family/rabbit/pkg/merr"
+	"github.com/aide-family/rabbit/pkg/middler"
+)
+
+// useOutbox 数据库模式下由各节点轮询消息日志表抢占消息，不再通过 Job.SendMessage 转发
+func (m *messageRepositoryImpl) useOutbox() bool {
+	return m.d.UseDatabase() && m.dispatchMode == conf.JobCore_OUTBOX
+}
+
+// wakeupOutbox 通知轮询协程立即抢占一次，新消息不必等待下一个轮询周期
+func (m *messageRepositoryImpl) wakeupOutbox() {
+	select {
+	case m.outboxWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// scheduleOutboxMessage 将消息的租约设置为发送时间并释放持有者，到期后由任意节点抢占
+func (m *messageRepositoryImpl) scheduleOutboxMessage(ctx context.Context, messageUID snowflake.ID, sendAt time.Time) error {
+	if err := m.messageLogRepo.UpdateMessageLogLease(ctx, messageUID, sendAt); err != nil {
+		return merr.ErrorInternal("update message lease failed").WithCause(err)
+	}
+	if !sendAt.After(time.Now()) {
+		m.wakeupOutbox()
+	}
+	m.helper.Debugw("msg", "message scheduled by lease", "uid", messageUID, "sendAt", sendAt)
+	return nil
+}
+
+// runOutboxPoller 定时抢占到期的待发送消息放入本节点的工作队列
+// 租约到期前其他节点不会重复抢占，发送中的消息也持有租约，节点宕机时租约过期后由其他节点接管
+func (m *messageRepositoryImpl) runOutboxPoller(ctx context.Context) {
+	if !m.useOutbox() {
+		return
+	}
+	if m.outboxPollInterval <= 0 {
+		m.outboxPollInterval = time.Second
+	}
+	if m.outboxLease <= 0 {
+		m.outboxLease = time.Minute
+	}
+	if m.outboxBatchSize <= 0 {
+		m.outboxBatchSize = 100
+	}
+	const namespaceRefreshInterval = time.Minute
+	m.wg.Go(func() {
+		ticker := time.NewTicker(m.outboxPollInterval)
+		defer ticker.Stop()
+		var namespaces []*do.Namespace
+		var refreshedAt time.Time
+		for {
+			if time.Since(refreshedAt) >= namespaceRefreshInterval {
+				items, err := listAllNamespaces(ctx, m.namespaceRepo)
+				if err != nil {
+					m.helper.Errorw("msg", "list namespaces failed, use cached namespaces", "error", err)
+				} else {
+					namespaces, refreshedAt = items, time.Now()
+				}
+			}
+			m.pollOutboxMessages(ctx, namespaces)
+
+			select {
+			case <-ticker.C:
+			case <-m.outboxWakeup:
+			case <-m.stopChan:
+				m.helper.Debug("msg", "message outbox poller stopped by stop channel")
+				return
+			case <-ctx.Done():
+				m.helper.Debug("msg", "message outbox poller stopped by context done")
+				return
+			}
+		}
+	})
+}
+
+// pollOutboxMessages 按工作队列的剩余容量抢占各命名空间的到期消息
+// 没能放入工作队列的消息立即释放租约，交给其他节点处理
+func (m *messageRepositoryImpl) pollOutboxMessages(ctx context.Context, namespaces []*do.Namespace) {
+	startAt := time.Now().Add(-m.recoveryWindow)
+	for _, namespace := range namespaces {
+		free := min(m.lanes.free(), m.outboxBatchSize)
+		if free <= 0 {
+			return
+		}
+		namespaceCtx := middler.WithNamespace(ctx, namespace.Name)
+		messageLogs, err := m.messageLogRepo.ClaimMessageLogs(namespaceCtx, startAt, m.outboxOwner, time.Now().Add(m.outboxLease), free)
+		if err != nil {
+			m.helper.Errorw("msg", "claim outbox message logs failed", "error", err, "namespace", namespace.Name)
+			continue
+		}
+		for _, messageLog := range messageLogs {
+			if m.enqueue(namespaceCtx, messageLog.UID, messageLog.Priority) {
+				continue
+			}
+			if err := m.messageLogRepo.UpdateMessageLogLease(namespaceCtx, messageLog.UID, time.Now()); err != nil {
+				m.helper.Warnw("msg", "release outbox message lease failed", "error", err, "uid", messageLog.UID)
+			}
+		}
+		if len(messageLogs) > 0 {
+			m.helper.Debugw("msg", "outbox messages claimed", "namespace", namespace.Name, "total", len(messageLogs))
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package impl
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aide-family/magicbox/hello"
+	"github.com/aide-family/magicbox/strutil"
+	klog "github.com/go-kratos/kratos/v2/log"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/conf"
+	"github.com/aide-family/rabbit/internal/data"
+	"github.com/aide-family/rabbit/pkg/middler"
+)
+
+const (
+	// retentionLeaseName 过期清理任务的租约名称
+	retentionLeaseName = "message_log_retention"
+	// archiveFileSuffix 归档文件后缀，每行一条 JSON 记录并使用 gzip 压缩
+	archiveFileSuffix = ".jsonl.gz"
+)
+
+func NewMessageRetentionRepository(
+	bc *conf.Bootstrap,
+	d *data.Data,
+	messageLogRepo repository.MessageLog,
+	namespaceRepo repository.Namespace,
+	jobLeaseRepo repository.JobLease,
+	helper *klog.Helper,
+) repository.MessageRetention {
+	retentionConf := bc.GetJobCore().GetRetention()
+	retentionRepo := &messageRetentionRepositoryImpl{
+		messageLogRepo: messageLogRepo,
+		namespaceRepo:  namespaceRepo,
+		jobLeaseRepo:   jobLeaseRepo,
+		helper:         klog.NewHelper(klog.With(helper.Logger(), "impl", "messageRetention")),
+		defaultPolicy:  &bo.RetentionPolicyBo{Days: retentionConf.GetDays(), Archive: retentionConf.GetArchive()},
+		interval:       retentionConf.GetInterval().AsDuration(),
+		archivePath:    retentionConf.GetArchivePath(),
+		owner:          hello.ID(),
+		stopChan:       make(chan struct{}),
+	}
+	if retentionRepo.interval <= 0 {
+		retentionRepo.interval = time.Hour
+	}
+	if strutil.IsEmpty(retentionRepo.archivePath) {
+		baseDir, err := os.Getwd()
+		if err != nil {
+			retentionRepo.helper.Errorf("failed to get current directory: %v", err)
+			baseDir = "."
+		}
+		retentionRepo.archivePath = filepath.Join(baseDir, "archives")
+	}
+
+	retentionRepo.run()
+	d.AppendClose("messageRetentionRepository", retentionRepo.stop)
+	return retentionRepo
+}
+
+type messageRetentionRepositoryImpl struct {
+	messageLogRepo repository.MessageLog
+	namespaceRepo  repository.Namespace
+	jobLeaseRepo   repository.JobLease
+	helper         *klog.Helper
+	defaultPolicy  *bo.RetentionPolicyBo // 全局默认保留策略
+	interval       time.Duration         // 后台检查过期日志的间隔
+	archivePath    string                // 归档目录
+	owner          string                // 租约持有者，即当前节点 ID
+	stopChan       chan struct{}
+	stopOnce       sync.Once
+	wg             sync.WaitGroup
+}
+
+// PurgeMessageLogs implements repository.MessageRetention.
+func (m *messageRetentionRepositoryImpl) PurgeMessageLogs(ctx context.Context, req *bo.PurgeMessageLogBo) (*bo.PurgeMessageLogResultBo, error) {
+	namespace, err := m.namespaceRepo.GetNamespaceByName(ctx, middler.GetNamespace(ctx))
+	if err != nil {
+		return nil, err
+	}
+	policy := m.defaultPolicy.WithMetadata(namespace.Metadata)
+	if req.RetentionDays > 0 {
+		policy.Days = req.RetentionDays
+	}
+	return m.purge(ctx, policy, req.DryRun)
+}
+
+// run 定时清理全部命名空间的过期日志，数据库模式下通过租约保证同一时间只有一个节点执行
+// 租约时长为两个检查间隔，持有者每次检查时续约，持有者宕机后由其他节点接管
+func (m *messageRetentionRepositoryImpl) run() {
+	m.wg.Go(func() {
+		ctx := context.Background()
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			acquired, err := m.jobLeaseRepo.TryAcquire(ctx, retentionLeaseName, m.owner, 2*m.interval)
+			if err != nil {
+				m.helper.Errorw("msg", "acquire retention lease failed", "error", err)
+			}
+			if acquired {
+				m.purgeAllNamespaces(ctx)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-m.stopChan:
+				m.helper.Debug("msg", "message retention stopped")
+				return
+			}
+		}
+	})
+}
+
+func (m *messageRetentionRepositoryImpl) purgeAllNamespaces(ctx context.Context) {
+	namespaces, err := listAllNamespaces(ctx, m.namespaceRepo)
+	if err != nil {
+		m.helper.Errorw("msg", "list namespaces failed, skip message retention", "error", err)
+		return
+	}
+	for _, namespace := range namespaces {
+		select {
+		case <-m.stopChan:
+			return
+		default:
+		}
+		namespaceCtx := middler.WithNamespace(ctx, namespace.Name)
+		if _, err := m.purge(namespaceCtx, m.defaultPolicy.WithMetadata(namespace.Metadata), false); err != nil {
+			m.helper.Errorw("msg", "purge expired message logs failed", "error", err, "namespace", namespace.Name)
+		}
+	}
+}
+
+// purge 归档并删除在保留期之前结束的周，跳过仍有未完成消息的周，某一周失败时停止，已处理的周保留在结果中
+func (m *messageRetentionRepositoryImpl) purge(ctx context.Context, policy *bo.RetentionPolicyBo, dryRun bool) (*bo.PurgeMessageLogResultBo, error) {
+	result := &bo.PurgeMessageLogResultBo{
+		Policy:  policy,
+		DryRun:  dryRun,
+		Weeks:   make([]*bo.MessageLogWeekBo, 0),
+		Skipped: make([]*bo.MessageLogWeekBo, 0),
+	}
+	if policy.Days == 0 {
+		return result, nil
+	}
+	result.ExpiredBefore = policy.ExpiredBefore(time.Now())
+	weeks, err := m.messageLogRepo.ListMessageLogWeeks(ctx)
+	if err != nil {
+		return result, err
+	}
+	for _, week := range weeks {
+		if week.WeekEnd().After(result.ExpiredBefore) {
+			continue
+		}
+		// 仍有待发送（包括定时发送）、发送中或待重试的消息时整周保留，删除后这些消息将无法恢复发送
+		if week.Unfinished > 0 {
+			result.Skipped = append(result.Skipped, week)
+			m.helper.Warnw("msg", "skip purging week with unfinished message logs", "namespace", week.Namespace, "weekStart", week.WeekStart.Format(time.DateOnly), "unfinished", week.Unfinished)
+			continue
+		}
+		if dryRun {
+			result.Weeks = append(result.Weeks, week)
+			continue
+		}
+		if policy.Archive {
+			archives, err := m.archive(ctx, week)
+			if err != nil {
+				return result, err
+			}
+			week.Archives = archives
+		}
+		if err := m.messageLogRepo.PurgeMessageLogWeek(ctx, week); err != nil {
+			return result, err
+		}
+		result.Weeks = append(result.Weeks, week)
+		m.helper.Infow("msg", "purge expired message logs", "namespace", week.Namespace, "weekStart", week.WeekStart.Format(time.DateOnly), "total", week.Total, "archives", week.Archives)
+	}
+	return result, nil
+}
+
+// archive 将一周的日志按来源写入 {archivePath}/{namespace}/{source}.jsonl.gz
+// 先写入临时文件，全部写完后再重命名，失败时删除临时文件，不会留下不完整的归档
+func (m *messageRetentionRepositoryImpl) archive(ctx context.Context, week *bo.MessageLogWeekBo) ([]string, error) {
+	dir := filepath.Join(m.archivePath, week.Namespace)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	files := make(map[string]*archiveFile)
+	write := func(source string, record any) error {
+		file, ok := files[source]
+		if !ok {
+			newFile, err := newArchiveFile(filepath.Join(dir, source+archiveFileSuffix))
+			if err != nil {
+				return err
+			}
+			files[source], file = newFile, newFile
+		}
+		return file.write(record)
+	}
+	exportErr := m.messageLogRepo.ExportMessageLogWeek(ctx, week, write)
+
+	archives := make([]string, 0, len(files))
+	for _, file := range files {
+		if err := file.close(exportErr == nil); err != nil && exportErr == nil {
+			exportErr = err
+		}
+		archives = append(archives, file.path)
+	}
+	if exportErr != nil {
+		return nil, exportErr
+	}
+	sort.Strings(archives)
+	return archives, nil
+}
+
+// archiveFile 正在写入的归档文件
+type archiveFile struct {
+	path    string
+	file    *os.File
+	gz      *gzip.Writer
+	encoder *json.Encoder
+}
+
+func newArchiveFile(path string) (*archiveFile, error) {
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(file)
+	return &archiveFile{path: path, file: file, gz: gz, encoder: json.NewEncoder(gz)}, nil
+}
+
+func (a *archiveFile) write(record any) error {
+	return a.encoder.Encode(record)
+}
+
+// close 关闭文件，commit 为 true 时重命名为正式的归档文件，否则删除临时文件
+func (a *archiveFile) close(commit bool) error {
+	err := a.gz.Close()
+	if closeErr := a.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || !commit {
+		_ = os.Remove(a.file.Name())
+		return err
+	}
+	return os.Rename(a.file.Name(), a.path)
+}
+
+func (m *messageRetentionRepositoryImpl) stop() error {
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+		m.wg.Wait()
+	})
+	return nil
+}
@@ -0,0 +1,89 @@
+package impl
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+)
+
+// scheduledTask 等待到期发送的消息
+type scheduledTask struct {
+	ctx        context.Context
+	messageUID snowflake.ID
+	sendAt     time.Time
+	priority   vobj.MessagePriority
+}
+
+// scheduledTasks 按发送时间排序的最小堆
+type scheduledTasks []*scheduledTask
+
+func (s scheduledTasks) Len() int           { return len(s) }
+func (s scheduledTasks) Less(i, j int) bool { return s[i].sendAt.Before(s[j].sendAt) }
+func (s scheduledTasks) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func (s *scheduledTasks) Push(x any) {
+	*s = append(*s, x.(*scheduledTask))
+}
+
+func (s *scheduledTasks) Pop() any {
+	old := *s
+	n := len(old)
+	task := old[n-1]
+	old[n-1] = nil
+	*s = old[:n-1]
+	return task
+}
+
+// messageScheduler 延迟队列，保存未到发送时间的消息
+type messageScheduler struct {
+	mu     sync.Mutex
+	tasks  scheduledTasks
+	wakeup chan struct{} // 有新任务加入时唤醒调度协程，重新计算等待时间
+}
+
+func newMessageScheduler() *messageScheduler {
+	return &messageScheduler{
+		tasks:  make(scheduledTasks, 0),
+		wakeup: make(chan struct{}, 1),
+	}
+}
+
+// push 加入延迟任务
+func (s *messageScheduler) push(task *scheduledTask) {
+	s.mu.Lock()
+	heap.Push(&s.tasks, task)
+	s.mu.Unlock()
+
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// popDue 取出所有已到期的任务
+func (s *messageScheduler) popDue(now time.Time) []*scheduledTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tasks []*scheduledTask
+	for s.tasks.Len() > 0 && !s.tasks[0].sendAt.After(now) {
+		tasks = append(tasks, heap.Pop(&s.tasks).(*scheduledTask))
+	}
+	return tasks
+}
+
+// nextSendAt 返回最近一个任务的发送时间
+func (s *messageScheduler) nextSendAt() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tasks.Len() == 0 {
+		return time.Time{}, false
+	}
+	return s.tasks[0].sendAt, true
+}
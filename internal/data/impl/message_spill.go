@@ -0,0 +1,134 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aide-family/magicbox/safety"
+	"github.com/bwmarrin/snowflake"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+	"github.com/aide-family/rabbit/pkg/middler"
+)
+
+var (
+	messageSpilledTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rabbit_message_spilled_total",
+		Help: "Total number of messages left in storage because the work queue was full.",
+	}, []string{"namespace"})
+	messageSpillRecoveredTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rabbit_message_spill_recovered_total",
+		Help: "Total number of spilled messages pulled back from storage into the work queue.",
+	}, []string{"namespace"})
+)
+
+// registerQueueMetrics 注册工作队列的监控指标，重复注册时忽略
+func (m *messageRepositoryImpl) registerQueueMetrics() {
+	collectors := []prometheus.Collector{
+		messageSpilledTotal,
+		messageSpillRecoveredTotal,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "rabbit_message_spill_namespaces",
+			Help: "Number of namespaces with spilled messages waiting to be pulled back.",
+		}, func() float64 { return float64(m.spilledNamespaces.Len()) }),
+	}
+	for _, priority := range lanePriorities {
+		lane := m.lanes.lanes[priority]
+		labels := prometheus.Labels{"priority": laneNames[priority]}
+		collectors = append(collectors,
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Name:        "rabbit_message_queue_depth",
+				Help:        "Number of messages waiting in the work queue of each priority.",
+				ConstLabels: labels,
+			}, func() float64 { return float64(len(lane)) }),
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Name:        "rabbit_message_queue_capacity",
+				Help:        "Capacity of the work queue of each priority.",
+				ConstLabels: labels,
+			}, func() float64 { return float64(cap(lane)) }),
+		)
+	}
+	for _, collector := range collectors {
+		if err := prometheus.Register(collector); err != nil {
+			var alreadyRegisteredErr prometheus.AlreadyRegisteredError
+			if !errors.As(err, &alreadyRegisteredErr) {
+				m.helper.Warnw("msg", "register message queue metrics failed", "error", err)
+			}
+		}
+	}
+}
+
+// spillMessage 工作队列已满时消息留在存储中，由 runSpillPoller 在队列空闲时重新拉取
+// 失败状态的消息（手动重试）重置为待处理，保证能被拉取到
+func (m *messageRepositoryImpl) spillMessage(ctx context.Context, messageUID snowflake.ID) {
+	namespace := middler.GetNamespace(ctx)
+	if _, err := m.messageLogRepo.UpdateMessageLogStatusIf(ctx, messageUID, vobj.MessageStatusFailed, vobj.MessageStatusPending); err != nil {
+		m.helper.Warnw("msg", "reset spilled message to pending failed", "error", err, "uid", messageUID)
+	}
+	m.spilledNamespaces.Set(namespace, safety.CopyValueCtx(ctx))
+	messageSpilledTotal.WithLabelValues(namespace).Inc()
+	m.helper.Debugw("msg", "message channel is full, message spilled to storage", "uid", messageUID, "namespace", namespace)
+}
+
+// runSpillPoller 定时从存储中拉取因队列已满而溢出的待处理消息
+func (m *messageRepositoryImpl) runSpillPoller(ctx context.Context) {
+	if m.spillPollInterval <= 0 {
+		m.spillPollInterval = 10 * time.Second
+	}
+	m.wg.Go(func() {
+		ticker := time.NewTicker(m.spillPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.pollSpilledMessages()
+			case <-m.stopChan:
+				m.helper.Debug("msg", "message spill poller stopped by stop channel")
+				return
+			case <-ctx.Done():
+				m.helper.Debug("msg", "message spill poller stopped by context done")
+				return
+			}
+		}
+	})
+}
+
+// pollSpilledMessages 将存在溢出消息的命名空间中已到期的待处理消息重新放入工作队列
+// 队列再次占满时保留溢出标记，等待下一轮拉取
+func (m *messageRepositoryImpl) pollSpilledMessages() {
+	for namespace, namespaceCtx := range m.spilledNamespaces.Map() {
+		if m.lanes.full() {
+			return
+		}
+		m.spilledNamespaces.Delete(namespace)
+		messageLogs, err := m.messageLogRepo.ListUnfinishedMessageLog(namespaceCtx, time.Now().Add(-m.recoveryWindow))
+		if err != nil {
+			m.helper.Errorw("msg", "list spilled message logs failed", "error", err, "namespace", namespace)
+			m.spilledNamespaces.Set(namespace, namespaceCtx)
+			continue
+		}
+		now := time.Now()
+		var recovered float64
+		for _, messageLog := range messageLogs {
+			if !messageLog.Status.IsPending() || messageLog.SendAt.After(now) {
+				continue
+			}
+			if !m.enqueue(namespaceCtx, messageLog.UID, messageLog.Priority) {
+				m.spilledNamespaces.Set(namespace, namespaceCtx)
+				break
+			}
+			recovered++
+		}
+		messageSpillRecoveredTotal.WithLabelValues(namespace).Add(recovered)
+		if recovered > 0 {
+			m.helper.Infow("msg", "spilled messages pulled back to work queue", "namespace", namespace, "total", recovered)
+		}
+	}
+}
+
+// enqueue 非阻塞地将消息放入优先级对应的工作队列，队列已满时返回 false
+func (m *messageRepositoryImpl) enqueue(ctx context.Context, messageUID snowflake.ID, priority vobj.MessagePriority) bool {
+	return m.lanes.push(&messageTask{ctx: safety.CopyValueCtx(ctx), messageUID: messageUID, priority: priority})
+}
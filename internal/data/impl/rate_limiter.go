@@ -0,0 +1,16 @@
+package impl
+
+import (
+	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/data"
+	"github.com/aide-family/rabbit/internal/data/impl/dbimpl"
+	"github.com/aide-family/rabbit/internal/data/impl/fileimpl"
+)
+
+func NewRateLimiterRepository(d *data.Data) repository.RateLimiter {
+	newRepo := fileimpl.NewRateLimiterRepository
+	if d.UseDatabase() {
+		newRepo = dbimpl.NewRateLimiterRepository
+	}
+	return newRepo(d)
+}
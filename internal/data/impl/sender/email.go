@@ -3,14 +3,15 @@ package sender
 
 import (
 	"context"
+	"io"
+	"net/textproto"
 	"strings"
 
-	"github.com/aide-family/magicbox/message"
-	"github.com/aide-family/magicbox/message/email"
 	"github.com/aide-family/magicbox/safety"
 	"github.com/aide-family/magicbox/serialize"
 	"github.com/aide-family/magicbox/strutil"
 	klog "github.com/go-kratos/kratos/v2/log"
+	"gopkg.in/gomail.v2"
 
 	"github.com/aide-family/rabbit/internal/biz/bo"
 	"github.com/aide-family/rabbit/internal/biz/repository"
@@ -22,14 +23,14 @@ import (
 func NewEmailSender(helper *klog.Helper) repository.MessageSender {
 	return &emailSender{
 		helper:     klog.NewHelper(klog.With(helper.Logger(), "impl.sender", "email")),
-		senders:    safety.NewSyncMap(make(map[int64]message.Sender)),
+		dialers:    safety.NewSyncMap(make(map[int64]*gomail.Dialer)),
 		sendHashes: safety.NewSyncMap(make(map[int64]string)),
 	}
 }
 
 type emailSender struct {
 	helper     *klog.Helper
-	senders    *safety.SyncMap[int64, message.Sender]
+	dialers    *safety.SyncMap[int64, *gomail.Dialer]
 	sendHashes *safety.SyncMap[int64, string]
 }
 
@@ -40,63 +41,99 @@ func (e *emailSender) Type() vobj.MessageType {
 
 // Send 发送邮件
 func (e *emailSender) Send(ctx context.Context, messageLog *bo.MessageLogItemBo) error {
-	msg, err := e.buildEmailMessage([]byte(string(messageLog.Message)))
-	if err != nil {
+	var emailMessage bo.SendEmailBo
+	if err := serialize.JSONUnmarshal([]byte(string(messageLog.Message)), &emailMessage); err != nil {
+		e.helper.Errorw("msg", "unmarshal email message failed", "error", err)
 		return merr.ErrorInternal("convert to email message failed").WithCause(err)
 	}
-	sender, err := e.getSender([]byte(string(messageLog.Config)))
+	emailConfig, dialer, err := e.getDialer([]byte(string(messageLog.Config)))
 	if err != nil {
 		return err
 	}
-	if err := sender.Send(ctx, msg); err != nil {
+	if err := sendMail(ctx, dialer, e.buildEmailMessage(emailConfig, &emailMessage)); err != nil {
 		e.helper.Errorw("msg", "send email failed", "error", err, "uid", messageLog.UID)
 		return merr.ErrorInternal("send email failed").WithCause(err)
 	}
 	return nil
 }
 
-func (e *emailSender) buildEmailMessage(messageBytes []byte) (*email.Message, error) {
-	var emailMessage bo.SendEmailBo
-	if err := serialize.JSONUnmarshal(messageBytes, &emailMessage); err != nil {
-		e.helper.Errorw("msg", "unmarshal email message failed", "error", err)
-		return nil, merr.ErrorInternal("unmarshal email message failed")
-	}
+// reservedEmailHeaders 由发送器设置的请求头，自定义请求头中的同名项被忽略
+// 地址类请求头决定实际收件人，MIME 类请求头由邮件正文和附件生成
+var reservedEmailHeaders = map[string]struct{}{
+	"From":                      {},
+	"Sender":                    {},
+	"To":                        {},
+	"Cc":                        {},
+	"Bcc":                       {},
+	"Subject":                   {},
+	"Mime-Version":              {},
+	"Content-Type":              {},
+	"Content-Transfer-Encoding": {},
+}
 
-	return &email.Message{
-		To:          emailMessage.To,
-		Cc:          emailMessage.Cc,
-		Subject:     emailMessage.Subject,
-		Body:        emailMessage.Body,
-		ContentType: emailMessage.ContentType,
-		Attachments: []*email.Attachment{},
-		Headers:     emailMessage.Headers,
-	}, nil
+func (e *emailSender) buildEmailMessage(emailConfig *bo.EmailConfigItemBo, emailMessage *bo.SendEmailBo) *gomail.Message {
+	msg := gomail.NewMessage(gomail.SetCharset("UTF-8"), gomail.SetEncoding(gomail.Base64))
+	// 自定义请求头先写入，发件人、收件人和主题随后覆盖，模板和请求不能通过请求头修改
+	for key, values := range emailMessage.Headers {
+		if _, ok := reservedEmailHeaders[textproto.CanonicalMIMEHeaderKey(key)]; ok {
+			continue
+		}
+		msg.SetHeader(key, values...)
+	}
+	msg.SetHeader("From", emailConfig.Username)
+	msg.SetHeader("To", emailMessage.To...)
+	if len(emailMessage.Cc) > 0 {
+		msg.SetHeader("Cc", emailMessage.Cc...)
+	}
+	msg.SetHeader("Subject", emailMessage.Subject)
+	// HTML 邮件以 multipart/alternative 发送，纯文本部分在前，不支持 HTML 的客户端显示纯文本
+	if emailMessage.IsHTML() {
+		msg.SetBody("text/plain", emailMessage.PlainText())
+		msg.AddAlternative(emailMessage.ContentType, emailMessage.Body)
+	} else {
+		msg.SetBody(emailMessage.ContentType, emailMessage.Body)
+	}
+	for _, attachment := range emailMessage.Attachments {
+		header := map[string][]string{"Content-Type": {attachment.ContentType}}
+		settings := []gomail.FileSetting{
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(attachment.Content)
+				return err
+			}),
+		}
+		// 内嵌图片以 multipart/related 发送，HTML 正文中通过 cid:{contentId} 引用
+		if attachment.Inline {
+			header["Content-ID"] = []string{"<" + attachment.ContentID + ">"}
+			msg.Embed(attachment.Filename, append(settings, gomail.SetHeader(header))...)
+			continue
+		}
+		msg.Attach(attachment.Filename, append(settings, gomail.SetHeader(header))...)
+	}
+	return msg
 }
 
-func (e *emailSender) getSender(emailConfigBytes []byte) (message.Sender, error) {
+func (e *emailSender) getDialer(emailConfigBytes []byte) (*bo.EmailConfigItemBo, *gomail.Dialer, error) {
 	var emailConfig bo.EmailConfigItemBo
 	if err := serialize.JSONUnmarshal(emailConfigBytes, &emailConfig); err != nil {
 		e.helper.Errorw("msg", "unmarshal email config failed", "error", err)
-		return nil, merr.ErrorInternal("unmarshal email config failed")
+		return nil, nil, merr.ErrorInternal("unmarshal email config failed")
+	}
+	if emailConfig.Status.IsDisabled() {
+		return nil, nil, merr.ErrorParams("email config %s(%s) is disabled", emailConfig.Name, emailConfig.UID)
 	}
 	sendHash := strutil.SHA256(string(emailConfigBytes))
 	hash, ok := e.sendHashes.Get(emailConfig.UID.Int64())
 	if ok && strings.EqualFold(sendHash, hash) {
-		sender, ok := e.senders.Get(emailConfig.UID.Int64())
+		dialer, ok := e.dialers.Get(emailConfig.UID.Int64())
 		if !ok {
 			e.helper.Errorw("msg", "email sender not found", "uid", emailConfig.UID)
-			return nil, merr.ErrorParams("email sender not found")
+			return nil, nil, merr.ErrorParams("email sender not found")
 		}
-		return sender, nil
+		return &emailConfig, dialer, nil
 	}
 
-	driver := email.SenderDriver(&emailConfig)
-	sender, err := message.NewSender(driver)
-	if err != nil {
-		e.helper.Errorw("msg", "create email sender failed", "error", err)
-		return nil, merr.ErrorInternal("create email sender failed").WithCause(err)
-	}
-	e.senders.Set(emailConfig.UID.Int64(), sender)
+	dialer := gomail.NewDialer(emailConfig.Host, int(emailConfig.Port), emailConfig.Username, emailConfig.Password)
+	e.dialers.Set(emailConfig.UID.Int64(), dialer)
 	e.sendHashes.Set(emailConfig.UID.Int64(), sendHash)
-	return sender, nil
+	return &emailConfig, dialer, nil
 }
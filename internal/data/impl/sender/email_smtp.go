@@ -0,0 +1,164 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"gopkg.in/gomail.v2"
+)
+
+// sendMail 使用 dialer 的配置连接 SMTP 服务器并发送邮件，与 gomail.Dialer.DialAndSend 的握手流程一致
+// gomail 的连接不受上下文控制，SMTP 服务器无响应时会一直阻塞发送协程
+// 这里连接的读写截止时间跟随上下文，超时或取消时立即中断连接
+func sendMail(ctx context.Context, dialer *gomail.Dialer, msg *gomail.Message) error {
+	var netDialer net.Dialer
+	conn, err := netDialer.DialContext(ctx, "tcp", net.JoinHostPort(dialer.Host, fmt.Sprint(dialer.Port)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer stop()
+
+	if dialer.SSL {
+		conn = tls.Client(conn, smtpTLSConfig(dialer))
+	}
+	client, err := smtp.NewClient(conn, dialer.Host)
+	if err != nil {
+		return contextError(ctx, err)
+	}
+	defer client.Close()
+	if dialer.LocalName != "" {
+		if err := client.Hello(dialer.LocalName); err != nil {
+			return contextError(ctx, err)
+		}
+	}
+	if !dialer.SSL {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(smtpTLSConfig(dialer)); err != nil {
+				return contextError(ctx, err)
+			}
+		}
+	}
+	if auth := smtpAuth(client, dialer); auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return contextError(ctx, err)
+		}
+	}
+
+	err = gomail.Send(gomail.SendFunc(func(from string, to []string, message io.WriterTo) error {
+		if err := client.Mail(from); err != nil {
+			return err
+		}
+		for _, addr := range to {
+			if err := client.Rcpt(addr); err != nil {
+				return err
+			}
+		}
+		w, err := client.Data()
+		if err != nil {
+			return err
+		}
+		if _, err := message.WriteTo(w); err != nil {
+			_ = w.Close()
+			return err
+		}
+		return w.Close()
+	}), msg)
+	if err != nil {
+		return contextError(ctx, err)
+	}
+	return contextError(ctx, client.Quit())
+}
+
+// contextError 连接因上下文超时或取消被中断时，返回上下文的错误而不是连接错误
+func contextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+	return err
+}
+
+func smtpTLSConfig(dialer *gomail.Dialer) *tls.Config {
+	if dialer.TLSConfig == nil {
+		return &tls.Config{ServerName: dialer.Host}
+	}
+	return dialer.TLSConfig
+}
+
+// smtpAuth 按服务器支持的认证方式选择认证，与 gomail 的选择顺序一致
+// 不修改共享的 dialer，同一配置的多个发送协程可以并发使用
+func smtpAuth(client *smtp.Client, dialer *gomail.Dialer) smtp.Auth {
+	if dialer.Auth != nil {
+		return dialer.Auth
+	}
+	if dialer.Username == "" {
+		return nil
+	}
+	ok, auths := client.Extension("AUTH")
+	if !ok {
+		return nil
+	}
+	switch {
+	case strings.Contains(auths, "CRAM-MD5"):
+		return smtp.CRAMMD5Auth(dialer.Username, dialer.Password)
+	case strings.Contains(auths, "LOGIN") && !strings.Contains(auths, "PLAIN"):
+		return &smtpLoginAuth{username: dialer.Username, password: dialer.Password, host: dialer.Host}
+	default:
+		return smtp.PlainAuth("", dialer.Username, dialer.Password, dialer.Host)
+	}
+}
+
+// smtpLoginAuth LOGIN 认证，非 TLS 连接只在服务器声明支持 LOGIN 时发送密码
+type smtpLoginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *smtpLoginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS {
+		advertised := false
+		for _, mechanism := range server.Auth {
+			if mechanism == "LOGIN" {
+				advertised = true
+				break
+			}
+		}
+		if !advertised {
+			return "", nil, errors.New("unencrypted connection")
+		}
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *smtpLoginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch {
+	case bytes.Equal(fromServer, []byte("Username:")):
+		return []byte(a.username), nil
+	case bytes.Equal(fromServer, []byte("Password:")):
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+	}
+}
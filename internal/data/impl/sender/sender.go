@@ -12,6 +12,8 @@ import (
 
 type Sender interface {
 	SendMessage(ctx context.Context, req *apiv1.JobSendMessageRequest) (*apiv1.JobSendReply, error)
+	// HealthCheck calls the health check endpoint of the cluster node, used to probe a node whose circuit is open
+	HealthCheck(ctx context.Context) error
 	Close() error
 }
 
@@ -37,6 +39,11 @@ func (c *clusterSender) SendMessage(ctx context.Context, req *apiv1.JobSendMessa
 	return apiv1.NewJobClient(c.conn).SendMessage(ctx, req)
 }
 
+func (c *clusterSender) HealthCheck(ctx context.Context) error {
+	_, err := apiv1.NewHealthClient(c.conn).HealthCheck(ctx, &apiv1.HealthCheckRequest{})
+	return err
+}
+
 func (c *clusterSender) Close() error {
 	if pointer.IsNotNil(c.conn) {
 		return c.conn.Close()
@@ -52,6 +59,11 @@ func (c *clusterHTTPSender) SendMessage(ctx context.Context, req *apiv1.JobSendM
 	return apiv1.NewJobHTTPClient(c.client).SendMessage(ctx, req)
 }
 
+func (c *clusterHTTPSender) HealthCheck(ctx context.Context) error {
+	_, err := apiv1.NewHealthHTTPClient(c.client).HealthCheck(ctx, &apiv1.HealthCheckRequest{})
+	return err
+}
+
 func (c *clusterHTTPSender) Close() error {
 	if pointer.IsNotNil(c.client) {
 		return c.client.Close()
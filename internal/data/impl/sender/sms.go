@@ -0,0 +1,105 @@
+// Package sender implements message senders for different message types.
+package sender
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aide-family/magicbox/safety"
+	"github.com/aide-family/magicbox/serialize"
+	"github.com/aide-family/magicbox/strutil"
+	klog "github.com/go-kratos/kratos/v2/log"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+	"github.com/aide-family/rabbit/internal/data/impl/sender/sms"
+	"github.com/aide-family/rabbit/pkg/merr"
+)
+
+// NewSMSSender 创建短信发送器
+func NewSMSSender(helper *klog.Helper) repository.MessageSender {
+	return &smsSender{
+		helper:     klog.NewHelper(klog.With(helper.Logger(), "impl.sender", "sms")),
+		drivers:    safety.NewSyncMap(make(map[int64]sms.Driver)),
+		sendHashes: safety.NewSyncMap(make(map[int64]string)),
+	}
+}
+
+type smsSender struct {
+	helper     *klog.Helper
+	drivers    *safety.SyncMap[int64, sms.Driver]
+	sendHashes *safety.SyncMap[int64, string]
+}
+
+// Type 返回发送器支持的消息类型
+func (s *smsSender) Type() vobj.MessageType {
+	return vobj.MessageTypeSMS
+}
+
+// Send 发送短信
+func (s *smsSender) Send(ctx context.Context, messageLog *bo.MessageLogItemBo) error {
+	var smsMessage bo.SendSMSBo
+	if err := serialize.JSONUnmarshal([]byte(string(messageLog.Message)), &smsMessage); err != nil {
+		return merr.ErrorInternal("unmarshal sms message failed").WithCause(err)
+	}
+	smsConfig, driver, err := s.getDriver([]byte(string(messageLog.Config)))
+	if err != nil {
+		return err
+	}
+	if err := driver.Send(ctx, &sms.Message{
+		PhoneNumbers:   smsMessage.PhoneNumbers,
+		TemplateCode:   smsMessage.TemplateCode,
+		TemplateParams: smsMessage.TemplateParams,
+		Content:        smsMessage.Content,
+	}); err != nil {
+		var partialErr *sms.PartialError
+		if errors.As(err, &partialErr) {
+			s.helper.Warnw("msg", "send sms to some phone numbers failed", "error", err, "uid", messageLog.UID, "provider", smsConfig.Provider)
+			return bo.NewPartialSendError(err.Error())
+		}
+		s.helper.Errorw("msg", "send sms failed", "error", err, "uid", messageLog.UID, "provider", smsConfig.Provider)
+		return merr.ErrorInternal("send sms failed").WithCause(err)
+	}
+	if smsConfig.Provider == vobj.SMSProviderMock {
+		s.helper.Infow("msg", "mock sms sent", "uid", messageLog.UID, "phoneNumbers", smsMessage.PhoneNumbers,
+			"templateCode", smsMessage.TemplateCode, "templateParams", smsMessage.TemplateParams, "content", smsMessage.Content)
+	}
+	return nil
+}
+
+func (s *smsSender) getDriver(configBytes []byte) (*bo.SMSConfigItemBo, sms.Driver, error) {
+	var smsConfig bo.SMSConfigItemBo
+	if err := serialize.JSONUnmarshal(configBytes, &smsConfig); err != nil {
+		return nil, nil, merr.ErrorInternal("unmarshal sms config failed").WithCause(err)
+	}
+	if smsConfig.Status.IsDisabled() {
+		return nil, nil, merr.ErrorParams("sms config %s(%s) is disabled", smsConfig.Name, smsConfig.UID)
+	}
+	sendHash := strutil.SHA256(string(configBytes))
+	hash, ok := s.sendHashes.Get(smsConfig.UID.Int64())
+	if ok && strings.EqualFold(sendHash, hash) {
+		driver, ok := s.drivers.Get(smsConfig.UID.Int64())
+		if !ok {
+			return nil, nil, merr.ErrorParams("sms driver not found")
+		}
+		return &smsConfig, driver, nil
+	}
+
+	driver, err := sms.NewDriver(&sms.Config{
+		Provider:        smsConfig.Provider,
+		AccessKeyID:     smsConfig.AccessKeyID,
+		AccessKeySecret: smsConfig.AccessKeySecret,
+		SignName:        smsConfig.SignName,
+		Endpoint:        smsConfig.Endpoint,
+		Region:          smsConfig.Region,
+		AppID:           smsConfig.AppID,
+	})
+	if err != nil {
+		return nil, nil, merr.ErrorParams("create sms driver failed").WithCause(err)
+	}
+	s.drivers.Set(smsConfig.UID.Int64(), driver)
+	s.sendHashes.Set(smsConfig.UID.Int64(), sendHash)
+	return &smsConfig, driver, nil
+}
@@ -0,0 +1,122 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	aliyunDefaultEndpoint = "https://dysmsapi.aliyuncs.com"
+	aliyunDefaultRegion   = "cn-hangzhou"
+	aliyunAPIVersion      = "2017-05-25"
+)
+
+// NewAliyunDriver 创建阿里云短信驱动，使用 RPC 风格接口及 HMAC-SHA1 签名
+func NewAliyunDriver(config *Config) (Driver, error) {
+	if config.AccessKeyID == "" || config.AccessKeySecret == "" {
+		return nil, errors.New("aliyun sms accessKeyId and accessKeySecret are required")
+	}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = aliyunDefaultEndpoint
+	}
+	region := config.Region
+	if region == "" {
+		region = aliyunDefaultRegion
+	}
+	return &aliyunDriver{
+		config:   config,
+		endpoint: strings.TrimRight(endpoint, "/"),
+		region:   region,
+		client:   &http.Client{Timeout: defaultTimeout},
+	}, nil
+}
+
+type aliyunDriver struct {
+	config   *Config
+	endpoint string
+	region   string
+	client   *http.Client
+}
+
+type aliyunReply struct {
+	Code      string `json:"Code"`
+	Message   string `json:"Message"`
+	BizID     string `json:"BizId"`
+	RequestID string `json:"RequestId"`
+}
+
+// Send implements Driver.
+func (a *aliyunDriver) Send(ctx context.Context, message *Message) error {
+	params := url.Values{}
+	params.Set("AccessKeyId", a.config.AccessKeyID)
+	params.Set("Action", "SendSms")
+	params.Set("Format", "JSON")
+	params.Set("RegionId", a.region)
+	params.Set("SignatureMethod", "HMAC-SHA1")
+	params.Set("SignatureNonce", strconv.FormatInt(time.Now().UnixNano(), 36))
+	params.Set("SignatureVersion", "1.0")
+	params.Set("Timestamp", time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+	params.Set("Version", aliyunAPIVersion)
+	params.Set("PhoneNumbers", strings.Join(message.PhoneNumbers, ","))
+	params.Set("SignName", a.config.SignName)
+	params.Set("TemplateCode", message.TemplateCode)
+	if len(message.TemplateParams) > 0 {
+		templateParam, err := json.Marshal(message.TemplateParams)
+		if err != nil {
+			return err
+		}
+		params.Set("TemplateParam", string(templateParam))
+	}
+
+	query := aliyunCanonicalQuery(params)
+	stringToSign := http.MethodGet + "&" + aliyunPercentEncode("/") + "&" + aliyunPercentEncode(query)
+	mac := hmac.New(sha1.New, []byte(a.config.AccessKeySecret+"&"))
+	mac.Write([]byte(stringToSign))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	requestURL := a.endpoint + "/?Signature=" + aliyunPercentEncode(signature) + "&" + query
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return err
+	}
+	var reply aliyunReply
+	if err := doRequest(a.client, req, &reply); err != nil {
+		return err
+	}
+	if !strings.EqualFold(reply.Code, "OK") {
+		return fmt.Errorf("aliyun sms send failed, code: %s, message: %s, requestId: %s", reply.Code, reply.Message, reply.RequestID)
+	}
+	return nil
+}
+
+func aliyunCanonicalQuery(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, aliyunPercentEncode(key)+"="+aliyunPercentEncode(params.Get(key)))
+	}
+	return strings.Join(pairs, "&")
+}
+
+func aliyunPercentEncode(value string) string {
+	encoded := url.QueryEscape(value)
+	encoded = strings.ReplaceAll(encoded, "+", "%20")
+	encoded = strings.ReplaceAll(encoded, "*", "%2A")
+	return strings.ReplaceAll(encoded, "%7E", "~")
+}
@@ -0,0 +1,100 @@
+package sms
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// huaweiSuccessCode 华为云短信接口成功状态码
+const huaweiSuccessCode = "000000"
+
+// NewHuaweiDriver 创建华为云短信驱动，使用 WSSE 鉴权，accessKeyId/accessKeySecret 为应用的 appKey/appSecret，appId 为短信通道号
+func NewHuaweiDriver(config *Config) (Driver, error) {
+	if config.AccessKeyID == "" || config.AccessKeySecret == "" {
+		return nil, errors.New("huawei sms accessKeyId(appKey) and accessKeySecret(appSecret) are required")
+	}
+	if config.Endpoint == "" {
+		return nil, errors.New("huawei sms endpoint is required")
+	}
+	if config.AppID == "" {
+		return nil, errors.New("huawei sms appId(sender channel) is required")
+	}
+	return &huaweiDriver{
+		config:   config,
+		endpoint: strings.TrimRight(config.Endpoint, "/") + "/sms/batchSendSms/v1",
+		client:   &http.Client{Timeout: defaultTimeout},
+	}, nil
+}
+
+type huaweiDriver struct {
+	config   *Config
+	endpoint string
+	client   *http.Client
+}
+
+type huaweiReply struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Result      []struct {
+		OriginTo string `json:"originTo"`
+		Status   string `json:"status"`
+	} `json:"result"`
+}
+
+// Send implements Driver.
+func (h *huaweiDriver) Send(ctx context.Context, message *Message) error {
+	form := url.Values{}
+	form.Set("from", h.config.AppID)
+	form.Set("to", strings.Join(message.PhoneNumbers, ","))
+	form.Set("templateId", message.TemplateCode)
+	if len(message.TemplateParams) > 0 {
+		templateParas, err := json.Marshal(orderedParams(message.TemplateParams))
+		if err != nil {
+			return err
+		}
+		form.Set("templateParas", string(templateParas))
+	}
+	if h.config.SignName != "" {
+		form.Set("signature", h.config.SignName)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", `WSSE realm="SDP",profile="UsernameToken",type="Appkey"`)
+	req.Header.Set("X-WSSE", h.wsseHeader())
+
+	var reply huaweiReply
+	if err := doRequest(h.client, req, &reply); err != nil {
+		return err
+	}
+	if reply.Code != huaweiSuccessCode {
+		return fmt.Errorf("huawei sms send failed, code: %s, description: %s", reply.Code, reply.Description)
+	}
+	failed := make(map[string]string)
+	for _, result := range reply.Result {
+		if result.Status != huaweiSuccessCode {
+			failed[result.OriginTo] = "status: " + result.Status
+		}
+	}
+	return sendResult(len(reply.Result), failed)
+}
+
+func (h *huaweiDriver) wsseHeader() string {
+	nonce := strconv.FormatInt(time.Now().UnixNano(), 36)
+	created := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	digest := sha256.Sum256([]byte(nonce + created + h.config.AccessKeySecret))
+	return fmt.Sprintf(`UsernameToken Username="%s",PasswordDigest="%s",Nonce="%s",Created="%s"`,
+		h.config.AccessKeyID, base64.StdEncoding.EncodeToString(digest[:]), nonce, created)
+}
@@ -0,0 +1,52 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// mockMessageLimit 本地模拟服务商保留的最近消息数
+const mockMessageLimit = 100
+
+var phoneNumberRegexp = regexp.MustCompile(`^\+?[0-9]{5,20}$`)
+
+// NewMockDriver 创建本地模拟服务商驱动，不调用外部接口，仅校验号码并记录消息，便于离线测试
+func NewMockDriver(config *Config) (Driver, error) {
+	return &MockDriver{config: config}, nil
+}
+
+type MockDriver struct {
+	config *Config
+
+	mu       sync.Mutex
+	messages []*Message
+}
+
+// Send implements Driver.
+func (m *MockDriver) Send(ctx context.Context, message *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, phoneNumber := range message.PhoneNumbers {
+		if !phoneNumberRegexp.MatchString(phoneNumber) {
+			return fmt.Errorf("invalid phone number %q", phoneNumber)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, message)
+	if len(m.messages) > mockMessageLimit {
+		m.messages = m.messages[len(m.messages)-mockMessageLimit:]
+	}
+	return nil
+}
+
+// Messages 返回最近发送的消息
+func (m *MockDriver) Messages() []*Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Message(nil), m.messages...)
+}
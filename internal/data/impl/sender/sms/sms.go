@@ -0,0 +1,140 @@
+// Package sms implements the sms provider drivers.
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aide-family/magicbox/safety"
+
+	"github.com/aide-family/rabbit/internal/biz/vobj"
+)
+
+// defaultTimeout 服务商接口请求超时时间
+const defaultTimeout = 10 * time.Second
+
+// Config 服务商配置
+type Config struct {
+	Provider        vobj.SMSProvider
+	AccessKeyID     string
+	AccessKeySecret string
+	SignName        string
+	Endpoint        string
+	Region          string
+	AppID           string
+}
+
+// Message 短信消息
+type Message struct {
+	PhoneNumbers   []string
+	TemplateCode   string
+	TemplateParams map[string]string
+	Content        string
+}
+
+// Driver 服务商驱动
+// 部分号码发送失败时返回 *PartialError，全部号码发送失败时返回其他错误
+type Driver interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// PartialError 部分号码发送失败，其余号码已发送成功
+type PartialError struct {
+	// Failed 发送失败的号码及原因
+	Failed map[string]string
+}
+
+func (e *PartialError) Error() string {
+	phoneNumbers := make([]string, 0, len(e.Failed))
+	for phoneNumber := range e.Failed {
+		phoneNumbers = append(phoneNumbers, phoneNumber)
+	}
+	sort.Strings(phoneNumbers)
+	failed := make([]string, 0, len(phoneNumbers))
+	for _, phoneNumber := range phoneNumbers {
+		failed = append(failed, phoneNumber+": "+e.Failed[phoneNumber])
+	}
+	return "sms send to some phone numbers failed, " + strings.Join(failed, "; ")
+}
+
+// sendResult 根据每个号码的发送结果返回错误，全部失败时返回第一个失败原因
+func sendResult(total int, failed map[string]string) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	if len(failed) < total {
+		return &PartialError{Failed: failed}
+	}
+	return errors.New((&PartialError{Failed: failed}).Error())
+}
+
+// DriverFunc 根据配置创建服务商驱动
+type DriverFunc func(config *Config) (Driver, error)
+
+var drivers = safety.NewSyncMap(map[vobj.SMSProvider]DriverFunc{
+	vobj.SMSProviderMock:    NewMockDriver,
+	vobj.SMSProviderAliyun:  NewAliyunDriver,
+	vobj.SMSProviderTencent: NewTencentDriver,
+	vobj.SMSProviderHuawei:  NewHuaweiDriver,
+})
+
+// Register 注册服务商驱动，已存在时覆盖
+func Register(provider vobj.SMSProvider, driverFunc DriverFunc) {
+	drivers.Set(provider, driverFunc)
+}
+
+// NewDriver 根据配置中的服务商创建驱动
+func NewDriver(config *Config) (Driver, error) {
+	driverFunc, ok := drivers.Get(config.Provider)
+	if !ok {
+		return nil, fmt.Errorf("sms provider %s not supported", config.Provider)
+	}
+	return driverFunc(config)
+}
+
+// orderedParams 按参数名排序返回参数值，参数名均为数字时按数值排序，用于按位置传参的服务商
+func orderedParams(params map[string]string) []string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, errA := strconv.Atoi(keys[i])
+		b, errB := strconv.Atoi(keys[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, params[key])
+	}
+	return values
+}
+
+// doRequest 发送请求并解析 JSON 响应
+func doRequest(client *http.Client, req *http.Request, reply any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, reply); err != nil {
+		return fmt.Errorf("unmarshal response failed, status: %d, body: %s: %w", resp.StatusCode, body, err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tencentDefaultEndpoint = "https://sms.tencentcloudapi.com"
+	tencentDefaultRegion   = "ap-guangzhou"
+	tencentAPIVersion      = "2021-01-11"
+	tencentService         = "sms"
+	tencentContentType     = "application/json; charset=utf-8"
+)
+
+// NewTencentDriver 创建腾讯云短信驱动，使用 TC3-HMAC-SHA256 签名，模板参数按参数名排序后按位置传递
+func NewTencentDriver(config *Config) (Driver, error) {
+	if config.AccessKeyID == "" || config.AccessKeySecret == "" {
+		return nil, errors.New("tencent sms accessKeyId and accessKeySecret are required")
+	}
+	if config.AppID == "" {
+		return nil, errors.New("tencent sms appId(SdkAppId) is required")
+	}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = tencentDefaultEndpoint
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tencent sms endpoint %q: %w", endpoint, err)
+	}
+	region := config.Region
+	if region == "" {
+		region = tencentDefaultRegion
+	}
+	return &tencentDriver{
+		config:   config,
+		endpoint: strings.TrimRight(endpoint, "/"),
+		host:     endpointURL.Host,
+		region:   region,
+		client:   &http.Client{Timeout: defaultTimeout},
+	}, nil
+}
+
+type tencentDriver struct {
+	config   *Config
+	endpoint string
+	host     string
+	region   string
+	client   *http.Client
+}
+
+type tencentRequest struct {
+	PhoneNumberSet   []string `json:"PhoneNumberSet"`
+	SmsSdkAppID      string   `json:"SmsSdkAppId"`
+	SignName         string   `json:"SignName"`
+	TemplateID       string   `json:"TemplateId"`
+	TemplateParamSet []string `json:"TemplateParamSet,omitempty"`
+}
+
+type tencentReply struct {
+	Response struct {
+		Error *struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+		SendStatusSet []struct {
+			PhoneNumber string `json:"PhoneNumber"`
+			Code        string `json:"Code"`
+			Message     string `json:"Message"`
+		} `json:"SendStatusSet"`
+		RequestID string `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// Send implements Driver.
+func (t *tencentDriver) Send(ctx context.Context, message *Message) error {
+	payload, err := json.Marshal(&tencentRequest{
+		PhoneNumberSet:   message.PhoneNumbers,
+		SmsSdkAppID:      t.config.AppID,
+		SignName:         t.config.SignName,
+		TemplateID:       message.TemplateCode,
+		TemplateParamSet: orderedParams(message.TemplateParams),
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	date := now.Format(time.DateOnly)
+	credentialScope := date + "/" + tencentService + "/tc3_request"
+	canonicalRequest := strings.Join([]string{
+		http.MethodPost,
+		"/",
+		"",
+		"content-type:" + tencentContentType + "\nhost:" + t.host + "\n",
+		"content-type;host",
+		sha256Hex(payload),
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		"TC3-HMAC-SHA256",
+		timestamp,
+		credentialScope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+	secretDate := hmacSHA256([]byte("TC3"+t.config.AccessKeySecret), date)
+	secretService := hmacSHA256(secretDate, tencentService)
+	secretSigning := hmacSHA256(secretService, "tc3_request")
+	signature := hex.EncodeToString(hmacSHA256(secretSigning, stringToSign))
+	authorization := fmt.Sprintf("TC3-HMAC-SHA256 Credential=%s/%s, SignedHeaders=content-type;host, Signature=%s",
+		t.config.AccessKeyID, credentialScope, signature)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Type", tencentContentType)
+	req.Header.Set("Host", t.host)
+	req.Header.Set("X-TC-Action", "SendSms")
+	req.Header.Set("X-TC-Timestamp", timestamp)
+	req.Header.Set("X-TC-Version", tencentAPIVersion)
+	req.Header.Set("X-TC-Region", t.region)
+
+	var reply tencentReply
+	if err := doRequest(t.client, req, &reply); err != nil {
+		return err
+	}
+	if reply.Response.Error != nil {
+		return fmt.Errorf("tencent sms send failed, code: %s, message: %s, requestId: %s",
+			reply.Response.Error.Code, reply.Response.Error.Message, reply.Response.RequestID)
+	}
+	failed := make(map[string]string)
+	for _, status := range reply.Response.SendStatusSet {
+		if !strings.EqualFold(status.Code, "Ok") {
+			failed[status.PhoneNumber] = fmt.Sprintf("code: %s, message: %s, requestId: %s", status.Code, status.Message, reply.Response.RequestID)
+		}
+	}
+	return sendResult(len(reply.Response.SendStatusSet), failed)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -32,6 +32,7 @@ func NewWebhookSender(helper *klog.Helper) repository.MessageSender {
 	w.drivers.Set(vobj.WebhookAppDingTalk, dingtalk.SenderDriver)
 	w.drivers.Set(vobj.WebhookAppWechat, wechat.SenderDriver)
 	w.drivers.Set(vobj.WebhookAppFeishu, feishu.SenderDriver)
+	w.drivers.Set(vobj.WebhookAppOther, otherSenderDriver)
 	return w
 }
 
@@ -61,6 +62,10 @@ func (w *webhookSender) Send(ctx context.Context, messageLog *bo.MessageLogItemB
 	}
 
 	if err := webhookSender.Send(ctx, webhookMessage); err != nil {
+		// 参数错误原样返回，调用方据此判断不再重试
+		if merr.IsParams(err) {
+			return err
+		}
 		return merr.ErrorInternal("send webhook message failed").WithCause(err)
 	}
 	return nil
@@ -79,6 +84,9 @@ func (w *webhookSender) getSender(configBytes []byte) (message.Sender, error) {
 	if err := serialize.JSONUnmarshal(configBytes, &webhookConfig); err != nil {
 		return nil, merr.ErrorInternal("unmarshal webhook config failed").WithCause(err)
 	}
+	if webhookConfig.Status.IsDisabled() {
+		return nil, merr.ErrorParams("webhook config %s(%s) is disabled", webhookConfig.Name, webhookConfig.UID)
+	}
 	sendHash := strutil.SHA256(string(configBytes))
 	hash, ok := w.sendHashes.Get(webhookConfig.UID.Int64())
 	if ok && strings.EqualFold(sendHash, hash) {
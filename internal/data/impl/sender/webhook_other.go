@@ -0,0 +1,116 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aide-family/magicbox/httpx"
+	"github.com/aide-family/magicbox/message"
+	"github.com/aide-family/magicbox/message/hook"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/pkg/merr"
+)
+
+const (
+	messageChannelOther message.MessageChannel = "webhook-other"
+
+	// otherResponseBodyLimit 读取响应体的上限，避免异常响应占用过多内存
+	otherResponseBodyLimit = 1 << 20
+)
+
+var (
+	_ message.Driver = (*otherDriver)(nil)
+	_ message.Sender = (*otherSender)(nil)
+)
+
+// otherConfig 通用 webhook 配置，在 hook.Config 的基础上支持请求方法、请求头、成功判定规则和请求签名
+type otherConfig interface {
+	hook.Config
+	GetMethod() string
+	GetHeaders() map[string]string
+	GetSuccessRule() *bo.SuccessRuleBo
+	GetSignature() *bo.SignatureBo
+}
+
+// otherSenderDriver 通用 HTTP webhook 驱动，按配置的请求方法和请求头原样发送消息体
+func otherSenderDriver(config hook.Config) message.Driver {
+	return &otherDriver{config: config}
+}
+
+type otherDriver struct {
+	config hook.Config
+}
+
+// New implements message.Driver.
+func (d *otherDriver) New() (message.Sender, error) {
+	config, ok := d.config.(otherConfig)
+	if !ok {
+		return nil, merr.ErrorInternal("invalid webhook config for other app")
+	}
+	signature := config.GetSignature()
+	if signature != nil && signature.Secret == "" {
+		return nil, merr.ErrorParams("webhook signature is enabled but secret is empty")
+	}
+	return &otherSender{
+		cli:         httpx.GetHTTPClient(),
+		config:      config,
+		successRule: config.GetSuccessRule(),
+		signature:   signature,
+	}, nil
+}
+
+type otherSender struct {
+	cli         *http.Client
+	config      otherConfig
+	successRule *bo.SuccessRuleBo
+	signature   *bo.SignatureBo
+}
+
+// Send implements message.Sender.
+func (s *otherSender) Send(ctx context.Context, msg message.Message) error {
+	body, err := msg.Message(messageChannelOther)
+	if err != nil {
+		return err
+	}
+	method := s.config.GetMethod()
+	var bodyReader io.Reader
+	if method == http.MethodGet {
+		body = nil
+	} else {
+		bodyReader = bytes.NewReader(body)
+	}
+	// 地址或请求方法无效时重试也不会成功，返回参数错误直接进入死信
+	req, err := http.NewRequestWithContext(ctx, method, s.config.GetURL(), bodyReader)
+	if err != nil {
+		return merr.ErrorParams("build webhook request failed").WithCause(err)
+	}
+	if (req.URL.Scheme != "http" && req.URL.Scheme != "https") || req.URL.Host == "" {
+		return merr.ErrorParams("invalid webhook url %s, expected http(s)://host", s.config.GetURL())
+	}
+	if bodyReader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range s.config.GetHeaders() {
+		req.Header.Set(key, value)
+	}
+	if s.signature != nil {
+		for key, value := range s.signature.Headers(body, time.Now()) {
+			req.Header.Set(key, value)
+		}
+	}
+
+	resp, err := s.cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, otherResponseBodyLimit))
+	if err != nil {
+		return merr.ErrorInternal("read webhook response failed").WithCause(err)
+	}
+	return s.successRule.Check(resp.StatusCode, respBody)
+}
@@ -0,0 +1,16 @@
+package impl
+
+import (
+	"github.com/aide-family/rabbit/internal/biz/repository"
+	"github.com/aide-family/rabbit/internal/data"
+	"github.com/aide-family/rabbit/internal/data/impl/dbimpl"
+	"github.com/aide-family/rabbit/internal/data/impl/fileimpl"
+)
+
+func NewSMSConfigRepository(d *data.Data) repository.SMSConfig {
+	newRepo := fileimpl.NewSMSConfigRepository
+	if d.UseDatabase() {
+		newRepo = dbimpl.NewSMSConfigRepository
+	}
+	return newRepo(d)
+}
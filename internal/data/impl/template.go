@@ -1,16 +1,17 @@
 package impl
 
 import (
+	klog "github.com/go-kratos/kratos/v2/log"
+
 	"github.com/aide-family/rabbit/internal/biz/repository"
 	"github.com/aide-family/rabbit/internal/data"
 	"github.com/aide-family/rabbit/internal/data/impl/dbimpl"
 	"github.com/aide-family/rabbit/internal/data/impl/fileimpl"
 )
 
-func NewTemplateRepository(d *data.Data) repository.Template {
-	newRepo := fileimpl.NewTemplateRepository
+func NewTemplateRepository(d *data.Data, helper *klog.Helper) repository.Template {
 	if d.UseDatabase() {
-		newRepo = dbimpl.NewTemplateRepository
+		return dbimpl.NewTemplateRepository(d)
 	}
-	return newRepo(d)
+	return fileimpl.NewTemplateRepository(d, helper)
 }
@@ -42,3 +42,8 @@ func GetMainTransaction(ctx context.Context) (TransactionValue, bool) {
 func GetBizTransaction(ctx context.Context, namespace string) (TransactionValue, bool) {
 	return getTransaction(ctx, namespace, false)
 }
+
+// WithoutTransaction 返回不携带事务的上下文，用于在事务中执行建表、改表等会隐式提交事务的语句
+func WithoutTransaction(ctx context.Context) context.Context {
+	return context.WithValue(ctx, transactionKey{}, nil)
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/transport/http"
+
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	"github.com/aide-family/rabbit/internal/service"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+	"github.com/aide-family/rabbit/pkg/middler"
+)
+
+// messageLogExportPath HTTP 导出消息日志的地址，参数与 ExportMessageLogsRequest 相同
+const messageLogExportPath = "/v1/message-logs/export"
+
+// BindExportMessageLogs 注册 ExportMessageLogs 的 HTTP 下载接口，鉴权和命名空间校验与其他接口相同
+// 响应头写出后发生的错误无法再返回给客户端，只能中断响应，客户端会收到不完整的文件
+func BindExportMessageLogs(httpSrv *http.Server, messageLogService *service.MessageLogService) {
+	route := httpSrv.Route("/")
+	route.GET(messageLogExportPath, func(ctx http.Context) error {
+		var in apiv1.ExportMessageLogsRequest
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, apiv1.MessageLog_ExportMessageLogs_FullMethodName)
+		w := ctx.Response()
+		h := ctx.Middleware(func(ctx context.Context, req any) (any, error) {
+			exportCtx, cancel := withoutServerTimeout(ctx)
+			defer cancel()
+			exportReq := req.(*apiv1.ExportMessageLogsRequest)
+			format := bo.NewExportMessageLogBo(exportReq).Format
+			filename := fmt.Sprintf("message-logs-%s-%s.%s", middler.GetNamespace(ctx), time.Now().Format("20060102150405"), format)
+			w.Header().Set("Content-Type", format.ContentType())
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+			buf := bufio.NewWriter(w)
+			if err := messageLogService.WriteMessageLogs(exportCtx, exportReq, buf); err != nil {
+				return nil, err
+			}
+			return nil, buf.Flush()
+		})
+		_, err := h(ctx, &in)
+		return err
+	})
+}
@@ -45,6 +45,7 @@ func newGRPCServer(grpcConf conf.ServerConfig, jwtConf conf.JWTConfig, namespace
 	}
 	opts := []grpc.ServerOption{
 		grpc.Middleware(grpcMiddlewares...),
+		grpc.StreamInterceptor(rabbitMiddler.StreamServer(authMiddleware)),
 	}
 	if network := grpcConf.GetNetwork(); network != "" {
 		opts = append(opts, grpc.Network(network))
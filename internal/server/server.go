@@ -147,6 +147,7 @@ func RegisterService(
 	jobSrv *JobServer,
 	healthService *service.HealthService,
 	emailService *service.EmailService,
+	smsService *service.SMSService,
 	webhookService *service.WebhookService,
 	senderService *service.SenderService,
 	namespaceService *service.NamespaceService,
@@ -159,6 +160,7 @@ func RegisterService(
 	srvs = append(srvs, RegisterHTTPService(c, httpSrv,
 		healthService,
 		emailService,
+		smsService,
 		webhookService,
 		senderService,
 		namespaceService,
@@ -168,6 +170,7 @@ func RegisterService(
 	srvs = append(srvs, RegisterGRPCService(c, grpcSrv,
 		healthService,
 		emailService,
+		smsService,
 		webhookService,
 		senderService,
 		namespaceService,
@@ -186,6 +189,7 @@ func RegisterHTTPService(
 	httpSrv *http.Server,
 	healthService *service.HealthService,
 	emailService *service.EmailService,
+	smsService *service.SMSService,
 	webhookService *service.WebhookService,
 	senderService *service.SenderService,
 	namespaceService *service.NamespaceService,
@@ -194,11 +198,14 @@ func RegisterHTTPService(
 ) Servers {
 	apiv1.RegisterHealthHTTPServer(httpSrv, healthService)
 	apiv1.RegisterEmailHTTPServer(httpSrv, emailService)
+	apiv1.RegisterSMSHTTPServer(httpSrv, smsService)
 	apiv1.RegisterWebhookHTTPServer(httpSrv, webhookService)
 	apiv1.RegisterSenderHTTPServer(httpSrv, senderService)
 	apiv1.RegisterNamespaceHTTPServer(httpSrv, namespaceService)
 	apiv1.RegisterMessageLogHTTPServer(httpSrv, messageLogService)
 	apiv1.RegisterTemplateHTTPServer(httpSrv, templateService)
+	BindWatchMessageLogs(httpSrv, messageLogService)
+	BindExportMessageLogs(httpSrv, messageLogService)
 	return Servers{httpSrv}
 }
 
@@ -208,6 +215,7 @@ func RegisterGRPCService(
 	grpcSrv *grpc.Server,
 	healthService *service.HealthService,
 	emailService *service.EmailService,
+	smsService *service.SMSService,
 	webhookService *service.WebhookService,
 	senderService *service.SenderService,
 	namespaceService *service.NamespaceService,
@@ -216,6 +224,7 @@ func RegisterGRPCService(
 ) Servers {
 	apiv1.RegisterHealthServer(grpcSrv, healthService)
 	apiv1.RegisterEmailServer(grpcSrv, emailService)
+	apiv1.RegisterSMSServer(grpcSrv, smsService)
 	apiv1.RegisterWebhookServer(grpcSrv, webhookService)
 	apiv1.RegisterSenderServer(grpcSrv, senderService)
 	apiv1.RegisterNamespaceServer(grpcSrv, namespaceService)
@@ -248,6 +257,7 @@ var namespaceAllowList = []string{
 	apiv1.OperationNamespaceGetNamespace,
 	apiv1.OperationNamespaceListNamespace,
 	apiv1.OperationHealthHealthCheck,
+	apiv1.OperationHealthListClusterNodes,
 }
 
 var authAllowList = []string{
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	nethttp "net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport/http"
+
+	"github.com/aide-family/rabbit/internal/service"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+)
+
+// messageLogWatchPath HTTP SSE 订阅消息状态变更的地址，参数与 WatchMessageLogsRequest 相同
+const messageLogWatchPath = "/v1/message-logs/watch"
+
+// sseHeartbeatInterval SSE 心跳间隔，保持连接并及时发现客户端断开
+const sseHeartbeatInterval = 15 * time.Second
+
+// BindWatchMessageLogs 注册 WatchMessageLogs 的 HTTP SSE 接口，鉴权和命名空间校验与其他接口相同
+func BindWatchMessageLogs(httpSrv *http.Server, messageLogService *service.MessageLogService) {
+	route := httpSrv.Route("/")
+	route.GET(messageLogWatchPath, func(ctx http.Context) error {
+		var in apiv1.WatchMessageLogsRequest
+		if err := ctx.BindQuery(&in); err != nil {
+			return err
+		}
+		http.SetOperation(ctx, apiv1.MessageLog_WatchMessageLogs_FullMethodName)
+		w := ctx.Response()
+		h := ctx.Middleware(func(ctx context.Context, req any) (any, error) {
+			serveMessageLogEvents(ctx, w, req.(*apiv1.WatchMessageLogsRequest), messageLogService)
+			return nil, nil
+		})
+		_, err := h(ctx, &in)
+		return err
+	})
+}
+
+// serveMessageLogEvents 以 SSE 推送状态变更，响应头写出后的错误以 error 事件返回
+// HTTP 服务的超时不限制订阅时长，客户端断开或心跳写入失败时结束
+func serveMessageLogEvents(ctx context.Context, w nethttp.ResponseWriter, req *apiv1.WatchMessageLogsRequest, messageLogService *service.MessageLogService) {
+	streamCtx, cancel := withoutServerTimeout(ctx)
+	defer cancel()
+
+	writer := &sseWriter{w: w, rc: nethttp.NewResponseController(w), codec: encoding.GetCodec("json")}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(nethttp.StatusOK)
+	if err := writer.heartbeat(); err != nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(sseHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-streamCtx.Done():
+				return
+			case <-ticker.C:
+				if err := writer.heartbeat(); err != nil {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	err := messageLogService.SubscribeMessageLogs(streamCtx, req, func(event *apiv1.MessageLogEvent) error {
+		return writer.event("", event)
+	})
+	if err != nil && streamCtx.Err() == nil {
+		_ = writer.event("error", kerrors.FromError(err))
+	}
+}
+
+type sseWriter struct {
+	mu    sync.Mutex
+	w     nethttp.ResponseWriter
+	rc    *nethttp.ResponseController
+	codec encoding.Codec
+}
+
+// event 写出一条事件，name 为空时客户端按默认的 message 事件处理
+func (s *sseWriter) event(name string, v any) error {
+	data, err := s.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if name != "" {
+		if _, err := fmt.Fprintf(s.w, "event: %s\n", name); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+func (s *sseWriter) heartbeat() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := fmt.Fprint(s.w, ": ping\n\n"); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// withoutServerTimeout 返回不受 HTTP 服务超时限制的 ctx，客户端断开时仍会取消
+func withoutServerTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	streamCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	go func() {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				cancel()
+			}
+		case <-streamCtx.Done():
+		}
+	}()
+	return streamCtx, cancel
+}
@@ -6,12 +6,14 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/aide-family/rabbit/internal/biz"
+	"github.com/aide-family/rabbit/internal/biz/bo"
 	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
 )
 
-func NewHealthService(healthBiz *biz.Health) *HealthService {
+func NewHealthService(healthBiz *biz.Health, jobBiz *biz.Job) *HealthService {
 	return &HealthService{
 		healthBiz: healthBiz,
+		jobBiz:    jobBiz,
 	}
 }
 
@@ -19,6 +21,7 @@ type HealthService struct {
 	apiv1.UnimplementedHealthServer
 
 	healthBiz *biz.Health
+	jobBiz    *biz.Job
 }
 
 func (s *HealthService) HealthCheck(ctx context.Context, req *apiv1.HealthCheckRequest) (*apiv1.HealthCheckReply, error) {
@@ -28,3 +31,7 @@ func (s *HealthService) HealthCheck(ctx context.Context, req *apiv1.HealthCheckR
 		Timestamp: timestamppb.Now(),
 	}, nil
 }
+
+func (s *HealthService) ListClusterNodes(ctx context.Context, req *apiv1.ListClusterNodesRequest) (*apiv1.ListClusterNodesReply, error) {
+	return bo.ToAPIV1ListClusterNodesReply(s.jobBiz.ListClusterNodes(ctx)), nil
+}
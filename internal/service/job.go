@@ -6,6 +6,7 @@ import (
 	"github.com/bwmarrin/snowflake"
 
 	"github.com/aide-family/rabbit/internal/biz"
+	"github.com/aide-family/rabbit/internal/biz/vobj"
 	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
 )
 
@@ -22,7 +23,7 @@ type JobService struct {
 }
 
 func (s *JobService) SendMessage(ctx context.Context, req *apiv1.JobSendMessageRequest) (*apiv1.JobSendReply, error) {
-	if err := s.jobBiz.AppendMessage(ctx, snowflake.ParseInt64(req.Uid)); err != nil {
+	if err := s.jobBiz.AppendMessage(ctx, snowflake.ParseInt64(req.Uid), vobj.MessagePriority(req.Priority)); err != nil {
 		return nil, err
 	}
 	return &apiv1.JobSendReply{Message: "success"}, nil
@@ -1,13 +1,18 @@
 package service
 
 import (
+	"bufio"
 	"context"
+	"io"
+	"time"
 
 	"github.com/bwmarrin/snowflake"
 
 	"github.com/aide-family/rabbit/internal/biz"
 	"github.com/aide-family/rabbit/internal/biz/bo"
 	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+	"github.com/aide-family/rabbit/pkg/merr"
+	"github.com/aide-family/rabbit/pkg/middler"
 )
 
 func NewMessageLogService(messageLogBiz *biz.MessageLog) *MessageLogService {
@@ -53,3 +58,118 @@ func (s *MessageLogService) ListMessageLog(ctx context.Context, req *apiv1.ListM
 	}
 	return bo.ToAPIV1ListMessageLogReply(pageResponseBo), nil
 }
+
+func (s *MessageLogService) ListDeadLetter(ctx context.Context, req *apiv1.ListDeadLetterRequest) (*apiv1.ListMessageLogReply, error) {
+	listBo := bo.NewListDeadLetterBo(req)
+	pageResponseBo, err := s.messageLogBiz.ListDeadLetter(ctx, listBo)
+	if err != nil {
+		return nil, err
+	}
+	return bo.ToAPIV1ListMessageLogReply(pageResponseBo), nil
+}
+
+func (s *MessageLogService) GetDeadLetter(ctx context.Context, req *apiv1.GetDeadLetterRequest) (*apiv1.DeadLetterItem, error) {
+	deadLetterBo, err := s.messageLogBiz.GetDeadLetter(ctx, snowflake.ParseInt64(req.Uid))
+	if err != nil {
+		return nil, err
+	}
+	return deadLetterBo.ToAPIV1DeadLetterItem(), nil
+}
+
+func (s *MessageLogService) ListMessageCallbackLog(ctx context.Context, req *apiv1.ListMessageCallbackLogRequest) (*apiv1.ListMessageCallbackLogReply, error) {
+	callbackLogs, err := s.messageLogBiz.ListMessageCallbackLog(ctx, snowflake.ParseInt64(req.Uid))
+	if err != nil {
+		return nil, err
+	}
+	return bo.ToAPIV1ListMessageCallbackLogReply(callbackLogs), nil
+}
+
+func (s *MessageLogService) RequeueDeadLetter(ctx context.Context, req *apiv1.RequeueDeadLetterRequest) (*apiv1.RequeueDeadLetterReply, error) {
+	result, err := s.messageLogBiz.RequeueDeadLetter(ctx, bo.NewRequeueDeadLetterBo(req))
+	if err != nil {
+		return nil, err
+	}
+	return result.ToAPIV1RequeueDeadLetterReply(), nil
+}
+
+func (s *MessageLogService) CountDeadLetter(ctx context.Context, req *apiv1.CountDeadLetterRequest) (*apiv1.CountDeadLetterReply, error) {
+	total, err := s.messageLogBiz.CountDeadLetter(ctx, time.Unix(req.StartAtUnix, 0), time.Unix(req.EndAtUnix, 0))
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.CountDeadLetterReply{Total: total}, nil
+}
+
+func (s *MessageLogService) PreviewPurgeMessageLogs(ctx context.Context, req *apiv1.PurgeMessageLogsRequest) (*apiv1.PurgeMessageLogsReply, error) {
+	result, err := s.messageLogBiz.PurgeMessageLogs(ctx, bo.NewPurgeMessageLogBo(req, true))
+	if err != nil {
+		return nil, err
+	}
+	return result.ToAPIV1PurgeMessageLogsReply(), nil
+}
+
+func (s *MessageLogService) PurgeMessageLogs(ctx context.Context, req *apiv1.PurgeMessageLogsRequest) (*apiv1.PurgeMessageLogsReply, error) {
+	result, err := s.messageLogBiz.PurgeMessageLogs(ctx, bo.NewPurgeMessageLogBo(req, false))
+	if err != nil {
+		return nil, err
+	}
+	return result.ToAPIV1PurgeMessageLogsReply(), nil
+}
+
+func (s *MessageLogService) WatchMessageLogs(req *apiv1.WatchMessageLogsRequest, stream apiv1.MessageLog_WatchMessageLogsServer) error {
+	return s.SubscribeMessageLogs(stream.Context(), req, stream.Send)
+}
+
+// SubscribeMessageLogs 持续推送消息状态变更直到 ctx 结束，gRPC 流和 HTTP SSE 共用
+// 订阅被服务端断开时返回错误，客户端应重新订阅并通过 ListMessageLog 补齐断开期间的变更
+func (s *MessageLogService) SubscribeMessageLogs(ctx context.Context, req *apiv1.WatchMessageLogsRequest, send func(*apiv1.MessageLogEvent) error) error {
+	events := s.messageLogBiz.WatchMessageLogs(ctx, bo.NewWatchMessageLogBo(middler.GetNamespace(ctx), req))
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return merr.ErrorInternal("message log watch is closed by server, please watch again")
+			}
+			if err := send(event.ToAPIV1MessageLogEvent()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// exportChunkSize gRPC 导出时每个数据块的大小
+const exportChunkSize = 32 * 1024
+
+func (s *MessageLogService) ExportMessageLogs(req *apiv1.ExportMessageLogsRequest, stream apiv1.MessageLog_ExportMessageLogsServer) error {
+	ctx := stream.Context()
+	if err := middler.ValidateStreamRequest(ctx, req); err != nil {
+		return err
+	}
+	w := bufio.NewWriterSize(chunkWriter(func(data []byte) error {
+		return stream.Send(&apiv1.ExportMessageLogsChunk{Data: data})
+	}), exportChunkSize)
+	if err := s.WriteMessageLogs(ctx, req, w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// WriteMessageLogs 将导出结果写入 w，gRPC 流和 HTTP 下载共用
+func (s *MessageLogService) WriteMessageLogs(ctx context.Context, req *apiv1.ExportMessageLogsRequest, w io.Writer) error {
+	return s.messageLogBiz.ExportMessageLogs(ctx, bo.NewExportMessageLogBo(req), w)
+}
+
+// chunkWriter 将每次写入作为一个数据块发送，Send 返回前已完成序列化，可以复用 data
+type chunkWriter func(data []byte) error
+
+func (w chunkWriter) Write(data []byte) (int, error) {
+	if err := w(data); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
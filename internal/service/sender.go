@@ -3,17 +3,32 @@ package service
 import (
 	"context"
 
+	"github.com/aide-family/magicbox/strutil"
+	"github.com/bwmarrin/snowflake"
+	"github.com/go-kratos/kratos/v2/transport"
+
 	"github.com/aide-family/rabbit/internal/biz"
 	"github.com/aide-family/rabbit/internal/biz/bo"
 	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
-	"github.com/bwmarrin/snowflake"
+	"github.com/aide-family/rabbit/pkg/merr"
+)
+
+// headerIdempotencyKey 请求体未携带幂等键时，从该请求头(gRPC 为同名 metadata)读取
+const headerIdempotencyKey = "Idempotency-Key"
+
+// 幂等键的最大长度，与请求体字段的校验规则一致，批量发送需要为每个目标追加 ":{下标}"
+const (
+	maxIdempotencyKeyLength      = 128
+	maxBatchIdempotencyKeyLength = 120
 )
 
-func NewSenderService(emailBiz *biz.Email, webhookBiz *biz.Webhook, messageBiz *biz.Message) *SenderService {
+func NewSenderService(emailBiz *biz.Email, smsBiz *biz.SMS, webhookBiz *biz.Webhook, messageBiz *biz.Message, senderBiz *biz.Sender) *SenderService {
 	return &SenderService{
 		emailBiz:   emailBiz,
+		smsBiz:     smsBiz,
 		webhookBiz: webhookBiz,
 		messageBiz: messageBiz,
+		senderBiz:  senderBiz,
 	}
 }
 
@@ -21,51 +36,148 @@ type SenderService struct {
 	apiv1.UnimplementedSenderServer
 
 	emailBiz   *biz.Email
+	smsBiz     *biz.SMS
 	webhookBiz *biz.Webhook
 	messageBiz *biz.Message
+	senderBiz  *biz.Sender
+}
+
+// getIdempotencyKey 优先使用请求体中的幂等键，其次使用请求头
+// 请求头不经过请求体的参数校验，在这里校验长度和字符，超长的幂等键写入时会失败或被截断后与其他幂等键冲突
+func getIdempotencyKey(ctx context.Context, idempotencyKey string, maxLength int) (string, error) {
+	if strutil.IsNotEmpty(idempotencyKey) {
+		return idempotencyKey, nil
+	}
+	tr, ok := transport.FromServerContext(ctx)
+	if !ok {
+		return "", nil
+	}
+	idempotencyKey = tr.RequestHeader().Get(headerIdempotencyKey)
+	if len(idempotencyKey) > maxLength {
+		return "", merr.ErrorParams("%s header must be at most %d characters", headerIdempotencyKey, maxLength)
+	}
+	for _, c := range idempotencyKey {
+		if c < '!' || c > '~' {
+			return "", merr.ErrorParams("%s header must only contain visible ASCII characters", headerIdempotencyKey)
+		}
+	}
+	return idempotencyKey, nil
 }
 
 func (s *SenderService) SendMessage(ctx context.Context, req *apiv1.SendMessageRequest) (*apiv1.SendReply, error) {
+	// 消息按状态 CAS 发送，已发送的消息不会重复发送，重复调用本身是幂等的
 	if err := s.messageBiz.SendMessage(ctx, snowflake.ParseInt64(req.Uid)); err != nil {
 		return nil, err
 	}
-	return &apiv1.SendReply{}, nil
+	return &apiv1.SendReply{Uid: req.Uid}, nil
 }
 
 func (s *SenderService) SendEmail(ctx context.Context, req *apiv1.SendEmailRequest) (*apiv1.SendReply, error) {
+	idempotencyKey, err := getIdempotencyKey(ctx, req.GetIdempotencyKey(), maxIdempotencyKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	req.IdempotencyKey = idempotencyKey
 	sendEmailBo := bo.NewSendEmailBo(req)
-	if err := s.emailBiz.AppendEmailMessage(ctx, sendEmailBo); err != nil {
+	uid, err := s.emailBiz.AppendEmailMessage(ctx, sendEmailBo)
+	if err != nil {
 		return nil, err
 	}
-	return &apiv1.SendReply{}, nil
+	return &apiv1.SendReply{Uid: uid.Int64()}, nil
 }
 
 func (s *SenderService) SendEmailWithTemplate(ctx context.Context, req *apiv1.SendEmailWithTemplateRequest) (*apiv1.SendReply, error) {
+	idempotencyKey, err := getIdempotencyKey(ctx, req.GetIdempotencyKey(), maxIdempotencyKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	req.IdempotencyKey = idempotencyKey
 	sendEmailWithTemplateBo, err := bo.NewSendEmailWithTemplateBo(req)
 	if err != nil {
 		return nil, err
 	}
-	if err := s.emailBiz.AppendEmailMessageWithTemplate(ctx, sendEmailWithTemplateBo); err != nil {
+	uid, err := s.emailBiz.AppendEmailMessageWithTemplate(ctx, sendEmailWithTemplateBo)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.SendReply{Uid: uid.Int64()}, nil
+}
+
+func (s *SenderService) SendSMS(ctx context.Context, req *apiv1.SendSMSRequest) (*apiv1.SendReply, error) {
+	idempotencyKey, err := getIdempotencyKey(ctx, req.GetIdempotencyKey(), maxIdempotencyKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	req.IdempotencyKey = idempotencyKey
+	sendSMSBo := bo.NewSendSMSBo(req)
+	uid, err := s.smsBiz.AppendSMSMessage(ctx, sendSMSBo)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.SendReply{Uid: uid.Int64()}, nil
+}
+
+func (s *SenderService) SendSMSWithTemplate(ctx context.Context, req *apiv1.SendSMSWithTemplateRequest) (*apiv1.SendReply, error) {
+	idempotencyKey, err := getIdempotencyKey(ctx, req.GetIdempotencyKey(), maxIdempotencyKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	req.IdempotencyKey = idempotencyKey
+	sendSMSWithTemplateBo, err := bo.NewSendSMSWithTemplateBo(req)
+	if err != nil {
 		return nil, err
 	}
-	return &apiv1.SendReply{}, nil
+	uid, err := s.smsBiz.AppendSMSMessageWithTemplate(ctx, sendSMSWithTemplateBo)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.SendReply{Uid: uid.Int64()}, nil
 }
 
 func (s *SenderService) SendWebhook(ctx context.Context, req *apiv1.SendWebhookRequest) (*apiv1.SendReply, error) {
+	idempotencyKey, err := getIdempotencyKey(ctx, req.GetIdempotencyKey(), maxIdempotencyKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	req.IdempotencyKey = idempotencyKey
 	sendWebhookBo := bo.NewSendWebhookBo(req)
-	if err := s.webhookBiz.AppendWebhookMessage(ctx, sendWebhookBo); err != nil {
+	uid, err := s.webhookBiz.AppendWebhookMessage(ctx, sendWebhookBo)
+	if err != nil {
 		return nil, err
 	}
-	return &apiv1.SendReply{}, nil
+	return &apiv1.SendReply{Uid: uid.Int64()}, nil
 }
 
 func (s *SenderService) SendWebhookWithTemplate(ctx context.Context, req *apiv1.SendWebhookWithTemplateRequest) (*apiv1.SendReply, error) {
+	idempotencyKey, err := getIdempotencyKey(ctx, req.GetIdempotencyKey(), maxIdempotencyKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	req.IdempotencyKey = idempotencyKey
 	sendWebhookWithTemplateBo, err := bo.NewSendWebhookWithTemplateBo(req)
 	if err != nil {
 		return nil, err
 	}
-	if err := s.webhookBiz.AppendWebhookMessageWithTemplate(ctx, sendWebhookWithTemplateBo); err != nil {
+	uid, err := s.webhookBiz.AppendWebhookMessageWithTemplate(ctx, sendWebhookWithTemplateBo)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.SendReply{Uid: uid.Int64()}, nil
+}
+
+func (s *SenderService) SendBatch(ctx context.Context, req *apiv1.SendBatchRequest) (*apiv1.SendBatchReply, error) {
+	idempotencyKey, err := getIdempotencyKey(ctx, req.GetIdempotencyKey(), maxBatchIdempotencyKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	req.IdempotencyKey = idempotencyKey
+	sendBatchBo, err := bo.NewSendBatchBo(req)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.senderBiz.SendBatch(ctx, sendBatchBo)
+	if err != nil {
 		return nil, err
 	}
-	return &apiv1.SendReply{}, nil
+	return bo.ToAPIV1SendBatchReply(results), nil
 }
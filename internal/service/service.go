@@ -7,6 +7,7 @@ import "github.com/google/wire"
 var ProviderSetService = wire.NewSet(
 	NewHealthService,
 	NewEmailService,
+	NewSMSService,
 	NewWebhookService,
 	NewSenderService,
 	NewNamespaceService,
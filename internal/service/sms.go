@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+
+	"github.com/bwmarrin/snowflake"
+
+	"github.com/aide-family/rabbit/internal/biz"
+	"github.com/aide-family/rabbit/internal/biz/bo"
+	apiv1 "github.com/aide-family/rabbit/pkg/api/v1"
+)
+
+func NewSMSService(smsConfigBiz *biz.SMSConfig) *SMSService {
+	return &SMSService{
+		smsConfigBiz: smsConfigBiz,
+	}
+}
+
+type SMSService struct {
+	apiv1.UnimplementedSMSServer
+
+	smsConfigBiz *biz.SMSConfig
+}
+
+func (s *SMSService) CreateSMSConfig(ctx context.Context, req *apiv1.CreateSMSConfigRequest) (*apiv1.CreateSMSConfigReply, error) {
+	createSMSConfigBo := bo.NewCreateSMSConfigBo(req)
+	if err := s.smsConfigBiz.CreateSMSConfig(ctx, createSMSConfigBo); err != nil {
+		return nil, err
+	}
+	return &apiv1.CreateSMSConfigReply{}, nil
+}
+
+func (s *SMSService) UpdateSMSConfig(ctx context.Context, req *apiv1.UpdateSMSConfigRequest) (*apiv1.UpdateSMSConfigReply, error) {
+	updateSMSConfigBo := bo.NewUpdateSMSConfigBo(req)
+	if err := s.smsConfigBiz.UpdateSMSConfig(ctx, updateSMSConfigBo); err != nil {
+		return nil, err
+	}
+	return &apiv1.UpdateSMSConfigReply{}, nil
+}
+
+func (s *SMSService) UpdateSMSConfigStatus(ctx context.Context, req *apiv1.UpdateSMSConfigStatusRequest) (*apiv1.UpdateSMSConfigStatusReply, error) {
+	updateSMSConfigStatusBo := bo.NewUpdateSMSConfigStatusBo(req)
+	if err := s.smsConfigBiz.UpdateSMSConfigStatus(ctx, updateSMSConfigStatusBo); err != nil {
+		return nil, err
+	}
+	return &apiv1.UpdateSMSConfigStatusReply{}, nil
+}
+
+func (s *SMSService) DeleteSMSConfig(ctx context.Context, req *apiv1.DeleteSMSConfigRequest) (*apiv1.DeleteSMSConfigReply, error) {
+	if err := s.smsConfigBiz.DeleteSMSConfig(ctx, snowflake.ParseInt64(req.Uid)); err != nil {
+		return nil, err
+	}
+	return &apiv1.DeleteSMSConfigReply{}, nil
+}
+
+func (s *SMSService) GetSMSConfig(ctx context.Context, req *apiv1.GetSMSConfigRequest) (*apiv1.SMSConfigItem, error) {
+	getSMSConfigBo, err := s.smsConfigBiz.GetSMSConfig(ctx, snowflake.ParseInt64(req.Uid))
+	if err != nil {
+		return nil, err
+	}
+	return getSMSConfigBo.ToAPIV1SMSConfigItem(), nil
+}
+
+func (s *SMSService) ListSMSConfig(ctx context.Context, req *apiv1.ListSMSConfigRequest) (*apiv1.ListSMSConfigReply, error) {
+	smsConfigListPageRequestBo := bo.NewListSMSConfigBo(req)
+	smsConfigListPageResponseBo, err := s.smsConfigBiz.ListSMSConfig(ctx, smsConfigListPageRequestBo)
+	if err != nil {
+		return nil, err
+	}
+	return bo.ToAPIV1ListSMSConfigReply(smsConfigListPageResponseBo), nil
+}
+
+func (s *SMSService) SelectSMSConfig(ctx context.Context, req *apiv1.SelectSMSConfigRequest) (*apiv1.SelectSMSConfigReply, error) {
+	selectBo := bo.NewSelectSMSConfigBo(req)
+	result, err := s.smsConfigBiz.SelectSMSConfig(ctx, selectBo)
+	if err != nil {
+		return nil, err
+	}
+	return bo.ToAPIV1SelectSMSConfigReply(&bo.SelectSMSConfigReplyParams{
+		Items:   result.Items,
+		Total:   result.Total,
+		LastUID: result.LastUID,
+		Limit:   req.Limit,
+	}), nil
+}
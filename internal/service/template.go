@@ -88,3 +88,64 @@ func (s *TemplateService) SelectTemplate(ctx context.Context, req *apiv1.SelectT
 		Limit:   req.Limit,
 	}), nil
 }
+
+func (s *TemplateService) ListTemplateRevision(ctx context.Context, req *apiv1.ListTemplateRevisionRequest) (*apiv1.ListTemplateRevisionReply, error) {
+	listBo := bo.NewListTemplateRevisionBo(req)
+	pageResponseBo, err := s.templateBiz.ListTemplateRevision(ctx, listBo)
+	if err != nil {
+		return nil, err
+	}
+	return bo.ToAPIV1ListTemplateRevisionReply(pageResponseBo), nil
+}
+
+func (s *TemplateService) DiffTemplateRevision(ctx context.Context, req *apiv1.DiffTemplateRevisionRequest) (*apiv1.DiffTemplateRevisionReply, error) {
+	diffBo, err := s.templateBiz.DiffTemplateRevision(ctx, bo.NewDiffTemplateRevisionBo(req))
+	if err != nil {
+		return nil, err
+	}
+	return diffBo.ToAPIV1DiffTemplateRevisionReply(), nil
+}
+
+func (s *TemplateService) RollbackTemplate(ctx context.Context, req *apiv1.RollbackTemplateRequest) (*apiv1.RollbackTemplateReply, error) {
+	revision, err := s.templateBiz.RollbackTemplate(ctx, bo.NewRollbackTemplateBo(req))
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.RollbackTemplateReply{Revision: revision}, nil
+}
+
+func (s *TemplateService) RenderTemplate(ctx context.Context, req *apiv1.RenderTemplateRequest) (*apiv1.RenderTemplateReply, error) {
+	renderBo, err := bo.NewRenderTemplateBo(req)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.templateBiz.RenderTemplate(ctx, renderBo)
+	if err != nil {
+		return nil, err
+	}
+	return result.ToAPIV1RenderTemplateReply(), nil
+}
+
+func (s *TemplateService) PreviewTemplate(ctx context.Context, req *apiv1.PreviewTemplateRequest) (*apiv1.RenderTemplateReply, error) {
+	previewBo, err := bo.NewPreviewTemplateBo(req)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.templateBiz.RenderTemplate(ctx, previewBo)
+	if err != nil {
+		return nil, err
+	}
+	return result.ToAPIV1RenderTemplateReply(), nil
+}
+
+func (s *TemplateService) ListTemplateFuncs(ctx context.Context, req *apiv1.ListTemplateFuncsRequest) (*apiv1.ListTemplateFuncsReply, error) {
+	return bo.ToAPIV1ListTemplateFuncsReply(req.Category), nil
+}
+
+func (s *TemplateService) GetTemplateSchema(ctx context.Context, req *apiv1.GetTemplateSchemaRequest) (*apiv1.GetTemplateSchemaReply, error) {
+	schemaBo, err := s.templateBiz.GetTemplateSchema(ctx, snowflake.ParseInt64(req.Uid), req.Revision)
+	if err != nil {
+		return nil, err
+	}
+	return schemaBo.ToAPIV1GetTemplateSchemaReply(), nil
+}
@@ -17,12 +17,15 @@ import (
 	"github.com/aide-family/rabbit/cmd/apply"
 	"github.com/aide-family/rabbit/cmd/config"
 	"github.com/aide-family/rabbit/cmd/delete"
+	"github.com/aide-family/rabbit/cmd/export"
 	"github.com/aide-family/rabbit/cmd/get"
+	"github.com/aide-family/rabbit/cmd/purge"
 	"github.com/aide-family/rabbit/cmd/run"
 	"github.com/aide-family/rabbit/cmd/run/all"
 	"github.com/aide-family/rabbit/cmd/run/grpc"
 	"github.com/aide-family/rabbit/cmd/run/http"
 	"github.com/aide-family/rabbit/cmd/run/job"
+	"github.com/aide-family/rabbit/cmd/schema"
 	"github.com/aide-family/rabbit/cmd/send"
 	"github.com/aide-family/rabbit/cmd/send/email"
 	"github.com/aide-family/rabbit/cmd/send/feishu"
@@ -67,7 +70,10 @@ func main() {
 		apply.NewCmd(),
 		config.NewCmd(defaultServerConfig),
 		delete.NewCmd(),
+		export.NewCmd(),
 		get.NewCmd(),
+		purge.NewCmd(),
+		schema.NewCmd(),
 		sendCmd,
 		runCmd,
 		version.NewCmd(),
@@ -0,0 +1,38 @@
+package middler
+
+import (
+	"context"
+
+	"github.com/aide-family/magicbox/server/middler"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"google.golang.org/grpc"
+)
+
+// StreamServer runs unary middlewares (auth, namespace, ...) before a streaming handler,
+// kratos only applies them to unary calls. The context enriched by the middlewares is
+// exposed to the handler through the stream's Context.
+func StreamServer(m ...middleware.Middleware) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		h := func(ctx context.Context, _ any) (any, error) {
+			return nil, handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		}
+		_, err := middleware.Chain(m...)(h)(ss.Context(), nil)
+		return err
+	}
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// ValidateStreamRequest validates the request of a server streaming call. The request is only
+// received inside the handler, so the Validate middleware can not run in StreamServer.
+func ValidateStreamRequest(ctx context.Context, req any) error {
+	_, err := middler.Validate()(func(context.Context, any) (any, error) { return nil, nil })(ctx, req)
+	return err
+}
@@ -0,0 +1,154 @@
+// Package signature signs and verifies outbound webhook requests sent by rabbit.
+//
+// The signed payload is "<timestamp>.<body>", the signature is the hex encoded
+// HMAC-SHA256 of the payload with the webhook secret, prefixed with "sha256=".
+// Receivers should reject requests whose timestamp is outside the replay window.
+package signature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultSignatureHeader is the header carrying the signature.
+	DefaultSignatureHeader = "X-Rabbit-Signature"
+	// DefaultTimestampHeader is the header carrying the unix timestamp in seconds.
+	DefaultTimestampHeader = "X-Rabbit-Timestamp"
+	// DefaultTolerance is the default replay window.
+	DefaultTolerance = 5 * time.Minute
+
+	prefix = "sha256="
+)
+
+var (
+	ErrMissingSignature  = errors.New("signature: missing signature or timestamp")
+	ErrInvalidTimestamp  = errors.New("signature: invalid timestamp")
+	ErrInvalidSignature  = errors.New(`signature: signature is not "sha256=" followed by a hex encoded HMAC-SHA256`)
+	ErrTimestampExpired  = errors.New("signature: timestamp outside the replay window")
+	ErrSignatureMismatch = errors.New("signature: signature mismatch")
+)
+
+// Sign returns the signature of body at timestamp, e.g. "sha256=5257a869...".
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return prefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Option configures the verifier.
+type Option func(*verifier)
+
+// WithTolerance sets the replay window, a non-positive value disables the timestamp check.
+func WithTolerance(tolerance time.Duration) Option {
+	return func(v *verifier) {
+		v.tolerance = tolerance
+	}
+}
+
+// WithSignatureHeader sets the header carrying the signature.
+func WithSignatureHeader(header string) Option {
+	return func(v *verifier) {
+		v.signatureHeader = header
+	}
+}
+
+// WithTimestampHeader sets the header carrying the timestamp.
+func WithTimestampHeader(header string) Option {
+	return func(v *verifier) {
+		v.timestampHeader = header
+	}
+}
+
+// WithNow sets the clock used to check the replay window.
+func WithNow(now func() time.Time) Option {
+	return func(v *verifier) {
+		v.now = now
+	}
+}
+
+type verifier struct {
+	tolerance       time.Duration
+	signatureHeader string
+	timestampHeader string
+	now             func() time.Time
+}
+
+func newVerifier(opts ...Option) *verifier {
+	v := &verifier{
+		tolerance:       DefaultTolerance,
+		signatureHeader: DefaultSignatureHeader,
+		timestampHeader: DefaultTimestampHeader,
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Verify checks the signature of body and that timestamp is within the replay window.
+func Verify(secret, timestamp, signature string, body []byte, opts ...Option) error {
+	v := newVerifier(opts...)
+	if timestamp == "" || signature == "" {
+		return ErrMissingSignature
+	}
+	ts, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	if v.tolerance > 0 {
+		if diff := v.now().Sub(time.Unix(ts, 0)); diff > v.tolerance || diff < -v.tolerance {
+			return ErrTimestampExpired
+		}
+	}
+	signature = strings.TrimSpace(signature)
+	if digest, ok := strings.CutPrefix(signature, prefix); !ok || hex.DecodedLen(len(digest)) != sha256.Size {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// VerifyRequest verifies r and returns its body, r.Body is replaced so it can be read again.
+func VerifyRequest(r *http.Request, secret string, opts ...Option) ([]byte, error) {
+	v := newVerifier(opts...)
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	timestamp := r.Header.Get(v.timestampHeader)
+	signature := r.Header.Get(v.signatureHeader)
+	return body, Verify(secret, timestamp, signature, body, opts...)
+}
+
+// Middleware rejects requests with an invalid signature with 401.
+func Middleware(secret string, opts ...Option) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := VerifyRequest(r, secret, opts...); err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package signature_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aide-family/rabbit/pkg/signature"
+)
+
+const secret = "webhook-secret"
+
+var now = time.Unix(1714979289, 0)
+
+func withNow() signature.Option {
+	return signature.WithNow(func() time.Time { return now })
+}
+
+func TestSign(t *testing.T) {
+	got := signature.Sign(secret, now.Unix(), []byte(`{"a":1}`))
+	if !strings.HasPrefix(got, "sha256=") || len(got) != len("sha256=")+64 {
+		t.Fatalf("Sign() = %q, want sha256= and 64 hex characters", got)
+	}
+	if again := signature.Sign(secret, now.Unix(), []byte(`{"a":1}`)); again != got {
+		t.Errorf("Sign() is not deterministic: %q != %q", again, got)
+	}
+	if other := signature.Sign(secret, now.Unix()+1, []byte(`{"a":1}`)); other == got {
+		t.Errorf("Sign() does not cover the timestamp")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"status":"firing"}`)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	valid := signature.Sign(secret, now.Unix(), body)
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		opts      []signature.Option
+		want      error
+	}{
+		{name: "valid", secret: secret, timestamp: timestamp, signature: valid, body: body},
+		{name: "valid with spaces", secret: secret, timestamp: " " + timestamp + " ", signature: " " + valid + " ", body: body},
+		{name: "tampered body", secret: secret, timestamp: timestamp, signature: valid, body: []byte(`{"status":"resolved"}`), want: signature.ErrSignatureMismatch},
+		{name: "wrong secret", secret: "other", timestamp: timestamp, signature: valid, body: body, want: signature.ErrSignatureMismatch},
+		{name: "upper case hex", secret: secret, timestamp: timestamp, signature: "sha256=" + strings.ToUpper(strings.TrimPrefix(valid, "sha256=")), body: body, want: signature.ErrSignatureMismatch},
+		{name: "timestamp not signed", secret: secret, timestamp: strconv.FormatInt(now.Unix()-1, 10), signature: valid, body: body, want: signature.ErrSignatureMismatch},
+		{name: "expired timestamp", secret: secret, timestamp: strconv.FormatInt(now.Add(-6*time.Minute).Unix(), 10), signature: signature.Sign(secret, now.Add(-6*time.Minute).Unix(), body), body: body, want: signature.ErrTimestampExpired},
+		{name: "future timestamp", secret: secret, timestamp: strconv.FormatInt(now.Add(6*time.Minute).Unix(), 10), signature: signature.Sign(secret, now.Add(6*time.Minute).Unix(), body), body: body, want: signature.ErrTimestampExpired},
+		{name: "skew within tolerance", secret: secret, timestamp: strconv.FormatInt(now.Add(-4*time.Minute).Unix(), 10), signature: signature.Sign(secret, now.Add(-4*time.Minute).Unix(), body), body: body},
+		{name: "custom tolerance", secret: secret, timestamp: strconv.FormatInt(now.Add(-time.Minute).Unix(), 10), signature: signature.Sign(secret, now.Add(-time.Minute).Unix(), body), body: body, opts: []signature.Option{signature.WithTolerance(30 * time.Second)}, want: signature.ErrTimestampExpired},
+		{name: "tolerance disabled", secret: secret, timestamp: "1", signature: signature.Sign(secret, 1, body), body: body, opts: []signature.Option{signature.WithTolerance(0)}},
+		{name: "missing signature", secret: secret, timestamp: timestamp, body: body, want: signature.ErrMissingSignature},
+		{name: "missing timestamp", secret: secret, signature: valid, body: body, want: signature.ErrMissingSignature},
+		{name: "invalid timestamp", secret: secret, timestamp: "yesterday", signature: valid, body: body, want: signature.ErrInvalidTimestamp},
+		{name: "missing prefix", secret: secret, timestamp: timestamp, signature: strings.TrimPrefix(valid, "sha256="), body: body, want: signature.ErrInvalidSignature},
+		{name: "other algorithm", secret: secret, timestamp: timestamp, signature: "sha1=" + strings.TrimPrefix(valid, "sha256="), body: body, want: signature.ErrInvalidSignature},
+		{name: "truncated digest", secret: secret, timestamp: timestamp, signature: valid[:len(valid)-2], body: body, want: signature.ErrInvalidSignature},
+		{name: "prefix only", secret: secret, timestamp: timestamp, signature: "sha256=", body: body, want: signature.ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]signature.Option{withNow()}, tt.opts...)
+			err := signature.Verify(tt.secret, tt.timestamp, tt.signature, tt.body, opts...)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	body := `{"status":"firing"}`
+	handler := signature.Middleware(secret, withNow())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		read, err := io.ReadAll(r.Body)
+		if err != nil || string(read) != body {
+			t.Errorf("handler body = %q, %v, want %q", read, err, body)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	tests := []struct {
+		name      string
+		signature string
+		body      string
+		want      int
+	}{
+		{name: "valid", signature: signature.Sign(secret, now.Unix(), []byte(body)), body: body, want: http.StatusNoContent},
+		{name: "tampered body", signature: signature.Sign(secret, now.Unix(), []byte(body)), body: `{"status":"resolved"}`, want: http.StatusUnauthorized},
+		{name: "missing header", body: body, want: http.StatusUnauthorized},
+		{name: "malformed header", signature: "not-a-signature", body: body, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(tt.body))
+			r.Header.Set(signature.DefaultTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+			if tt.signature != "" {
+				r.Header.Set(signature.DefaultSignatureHeader, tt.signature)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d, body %q", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestVerifyRequestCustomHeaders(t *testing.T) {
+	body := []byte(`{}`)
+	r := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(string(body)))
+	r.Header.Set("X-Ts", strconv.FormatInt(now.Unix(), 10))
+	r.Header.Set("X-Sig", signature.Sign(secret, now.Unix(), body))
+	got, err := signature.VerifyRequest(r, secret, withNow(), signature.WithTimestampHeader("X-Ts"), signature.WithSignatureHeader("X-Sig"))
+	if err != nil {
+		t.Fatalf("VerifyRequest failed: %v", err)
+	}
+	if string(got) != string(body) {
+		t.Errorf("VerifyRequest body = %q, want %q", got, body)
+	}
+	if again, _ := io.ReadAll(r.Body); string(again) != string(body) {
+		t.Errorf("request body is not readable again: %q", again)
+	}
+}
@@ -0,0 +1,155 @@
+package tmplfunc
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+func list(items ...any) []any {
+	return items
+}
+
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("dict expects key value pairs")
+	}
+	object := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, errors.New("dict keys must be strings")
+		}
+		object[key] = pairs[i+1]
+	}
+	return object, nil
+}
+
+func keys(m any) ([]string, error) {
+	object, err := toMap(m)
+	if err != nil {
+		return nil, err
+	}
+	return sortedKeys(object), nil
+}
+
+func values(m any) ([]any, error) {
+	object, err := toMap(m)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]any, 0, len(object))
+	for _, key := range sortedKeys(object) {
+		items = append(items, object[key])
+	}
+	return items, nil
+}
+
+func hasKey(m any, key string) (bool, error) {
+	object, err := toMap(m)
+	if err != nil {
+		return false, err
+	}
+	_, ok := object[key]
+	return ok, nil
+}
+
+func has(v any, l any) (bool, error) {
+	items, err := toSlice(l)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range items {
+		if reflect.DeepEqual(item, v) || toString(item) == toString(v) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func join(sep string, l any) (string, error) {
+	if s, ok := l.(string); ok {
+		return s, nil
+	}
+	items, err := toSlice(l)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		parts = append(parts, toString(item))
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func joinPairs(sep string, m any) (string, error) {
+	object, err := toMap(m)
+	if err != nil {
+		return "", err
+	}
+	pairs := make([]string, 0, len(object))
+	for _, key := range sortedKeys(object) {
+		pairs = append(pairs, key+"="+toString(object[key]))
+	}
+	return strings.Join(pairs, sep), nil
+}
+
+func first(l any) (any, error) {
+	items, err := toSlice(l)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return items[0], nil
+}
+
+func last(l any) (any, error) {
+	items, err := toSlice(l)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return items[len(items)-1], nil
+}
+
+func uniq(l any) ([]any, error) {
+	items, err := toSlice(l)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]any, 0, len(items))
+	for _, item := range items {
+		duplicated := false
+		for _, kept := range result {
+			if reflect.DeepEqual(kept, item) {
+				duplicated = true
+				break
+			}
+		}
+		if !duplicated {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func sortAlpha(l any) ([]string, error) {
+	items, err := toSlice(l)
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]string, 0, len(items))
+	for _, item := range items {
+		sorted = append(sorted, toString(item))
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,182 @@
+package tmplfunc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// toFloat64 converts numbers and numeric strings to float64.
+func toFloat64(v any) (float64, error) {
+	switch value := v.(type) {
+	case float64:
+		return value, nil
+	case float32:
+		return float64(value), nil
+	case decimal:
+		return float64(value), nil
+	case int:
+		return float64(value), nil
+	case int8:
+		return float64(value), nil
+	case int16:
+		return float64(value), nil
+	case int32:
+		return float64(value), nil
+	case int64:
+		return float64(value), nil
+	case uint:
+		return float64(value), nil
+	case uint8:
+		return float64(value), nil
+	case uint16:
+		return float64(value), nil
+	case uint32:
+		return float64(value), nil
+	case uint64:
+		return float64(value), nil
+	case json.Number:
+		return value.Float64()
+	case time.Duration:
+		return float64(value), nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", value)
+		}
+		return number, nil
+	case nil:
+		return 0, fmt.Errorf("missing number")
+	default:
+		return 0, fmt.Errorf("%T is not a number", v)
+	}
+}
+
+// decimal is a non-integral result, printed without an exponent.
+type decimal float64
+
+func (d decimal) String() string {
+	return strconv.FormatFloat(float64(d), 'f', -1, 64)
+}
+
+// number returns integral results as int64 and others as decimal, so they are
+// printed without an exponent or a trailing ".0".
+func number(f float64) any {
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return int64(f)
+	}
+	return decimal(f)
+}
+
+// toTime converts times, unix timestamps and time strings to a time.
+func toTime(v any) (time.Time, error) {
+	switch value := v.(type) {
+	case time.Time:
+		return value, nil
+	case *time.Time:
+		if value == nil {
+			return time.Time{}, fmt.Errorf("missing time")
+		}
+		return *value, nil
+	case string:
+		value = strings.TrimSpace(value)
+		for _, layout := range []string{time.RFC3339Nano, time.DateTime, time.DateOnly} {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, nil
+			}
+		}
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return time.Time{}, fmt.Errorf("%q is not a time", value)
+		}
+	}
+	seconds, err := toFloat64(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%v is not a time", v)
+	}
+	// Millisecond timestamps after 2001 and second timestamps before year 33658 are told apart by 1e12.
+	if math.Abs(seconds) >= 1e12 {
+		return time.UnixMilli(int64(seconds)), nil
+	}
+	whole, fraction := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(fraction*1e9)), nil
+}
+
+// toDurationValue converts durations, seconds and Go duration strings to a duration.
+func toDurationValue(v any) (time.Duration, error) {
+	switch value := v.(type) {
+	case time.Duration:
+		return value, nil
+	case string:
+		if d, err := time.ParseDuration(strings.TrimSpace(value)); err == nil {
+			return d, nil
+		}
+	}
+	seconds, err := toFloat64(v)
+	if err != nil {
+		return 0, fmt.Errorf("%v is not a duration", v)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// toSlice converts any slice or array to []any, nil becomes an empty list.
+func toSlice(v any) ([]any, error) {
+	switch value := v.(type) {
+	case nil:
+		return []any{}, nil
+	case []any:
+		return value, nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%T is not a list", v)
+	}
+	items := make([]any, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		items = append(items, rv.Index(i).Interface())
+	}
+	return items, nil
+}
+
+// toMap converts any map with string keys to map[string]any, nil becomes an empty object.
+func toMap(v any) (map[string]any, error) {
+	switch value := v.(type) {
+	case nil:
+		return map[string]any{}, nil
+	case map[string]any:
+		return value, nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("%T is not an object", v)
+	}
+	object := make(map[string]any, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		object[iter.Key().String()] = iter.Value().Interface()
+	}
+	return object, nil
+}
+
+// toString formats any value as a string, nil becomes "".
+func toString(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	case fmt.Stringer:
+		return value.String()
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(value), 'f', -1, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,54 @@
+package tmplfunc
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+func toJSON(v any) (string, error) {
+	return encodeJSON(v, "")
+}
+
+func toPrettyJSON(v any) (string, error) {
+	return encodeJSON(v, "  ")
+}
+
+func fromJSON(s string) (any, error) {
+	var value any
+	if err := json.Unmarshal([]byte(s), &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func jsonEscape(v any) (string, error) {
+	quoted, err := encodeJSON(toString(v), "")
+	if err != nil {
+		return "", err
+	}
+	return quoted[1 : len(quoted)-1], nil
+}
+
+// markdownReplacer escapes the CommonMark punctuation that may change the formatting.
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `{`, `\{`, `}`, `\}`,
+	`[`, `\[`, `]`, `\]`, `(`, `\(`, `)`, `\)`, `#`, `\#`, `+`, `\+`,
+	`-`, `\-`, `.`, `\.`, `!`, `\!`, `|`, `\|`, `<`, `\<`, `>`, `\>`, `~`, `\~`,
+)
+
+func markdownEscape(v any) string {
+	return markdownReplacer.Replace(toString(v))
+}
+
+// encodeJSON encodes without escaping <, > and &, and without the trailing newline.
+func encodeJSON(v any, indent string) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", indent)
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
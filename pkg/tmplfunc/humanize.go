@@ -0,0 +1,102 @@
+package tmplfunc
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func humanizeDuration(v any) (string, error) {
+	d, err := toDurationValue(v)
+	if err != nil {
+		return "", err
+	}
+	return formatDuration(d), nil
+}
+
+// durationUnits are the units of humanized durations, from the largest.
+var durationUnits = []struct {
+	suffix string
+	unit   time.Duration
+}{
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+}
+
+// formatDuration keeps the two most significant units, durations under a second are shown in milliseconds.
+func formatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	if d < time.Second {
+		return sign + strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+	}
+	parts := make([]string, 0, 2)
+	for _, unit := range durationUnits {
+		if len(parts) == 2 {
+			break
+		}
+		if count := d / unit.unit; count > 0 {
+			parts = append(parts, strconv.FormatInt(int64(count), 10)+unit.suffix)
+			d -= count * unit.unit
+		} else if len(parts) > 0 {
+			// Only adjacent units are shown, 1d 0h 5m becomes 1d rather than 1d 5m.
+			break
+		}
+	}
+	return sign + strings.Join(parts, " ")
+}
+
+func humanizeBytes(v any) (string, error) {
+	size, err := toFloat64(v)
+	if err != nil {
+		return "", err
+	}
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	index := 0
+	for math.Abs(size) >= 1024 && index < len(units)-1 {
+		size /= 1024
+		index++
+	}
+	if index == 0 {
+		return fmt.Sprintf("%d B", int64(size)), nil
+	}
+	return strconv.FormatFloat(math.Round(size*10)/10, 'f', -1, 64) + " " + units[index], nil
+}
+
+func humanizeNumber(v any) (string, error) {
+	value, err := toFloat64(v)
+	if err != nil {
+		return "", err
+	}
+	formatted := strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+	sign := ""
+	if strings.HasPrefix(formatted, "-") {
+		sign, formatted = "-", formatted[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(formatted, ".")
+	var builder strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteRune(digit)
+	}
+	if hasFraction {
+		builder.WriteString("." + fraction)
+	}
+	return sign + builder.String(), nil
+}
+
+func humanizePercent(v any) (string, error) {
+	ratio, err := toFloat64(v)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(math.Round(ratio*10000)/100, 'f', -1, 64) + "%", nil
+}
@@ -0,0 +1,99 @@
+package tmplfunc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanizeDuration(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{v: 250 * time.Millisecond, want: "250ms"},
+		{v: 0, want: "0ms"},
+		{v: 45, want: "45s"},
+		{v: 303, want: "5m 3s"},
+		{v: "1h30m", want: "1h 30m"},
+		{v: 26 * time.Hour, want: "1d 2h"},
+		{v: 24*time.Hour + 5*time.Minute, want: "1d"},
+		{v: 90061, want: "1d 1h"},
+		{v: -90, want: "-1m 30s"},
+		{v: "1.5", want: "1s"},
+	}
+	for _, tt := range tests {
+		got, err := humanizeDuration(tt.v)
+		if err != nil {
+			t.Errorf("humanizeDuration(%v) failed: %v", tt.v, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("humanizeDuration(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+	if _, err := humanizeDuration("soon"); err == nil {
+		t.Errorf("humanizeDuration with an invalid duration did not fail")
+	}
+}
+
+func TestHumanizeBytes(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{v: 0, want: "0 B"},
+		{v: 1023, want: "1023 B"},
+		{v: 1024, want: "1 KiB"},
+		{v: 1536 * 1024, want: "1.5 MiB"},
+		{v: "1073741824", want: "1 GiB"},
+		{v: -2048, want: "-2 KiB"},
+		{v: float64(1 << 62), want: "4 EiB"},
+	}
+	for _, tt := range tests {
+		got, err := humanizeBytes(tt.v)
+		if err != nil || got != tt.want {
+			t.Errorf("humanizeBytes(%v) = %q, %v, want %q", tt.v, got, err, tt.want)
+		}
+	}
+}
+
+func TestHumanizeNumber(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{v: 0, want: "0"},
+		{v: 999, want: "999"},
+		{v: 1000, want: "1,000"},
+		{v: 1234567.891, want: "1,234,567.89"},
+		{v: -1234.5, want: "-1,234.5"},
+		{v: "100000", want: "100,000"},
+	}
+	for _, tt := range tests {
+		got, err := humanizeNumber(tt.v)
+		if err != nil || got != tt.want {
+			t.Errorf("humanizeNumber(%v) = %q, %v, want %q", tt.v, got, err, tt.want)
+		}
+	}
+}
+
+func TestHumanizePercent(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{v: 0.1234, want: "12.34%"},
+		{v: 1, want: "100%"},
+		{v: 0.00001, want: "0%"},
+		{v: "0.5", want: "50%"},
+	}
+	for _, tt := range tests {
+		got, err := humanizePercent(tt.v)
+		if err != nil || got != tt.want {
+			t.Errorf("humanizePercent(%v) = %q, %v, want %q", tt.v, got, err, tt.want)
+		}
+	}
+	if _, err := humanizePercent(nil); err == nil {
+		t.Errorf("humanizePercent(nil) did not fail")
+	}
+}
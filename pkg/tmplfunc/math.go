@@ -0,0 +1,119 @@
+package tmplfunc
+
+import (
+	"errors"
+	"math"
+)
+
+func add(a, b any) (any, error) {
+	return arithmetic(a, b, func(x, y float64) float64 { return x + y })
+}
+
+func sub(a, b any) (any, error) {
+	return arithmetic(a, b, func(x, y float64) float64 { return x - y })
+}
+
+func mul(a, b any) (any, error) {
+	return arithmetic(a, b, func(x, y float64) float64 { return x * y })
+}
+
+func div(a, b any) (any, error) {
+	divisor, err := toFloat64(b)
+	if err != nil {
+		return nil, err
+	}
+	if divisor == 0 {
+		return nil, errors.New("division by zero")
+	}
+	return arithmetic(a, b, func(x, y float64) float64 { return x / y })
+}
+
+func mod(a, b any) (int64, error) {
+	x, err := toInt(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := toInt(b)
+	if err != nil {
+		return 0, err
+	}
+	if y == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return x % y, nil
+}
+
+func maxNumber(a any, values ...any) (any, error) {
+	return pick(a, values, func(x, y float64) bool { return y > x })
+}
+
+func minNumber(a any, values ...any) (any, error) {
+	return pick(a, values, func(x, y float64) bool { return y < x })
+}
+
+func round(places int, v any) (any, error) {
+	value, err := toFloat64(v)
+	if err != nil {
+		return nil, err
+	}
+	scale := math.Pow(10, float64(places))
+	return number(math.Round(value*scale) / scale), nil
+}
+
+func floor(v any) (any, error) {
+	value, err := toFloat64(v)
+	if err != nil {
+		return nil, err
+	}
+	return number(math.Floor(value)), nil
+}
+
+func ceil(v any) (any, error) {
+	value, err := toFloat64(v)
+	if err != nil {
+		return nil, err
+	}
+	return number(math.Ceil(value)), nil
+}
+
+func toInt(v any) (int64, error) {
+	value, err := toFloat64(v)
+	if err != nil {
+		return 0, err
+	}
+	return int64(value), nil
+}
+
+func toFloat(v any) (float64, error) {
+	return toFloat64(v)
+}
+
+func arithmetic(a, b any, op func(x, y float64) float64) (any, error) {
+	x, err := toFloat64(a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := toFloat64(b)
+	if err != nil {
+		return nil, err
+	}
+	return number(op(x, y)), nil
+}
+
+// pick returns the value preferred by better.
+func pick(a any, values []any, better func(current, candidate float64) bool) (any, error) {
+	result, err := toFloat64(a)
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		candidate, err := toFloat64(value)
+		if err != nil {
+			return nil, err
+		}
+		if better(result, candidate) {
+			result = candidate
+		}
+	}
+	return number(result), nil
+}
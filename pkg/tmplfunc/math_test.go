@@ -0,0 +1,27 @@
+package tmplfunc
+
+import "testing"
+
+func TestMath(t *testing.T) {
+	data := map[string]any{"count": 3, "ratio": 0.25, "text": "12.5", "bad": "abc"}
+	runRenderCases(t, []renderCase{
+		{name: "add ints", text: `{{ add .count 1 }}`, data: data, want: "4"},
+		{name: "add numeric string", text: `{{ add .text 1 }}`, data: data, want: "13.5"},
+		{name: "sub", text: `{{ sub 1 .count }}`, data: data, want: "-2"},
+		{name: "mul to integer", text: `{{ mul .ratio 100 }}`, data: data, want: "25"},
+		{name: "div decimal", text: `{{ div 1 3 }}`, data: data, want: "0.3333333333333333"},
+		{name: "div integer", text: `{{ div 10 4 }}`, data: data, want: "2.5"},
+		{name: "large integer has no exponent", text: `{{ mul 1000000 1000000 }}`, want: "1000000000000"},
+		{name: "mod", text: `{{ mod 7 .count }}`, data: data, want: "1"},
+		{name: "max", text: `{{ max 1 .count 2 }}`, data: data, want: "3"},
+		{name: "min", text: `{{ min 1 .ratio 2 }}`, data: data, want: "0.25"},
+		{name: "round", text: `{{ round 2 3.14159 }} {{ round 0 2.5 }} {{ round 0 -2.5 }}`, want: "3.14 3 -3"},
+		{name: "floor and ceil", text: `{{ floor 1.5 }} {{ ceil 1.5 }} {{ floor -1.5 }}`, want: "1 2 -2"},
+		{name: "toInt truncates", text: `{{ toInt "3.9" }}`, want: "3"},
+		{name: "div by zero", text: `{{ div 1 0 }}`, wantErr: "division by zero"},
+		{name: "div by zero string", text: `{{ div 1 "0" }}`, wantErr: "division by zero"},
+		{name: "mod by zero", text: `{{ mod 1 0 }}`, wantErr: "division by zero"},
+		{name: "not a number", text: `{{ add .bad 1 }}`, data: data, wantErr: `"abc" is not a number`},
+		{name: "missing number", text: `{{ add .missing 1 }}`, data: data, wantErr: "missing number"},
+	})
+}
@@ -0,0 +1,144 @@
+package tmplfunc
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func defaultValue(def, v any) any {
+	if empty(v) {
+		return def
+	}
+	return v
+}
+
+func coalesce(values ...any) any {
+	for _, value := range values {
+		if !empty(value) {
+			return value
+		}
+	}
+	return nil
+}
+
+func empty(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.String:
+		return rv.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
+
+func ternary(a, b any, cond bool) any {
+	if cond {
+		return a
+	}
+	return b
+}
+
+func upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+func lower(s string) string {
+	return strings.ToLower(s)
+}
+
+func title(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		if i == 0 || unicode.IsSpace(runes[i-1]) {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+	return string(runes)
+}
+
+func trim(s string) string {
+	return strings.TrimSpace(s)
+}
+
+func trimPrefix(prefix, s string) string {
+	return strings.TrimPrefix(s, prefix)
+}
+
+func trimSuffix(suffix, s string) string {
+	return strings.TrimSuffix(s, suffix)
+}
+
+func replace(old, new, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
+
+func contains(substr, s string) bool {
+	return strings.Contains(s, substr)
+}
+
+func hasPrefix(prefix, s string) bool {
+	return strings.HasPrefix(s, prefix)
+}
+
+func hasSuffix(suffix, s string) bool {
+	return strings.HasSuffix(s, suffix)
+}
+
+func split(sep, s string) []string {
+	return strings.Split(s, sep)
+}
+
+// maxGeneratedBytes limits the bytes repeat and indent may add to their input, so a
+// large n from the template data cannot exhaust the memory of the sender.
+const maxGeneratedBytes = 16 << 10
+
+func repeat(n int, s string) (string, error) {
+	if n <= 0 || s == "" {
+		return "", nil
+	}
+	if n > maxGeneratedBytes/len(s) {
+		return "", fmt.Errorf("repeat generates more than %d bytes", maxGeneratedBytes)
+	}
+	return strings.Repeat(s, n), nil
+}
+
+// truncateSuffix marks truncated strings and counts towards the length.
+const truncateSuffix = "..."
+
+func truncate(n int, s string) string {
+	if n <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	if n <= len(truncateSuffix) {
+		return string(runes[:n])
+	}
+	return string(runes[:n-len(truncateSuffix)]) + truncateSuffix
+}
+
+func indent(n int, s string) (string, error) {
+	if n <= 0 {
+		return s, nil
+	}
+	if n > maxGeneratedBytes/(strings.Count(s, "\n")+1) {
+		return "", fmt.Errorf("indent generates more than %d bytes", maxGeneratedBytes)
+	}
+	padding := strings.Repeat(" ", n)
+	return padding + strings.ReplaceAll(s, "\n", "\n"+padding), nil
+}
+
+func quote(v any) string {
+	return strconv.Quote(toString(v))
+}
@@ -0,0 +1,102 @@
+package tmplfunc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultAndEmpty(t *testing.T) {
+	data := map[string]any{
+		"zero":  0,
+		"falsy": false,
+		"blank": "",
+		"list":  []any{},
+		"obj":   map[string]any{},
+		"nil":   nil,
+		"value": "set",
+		"items": []any{1},
+	}
+	runRenderCases(t, []renderCase{
+		{name: "missing", text: `{{ .missing | default "x" }}`, data: data, want: "x"},
+		{name: "nil", text: `{{ .nil | default "x" }}`, data: data, want: "x"},
+		{name: "zero", text: `{{ .zero | default 5 }}`, data: data, want: "5"},
+		{name: "false", text: `{{ .falsy | default true }}`, data: data, want: "true"},
+		{name: "empty string", text: `{{ .blank | default "x" }}`, data: data, want: "x"},
+		{name: "empty list", text: `{{ .list | default "x" }}`, data: data, want: "x"},
+		{name: "empty object", text: `{{ .obj | default "x" }}`, data: data, want: "x"},
+		{name: "set value", text: `{{ .value | default "x" }}`, data: data, want: "set"},
+		{name: "empty", text: `{{ empty .missing }} {{ empty .items }} {{ empty .value }}`, data: data, want: "true false false"},
+		{name: "coalesce", text: `{{ coalesce .missing .blank .zero .value "x" }}`, data: data, want: "set"},
+		{name: "coalesce all empty", text: `{{ coalesce .missing .blank | default "none" }}`, data: data, want: "none"},
+		{name: "ternary", text: `{{ ternary "a" "b" true }} {{ .falsy | ternary "a" "b" }}`, data: data, want: "a b"},
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		n    int
+		s    string
+		want string
+	}{
+		{n: 10, s: "short", want: "short"},
+		{n: 5, s: "short", want: "short"},
+		{n: 8, s: "hello world", want: "hello..."},
+		{n: 3, s: "hello", want: "hel"},
+		{n: 0, s: "hello", want: ""},
+		{n: -1, s: "hello", want: ""},
+		{n: 4, s: "你好世界", want: "你好世界"},
+		{n: 5, s: "告警已恢复请确认", want: "告警..."},
+		{n: 2, s: "告警已恢复", want: "告警"},
+		{n: 4, s: "a😀b😀c", want: "a..."},
+	}
+	for _, tt := range tests {
+		got := truncate(tt.n, tt.s)
+		if got != tt.want {
+			t.Errorf("truncate(%d, %q) = %q, want %q", tt.n, tt.s, got, tt.want)
+		}
+		if !strings.HasSuffix(got, truncateSuffix) && !strings.HasPrefix(tt.s, got) {
+			t.Errorf("truncate(%d, %q) = %q is not a prefix", tt.n, tt.s, got)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	data := map[string]any{"name": "disk_usage high", "text": "a\nb"}
+	runRenderCases(t, []renderCase{
+		{name: "upper", text: `{{ .name | upper }}`, data: data, want: "DISK_USAGE HIGH"},
+		{name: "title", text: `{{ .name | title }}`, data: data, want: "Disk_usage High"},
+		{name: "replace", text: `{{ .name | replace "_" " " }}`, data: data, want: "disk usage high"},
+		{name: "trim prefix", text: `{{ "http://host" | trimPrefix "http://" }}`, want: "host"},
+		{name: "contains", text: `{{ .name | contains "usage" }}`, data: data, want: "true"},
+		{name: "split", text: `{{ range split "," "a,b" }}[{{ . }}]{{ end }}`, want: "[a][b]"},
+		{name: "indent", text: `{{ .text | indent 2 }}`, data: data, want: "  a\n  b"},
+		{name: "indent zero", text: `{{ .text | indent 0 }}`, data: data, want: "a\nb"},
+		{name: "repeat", text: `{{ repeat 3 "-" }}`, want: "---"},
+		{name: "repeat negative", text: `{{ repeat -1 "-" }}`, want: ""},
+		{name: "quote", text: `{{ quote .text }}`, data: data, want: `"a\nb"`},
+	})
+}
+
+func TestRepeatLimit(t *testing.T) {
+	if got, err := repeat(maxGeneratedBytes, "-"); err != nil || len(got) != maxGeneratedBytes {
+		t.Errorf("repeat at the limit = %d bytes, %v", len(got), err)
+	}
+	if _, err := repeat(maxGeneratedBytes/2+1, "ab"); err == nil {
+		t.Errorf("repeat over the limit did not fail")
+	}
+	if _, err := repeat(1<<62, "ab"); err == nil {
+		t.Errorf("repeat with a huge n did not fail")
+	}
+}
+
+func TestIndentLimit(t *testing.T) {
+	if _, err := indent(maxGeneratedBytes, "a"); err != nil {
+		t.Errorf("indent at the limit failed: %v", err)
+	}
+	if _, err := indent(maxGeneratedBytes/2+1, "a\nb"); err == nil {
+		t.Errorf("indent over the limit did not fail")
+	}
+	if _, err := indent(1<<62, "a"); err == nil {
+		t.Errorf("indent with a huge n did not fail")
+	}
+}
@@ -0,0 +1,48 @@
+package tmplfunc
+
+import (
+	"fmt"
+	"time"
+)
+
+func now() time.Time {
+	return time.Now()
+}
+
+func date(layout string, t any) (string, error) {
+	return dateInZone(layout, t, "")
+}
+
+func dateInZone(layout string, t any, zone string) (string, error) {
+	value, err := toTime(t)
+	if err != nil {
+		return "", err
+	}
+	location := time.UTC
+	if zone != "" {
+		if location, err = time.LoadLocation(zone); err != nil {
+			return "", fmt.Errorf("unknown time zone %q", zone)
+		}
+	}
+	return value.In(location).Format(layout), nil
+}
+
+func duration(v any) (time.Duration, error) {
+	return toDurationValue(v)
+}
+
+func since(t any) (time.Duration, error) {
+	value, err := toTime(t)
+	if err != nil {
+		return 0, err
+	}
+	return time.Since(value), nil
+}
+
+func ago(t any) (string, error) {
+	elapsed, err := since(t)
+	if err != nil {
+		return "", err
+	}
+	return formatDuration(elapsed.Truncate(time.Second)) + " ago", nil
+}
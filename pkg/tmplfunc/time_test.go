@@ -0,0 +1,96 @@
+package tmplfunc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToTime(t *testing.T) {
+	want := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	tests := []struct {
+		name    string
+		v       any
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "time", v: want, want: want},
+		{name: "time pointer", v: &want, want: want},
+		{name: "nil time pointer", v: (*time.Time)(nil), wantErr: true},
+		{name: "rfc3339", v: "2024-05-06T15:08:09+08:00", want: want},
+		{name: "rfc3339 nano", v: "2024-05-06T07:08:09.5Z", want: want.Add(500 * time.Millisecond)},
+		{name: "date time", v: "2024-05-06 07:08:09", want: want},
+		{name: "date", v: " 2024-05-06 ", want: time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)},
+		{name: "unix seconds", v: want.Unix(), want: want},
+		{name: "unix seconds float", v: float64(want.Unix()) + 0.25, want: want.Add(250 * time.Millisecond)},
+		{name: "unix seconds string", v: "1714979289", want: want},
+		{name: "unix milliseconds", v: want.UnixMilli() + 7, want: want.Add(7 * time.Millisecond)},
+		{name: "invalid string", v: "yesterday", wantErr: true},
+		{name: "nil", v: nil, wantErr: true},
+		{name: "bool", v: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toTime(tt.v)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("toTime(%v) = %v, want error", tt.v, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("toTime(%v) failed: %v", tt.v, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("toTime(%v) = %v, want %v", tt.v, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateInZone(t *testing.T) {
+	const layout = "2006-01-02 15:04 MST"
+	tests := []struct {
+		name    string
+		zone    string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty zone is utc", zone: "", want: "2024-05-06 07:08 UTC"},
+		{name: "shanghai", zone: "Asia/Shanghai", want: "2024-05-06 15:08 CST"},
+		{name: "new york daylight saving", zone: "America/New_York", want: "2024-05-06 03:08 EDT"},
+		{name: "unknown zone", zone: "Mars/Olympus", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dateInZone(layout, "2024-05-06T07:08:09Z", tt.zone)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("dateInZone(%q) = %q, want error", tt.zone, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Skipf("time zone database is unavailable: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("dateInZone(%q) = %q, want %q", tt.zone, got, tt.want)
+			}
+		})
+	}
+	if got, err := date("2006-01-02", 1714979289); err != nil || got != "2024-05-06" {
+		t.Errorf("date() = %q, %v", got, err)
+	}
+	if _, err := date("2006-01-02", "not a time"); err == nil {
+		t.Errorf("date() with an invalid time did not fail")
+	}
+}
+
+func TestAgo(t *testing.T) {
+	got, err := ago(time.Now().Add(-90 * time.Minute))
+	if err != nil {
+		t.Fatalf("ago failed: %v", err)
+	}
+	if got != "1h 30m ago" {
+		t.Errorf("ago() = %q, want %q", got, "1h 30m ago")
+	}
+}
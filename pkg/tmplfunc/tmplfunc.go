@@ -0,0 +1,142 @@
+// Package tmplfunc is the function library available to rabbit message templates.
+//
+// The same functions are registered for email, sms and webhook templates, for both
+// sending and previewing. Functions that take the piped value accept it as their
+// last argument, so both {{ truncate 70 .summary }} and {{ .summary | truncate 70 }}
+// work. Invalid input makes the function return an error, which fails the render
+// instead of silently producing a wrong message.
+package tmplfunc
+
+import (
+	"sort"
+	"text/template"
+)
+
+// Categories of the functions, used to group them in the documentation.
+const (
+	CategoryTime       = "time"
+	CategoryString     = "string"
+	CategoryMath       = "math"
+	CategoryCollection = "collection"
+	CategoryEncoding   = "encoding"
+	CategoryHumanize   = "humanize"
+)
+
+// Func is a documented template function.
+type Func struct {
+	Name        string
+	Category    string
+	Signature   string
+	Description string
+	Example     string
+	Fn          any
+}
+
+var funcs = []*Func{
+	// time
+	{Name: "now", Category: CategoryTime, Signature: "now() time.Time", Description: "The current time.", Example: `{{ now | date "2006-01-02" }}`, Fn: now},
+	{Name: "toTime", Category: CategoryTime, Signature: "toTime(v any) time.Time", Description: "Converts a unix timestamp in seconds or milliseconds, or an RFC3339, \"2006-01-02 15:04:05\" or \"2006-01-02\" string to a time.", Example: `{{ (toTime .startsAt).Unix }}`, Fn: toTime},
+	{Name: "date", Category: CategoryTime, Signature: "date(layout string, t any) string", Description: "Formats a time with a Go layout in UTC. The time is converted with toTime.", Example: `{{ .startsAt | date "2006-01-02 15:04:05" }}`, Fn: date},
+	{Name: "dateInZone", Category: CategoryTime, Signature: "dateInZone(layout string, t any, zone string) string", Description: "Formats a time with a Go layout in an IANA time zone, e.g. the recipient's time zone. An empty zone means UTC.", Example: `{{ dateInZone "2006-01-02 15:04" .startsAt .recipient.timezone }}`, Fn: dateInZone},
+	{Name: "duration", Category: CategoryTime, Signature: "duration(v any) time.Duration", Description: "Converts seconds or a Go duration string such as \"1h30m\" to a duration.", Example: `{{ (duration .timeout).Minutes }}`, Fn: duration},
+	{Name: "since", Category: CategoryTime, Signature: "since(t any) time.Duration", Description: "The duration elapsed since a time.", Example: `{{ since .startsAt | humanizeDuration }}`, Fn: since},
+	{Name: "ago", Category: CategoryTime, Signature: "ago(t any) string", Description: "The humanized duration elapsed since a time, suffixed with \"ago\".", Example: `started {{ ago .startsAt }}`, Fn: ago},
+
+	// string
+	{Name: "default", Category: CategoryString, Signature: "default(def any, v any) any", Description: "Returns def when v is missing or empty (nil, false, 0, \"\", or an empty list or object).", Example: `{{ .severity | default "unknown" }}`, Fn: defaultValue},
+	{Name: "coalesce", Category: CategoryString, Signature: "coalesce(values ...any) any", Description: "Returns the first non-empty value.", Example: `{{ coalesce .summary .description "no description" }}`, Fn: coalesce},
+	{Name: "empty", Category: CategoryString, Signature: "empty(v any) bool", Description: "Reports whether v is missing or empty.", Example: `{{ if empty .labels }}no labels{{ end }}`, Fn: empty},
+	{Name: "ternary", Category: CategoryString, Signature: "ternary(a any, b any, cond bool) any", Description: "Returns a when cond is true, otherwise b.", Example: `{{ .resolved | ternary "RESOLVED" "FIRING" }}`, Fn: ternary},
+	{Name: "toString", Category: CategoryString, Signature: "toString(v any) string", Description: "Formats any value as a string, nil becomes \"\".", Example: `{{ toString .value }}`, Fn: toString},
+	{Name: "upper", Category: CategoryString, Signature: "upper(s string) string", Description: "Converts to upper case.", Example: `{{ .severity | upper }}`, Fn: upper},
+	{Name: "lower", Category: CategoryString, Signature: "lower(s string) string", Description: "Converts to lower case.", Example: `{{ .severity | lower }}`, Fn: lower},
+	{Name: "title", Category: CategoryString, Signature: "title(s string) string", Description: "Upper cases the first letter of each word.", Example: `{{ .status | title }}`, Fn: title},
+	{Name: "trim", Category: CategoryString, Signature: "trim(s string) string", Description: "Removes leading and trailing white space.", Example: `{{ .summary | trim }}`, Fn: trim},
+	{Name: "trimPrefix", Category: CategoryString, Signature: "trimPrefix(prefix string, s string) string", Description: "Removes a leading prefix.", Example: `{{ .instance | trimPrefix "http://" }}`, Fn: trimPrefix},
+	{Name: "trimSuffix", Category: CategoryString, Signature: "trimSuffix(suffix string, s string) string", Description: "Removes a trailing suffix.", Example: `{{ .instance | trimSuffix ":9100" }}`, Fn: trimSuffix},
+	{Name: "replace", Category: CategoryString, Signature: "replace(old string, new string, s string) string", Description: "Replaces all occurrences of old with new.", Example: `{{ .name | replace "_" " " }}`, Fn: replace},
+	{Name: "contains", Category: CategoryString, Signature: "contains(substr string, s string) bool", Description: "Reports whether s contains substr.", Example: `{{ if contains "prod" .env }}...{{ end }}`, Fn: contains},
+	{Name: "hasPrefix", Category: CategoryString, Signature: "hasPrefix(prefix string, s string) bool", Description: "Reports whether s begins with prefix.", Example: `{{ if hasPrefix "db-" .instance }}...{{ end }}`, Fn: hasPrefix},
+	{Name: "hasSuffix", Category: CategoryString, Signature: "hasSuffix(suffix string, s string) bool", Description: "Reports whether s ends with suffix.", Example: `{{ if hasSuffix ".cn" .domain }}...{{ end }}`, Fn: hasSuffix},
+	{Name: "split", Category: CategoryString, Signature: "split(sep string, s string) []string", Description: "Splits s by sep.", Example: `{{ range split "," .receivers }}...{{ end }}`, Fn: split},
+	{Name: "repeat", Category: CategoryString, Signature: "repeat(n int, s string) string", Description: "Repeats s n times, generating at most 16 KiB.", Example: `{{ repeat 20 "-" }}`, Fn: repeat},
+	{Name: "truncate", Category: CategoryString, Signature: "truncate(n int, s string) string", Description: "Shortens s to at most n characters (not bytes), ending with \"...\" when truncated. Useful for sms length limits.", Example: `{{ .summary | truncate 70 }}`, Fn: truncate},
+	{Name: "indent", Category: CategoryString, Signature: "indent(n int, s string) string", Description: "Indents every line of s with n spaces, adding at most 16 KiB.", Example: `{{ .details | indent 4 }}`, Fn: indent},
+	{Name: "quote", Category: CategoryString, Signature: "quote(v any) string", Description: "Formats v as a double quoted Go string.", Example: `{{ .name | quote }}`, Fn: quote},
+
+	// math
+	{Name: "add", Category: CategoryMath, Signature: "add(a any, b any) number", Description: "a + b.", Example: `{{ add .count 1 }}`, Fn: add},
+	{Name: "sub", Category: CategoryMath, Signature: "sub(a any, b any) number", Description: "a - b.", Example: `{{ sub .total .ok }}`, Fn: sub},
+	{Name: "mul", Category: CategoryMath, Signature: "mul(a any, b any) number", Description: "a * b.", Example: `{{ mul .ratio 100 }}`, Fn: mul},
+	{Name: "div", Category: CategoryMath, Signature: "div(a any, b any) number", Description: "a / b, dividing by zero is an error.", Example: `{{ div .bytes 1024 }}`, Fn: div},
+	{Name: "mod", Category: CategoryMath, Signature: "mod(a any, b any) int", Description: "The remainder of the integer division a / b.", Example: `{{ mod .index 2 }}`, Fn: mod},
+	{Name: "max", Category: CategoryMath, Signature: "max(a any, values ...any) number", Description: "The largest value.", Example: `{{ max .current .threshold }}`, Fn: maxNumber},
+	{Name: "min", Category: CategoryMath, Signature: "min(a any, values ...any) number", Description: "The smallest value.", Example: `{{ min .current .threshold }}`, Fn: minNumber},
+	{Name: "round", Category: CategoryMath, Signature: "round(places int, v any) number", Description: "Rounds v half away from zero to the given decimal places.", Example: `{{ .value | round 2 }}`, Fn: round},
+	{Name: "floor", Category: CategoryMath, Signature: "floor(v any) number", Description: "The greatest integer value less than or equal to v.", Example: `{{ floor .value }}`, Fn: floor},
+	{Name: "ceil", Category: CategoryMath, Signature: "ceil(v any) number", Description: "The least integer value greater than or equal to v.", Example: `{{ ceil .value }}`, Fn: ceil},
+	{Name: "toInt", Category: CategoryMath, Signature: "toInt(v any) int", Description: "Converts a number or numeric string to an integer, truncating the fraction.", Example: `{{ toInt .port }}`, Fn: toInt},
+	{Name: "toFloat", Category: CategoryMath, Signature: "toFloat(v any) float64", Description: "Converts a number or numeric string to a float.", Example: `{{ toFloat .value }}`, Fn: toFloat},
+
+	// collection
+	{Name: "list", Category: CategoryCollection, Signature: "list(items ...any) []any", Description: "Builds a list.", Example: `{{ join ", " (list .a .b) }}`, Fn: list},
+	{Name: "dict", Category: CategoryCollection, Signature: "dict(pairs ...any) map[string]any", Description: "Builds an object from key value pairs.", Example: `{{ toJSON (dict "name" .name "value" .value) }}`, Fn: dict},
+	{Name: "keys", Category: CategoryCollection, Signature: "keys(m map[string]any) []string", Description: "The sorted keys of an object.", Example: `{{ keys .labels | join ", " }}`, Fn: keys},
+	{Name: "values", Category: CategoryCollection, Signature: "values(m map[string]any) []any", Description: "The values of an object, sorted by key.", Example: `{{ values .labels | join ", " }}`, Fn: values},
+	{Name: "hasKey", Category: CategoryCollection, Signature: "hasKey(m map[string]any, key string) bool", Description: "Reports whether an object has the key.", Example: `{{ if hasKey .labels "team" }}...{{ end }}`, Fn: hasKey},
+	{Name: "has", Category: CategoryCollection, Signature: "has(v any, list []any) bool", Description: "Reports whether the list contains v.", Example: `{{ if .receivers | has "sre" }}...{{ end }}`, Fn: has},
+	{Name: "join", Category: CategoryCollection, Signature: "join(sep string, list []any) string", Description: "Joins the formatted items of a list with sep.", Example: `{{ .tags | join ", " }}`, Fn: join},
+	{Name: "joinPairs", Category: CategoryCollection, Signature: "joinPairs(sep string, m map[string]any) string", Description: "Formats an object as key=value pairs sorted by key and joined with sep, e.g. alert labels.", Example: `{{ .labels | joinPairs ", " }}`, Fn: joinPairs},
+	{Name: "first", Category: CategoryCollection, Signature: "first(list []any) any", Description: "The first item of a list, nil when empty.", Example: `{{ first .alerts }}`, Fn: first},
+	{Name: "last", Category: CategoryCollection, Signature: "last(list []any) any", Description: "The last item of a list, nil when empty.", Example: `{{ last .alerts }}`, Fn: last},
+	{Name: "uniq", Category: CategoryCollection, Signature: "uniq(list []any) []any", Description: "Removes duplicated items, keeping the first occurrence.", Example: `{{ .receivers | uniq | join ", " }}`, Fn: uniq},
+	{Name: "sortAlpha", Category: CategoryCollection, Signature: "sortAlpha(list []any) []string", Description: "Formats the items of a list as strings and sorts them.", Example: `{{ .tags | sortAlpha | join ", " }}`, Fn: sortAlpha},
+
+	// encoding
+	{Name: "toJSON", Category: CategoryEncoding, Signature: "toJSON(v any) string", Description: "Encodes v as compact JSON without escaping HTML characters.", Example: `{"labels": {{ toJSON .labels }}}`, Fn: toJSON},
+	{Name: "toPrettyJSON", Category: CategoryEncoding, Signature: "toPrettyJSON(v any) string", Description: "Encodes v as JSON indented with two spaces.", Example: `{{ toPrettyJSON .annotations }}`, Fn: toPrettyJSON},
+	{Name: "fromJSON", Category: CategoryEncoding, Signature: "fromJSON(s string) any", Description: "Decodes a JSON string.", Example: `{{ (fromJSON .payload).status }}`, Fn: fromJSON},
+	{Name: "jsonEscape", Category: CategoryEncoding, Signature: "jsonEscape(v any) string", Description: "Escapes v for use inside a JSON string literal, without the surrounding quotes. Use it for values inserted into webhook payloads.", Example: `{"text": "{{ .summary | jsonEscape }}"}`, Fn: jsonEscape},
+	{Name: "markdownEscape", Category: CategoryEncoding, Signature: "markdownEscape(v any) string", Description: "Escapes the markdown control characters, so values are shown literally in markdown messages.", Example: `**{{ .name | markdownEscape }}**`, Fn: markdownEscape},
+
+	// humanize
+	{Name: "humanizeDuration", Category: CategoryHumanize, Signature: "humanizeDuration(v any) string", Description: "Formats a duration, or seconds, with its two most significant units, e.g. \"1d 2h\", \"5m 3s\", \"250ms\".", Example: `{{ .durationSeconds | humanizeDuration }}`, Fn: humanizeDuration},
+	{Name: "humanizeBytes", Category: CategoryHumanize, Signature: "humanizeBytes(v any) string", Description: "Formats a byte size with IEC units, e.g. \"1.5 MiB\".", Example: `{{ .freeBytes | humanizeBytes }}`, Fn: humanizeBytes},
+	{Name: "humanizeNumber", Category: CategoryHumanize, Signature: "humanizeNumber(v any) string", Description: "Formats a number with thousands separators and at most two decimals, e.g. \"1,234,567.89\".", Example: `{{ .requests | humanizeNumber }}`, Fn: humanizeNumber},
+	{Name: "humanizePercent", Category: CategoryHumanize, Signature: "humanizePercent(v any) string", Description: "Formats a ratio as a percentage with at most two decimals, e.g. 0.1234 becomes \"12.34%\".", Example: `{{ .errorRate | humanizePercent }}`, Fn: humanizePercent},
+}
+
+// Funcs returns the documented functions sorted by category and name.
+func Funcs() []*Func {
+	sorted := make([]*Func, 0, len(funcs))
+	for _, fn := range funcs {
+		item := *fn
+		sorted = append(sorted, &item)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Category != sorted[j].Category {
+			return sorted[i].Category < sorted[j].Category
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// FuncMap returns the functions to register with Template.Funcs. The result can be
+// converted to html/template.FuncMap.
+func FuncMap() template.FuncMap {
+	funcMap := make(template.FuncMap, len(funcs))
+	for _, fn := range funcs {
+		funcMap[fn.Name] = fn.Fn
+	}
+	return funcMap
+}
+
+// OptionalFuncs are the functions that handle missing values themselves, a missing
+// variable passed to them is expected and should not be reported.
+var OptionalFuncs = map[string]struct{}{
+	"default":  {},
+	"coalesce": {},
+	"empty":    {},
+	"hasKey":   {},
+}
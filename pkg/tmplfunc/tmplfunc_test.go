@@ -0,0 +1,63 @@
+package tmplfunc
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// renderCase renders text with the function library and compares the output, or the
+// error when wantErr is set.
+type renderCase struct {
+	name    string
+	text    string
+	data    any
+	want    string
+	wantErr string
+}
+
+func runRenderCases(t *testing.T, tests []renderCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := template.New("test").Funcs(FuncMap()).Parse(tt.text)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			var buf strings.Builder
+			err = tmpl.Execute(&buf, tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Execute = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuncs(t *testing.T) {
+	funcMap := FuncMap()
+	for _, fn := range Funcs() {
+		if fn.Signature == "" || fn.Description == "" || fn.Example == "" {
+			t.Errorf("function %s is not documented", fn.Name)
+		}
+		if !strings.HasPrefix(fn.Signature, fn.Name+"(") {
+			t.Errorf("function %s has signature %q", fn.Name, fn.Signature)
+		}
+		if _, err := template.New(fn.Name).Funcs(funcMap).Parse(fn.Example); err != nil {
+			t.Errorf("example of %s does not parse: %v", fn.Name, err)
+		}
+	}
+	for name := range OptionalFuncs {
+		if _, ok := funcMap[name]; !ok {
+			t.Errorf("optional function %s is not registered", name)
+		}
+	}
+}